@@ -28,3 +28,7 @@ import (
 func TestModbusDriver(t *testing.T) {
 	testutils.RunDriverTestsuite(t, modbus.NewDriver(), "assets/testing/protocols/modbus/DriverTestsuite.xml")
 }
+
+func TestModbusDriverOptimizedReads(t *testing.T) {
+	testutils.RunDriverTestsuite(t, modbus.NewDriver(), "assets/testing/protocols/modbus/OptimizedReadsDriverTestsuite.xml")
+}
@@ -0,0 +1,88 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package ads
+
+import (
+	"fmt"
+	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/ads/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/interceptors"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ADS itself allows way bigger reads, but this keeps the AMS packets small
+	MaxBlockSize = 1024
+)
+
+// BlockReadSupport allows reading consecutive bytes of an index group with a single item
+type BlockReadSupport struct {
+}
+
+func NewBlockReadSupport() BlockReadSupport {
+	return BlockReadSupport{}
+}
+
+func (m BlockReadSupport) GetFieldBlock(field apiModel.PlcField) (interceptors.FieldBlock, bool) {
+	// Symbolic fields are only resolved while reading and strings have a size of their own
+	adsField, ok := field.(DirectPlcField)
+	if !ok || adsField.FieldType != DirectAdsField {
+		return interceptors.FieldBlock{}, false
+	}
+	size := uint32(adsField.Datatype.NumBytes()) * adsField.NumberOfElements
+	if size == 0 {
+		return interceptors.FieldBlock{}, false
+	}
+	return interceptors.FieldBlock{
+		Area:  fmt.Sprintf("%d", adsField.IndexGroup),
+		Start: adsField.IndexOffset,
+		Size:  size,
+	}, true
+}
+
+func (m BlockReadSupport) GetMaxBlockSize(_ string) uint32 {
+	return MaxBlockSize
+}
+
+func (m BlockReadSupport) NewBlockField(field apiModel.PlcField, block interceptors.FieldBlock) apiModel.PlcField {
+	adsField := field.(DirectPlcField)
+	return DirectPlcField{
+		IndexGroup:  adsField.IndexGroup,
+		IndexOffset: block.Start,
+		PlcField: PlcField{
+			FieldType:        DirectAdsBlockField,
+			NumberOfElements: block.Size,
+			Datatype:         readWriteModel.AdsDataType_BYTE,
+		},
+	}
+}
+
+func (m BlockReadSupport) DecodeFieldValue(field apiModel.PlcField, data []byte) (values.PlcValue, error) {
+	adsField, err := castToAdsFieldFromPlcField(field)
+	if err != nil {
+		return nil, err
+	}
+	value, err := readWriteModel.DataItemParse(utils.NewLittleEndianReadBuffer(data), adsField.GetDatatype().DataFormatName(), adsField.GetStringLength())
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing data item")
+	}
+	return value, nil
+}
@@ -21,17 +21,28 @@ package ads
 
 import (
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/ads/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var optionSchema = options.OptionSchema{
+	{Name: "sourceAmsNetId", Type: options.OptionTypeString, Required: true, Description: "AMS Net ID of this client (e.g. 192.168.23.20.1.1)"},
+	{Name: "sourceAmsPort", Type: options.OptionTypeInteger, Required: true, Description: "AMS port of this client"},
+	{Name: "targetAmsNetId", Type: options.OptionTypeString, Required: true, Description: "AMS Net ID of the PLC (e.g. 192.168.23.10.1.1)"},
+	{Name: "targetAmsPort", Type: options.OptionTypeInteger, Required: true, Description: "AMS port of the PLC runtime (e.g. 851)"},
+}.Merge(spi.RequestQueueOptionSchema)
+
 type Configuration struct {
 	sourceAmsNetId readWriteModel.AmsNetId
 	sourceAmsPort  uint16
 	targetAmsNetId readWriteModel.AmsNetId
 	targetAmsPort  uint16
+	requestQueue   spi.RequestQueueConfiguration
 }
 
 func ParseFromOptions(options map[string][]string) (Configuration, error) {
@@ -84,7 +95,7 @@ func ParseFromOptions(options map[string][]string) (Configuration, error) {
 	}
 	configuration.sourceAmsPort = uint16(atoi)
 	targetAmsNetId := getFromOptions(options, "targetAmsNetId")
-	if sourceAmsNetId == "" {
+	if targetAmsNetId == "" {
 		return Configuration{}, errors.New("Required parameter targetAmsNetId missing")
 	}
 	split = strings.Split(targetAmsNetId, ".")
@@ -130,6 +141,11 @@ func ParseFromOptions(options map[string][]string) (Configuration, error) {
 	}
 	configuration.targetAmsPort = uint16(atoi)
 
+	configuration.requestQueue, err = spi.ParseRequestQueueConfiguration(options, time.Second*1)
+	if err != nil {
+		return Configuration{}, err
+	}
+
 	return configuration, nil
 }
 
@@ -19,6 +19,7 @@
 package ads
 
 import (
+	"context"
 	"fmt"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/interceptors"
@@ -26,7 +27,8 @@ import (
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
-	"github.com/rs/zerolog/log"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"time"
 )
 
@@ -59,18 +61,26 @@ type Connection struct {
 	fieldHandler       spi.PlcFieldHandler
 	valueHandler       spi.PlcValueHandler
 	requestInterceptor internalModel.RequestInterceptor
-	configuration      Configuration
-	reader             *Reader
-	writer             *Writer
-}
-
-func NewConnection(messageCodec spi.MessageCodec, configuration Configuration, fieldHandler spi.PlcFieldHandler) (*Connection, error) {
+	// Reads are optimized by reading consecutive fields of an index group at once
+	readRequestInterceptor internalModel.ReadRequestInterceptor
+	configuration          Configuration
+	reader                 *Reader
+	writer                 *Writer
+	// Reader and writer hand out invoke ids independently, so all requests of this connection are queued in here
+	tm     *spi.RequestTransactionManager
+	logger zerolog.Logger
+}
+
+func NewConnection(messageCodec spi.MessageCodec, configuration Configuration, fieldHandler spi.PlcFieldHandler, logger zerolog.Logger) (*Connection, error) {
+	tm := configuration.requestQueue.NewRequestTransactionManager(1, spi.WithLogger(logger))
 	reader := *NewReader(
 		messageCodec,
 		configuration.targetAmsNetId,
 		configuration.targetAmsPort,
 		configuration.sourceAmsNetId,
 		configuration.sourceAmsPort,
+		tm,
+		configuration.requestQueue,
 	)
 	writer := *NewWriter(
 		messageCodec,
@@ -78,30 +88,39 @@ func NewConnection(messageCodec spi.MessageCodec, configuration Configuration, f
 		configuration.targetAmsPort,
 		configuration.sourceAmsNetId,
 		configuration.sourceAmsPort,
+		tm,
+		configuration.requestQueue,
 		&reader,
 	)
 	return &Connection{
-		messageCodec:       messageCodec,
-		fieldHandler:       fieldHandler,
-		valueHandler:       NewValueHandler(),
-		requestInterceptor: interceptors.NewSingleItemRequestInterceptor(),
-		reader:             &reader,
-		writer:             &writer,
+		messageCodec:           messageCodec,
+		fieldHandler:           fieldHandler,
+		valueHandler:           NewValueHandler(),
+		requestInterceptor:     interceptors.NewSingleItemRequestInterceptor(logger),
+		readRequestInterceptor: interceptors.NewReadOptimizingRequestInterceptor(NewBlockReadSupport(), 0, logger),
+		reader:                 &reader,
+		writer:                 &writer,
+		tm:                     tm,
+		logger:                 logger,
 	}, nil
 }
 
 func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
-	log.Trace().Msg("Connecting")
-	ch := make(chan plc4go.PlcConnectionConnectResult)
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *Connection) ConnectWithContext(ctx context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	m.logger.Trace().Msg("Connecting")
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
 	go func() {
-		err := m.messageCodec.Connect()
+		err := m.messageCodec.ConnectWithContext(ctx)
 		ch <- plc4go.NewPlcConnectionConnectResult(m, err)
 	}()
 	return ch
 }
 
 func (m *Connection) BlockingClose() {
-	log.Trace().Msg("Closing blocked")
+	m.logger.Trace().Msg("Closing blocked")
 	closeResults := m.Close()
 	select {
 	case <-closeResults:
@@ -112,21 +131,45 @@ func (m *Connection) BlockingClose() {
 }
 
 func (m *Connection) Close() <-chan plc4go.PlcConnectionCloseResult {
-	log.Trace().Msg("Close")
-	// TODO: Implement ...
-	ch := make(chan plc4go.PlcConnectionCloseResult)
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *Connection) CloseWithContext(_ context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	m.logger.Trace().Msg("Close")
+	// TODO: Implement a graceful disconnect on protocol level ...
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
 	go func() {
-		ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+		// Fail everything still waiting for its turn
+		m.tm.Close()
+		err := m.messageCodec.Disconnect()
+		if err != nil {
+			err = errors.Wrap(err, "error disconnecting")
+		}
+		ch <- plc4go.NewPlcConnectionCloseResult(m, err)
 	}()
 	return ch
 }
 
+func (m *Connection) GetRequestQueueMetrics() apiModel.PlcRequestQueueMetrics {
+	return m.tm.GetMetrics()
+}
+
+func (m *Connection) GetConnectionMetrics() apiModel.PlcConnectionMetrics {
+	return spi.GetConnectionMetrics(m.messageCodec).GetMetrics()
+}
+
 func (m *Connection) IsConnected() bool {
-	panic("implement me")
+	return m.messageCodec.IsRunning()
 }
 
 func (m *Connection) Ping() <-chan plc4go.PlcConnectionPingResult {
-	panic("implement me")
+	return m.PingWithContext(context.Background())
+}
+
+func (m *Connection) PingWithContext(_ context.Context) <-chan plc4go.PlcConnectionPingResult {
+	result := make(chan plc4go.PlcConnectionPingResult, 1)
+	result <- plc4go.NewPlcConnectionPingResult(plc4go.ErrPingNotSupported)
+	return result
 }
 
 func (m *Connection) GetMetadata() apiModel.PlcConnectionMetadata {
@@ -134,23 +177,30 @@ func (m *Connection) GetMetadata() apiModel.PlcConnectionMetadata {
 }
 
 func (m *Connection) ReadRequestBuilder() apiModel.PlcReadRequestBuilder {
-	return internalModel.NewDefaultPlcReadRequestBuilder(m.fieldHandler, m.reader)
+	return internalModel.NewDefaultPlcReadRequestBuilderWithInterceptor(m.fieldHandler,
+		spi.NewMeteredReader(m.reader, spi.GetConnectionMetrics(m.messageCodec)), m.readRequestInterceptor)
 }
 
 func (m *Connection) WriteRequestBuilder() apiModel.PlcWriteRequestBuilder {
-	return internalModel.NewDefaultPlcWriteRequestBuilder(m.fieldHandler, m.valueHandler, m.writer)
+	// ADS reads multiple fields at once, but writes them one by one
+	return internalModel.NewDefaultPlcWriteRequestBuilderWithInterceptor(m.fieldHandler, m.valueHandler,
+		spi.NewMeteredWriter(m.writer, spi.GetConnectionMetrics(m.messageCodec)), m.requestInterceptor)
 }
 
 func (m *Connection) SubscriptionRequestBuilder() apiModel.PlcSubscriptionRequestBuilder {
-	panic("implement me")
+	return internalModel.NewFailingPlcSubscriptionRequestBuilder(internalModel.ErrNotSupported)
 }
 
 func (m *Connection) UnsubscriptionRequestBuilder() apiModel.PlcUnsubscriptionRequestBuilder {
-	panic("implement me")
+	return internalModel.NewFailingPlcUnsubscriptionRequestBuilder(internalModel.ErrNotSupported)
 }
 
 func (m *Connection) BrowseRequestBuilder() apiModel.PlcBrowseRequestBuilder {
-	panic("implement me")
+	return internalModel.NewFailingPlcBrowseRequestBuilder(internalModel.ErrNotSupported)
+}
+
+func (m *Connection) GetMessageCodec() spi.MessageCodec {
+	return m.messageCodec
 }
 
 func (m *Connection) GetTransportInstance() transports.TransportInstance {
@@ -19,12 +19,13 @@
 package ads
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"net/url"
 )
 
@@ -47,19 +48,28 @@ func (m *Driver) GetProtocolName() string {
 }
 
 func (m *Driver) GetDefaultTransport() string {
-	panic("implement me")
+	return "tcp"
 }
 
 func (m *Driver) CheckQuery(query string) error {
 	panic("implement me")
 }
 
+func (m *Driver) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
 func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
-	log.Debug().Stringer("transportUrl", &transportUrl).Msgf("Get connection for transport url with %d transport(s) and %d option(s)", len(transports), len(options))
+	return m.GetConnectionWithContext(context.Background(), transportUrl, transports, options)
+}
+
+func (m *Driver) GetConnectionWithContext(ctx context.Context, transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
+	logger := spi.LoggerFromContext(ctx)
+	logger.Debug().Stringer("transportUrl", &transportUrl).Msgf("Get connection for transport url with %d transport(s) and %d option(s)", len(transports), len(options))
 	// Get an the transport specified in the url
 	transport, ok := transports[transportUrl.Scheme]
 	if !ok {
-		log.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't find a transport for scheme %s", transportUrl.Scheme)
+		logger.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't find a transport for scheme %s", transportUrl.Scheme)
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
 			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Errorf("couldn't find transport for given transport url %#v", transportUrl))
@@ -71,7 +81,7 @@ func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]trans
 	// Have the transport create a new transport-instance.
 	transportInstance, err := transport.CreateTransportInstance(transportUrl, options)
 	if err != nil {
-		log.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't create a transport instance for port %#v", options["defaultTcpPort"])
+		logger.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't create a transport instance for port %#v", options["defaultTcpPort"])
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.New("couldn't initialize transport configuration for given transport url "+transportUrl.String()))
 		return ch
@@ -79,18 +89,19 @@ func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]trans
 
 	// Create a new codec for taking care of encoding/decoding of messages
 	codec := NewMessageCodec(transportInstance)
-	log.Debug().Msgf("working with codec %#v", codec)
+	codec.SetLogger(logger)
+	logger.Debug().Msgf("working with codec %#v", codec)
 
 	configuration, err := ParseFromOptions(options)
 	if err != nil {
-		log.Error().Err(err).Msgf("Invalid options")
+		logger.Error().Err(err).Msgf("Invalid options")
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(err, "invalid configuration"))
 		return ch
 	}
 
 	// Create the new connection
-	connection, err := NewConnection(codec, configuration, m.fieldHandler)
+	connection, err := NewConnection(codec, configuration, m.fieldHandler, logger)
 	if err != nil {
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
@@ -98,8 +109,8 @@ func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]trans
 		}()
 		return ch
 	}
-	log.Info().Stringer("connection", connection).Msg("created connection, connecting now")
-	return connection.Connect()
+	logger.Info().Stringer("connection", connection).Msg("created connection, connecting now")
+	return connection.ConnectWithContext(ctx)
 }
 
 func (m *Driver) Discover(_ func(event model.PlcDiscoveryEvent)) error {
@@ -36,6 +36,7 @@ type FieldType uint8
 const (
 	DirectAdsStringField   FieldType = 0x00
 	DirectAdsField         FieldType = 0x01
+	DirectAdsBlockField    FieldType = 0x02
 	SymbolicAdsStringField FieldType = 0x03
 	SymbolicAdsField       FieldType = 0x04
 )
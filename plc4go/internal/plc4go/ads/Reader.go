@@ -19,18 +19,19 @@
 package ads
 
 import (
+	"context"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/ads/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	plc4goModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	spiValues "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"math"
 	"sync"
 	"sync/atomic"
-	"time"
 )
 
 type Reader struct {
@@ -40,11 +41,14 @@ type Reader struct {
 	sourceAmsNetId        readWriteModel.AmsNetId
 	sourceAmsPort         uint16
 	messageCodec          spi.MessageCodec
+	tm                    *spi.RequestTransactionManager
+	requestQueue          spi.RequestQueueConfiguration
+	logger                zerolog.Logger
 	fieldMapping          map[SymbolicPlcField]DirectPlcField
 	mappingLock           sync.Mutex
 }
 
-func NewReader(messageCodec spi.MessageCodec, targetAmsNetId readWriteModel.AmsNetId, targetAmsPort uint16, sourceAmsNetId readWriteModel.AmsNetId, sourceAmsPort uint16) *Reader {
+func NewReader(messageCodec spi.MessageCodec, targetAmsNetId readWriteModel.AmsNetId, targetAmsPort uint16, sourceAmsNetId readWriteModel.AmsNetId, sourceAmsPort uint16, tm *spi.RequestTransactionManager, requestQueue spi.RequestQueueConfiguration) *Reader {
 	return &Reader{
 		transactionIdentifier: 0,
 		targetAmsNetId:        targetAmsNetId,
@@ -52,31 +56,34 @@ func NewReader(messageCodec spi.MessageCodec, targetAmsNetId readWriteModel.AmsN
 		sourceAmsNetId:        sourceAmsNetId,
 		sourceAmsPort:         sourceAmsPort,
 		messageCodec:          messageCodec,
+		tm:                    tm,
+		requestQueue:          requestQueue,
+		logger:                *tm.GetLogger(),
 		fieldMapping:          make(map[SymbolicPlcField]DirectPlcField),
 	}
 }
 
-func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
-	log.Trace().Msg("Reading")
-	result := make(chan model.PlcReadRequestResult)
+func (m *Reader) Read(ctx context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	m.logger.Trace().Msg("Reading")
+	result := make(chan model.PlcReadRequestResult, 1)
 	go func() {
 		if len(readRequest.GetFieldNames()) <= 1 {
-			m.singleRead(readRequest, result)
+			m.singleRead(ctx, readRequest, result)
 		} else {
-			m.multiRead(readRequest, result)
+			m.multiRead(ctx, readRequest, result)
 		}
 	}()
 	return result
 }
 
-func (m *Reader) singleRead(readRequest model.PlcReadRequest, result chan model.PlcReadRequestResult) {
+func (m *Reader) singleRead(ctx context.Context, readRequest model.PlcReadRequest, result chan model.PlcReadRequestResult) {
 	if len(readRequest.GetFieldNames()) != 1 {
 		result <- model.PlcReadRequestResult{
 			Request:  readRequest,
 			Response: nil,
 			Err:      errors.New("ads only supports single-item requests"),
 		}
-		log.Debug().Msgf("ads only supports single-item requests. Got %d fields", len(readRequest.GetFieldNames()))
+		m.logger.Debug().Msgf("ads only supports single-item requests. Got %d fields", len(readRequest.GetFieldNames()))
 		return
 	}
 	// If we are requesting only one field, use a
@@ -90,17 +97,17 @@ func (m *Reader) singleRead(readRequest model.PlcReadRequest, result chan model.
 				Response: nil,
 				Err:      errors.Wrap(err, "invalid field item type"),
 			}
-			log.Debug().Msgf("Invalid field item type %T", field)
+			m.logger.Debug().Msgf("Invalid field item type %T", field)
 			return
 		}
-		field, err = m.resolveField(adsField)
+		field, err = m.resolveField(ctx, adsField)
 		if err != nil {
 			result <- model.PlcReadRequestResult{
 				Request:  readRequest,
 				Response: nil,
 				Err:      errors.Wrap(err, "invalid field item type"),
 			}
-			log.Debug().Msgf("Invalid field item type %T", field)
+			m.logger.Debug().Msgf("Invalid field item type %T", field)
 			return
 		}
 	}
@@ -111,7 +118,7 @@ func (m *Reader) singleRead(readRequest model.PlcReadRequest, result chan model.
 			Response: nil,
 			Err:      errors.Wrap(err, "invalid field item type"),
 		}
-		log.Debug().Msgf("Invalid field item type %T", field)
+		m.logger.Debug().Msgf("Invalid field item type %T", field)
 		return
 	}
 	userdata := readWriteModel.AmsPacket{
@@ -128,6 +135,9 @@ func (m *Reader) singleRead(readRequest model.PlcReadRequest, result chan model.
 
 	readLength := uint32(adsField.Datatype.NumBytes())
 	switch {
+	case adsField.FieldType == DirectAdsBlockField:
+		// Blocks are read as raw bytes
+		readLength = adsField.NumberOfElements
 	case adsField.GetDatatype() == readWriteModel.AdsDataType_STRING:
 		// If an explicit size is given with the string, use this, if not use 256
 		if adsField.GetStringLength() != 0 {
@@ -147,10 +157,10 @@ func (m *Reader) singleRead(readRequest model.PlcReadRequest, result chan model.
 	}
 	userdata.Data = readWriteModel.NewAdsReadRequest(adsField.IndexGroup, adsField.IndexOffset, readLength)
 
-	m.sendOverTheWire(userdata, readRequest, result)
+	m.sendOverTheWire(ctx, userdata, readRequest, result)
 }
 
-func (m *Reader) multiRead(readRequest model.PlcReadRequest, result chan model.PlcReadRequestResult) {
+func (m *Reader) multiRead(ctx context.Context, readRequest model.PlcReadRequest, result chan model.PlcReadRequestResult) {
 	// Calculate the size of all fields together.
 	// Calculate the expected size of the response data.
 	expectedResponseDataSize := uint32(0)
@@ -210,17 +220,17 @@ func (m *Reader) multiRead(readRequest model.PlcReadRequest, result chan model.P
 					Response: nil,
 					Err:      errors.Wrap(err, "invalid field item type"),
 				}
-				log.Debug().Msgf("Invalid field item type %T", field)
+				m.logger.Debug().Msgf("Invalid field item type %T", field)
 				return
 			}
-			field, err = m.resolveField(adsField)
+			field, err = m.resolveField(ctx, adsField)
 			if err != nil {
 				result <- model.PlcReadRequestResult{
 					Request:  readRequest,
 					Response: nil,
 					Err:      errors.Wrap(err, "invalid field item type"),
 				}
-				log.Debug().Msgf("Invalid field item type %T", field)
+				m.logger.Debug().Msgf("Invalid field item type %T", field)
 				return
 			}
 		}
@@ -231,7 +241,7 @@ func (m *Reader) multiRead(readRequest model.PlcReadRequest, result chan model.P
 				Response: nil,
 				Err:      errors.Wrap(err, "invalid field item type"),
 			}
-			log.Debug().Msgf("Invalid field item type %T", field)
+			m.logger.Debug().Msgf("Invalid field item type %T", field)
 			return
 		}
 		// With multi-requests, the index-group is fixed and the index offset indicates the number of elements.
@@ -239,72 +249,77 @@ func (m *Reader) multiRead(readRequest model.PlcReadRequest, result chan model.P
 	}
 	userdata.Data = readWriteModel.NewAdsReadWriteRequest(uint32(readWriteModel.ReservedIndexGroups_ADSIGRP_MULTIPLE_READ), uint32(len(readRequest.GetFieldNames())), expectedResponseDataSize, items, nil)
 
-	m.sendOverTheWire(userdata, readRequest, result)
+	m.sendOverTheWire(ctx, userdata, readRequest, result)
 }
 
-func (m *Reader) sendOverTheWire(userdata readWriteModel.AmsPacket, readRequest model.PlcReadRequest, result chan model.PlcReadRequestResult) {
-	// Calculate a new transaction identifier
-	transactionIdentifier := atomic.AddUint32(&m.transactionIdentifier, 1)
-	if transactionIdentifier > math.MaxUint8 {
-		transactionIdentifier = 1
-		atomic.StoreUint32(&m.transactionIdentifier, 1)
-	}
-	log.Debug().Msgf("Calculated transaction identifier %x", transactionIdentifier)
-	userdata.InvokeId = transactionIdentifier
-
-	// Assemble the finished tcp paket
-	log.Trace().Msg("Assemble tcp paket")
-	amsTcpPaket := readWriteModel.AmsTCPPacket{
-		Userdata: &userdata,
-	}
+func (m *Reader) sendOverTheWire(ctx context.Context, userdata readWriteModel.AmsPacket, readRequest model.PlcReadRequest, result chan model.PlcReadRequestResult) {
+	// Repeat the read, if it times out, as reading twice doesn't hurt
+	timeout := m.requestQueue.GetRequestTimeout(readRequest.GetTimeout())
+	retries := m.requestQueue.GetRetries(readRequest.GetRetries())
+	result <- plc4goModel.ReadWithRetries(ctx, m.logger, readRequest, retries, func() model.PlcReadRequestResult {
+		// Calculate a new transaction identifier
+		transactionIdentifier := atomic.AddUint32(&m.transactionIdentifier, 1)
+		if transactionIdentifier > math.MaxUint8 {
+			transactionIdentifier = 1
+			atomic.StoreUint32(&m.transactionIdentifier, 1)
+		}
+		m.logger.Debug().Msgf("Calculated transaction identifier %x", transactionIdentifier)
+		userdata.InvokeId = transactionIdentifier
 
-	// Send the TCP Paket over the wire
-	log.Trace().Msg("Send TCP Paket")
-	if err := m.messageCodec.SendRequest(
-		amsTcpPaket,
-		func(message interface{}) bool {
-			paket := readWriteModel.CastAmsTCPPacket(message)
-			return paket.Userdata.InvokeId == transactionIdentifier
-		},
-		func(message interface{}) error {
-			// Convert the response into an amsTcpPaket
-			log.Trace().Msg("convert response to amsTcpPaket")
-			amsTcpPaket := readWriteModel.CastAmsTCPPacket(message)
-			// Convert the ads response into a PLC4X response
-			log.Trace().Msg("convert response to PLC4X response")
-			readResponse, err := m.ToPlc4xReadResponse(*amsTcpPaket, readRequest)
+		// Assemble the finished tcp paket
+		m.logger.Trace().Msg("Assemble tcp paket")
+		amsTcpPaket := readWriteModel.AmsTCPPacket{
+			Userdata: &userdata,
+		}
 
-			if err != nil {
-				result <- model.PlcReadRequestResult{
-					Request: readRequest,
-					Err:     errors.Wrap(err, "Error decoding response"),
-				}
-				// TODO: should we return the error here?
-				return nil
-			}
-			result <- model.PlcReadRequestResult{
-				Request:  readRequest,
-				Response: readResponse,
+		// Send the TCP Paket over the wire, as soon as it's our turn on this connection
+		var readResponse model.PlcReadResponse
+		transaction := m.tm.StartRequest()
+		transaction.SetPriority(m.requestQueue.ReadPriority)
+		transaction.SubmitWithContext(ctx, func() {
+			transaction.GetLogger().Trace().Msg("Send TCP Paket")
+			if err := m.messageCodec.SendRequestWithCorrelationKey(
+				transaction.GetContext(),
+				amsTcpPaket,
+				transactionIdentifier,
+				func(message interface{}) bool {
+					paket := readWriteModel.CastAmsTCPPacket(message)
+					return paket.Userdata.InvokeId == transactionIdentifier
+				},
+				func(message interface{}) error {
+					// Convert the response into an amsTcpPaket
+					transaction.GetLogger().Trace().Msg("convert response to amsTcpPaket")
+					amsTcpPaket := readWriteModel.CastAmsTCPPacket(message)
+					// Convert the ads response into a PLC4X response
+					transaction.GetLogger().Trace().Msg("convert response to PLC4X response")
+					response, err := m.ToPlc4xReadResponse(*amsTcpPaket, readRequest)
+					if err != nil {
+						return transaction.FailRequest(errors.Wrap(err, "Error decoding response"))
+					}
+					readResponse = response
+					return transaction.EndRequest()
+				},
+				func(err error) error {
+					return transaction.FailRequest(errors.Wrap(err, "got timeout while waiting for response"))
+				},
+				timeout); err != nil {
+				_ = transaction.FailRequest(errors.Wrap(err, "error sending message"))
 			}
-			return nil
-		},
-		func(err error) error {
-			result <- model.PlcReadRequestResult{
+		})
+		if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+			return model.PlcReadRequestResult{
 				Request: readRequest,
-				Err:     errors.Wrap(err, "got timeout while waiting for response"),
+				Err:     err,
 			}
-			return nil
-		},
-		time.Second*1); err != nil {
-		result <- model.PlcReadRequestResult{
+		}
+		return model.PlcReadRequestResult{
 			Request:  readRequest,
-			Response: nil,
-			Err:      errors.Wrap(err, "error sending message"),
+			Response: readResponse,
 		}
-	}
+	})
 }
 
-func (m *Reader) resolveField(symbolicField SymbolicPlcField) (DirectPlcField, error) {
+func (m *Reader) resolveField(ctx context.Context, symbolicField SymbolicPlcField) (DirectPlcField, error) {
 	if directPlcField, ok := m.fieldMapping[symbolicField]; ok {
 		return directPlcField, nil
 	}
@@ -332,22 +347,22 @@ func (m *Reader) resolveField(symbolicField SymbolicPlcField) (DirectPlcField, e
 		nil,
 		utils.ByteArrayToInt8Array([]byte(symbolicField.SymbolicAddress+"\000")),
 	)
-	result := make(chan model.PlcReadRequestResult)
+	result := make(chan model.PlcReadRequestResult, 1)
 	go func() {
 		dummyRequest := plc4goModel.NewDefaultPlcReadRequest(map[string]model.PlcField{"dummy": DirectPlcField{PlcField: PlcField{Datatype: readWriteModel.AdsDataType_UINT32}}}, []string{"dummy"}, nil, nil)
-		m.sendOverTheWire(userdata, dummyRequest, result)
+		m.sendOverTheWire(ctx, userdata, dummyRequest, result)
 	}()
 	// We wait synchronous for the resolution response before we can continue
 	response := <-result
 	if response.Err != nil {
-		log.Debug().Err(response.Err).Msg("Error during resolve")
+		m.logger.Debug().Err(response.Err).Msg("Error during resolve")
 		return DirectPlcField{}, response.Err
 	}
 	if response.Response.GetResponseCode("dummy") != model.PlcResponseCode_OK {
 		return DirectPlcField{}, errors.Errorf("Got a response error %#v", response.Response.GetResponseCode("dummy"))
 	}
 	handle := response.Response.GetValue("dummy").GetUint32()
-	log.Debug().Uint32("handle", handle).Str("symbolicAddress", symbolicField.SymbolicAddress).Msg("Resolved symbolic address")
+	m.logger.Debug().Uint32("handle", handle).Str("symbolicAddress", symbolicField.SymbolicAddress).Msg("Resolved symbolic address")
 	directPlcField := DirectPlcField{
 		IndexGroup:  uint32(readWriteModel.ReservedIndexGroups_ADSIGRP_SYM_VALBYHND),
 		IndexOffset: handle,
@@ -387,7 +402,7 @@ func (m *Reader) ToPlc4xReadResponse(amsTcpPaket readWriteModel.AmsTCPPacket, re
 			}
 			responseCode, err := rb.ReadUint32(32)
 			if err != nil {
-				log.Error().Err(err).Str("fieldName", fieldName).Msgf("Error parsing field %s", fieldName)
+				m.logger.Error().Err(err).Str("fieldName", fieldName).Msgf("Error parsing field %s", fieldName)
 				responseCodes[fieldName] = model.PlcResponseCode_INTERNAL_ERROR
 				continue
 			}
@@ -396,7 +411,7 @@ func (m *Reader) ToPlc4xReadResponse(amsTcpPaket readWriteModel.AmsTCPPacket, re
 				responseCodes[fieldName] = model.PlcResponseCode_OK
 			default:
 				// TODO: Implement this a little more ...
-				log.Error().Stringer("adsReturnCode", readWriteModel.ReturnCodeByValue(responseCode)).Msgf("Unmapped return code for %s", fieldName)
+				m.logger.Error().Stringer("adsReturnCode", readWriteModel.ReturnCodeByValue(responseCode)).Msgf("Unmapped return code for %s", fieldName)
 				responseCodes[fieldName] = model.PlcResponseCode_INTERNAL_ERROR
 			}
 		}
@@ -407,17 +422,34 @@ func (m *Reader) ToPlc4xReadResponse(amsTcpPaket readWriteModel.AmsTCPPacket, re
 	plcValues := map[string]values.PlcValue{}
 	// Get the field from the request
 	for _, fieldName := range readRequest.GetFieldNames() {
-		log.Debug().Msgf("get a field from request with name %s", fieldName)
+		m.logger.Debug().Msgf("get a field from request with name %s", fieldName)
 		field, err := castToAdsFieldFromPlcField(readRequest.GetField(fieldName))
 		if err != nil {
 			return nil, errors.Wrap(err, "error casting to ads-field")
 		}
 
+		// Blocks are sliced into the values of the fields they contain by the interceptor
+		if directField, ok := field.(DirectPlcField); ok && directField.FieldType == DirectAdsBlockField {
+			data := make([]byte, directField.NumberOfElements)
+			for i := range data {
+				if data[i], err = rb.ReadUint8(8); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				m.logger.Error().Err(err).Msg("Error reading block")
+				responseCodes[fieldName] = model.PlcResponseCode_INTERNAL_ERROR
+				continue
+			}
+			plcValues[fieldName] = spiValues.NewPlcByteArray(data)
+			continue
+		}
+
 		// Decode the data according to the information from the request
-		log.Trace().Msg("decode data")
+		m.logger.Trace().Msg("decode data")
 		value, err := readWriteModel.DataItemParse(rb, field.GetDatatype().DataFormatName(), field.GetStringLength())
 		if err != nil {
-			log.Error().Err(err).Msg("Error parsing data item")
+			m.logger.Error().Err(err).Msg("Error parsing data item")
 			responseCodes[fieldName] = model.PlcResponseCode_INTERNAL_ERROR
 			continue
 		}
@@ -426,6 +458,6 @@ func (m *Reader) ToPlc4xReadResponse(amsTcpPaket readWriteModel.AmsTCPPacket, re
 	}
 
 	// Return the response
-	log.Trace().Msg("Returning the response")
+	m.logger.Trace().Msg("Returning the response")
 	return plc4goModel.NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues), nil
 }
@@ -19,16 +19,16 @@
 package ads
 
 import (
+	"context"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/ads/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	plc4goModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"math"
 	"sync/atomic"
-	"time"
 )
 
 type Writer struct {
@@ -38,10 +38,13 @@ type Writer struct {
 	sourceAmsNetId        readWriteModel.AmsNetId
 	sourceAmsPort         uint16
 	messageCodec          spi.MessageCodec
+	tm                    *spi.RequestTransactionManager
+	requestQueue          spi.RequestQueueConfiguration
+	logger                zerolog.Logger
 	reader                *Reader
 }
 
-func NewWriter(messageCodec spi.MessageCodec, targetAmsNetId readWriteModel.AmsNetId, targetAmsPort uint16, sourceAmsNetId readWriteModel.AmsNetId, sourceAmsPort uint16, reader *Reader) *Writer {
+func NewWriter(messageCodec spi.MessageCodec, targetAmsNetId readWriteModel.AmsNetId, targetAmsPort uint16, sourceAmsNetId readWriteModel.AmsNetId, sourceAmsPort uint16, tm *spi.RequestTransactionManager, requestQueue spi.RequestQueueConfiguration, reader *Reader) *Writer {
 	return &Writer{
 		transactionIdentifier: 0,
 		targetAmsNetId:        targetAmsNetId,
@@ -49,12 +52,15 @@ func NewWriter(messageCodec spi.MessageCodec, targetAmsNetId readWriteModel.AmsN
 		sourceAmsNetId:        sourceAmsNetId,
 		sourceAmsPort:         sourceAmsPort,
 		messageCodec:          messageCodec,
+		tm:                    tm,
+		requestQueue:          requestQueue,
+		logger:                *tm.GetLogger(),
 		reader:                reader,
 	}
 }
 
-func (m *Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
-	result := make(chan model.PlcWriteRequestResult)
+func (m *Writer) Write(ctx context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
+	result := make(chan model.PlcWriteRequestResult, 1)
 	go func() {
 		// If we are requesting only one field, use a
 		if len(writeRequest.GetFieldNames()) != 1 {
@@ -77,17 +83,17 @@ func (m *Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWrite
 					Response: nil,
 					Err:      errors.Wrap(err, "invalid field item type"),
 				}
-				log.Debug().Msgf("Invalid field item type %T", field)
+				m.logger.Debug().Msgf("Invalid field item type %T", field)
 				return
 			}
-			field, err = m.reader.resolveField(adsField)
+			field, err = m.reader.resolveField(ctx, adsField)
 			if err != nil {
 				result <- model.PlcWriteRequestResult{
 					Request:  writeRequest,
 					Response: nil,
 					Err:      errors.Wrap(err, "invalid field item type"),
 				}
-				log.Debug().Msgf("Invalid field item type %T", field)
+				m.logger.Debug().Msgf("Invalid field item type %T", field)
 				return
 			}
 		}
@@ -142,55 +148,65 @@ func (m *Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWrite
 			return
 		}
 
-		// Calculate a new unit identifier
-		// TODO: this is not threadsafe as the whole operation is not atomic
-		transactionIdentifier := atomic.AddUint32(&m.transactionIdentifier, 1)
-		if transactionIdentifier > math.MaxUint8 {
-			transactionIdentifier = 0
-			atomic.StoreUint32(&m.transactionIdentifier, 0)
-		}
-		userdata.InvokeId = transactionIdentifier
-
-		// Assemble the finished amsTcpPaket
-		log.Trace().Msg("Assemble amsTcpPaket")
-		amsTcpPaket := readWriteModel.AmsTCPPacket{
-			Userdata: &userdata,
-		}
+		timeout := m.requestQueue.GetRequestTimeout(writeRequest.GetTimeout())
+		result <- plc4goModel.WriteWithoutRetries(m.logger, writeRequest, func() model.PlcWriteRequestResult {
+			// Calculate a new unit identifier
+			// TODO: this is not threadsafe as the whole operation is not atomic
+			transactionIdentifier := atomic.AddUint32(&m.transactionIdentifier, 1)
+			if transactionIdentifier > math.MaxUint8 {
+				transactionIdentifier = 0
+				atomic.StoreUint32(&m.transactionIdentifier, 0)
+			}
+			userdata.InvokeId = transactionIdentifier
 
-		// Send the TCP Paket over the wire
-		err = m.messageCodec.SendRequest(
-			amsTcpPaket,
-			func(message interface{}) bool {
-				paket := readWriteModel.CastAmsTCPPacket(message)
-				return paket.Userdata.InvokeId == transactionIdentifier
-			},
-			func(message interface{}) error {
-				// Convert the response into an responseAmsTcpPaket
-				responseAmsTcpPaket := readWriteModel.CastAmsTCPPacket(message)
-				// Convert the ads response into a PLC4X response
-				readResponse, err := m.ToPlc4xWriteResponse(amsTcpPaket, *responseAmsTcpPaket, writeRequest)
+			// Assemble the finished amsTcpPaket
+			m.logger.Trace().Msg("Assemble amsTcpPaket")
+			amsTcpPaket := readWriteModel.AmsTCPPacket{
+				Userdata: &userdata,
+			}
 
-				if err != nil {
-					result <- model.PlcWriteRequestResult{
-						Request: writeRequest,
-						Err:     errors.Wrap(err, "Error decoding response"),
-					}
-				} else {
-					result <- model.PlcWriteRequestResult{
-						Request:  writeRequest,
-						Response: readResponse,
-					}
+			// Send the TCP Paket over the wire, as soon as it's our turn on this connection
+			var writeResponse model.PlcWriteResponse
+			transaction := m.tm.StartRequest()
+			transaction.SetPriority(m.requestQueue.WritePriority)
+			transaction.SubmitWithContext(ctx, func() {
+				if err := m.messageCodec.SendRequestWithCorrelationKey(
+					transaction.GetContext(),
+					amsTcpPaket,
+					transactionIdentifier,
+					func(message interface{}) bool {
+						paket := readWriteModel.CastAmsTCPPacket(message)
+						return paket.Userdata.InvokeId == transactionIdentifier
+					},
+					func(message interface{}) error {
+						// Convert the response into an responseAmsTcpPaket
+						responseAmsTcpPaket := readWriteModel.CastAmsTCPPacket(message)
+						// Convert the ads response into a PLC4X response
+						response, err := m.ToPlc4xWriteResponse(amsTcpPaket, *responseAmsTcpPaket, writeRequest)
+						if err != nil {
+							return transaction.FailRequest(errors.Wrap(err, "Error decoding response"))
+						}
+						writeResponse = response
+						return transaction.EndRequest()
+					},
+					func(err error) error {
+						return transaction.FailRequest(errors.Wrap(err, "got timeout while waiting for response"))
+					},
+					timeout); err != nil {
+					_ = transaction.FailRequest(errors.Wrap(err, "error sending message"))
 				}
-				return nil
-			},
-			func(err error) error {
-				result <- model.PlcWriteRequestResult{
+			})
+			if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+				return model.PlcWriteRequestResult{
 					Request: writeRequest,
-					Err:     errors.New("got timeout while waiting for response"),
+					Err:     err,
 				}
-				return nil
-			},
-			time.Second*1)
+			}
+			return model.PlcWriteRequestResult{
+				Request:  writeRequest,
+				Response: writeResponse,
+			}
+		})
 	}()
 	return result
 }
@@ -210,6 +226,6 @@ func (m *Writer) ToPlc4xWriteResponse(requestTcpPaket readWriteModel.AmsTCPPacke
 	}
 
 	// Return the response
-	log.Trace().Msg("Returning the response")
+	m.logger.Trace().Msg("Returning the response")
 	return plc4goModel.NewDefaultPlcWriteResponse(writeRequest, responseCodes), nil
 }
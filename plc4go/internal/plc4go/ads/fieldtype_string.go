@@ -27,23 +27,24 @@ func _() {
 	var x [1]struct{}
 	_ = x[DirectAdsStringField-0]
 	_ = x[DirectAdsField-1]
+	_ = x[DirectAdsBlockField-2]
 	_ = x[SymbolicAdsStringField-3]
 	_ = x[SymbolicAdsField-4]
 }
 
 const (
-	_FieldType_name_0 = "DirectAdsStringFieldDirectAdsField"
+	_FieldType_name_0 = "DirectAdsStringFieldDirectAdsFieldDirectAdsBlockField"
 	_FieldType_name_1 = "SymbolicAdsStringFieldSymbolicAdsField"
 )
 
 var (
-	_FieldType_index_0 = [...]uint8{0, 20, 34}
+	_FieldType_index_0 = [...]uint8{0, 20, 34, 53}
 	_FieldType_index_1 = [...]uint8{0, 22, 38}
 )
 
 func (i FieldType) String() string {
 	switch {
-	case i <= 1:
+	case i <= 2:
 		return _FieldType_name_0[_FieldType_index_0[i]:_FieldType_index_0[i+1]]
 	case 3 <= i && i <= 4:
 		i -= 3
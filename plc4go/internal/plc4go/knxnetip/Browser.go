@@ -19,6 +19,7 @@
 package knxnetip
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
@@ -28,7 +29,6 @@ import (
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"strconv"
 	"strings"
 	"time"
@@ -48,14 +48,14 @@ func NewBrowser(connection *Connection, messageCodec spi.MessageCodec) *Browser
 	}
 }
 
-func (m Browser) Browse(browseRequest apiModel.PlcBrowseRequest) <-chan apiModel.PlcBrowseRequestResult {
-	return m.BrowseWithInterceptor(browseRequest, func(result apiModel.PlcBrowseEvent) bool {
+func (m Browser) Browse(ctx context.Context, browseRequest apiModel.PlcBrowseRequest) <-chan apiModel.PlcBrowseRequestResult {
+	return m.BrowseWithInterceptor(ctx, browseRequest, func(result apiModel.PlcBrowseEvent) bool {
 		return true
 	})
 }
 
-func (m Browser) BrowseWithInterceptor(browseRequest apiModel.PlcBrowseRequest, interceptor func(result apiModel.PlcBrowseEvent) bool) <-chan apiModel.PlcBrowseRequestResult {
-	result := make(chan apiModel.PlcBrowseRequestResult)
+func (m Browser) BrowseWithInterceptor(ctx context.Context, browseRequest apiModel.PlcBrowseRequest, interceptor func(result apiModel.PlcBrowseEvent) bool) <-chan apiModel.PlcBrowseRequestResult {
+	result := make(chan apiModel.PlcBrowseRequestResult, 1)
 	sendResult := func(browseResponse apiModel.PlcBrowseResponse, err error) {
 		result <- apiModel.PlcBrowseRequestResult{
 			Request:  browseRequest,
@@ -67,6 +67,10 @@ func (m Browser) BrowseWithInterceptor(browseRequest apiModel.PlcBrowseRequest,
 	go func() {
 		results := map[string][]apiModel.PlcBrowseQueryResult{}
 		for _, queryName := range browseRequest.GetQueryNames() {
+			if err := ctx.Err(); err != nil {
+				sendResult(nil, errors.Wrap(err, "browse request cancelled"))
+				return
+			}
 			queryString := browseRequest.GetQueryString(queryName)
 			field, err := m.connection.fieldHandler.ParseQuery(queryString)
 			if err != nil {
@@ -76,7 +80,7 @@ func (m Browser) BrowseWithInterceptor(browseRequest apiModel.PlcBrowseRequest,
 
 			switch field.(type) {
 			case DeviceQueryField:
-				queryResults, err := m.executeDeviceQuery(field.(DeviceQueryField), browseRequest, queryName, interceptor)
+				queryResults, err := m.executeDeviceQuery(ctx, field.(DeviceQueryField), browseRequest, queryName, interceptor)
 				if err != nil {
 					// TODO: Return some sort of return code like with the read and write APIs
 					results[queryName] = nil
@@ -84,7 +88,7 @@ func (m Browser) BrowseWithInterceptor(browseRequest apiModel.PlcBrowseRequest,
 					results[queryName] = queryResults
 				}
 			case CommunicationObjectQueryField:
-				queryResults, err := m.executeCommunicationObjectQuery(field.(CommunicationObjectQueryField))
+				queryResults, err := m.executeCommunicationObjectQuery(ctx, field.(CommunicationObjectQueryField))
 				if err != nil {
 					// TODO: Return some sort of return code like with the read and write APIs
 					results[queryName] = nil
@@ -101,7 +105,7 @@ func (m Browser) BrowseWithInterceptor(browseRequest apiModel.PlcBrowseRequest,
 	return result
 }
 
-func (m Browser) executeDeviceQuery(field DeviceQueryField, browseRequest apiModel.PlcBrowseRequest, queryName string, interceptor func(result apiModel.PlcBrowseEvent) bool) ([]apiModel.PlcBrowseQueryResult, error) {
+func (m Browser) executeDeviceQuery(ctx context.Context, field DeviceQueryField, browseRequest apiModel.PlcBrowseRequest, queryName string, interceptor func(result apiModel.PlcBrowseEvent) bool) ([]apiModel.PlcBrowseQueryResult, error) {
 	// Create a list of address strings, which doesn't contain any ranges, lists or wildcards
 	knxAddresses, err := m.calculateAddresses(field)
 	if err != nil {
@@ -115,7 +119,7 @@ func (m Browser) executeDeviceQuery(field DeviceQueryField, browseRequest apiMod
 	// Parse each of these expanded addresses and handle them accordingly.
 	for _, knxAddress := range knxAddresses {
 		// Send a connection request to the device
-		deviceConnections := m.connection.DeviceConnect(knxAddress)
+		deviceConnections := m.connection.DeviceConnect(ctx, knxAddress)
 		select {
 		case deviceConnection := <-deviceConnections:
 			// If the request returned a connection, process it,
@@ -146,7 +150,7 @@ func (m Browser) executeDeviceQuery(field DeviceQueryField, browseRequest apiMod
 					queryResults = append(queryResults, queryResult)
 				}
 
-				deviceDisconnections := m.connection.DeviceDisconnect(knxAddress)
+				deviceDisconnections := m.connection.DeviceDisconnect(ctx, knxAddress)
 				select {
 				case _ = <-deviceDisconnections:
 				case <-time.After(m.connection.defaultTtl * 10):
@@ -162,7 +166,7 @@ func (m Browser) executeDeviceQuery(field DeviceQueryField, browseRequest apiMod
 	return queryResults, nil
 }
 
-func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryField) ([]apiModel.PlcBrowseQueryResult, error) {
+func (m Browser) executeCommunicationObjectQuery(ctx context.Context, field CommunicationObjectQueryField) ([]apiModel.PlcBrowseQueryResult, error) {
 	var results []apiModel.PlcBrowseQueryResult
 
 	knxAddress := field.toKnxAddress()
@@ -170,7 +174,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 
 	// If we have a building Key, try that to login in order to access protected
 	if m.connection.buildingKey != nil {
-		arr := m.connection.DeviceAuthenticate(*knxAddress, m.connection.buildingKey)
+		arr := m.connection.DeviceAuthenticate(ctx, *knxAddress, m.connection.buildingKey)
 		<-arr
 	}
 
@@ -185,7 +189,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating read request")
 	}
-	rrr := readRequest.Execute()
+	rrr := readRequest.ExecuteWithContext(ctx)
 	readResult := <-rrr
 	if readResult.Err != nil {
 		return nil, errors.Wrap(readResult.Err, "error reading the group address table starting address:")
@@ -212,7 +216,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating read request")
 	}
-	rrr = readRequest.Execute()
+	rrr = readRequest.ExecuteWithContext(ctx)
 	readResult = <-rrr
 	if readResult.Err != nil {
 		return nil, errors.Wrap(readResult.Err, "error reading the number of group address table entries")
@@ -243,7 +247,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating read request")
 	}
-	rrr = readRequest.Execute()
+	rrr = readRequest.ExecuteWithContext(ctx)
 	readResult = <-rrr
 	if readResult.Err != nil {
 		return nil, errors.Wrap(readResult.Err, "error reading the group address table content")
@@ -276,7 +280,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating read request")
 	}
-	rrr = readRequest.Execute()
+	rrr = readRequest.ExecuteWithContext(ctx)
 	readResult = <-rrr
 	if readResult.Err != nil {
 		return nil, errors.Wrap(readResult.Err, "error reading the group address association table address")
@@ -302,7 +306,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating read request")
 	}
-	rrr = readRequest.Execute()
+	rrr = readRequest.ExecuteWithContext(ctx)
 	readResult = <-rrr
 	if readResult.Err != nil {
 		return nil, errors.Wrap(readResult.Err, "error reading the number of group address association table entries")
@@ -331,7 +335,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating read request")
 	}
-	rrr = readRequest.Execute()
+	rrr = readRequest.ExecuteWithContext(ctx)
 	readResult = <-rrr
 	if readResult.Err != nil {
 		return nil, errors.Wrap(readResult.Err, "error reading the group address association table content")
@@ -377,7 +381,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating read request")
 		}
-		rrr = readRequest.Execute()
+		rrr = readRequest.ExecuteWithContext(ctx)
 		readResult = <-rrr
 		for groupAddress, comObjectNumber := range groupAddressComObjectNumberMapping {
 			if readResult.Response.GetResponseCode(strconv.Itoa(int(comObjectNumber))) != apiModel.PlcResponseCode_OK {
@@ -388,7 +392,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 			rb := utils.NewReadBuffer(data)
 			descriptor, err := driverModel.GroupObjectDescriptorRealisationTypeBParse(rb)
 			if err != nil {
-				log.Info().Err(err).Msg("error parsing com object descriptor")
+				m.connection.logger.Info().Err(err).Msg("error parsing com object descriptor")
 				continue
 			}
 
@@ -439,7 +443,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 			return nil, errors.Wrap(err, "error creating read request")
 		}
 
-		rrr := readRequest.Execute()
+		rrr := readRequest.ExecuteWithContext(ctx)
 		readRequestResult := <-rrr
 		readResponse := readRequestResult.Response
 		var programVersionData []byte
@@ -472,7 +476,7 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating read request")
 		}
-		rrr = readRequest.Execute()
+		rrr = readRequest.ExecuteWithContext(ctx)
 		readResult = <-rrr
 
 		for _, fieldName := range readResult.Response.GetFieldNames() {
@@ -513,11 +517,11 @@ func (m Browser) executeCommunicationObjectQuery(field CommunicationObjectQueryF
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating read request")
 		}
-		rrr = readRequest.Execute()
+		rrr = readRequest.ExecuteWithContext(ctx)
 		readResult = <-rrr
 		if readResult.Response.GetResponseCode("comObjectTableAddress") == apiModel.PlcResponseCode_OK {
 			comObjectTableAddress := readResult.Response.GetValue("comObjectTableAddress").GetUint16()
-			log.Info().Msgf("Com Object Table Address: %x", comObjectTableAddress)
+			m.connection.logger.Info().Msgf("Com Object Table Address: %x", comObjectTableAddress)
 		}
 	}
 
@@ -20,6 +20,7 @@ package knxnetip
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
@@ -32,7 +33,7 @@ import (
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"strconv"
 	"strings"
 	"sync"
@@ -103,7 +104,9 @@ type Connection struct {
 	valueHandler             spi.PlcValueHandler
 	connectionStateTimer     *time.Ticker
 	quitConnectionStateTimer chan struct{}
+	subscriber               *Subscriber
 	subscribers              []*Subscriber
+	subscribersLock          sync.RWMutex
 
 	valueCache      map[uint16][]int8
 	valueCacheMutex sync.RWMutex
@@ -121,6 +124,10 @@ type Connection struct {
 	SequenceCounter               int32
 	TunnelingRequestExpectationId int32
 	DeviceConnections             map[driverModel.KnxAddress]*KnxDeviceConnection
+	// The gateway only processes one tunneling request at a time
+	tm           *spi.RequestTransactionManager
+	requestQueue spi.RequestQueueConfiguration
+	logger       zerolog.Logger
 
 	requestInterceptor internalModel.RequestInterceptor
 	plc4go.PlcConnection
@@ -159,21 +166,26 @@ type InternalResult struct {
 	err             error
 }
 
-func NewConnection(transportInstance transports.TransportInstance, options map[string][]string, fieldHandler spi.PlcFieldHandler) *Connection {
+func NewConnection(transportInstance transports.TransportInstance, options map[string][]string, fieldHandler spi.PlcFieldHandler, requestQueue spi.RequestQueueConfiguration, logger zerolog.Logger) *Connection {
 	connection := &Connection{
 		options:                 options,
 		fieldHandler:            fieldHandler,
 		valueHandler:            NewValueHandler(),
-		requestInterceptor:      interceptors.NewSingleItemRequestInterceptor(),
+		requestInterceptor:      interceptors.NewSingleItemRequestInterceptor(logger),
 		subscribers:             []*Subscriber{},
 		valueCache:              map[uint16][]int8{},
 		valueCacheMutex:         sync.RWMutex{},
 		metadata:                &ConnectionMetadata{},
-		defaultTtl:              time.Second * 10,
+		defaultTtl:              requestQueue.RequestTimeout,
 		DeviceConnections:       map[driverModel.KnxAddress]*KnxDeviceConnection{},
+		tm:                      requestQueue.NewRequestTransactionManager(1, spi.WithLogger(logger)),
+		requestQueue:            requestQueue,
+		logger:                  logger,
 		handleTunnelingRequests: true,
 	}
 	connection.connectionTtl = connection.defaultTtl * 2
+	// All subscriptions of this connection are handled by the same subscriber, so they can be unsubscribed again
+	connection.subscriber = NewSubscriber(connection)
 
 	// If a building key was provided, save that in a dedicated variable
 	if buildingKey, ok := options["buildingKey"]; ok {
@@ -182,24 +194,30 @@ func NewConnection(transportInstance transports.TransportInstance, options map[s
 			connection.buildingKey = bc
 		}
 	}
-	connection.messageCodec = NewMessageCodec(transportInstance, connection.interceptIncomingMessage)
+	messageCodec := NewMessageCodec(transportInstance, connection.interceptIncomingMessage)
+	messageCodec.SetLogger(logger)
+	connection.messageCodec = messageCodec
 	return connection
 }
 
 func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
-	result := make(chan plc4go.PlcConnectionConnectResult)
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *Connection) ConnectWithContext(ctx context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	result := make(chan plc4go.PlcConnectionConnectResult, 1)
 	sendResult := func(connection plc4go.PlcConnection, err error) {
 		result <- plc4go.NewPlcConnectionConnectResult(connection, err)
 	}
 
 	go func() {
-		err := m.messageCodec.Connect()
+		err := m.messageCodec.ConnectWithContext(ctx)
 		if err != nil {
 			sendResult(nil, errors.Wrap(err, "error opening connection"))
 			return
 		}
 
-		searchResponse, err := m.sendGatewaySearchRequest()
+		searchResponse, err := m.sendGatewaySearchRequest(ctx)
 		if err != nil {
 			sendResult(nil, errors.Wrap(err, "error discovering device capabilities"))
 			return
@@ -232,7 +250,7 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 		// Via this connection we then get access to the entire KNX network this Gateway is connected to.
 		if supportsTunneling {
 			// As soon as we got a successful search-response back, send a connection request.
-			connectionResponse, err := m.sendGatewayConnectionRequest()
+			connectionResponse, err := m.sendGatewayConnectionRequest(ctx)
 			if err != nil {
 				sendResult(nil, errors.Wrap(err, "error connecting to device"))
 				return
@@ -266,15 +284,15 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 						if tunnelingRequest == nil {
 							tunnelingResponse := driverModel.CastTunnelingResponse(incomingMessage)
 							if tunnelingResponse != nil {
-								log.Warn().Msgf("Got an unhandled TunnelingResponse message %v\n", tunnelingResponse)
+								m.logger.Warn().Msgf("Got an unhandled TunnelingResponse message %v\n", tunnelingResponse)
 							} else {
-								log.Warn().Msgf("Not a TunnelingRequest or TunnelingResponse message %v\n", incomingMessage)
+								m.logger.Warn().Msgf("Not a TunnelingRequest or TunnelingResponse message %v\n", incomingMessage)
 							}
 							continue
 						}
 
 						if tunnelingRequest.TunnelingRequestDataBlock.CommunicationChannelId != m.CommunicationChannelId {
-							log.Warn().Msgf("Not for this connection %v\n", tunnelingRequest)
+							m.logger.Warn().Msgf("Not for this connection %v\n", tunnelingRequest)
 							continue
 						}
 
@@ -307,7 +325,7 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 							m.handleIncomingTunnelingRequest(tunnelingRequest)
 						}
 					}
-					log.Warn().Msg("Tunneling handler shat down")
+					m.logger.Warn().Msg("Tunneling handler shat down")
 				}()
 
 				// Fire the "connected" event
@@ -332,7 +350,11 @@ func (m *Connection) BlockingClose() {
 }
 
 func (m *Connection) Close() <-chan plc4go.PlcConnectionCloseResult {
-	result := make(chan plc4go.PlcConnectionCloseResult)
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *Connection) CloseWithContext(ctx context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	result := make(chan plc4go.PlcConnectionCloseResult, 1)
 
 	go func() {
 		// Stop the connection-state checker.
@@ -342,17 +364,20 @@ func (m *Connection) Close() <-chan plc4go.PlcConnectionCloseResult {
 
 		// Disconnect from all knx devices we are still connected to.
 		for targetAddress := range m.DeviceConnections {
-			disconnects := m.DeviceDisconnect(targetAddress)
+			disconnects := m.DeviceDisconnect(ctx, targetAddress)
 			select {
 			case _ = <-disconnects:
 			case <-time.After(m.defaultTtl):
 				// If we got a timeout here, well just continue the device will just auto disconnect.
-				log.Debug().Msgf("Timeout disconnecting from device %s.", KnxAddressToString(&targetAddress))
+				m.logger.Debug().Msgf("Timeout disconnecting from device %s.", KnxAddressToString(&targetAddress))
 			}
 		}
 
+		// Fail everything still waiting for its turn
+		m.tm.Close()
+
 		// Send a disconnect request from the gateway.
-		_, err := m.sendGatewayDisconnectionRequest()
+		_, err := m.sendGatewayDisconnectionRequest(ctx)
 		if err != nil {
 			result <- plc4go.NewPlcConnectionCloseResult(m, errors.Wrap(err, "got an error while disconnecting"))
 		} else {
@@ -363,6 +388,14 @@ func (m *Connection) Close() <-chan plc4go.PlcConnectionCloseResult {
 	return result
 }
 
+func (m *Connection) GetRequestQueueMetrics() apiModel.PlcRequestQueueMetrics {
+	return m.tm.GetMetrics()
+}
+
+func (m *Connection) GetConnectionMetrics() apiModel.PlcConnectionMetrics {
+	return spi.GetConnectionMetrics(m.messageCodec).GetMetrics()
+}
+
 func (m *Connection) IsConnected() bool {
 	if m.messageCodec != nil {
 		pingChannel := m.Ping()
@@ -378,11 +411,15 @@ func (m *Connection) IsConnected() bool {
 }
 
 func (m *Connection) Ping() <-chan plc4go.PlcConnectionPingResult {
-	result := make(chan plc4go.PlcConnectionPingResult)
+	return m.PingWithContext(context.Background())
+}
+
+func (m *Connection) PingWithContext(ctx context.Context) <-chan plc4go.PlcConnectionPingResult {
+	result := make(chan plc4go.PlcConnectionPingResult, 1)
 
 	go func() {
 		// Send the connection state request
-		_, err := m.sendConnectionStateRequest()
+		_, err := m.sendConnectionStateRequest(ctx)
 		if err != nil {
 			result <- plc4go.NewPlcConnectionPingResult(errors.Wrap(err, "got an error"))
 		} else {
@@ -400,26 +437,31 @@ func (m *Connection) GetMetadata() apiModel.PlcConnectionMetadata {
 
 func (m *Connection) ReadRequestBuilder() apiModel.PlcReadRequestBuilder {
 	return internalModel.NewDefaultPlcReadRequestBuilder(
-		m.fieldHandler, NewReader(m))
+		m.fieldHandler, spi.NewMeteredReader(NewReader(m), spi.GetConnectionMetrics(m.messageCodec)))
 }
 
 func (m *Connection) WriteRequestBuilder() apiModel.PlcWriteRequestBuilder {
 	return internalModel.NewDefaultPlcWriteRequestBuilder(
-		m.fieldHandler, m.valueHandler, NewWriter(m.messageCodec))
+		m.fieldHandler, m.valueHandler, spi.NewMeteredWriter(NewWriter(m.messageCodec), spi.GetConnectionMetrics(m.messageCodec)))
 }
 
 func (m *Connection) SubscriptionRequestBuilder() apiModel.PlcSubscriptionRequestBuilder {
 	return internalModel.NewDefaultPlcSubscriptionRequestBuilder(
-		m.fieldHandler, m.valueHandler, NewSubscriber(m))
+		m.fieldHandler, m.valueHandler, spi.NewMeteredSubscriber(m.subscriber, spi.GetConnectionMetrics(m.messageCodec)))
 }
 
 func (m *Connection) BrowseRequestBuilder() apiModel.PlcBrowseRequestBuilder {
-	return internalModel.NewDefaultPlcBrowseRequestBuilder(NewBrowser(m, m.messageCodec))
+	return internalModel.NewDefaultPlcBrowseRequestBuilder(
+		spi.NewMeteredBrowser(NewBrowser(m, m.messageCodec), spi.GetConnectionMetrics(m.messageCodec)))
 }
 
 func (m *Connection) UnsubscriptionRequestBuilder() apiModel.PlcUnsubscriptionRequestBuilder {
-	return nil /*internalModel.NewDefaultPlcUnsubscriptionRequestBuilder(
-	  m.fieldHandler, m.valueHandler, NewSubscriber(m.messageCodec))*/
+	return internalModel.NewDefaultPlcUnsubscriptionRequestBuilder(
+		spi.NewMeteredSubscriber(m.subscriber, spi.GetConnectionMetrics(m.messageCodec)))
+}
+
+func (m *Connection) GetMessageCodec() spi.MessageCodec {
+	return m.messageCodec
 }
 
 func (m *Connection) GetTransportInstance() transports.TransportInstance {
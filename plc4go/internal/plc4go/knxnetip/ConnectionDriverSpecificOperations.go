@@ -20,12 +20,12 @@
 package knxnetip
 
 import (
+	"context"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	values2 "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"math"
 	"strconv"
 )
@@ -41,7 +41,7 @@ import (
 // They expect the called private functions to handle timeouts, so these will not.
 ///////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (m *Connection) ReadGroupAddress(groupAddress []int8, datapointType *driverModel.KnxDatapointType) <-chan KnxReadResult {
+func (m *Connection) ReadGroupAddress(ctx context.Context, groupAddress []int8, datapointType *driverModel.KnxDatapointType) <-chan KnxReadResult {
 	result := make(chan KnxReadResult)
 
 	sendResponse := func(value *values.PlcValue, numItems uint8, err error) {
@@ -56,7 +56,7 @@ func (m *Connection) ReadGroupAddress(groupAddress []int8, datapointType *driver
 	}
 
 	go func() {
-		groupAddressReadResponse, err := m.sendGroupAddressReadRequest(groupAddress)
+		groupAddressReadResponse, err := m.sendGroupAddressReadRequest(ctx, groupAddress)
 		if err != nil {
 			sendResponse(nil, 0, errors.Wrap(err, "error reading group address"))
 			return
@@ -91,7 +91,7 @@ func (m *Connection) ReadGroupAddress(groupAddress []int8, datapointType *driver
 	return result
 }
 
-func (m *Connection) DeviceConnect(targetAddress driverModel.KnxAddress) <-chan KnxDeviceConnectResult {
+func (m *Connection) DeviceConnect(ctx context.Context, targetAddress driverModel.KnxAddress) <-chan KnxDeviceConnectResult {
 	result := make(chan KnxDeviceConnectResult)
 
 	sendResponse := func(connection *KnxDeviceConnection, err error) {
@@ -112,7 +112,7 @@ func (m *Connection) DeviceConnect(targetAddress driverModel.KnxAddress) <-chan
 		}
 
 		// First send a connection request
-		controlConnectResponse, err := m.sendDeviceConnectionRequest(targetAddress)
+		controlConnectResponse, err := m.sendDeviceConnectionRequest(ctx, targetAddress)
 		if err != nil {
 			sendResponse(nil, errors.Wrap(err, "error creating device connection"))
 			return
@@ -132,7 +132,7 @@ func (m *Connection) DeviceConnect(targetAddress driverModel.KnxAddress) <-chan
 		m.DeviceConnections[targetAddress] = connection
 
 		// If the connection request was successful, try to read the device-descriptor
-		deviceDescriptorResponse, err := m.sendDeviceDeviceDescriptorReadRequest(targetAddress)
+		deviceDescriptorResponse, err := m.sendDeviceDeviceDescriptorReadRequest(ctx, targetAddress)
 		if err != nil {
 			sendResponse(nil, errors.New(
 				"error reading device descriptor: "+err.Error()))
@@ -148,7 +148,7 @@ func (m *Connection) DeviceConnect(targetAddress driverModel.KnxAddress) <-chan
 		// default APDU Size of 15
 		// Defined in: 03_05_01 Resources v01.09.03 AS Page 40
 		deviceApduSize := uint16(15)
-		propertyValueResponse, err := m.sendDevicePropertyReadRequest(targetAddress, 0, 56, 1, 1)
+		propertyValueResponse, err := m.sendDevicePropertyReadRequest(ctx, targetAddress, 0, 56, 1, 1)
 		if err == nil {
 			// If the count is 0, then this property doesn't exist or the user has no permission to read it.
 			// In all other cases we expect the response to contain the value.
@@ -163,7 +163,7 @@ func (m *Connection) DeviceConnect(targetAddress driverModel.KnxAddress) <-chan
 				if err == nil {
 					deviceApduSize = plcValue.GetUint16()
 				} else {
-					log.Debug().Err(err).Msgf("Error parsing knx property")
+					m.logger.Debug().Err(err).Msgf("Error parsing knx property")
 				}
 			}
 		}
@@ -177,7 +177,7 @@ func (m *Connection) DeviceConnect(targetAddress driverModel.KnxAddress) <-chan
 	return result
 }
 
-func (m *Connection) DeviceDisconnect(targetAddress driverModel.KnxAddress) <-chan KnxDeviceDisconnectResult {
+func (m *Connection) DeviceDisconnect(ctx context.Context, targetAddress driverModel.KnxAddress) <-chan KnxDeviceDisconnectResult {
 	result := make(chan KnxDeviceDisconnectResult)
 
 	sendResponse := func(connection *KnxDeviceConnection, err error) {
@@ -192,7 +192,7 @@ func (m *Connection) DeviceDisconnect(targetAddress driverModel.KnxAddress) <-ch
 
 	go func() {
 		if connection, ok := m.DeviceConnections[targetAddress]; ok {
-			_, err := m.sendDeviceDisconnectionRequest(targetAddress)
+			_, err := m.sendDeviceDisconnectionRequest(ctx, targetAddress)
 
 			// Remove the connection from the list.
 			delete(m.DeviceConnections, targetAddress)
@@ -206,7 +206,7 @@ func (m *Connection) DeviceDisconnect(targetAddress driverModel.KnxAddress) <-ch
 	return result
 }
 
-func (m *Connection) DeviceAuthenticate(targetAddress driverModel.KnxAddress, buildingKey []byte) <-chan KnxDeviceAuthenticateResult {
+func (m *Connection) DeviceAuthenticate(ctx context.Context, targetAddress driverModel.KnxAddress, buildingKey []byte) <-chan KnxDeviceAuthenticateResult {
 	result := make(chan KnxDeviceAuthenticateResult)
 
 	sendResponse := func(err error) {
@@ -223,7 +223,7 @@ func (m *Connection) DeviceAuthenticate(targetAddress driverModel.KnxAddress, bu
 		// if not, create a new one.
 		connection, ok := m.DeviceConnections[targetAddress]
 		if !ok {
-			connections := m.DeviceConnect(targetAddress)
+			connections := m.DeviceConnect(ctx, targetAddress)
 			deviceConnectionResult := <-connections
 			// If we didn't get a connect, abort
 			if deviceConnectionResult.err != nil {
@@ -237,7 +237,7 @@ func (m *Connection) DeviceAuthenticate(targetAddress driverModel.KnxAddress, bu
 			return
 		}
 		authenticationLevel := uint8(0)
-		authenticationResponse, err := m.sendDeviceAuthentication(targetAddress, authenticationLevel, buildingKey)
+		authenticationResponse, err := m.sendDeviceAuthentication(ctx, targetAddress, authenticationLevel, buildingKey)
 		if err == nil {
 			if authenticationResponse.Level == authenticationLevel {
 				sendResponse(nil)
@@ -254,7 +254,7 @@ func (m *Connection) DeviceAuthenticate(targetAddress driverModel.KnxAddress, bu
 	return result
 }
 
-func (m *Connection) DeviceReadProperty(targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8, propertyIndex uint16, numElements uint8) <-chan KnxReadResult {
+func (m *Connection) DeviceReadProperty(ctx context.Context, targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8, propertyIndex uint16, numElements uint8) <-chan KnxReadResult {
 	result := make(chan KnxReadResult)
 
 	sendResponse := func(value *values.PlcValue, numItems uint8, err error) {
@@ -273,7 +273,7 @@ func (m *Connection) DeviceReadProperty(targetAddress driverModel.KnxAddress, ob
 		// if not, create a new one.
 		connection, ok := m.DeviceConnections[targetAddress]
 		if !ok {
-			connections := m.DeviceConnect(targetAddress)
+			connections := m.DeviceConnect(ctx, targetAddress)
 			deviceConnectionResult := <-connections
 			// If we didn't get a connect, abort
 			if deviceConnectionResult.err != nil {
@@ -289,7 +289,7 @@ func (m *Connection) DeviceReadProperty(targetAddress driverModel.KnxAddress, ob
 			sendResponse(nil, 0, errors.New("unable to connect to device"))
 			return
 		}
-		propertyValueResponse, err := m.sendDevicePropertyReadRequest(targetAddress, objectId, propertyId, propertyIndex, numElements)
+		propertyValueResponse, err := m.sendDevicePropertyReadRequest(ctx, targetAddress, objectId, propertyId, propertyIndex, numElements)
 		if err != nil {
 			sendResponse(nil, 0, err)
 			return
@@ -329,7 +329,7 @@ func (m *Connection) DeviceReadProperty(targetAddress driverModel.KnxAddress, ob
 	return result
 }
 
-func (m *Connection) DeviceReadPropertyDescriptor(targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8) <-chan KnxReadResult {
+func (m *Connection) DeviceReadPropertyDescriptor(ctx context.Context, targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8) <-chan KnxReadResult {
 	result := make(chan KnxReadResult)
 
 	sendResponse := func(value *values.PlcValue, numItems uint8, err error) {
@@ -348,7 +348,7 @@ func (m *Connection) DeviceReadPropertyDescriptor(targetAddress driverModel.KnxA
 		// if not, create a new one.
 		connection, ok := m.DeviceConnections[targetAddress]
 		if !ok {
-			connections := m.DeviceConnect(targetAddress)
+			connections := m.DeviceConnect(ctx, targetAddress)
 			deviceConnectionResult := <-connections
 			// If we didn't get a connect, abort
 			if deviceConnectionResult.err != nil {
@@ -365,7 +365,7 @@ func (m *Connection) DeviceReadPropertyDescriptor(targetAddress driverModel.KnxA
 			return
 		}
 		// If we successfully got a connection, read the property
-		propertyDescriptionResponse, err := m.sendDevicePropertyDescriptionReadRequest(targetAddress, objectId, propertyId)
+		propertyDescriptionResponse, err := m.sendDevicePropertyDescriptionReadRequest(ctx, targetAddress, objectId, propertyId)
 		if err != nil {
 			sendResponse(nil, 0, err)
 			return
@@ -384,7 +384,7 @@ func (m *Connection) DeviceReadPropertyDescriptor(targetAddress driverModel.KnxA
 	return result
 }
 
-func (m *Connection) DeviceReadMemory(targetAddress driverModel.KnxAddress, address uint16, numElements uint8, datapointType *driverModel.KnxDatapointType) <-chan KnxReadResult {
+func (m *Connection) DeviceReadMemory(ctx context.Context, targetAddress driverModel.KnxAddress, address uint16, numElements uint8, datapointType *driverModel.KnxDatapointType) <-chan KnxReadResult {
 	result := make(chan KnxReadResult)
 
 	sendResponse := func(value *values.PlcValue, numItems uint8, err error) {
@@ -409,7 +409,7 @@ func (m *Connection) DeviceReadMemory(targetAddress driverModel.KnxAddress, addr
 		// if not, create a new one.
 		connection, ok := m.DeviceConnections[targetAddress]
 		if !ok {
-			connections := m.DeviceConnect(targetAddress)
+			connections := m.DeviceConnect(ctx, targetAddress)
 			deviceConnectionResult := <-connections
 			// If we didn't get a connect, abort
 			if deviceConnectionResult.err != nil {
@@ -439,7 +439,7 @@ func (m *Connection) DeviceReadMemory(targetAddress driverModel.KnxAddress, addr
 			maxNumElementsPerRequest := uint8(math.Floor(float64(maxNumBytes / elementSize)))
 			numElements := uint8(math.Min(float64(remainingRequestElements), float64(maxNumElementsPerRequest)))
 			numBytes := numElements * uint8(math.Max(float64(1), float64(datapointType.DatapointMainType().SizeInBits()/8)))
-			memoryReadResponse, err := m.sendDeviceMemoryReadRequest(targetAddress, curStartingAddress, numBytes)
+			memoryReadResponse, err := m.sendDeviceMemoryReadRequest(ctx, targetAddress, curStartingAddress, numBytes)
 			if err != nil {
 				// TODO: do we need to send a response here
 				return
@@ -20,13 +20,13 @@
 package knxnetip
 
 import (
+	"context"
 	"fmt"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/udp"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"math"
 	"net"
 	"strconv"
@@ -82,8 +82,8 @@ func (m *Connection) handleIncomingTunnelingRequest(tunnelingRequest *driverMode
 					// If this is an individual address and it is targeted at us, we need to ack that.
 					targetAddress := Int8ArrayToKnxAddress(dataFrame.DestinationAddress)
 					if *targetAddress == *m.ClientKnxAddress {
-						log.Info().Msg("Acknowleding an unhandled data message.")
-						_ = m.sendDeviceAck(*dataFrame.SourceAddress, dataFrame.Apdu.Counter, func(err error) {})
+						m.logger.Info().Msg("Acknowleding an unhandled data message.")
+						_ = m.sendDeviceAck(context.Background(), *dataFrame.SourceAddress, dataFrame.Apdu.Counter, func(err error) {})
 					}
 				}
 			case *driverModel.ApduControlContainer:
@@ -93,12 +93,12 @@ func (m *Connection) handleIncomingTunnelingRequest(tunnelingRequest *driverMode
 				// If this is an individual address and it is targeted at us, we need to ack that.
 				targetAddress := Int8ArrayToKnxAddress(dataFrame.DestinationAddress)
 				if *targetAddress == *m.ClientKnxAddress {
-					log.Info().Msg("Acknowleding an unhandled contol message.")
-					_ = m.sendDeviceAck(*dataFrame.SourceAddress, dataFrame.Apdu.Counter, func(err error) {})
+					m.logger.Info().Msg("Acknowleding an unhandled contol message.")
+					_ = m.sendDeviceAck(context.Background(), *dataFrame.SourceAddress, dataFrame.Apdu.Counter, func(err error) {})
 				}
 			}
 		default:
-			log.Info().Msg("Unknown unhandled message.")
+			m.logger.Info().Msg("Unknown unhandled message.")
 		}
 	}()
 }
@@ -116,10 +116,11 @@ func (m *Connection) handleValueCacheUpdate(destinationAddress []int8, payload [
 		m.valueCacheMutex.Unlock()
 		changed = true
 	}
-	if m.subscribers != nil {
-		for _, subscriber := range m.subscribers {
-			subscriber.handleValueChange(destinationAddress, payload, changed)
-		}
+	m.subscribersLock.RLock()
+	subscribers := m.subscribers
+	m.subscribersLock.RUnlock()
+	for _, subscriber := range subscribers {
+		subscriber.handleValueChange(destinationAddress, payload, changed)
 	}
 }
 
@@ -142,7 +143,7 @@ func (m *Connection) resetTimeout() {
 }
 
 func (m *Connection) resetConnection() {
-	log.Warn().Msg("Bad connection detected")
+	m.logger.Warn().Msg("Bad connection detected")
 }
 
 func (m *Connection) getGroupAddressNumLevels() uint8 {
@@ -157,9 +158,11 @@ func (m *Connection) getGroupAddressNumLevels() uint8 {
 }
 
 func (m *Connection) addSubscriber(subscriber *Subscriber) {
+	m.subscribersLock.Lock()
+	defer m.subscribersLock.Unlock()
 	for _, sub := range m.subscribers {
 		if sub == subscriber {
-			log.Debug().Msgf("Subscriber %v already added", subscriber)
+			m.logger.Debug().Msgf("Subscriber %v already added", subscriber)
 			return
 		}
 	}
@@ -167,9 +170,15 @@ func (m *Connection) addSubscriber(subscriber *Subscriber) {
 }
 
 func (m *Connection) removeSubscriber(subscriber *Subscriber) {
+	m.subscribersLock.Lock()
+	defer m.subscribersLock.Unlock()
 	for i, sub := range m.subscribers {
 		if sub == subscriber {
-			m.subscribers = append(m.subscribers[:i], m.subscribers[i+1:]...)
+			// Copy, as handleValueChange might still be iterating the old slice
+			subscribers := make([]*Subscriber, 0, len(m.subscribers)-1)
+			subscribers = append(subscribers, m.subscribers[:i]...)
+			m.subscribers = append(subscribers, m.subscribers[i+1:]...)
+			return
 		}
 	}
 }
@@ -20,6 +20,7 @@
 package knxnetip
 
 import (
+	"context"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
@@ -37,9 +38,12 @@ import (
 // it and returning it to the calling function.
 //
 // They all assume the connection is checked and is available.
+//
+// The tunneling requests to KNX devices are queued in the connection's request transaction manager, so only one
+// of them is in flight at a time. Their *Unqueued variants do the actual sending.
 ///////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (m *Connection) sendGatewaySearchRequest() (*driverModel.SearchResponse, error) {
+func (m *Connection) sendGatewaySearchRequest(ctx context.Context) (*driverModel.SearchResponse, error) {
 	localAddress, err := m.getLocalAddress()
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting local address")
@@ -53,7 +57,7 @@ func (m *Connection) sendGatewaySearchRequest() (*driverModel.SearchResponse, er
 
 	result := make(chan *driverModel.SearchResponse)
 	errorResult := make(chan error)
-	err = m.messageCodec.SendRequest(searchRequest,
+	err = m.messageCodec.SendRequestWithContext(ctx, searchRequest,
 		func(message interface{}) bool {
 			searchResponse := driverModel.CastSearchResponse(message)
 			return searchResponse != nil
@@ -85,7 +89,7 @@ func (m *Connection) sendGatewaySearchRequest() (*driverModel.SearchResponse, er
 	}
 }
 
-func (m *Connection) sendGatewayConnectionRequest() (*driverModel.ConnectionResponse, error) {
+func (m *Connection) sendGatewayConnectionRequest(ctx context.Context) (*driverModel.ConnectionResponse, error) {
 	localAddress, err := m.getLocalAddress()
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting local address")
@@ -100,7 +104,7 @@ func (m *Connection) sendGatewayConnectionRequest() (*driverModel.ConnectionResp
 
 	result := make(chan *driverModel.ConnectionResponse)
 	errorResult := make(chan error)
-	err = m.messageCodec.SendRequest(connectionRequest,
+	err = m.messageCodec.SendRequestWithContext(ctx, connectionRequest,
 		func(message interface{}) bool {
 			connectionResponse := driverModel.CastConnectionResponse(message)
 			return connectionResponse != nil
@@ -132,7 +136,7 @@ func (m *Connection) sendGatewayConnectionRequest() (*driverModel.ConnectionResp
 	}
 }
 
-func (m *Connection) sendGatewayDisconnectionRequest() (*driverModel.DisconnectResponse, error) {
+func (m *Connection) sendGatewayDisconnectionRequest(ctx context.Context) (*driverModel.DisconnectResponse, error) {
 	localAddress, err := m.getLocalAddress()
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting local address")
@@ -150,7 +154,7 @@ func (m *Connection) sendGatewayDisconnectionRequest() (*driverModel.DisconnectR
 
 	result := make(chan *driverModel.DisconnectResponse)
 	errorResult := make(chan error)
-	err = m.messageCodec.SendRequest(disconnectRequest,
+	err = m.messageCodec.SendRequestWithContext(ctx, disconnectRequest,
 		func(message interface{}) bool {
 			disconnectResponse := driverModel.CastDisconnectResponse(message)
 			return disconnectResponse != nil
@@ -182,7 +186,7 @@ func (m *Connection) sendGatewayDisconnectionRequest() (*driverModel.DisconnectR
 	}
 }
 
-func (m *Connection) sendConnectionStateRequest() (*driverModel.ConnectionStateResponse, error) {
+func (m *Connection) sendConnectionStateRequest(ctx context.Context) (*driverModel.ConnectionStateResponse, error) {
 	localAddress, err := m.getLocalAddress()
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting local address")
@@ -197,7 +201,7 @@ func (m *Connection) sendConnectionStateRequest() (*driverModel.ConnectionStateR
 
 	result := make(chan *driverModel.ConnectionStateResponse)
 	errorResult := make(chan error)
-	err = m.messageCodec.SendRequest(connectionStateRequest,
+	err = m.messageCodec.SendRequestWithContext(ctx, connectionStateRequest,
 		func(message interface{}) bool {
 			connectionStateResponse := driverModel.CastConnectionStateResponse(message)
 			return connectionStateResponse != nil
@@ -229,7 +233,18 @@ func (m *Connection) sendConnectionStateRequest() (*driverModel.ConnectionStateR
 	}
 }
 
-func (m *Connection) sendGroupAddressReadRequest(groupAddress []int8) (*driverModel.ApduDataGroupValueResponse, error) {
+func (m *Connection) sendGroupAddressReadRequest(ctx context.Context, groupAddress []int8) (*driverModel.ApduDataGroupValueResponse, error) {
+	var response *driverModel.ApduDataGroupValueResponse
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendGroupAddressReadRequestUnqueued(ctx, groupAddress)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendGroupAddressReadRequestUnqueued(ctx context.Context, groupAddress []int8) (*driverModel.ApduDataGroupValueResponse, error) {
 	// Send the property read request and wait for a confirmation that this property is readable.
 	groupAddressReadRequest := driverModel.NewTunnelingRequest(
 		driverModel.NewTunnelingRequestDataBlock(m.CommunicationChannelId, m.getNewSequenceCounter()),
@@ -253,7 +268,8 @@ func (m *Connection) sendGroupAddressReadRequest(groupAddress []int8) (*driverMo
 
 	result := make(chan *driverModel.ApduDataGroupValueResponse)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		groupAddressReadRequest,
 		func(message interface{}) bool {
 			tunnelingRequest := driverModel.CastTunnelingRequest(message)
@@ -312,7 +328,18 @@ func (m *Connection) sendGroupAddressReadRequest(groupAddress []int8) (*driverMo
 	}
 }
 
-func (m *Connection) sendDeviceConnectionRequest(targetAddress driverModel.KnxAddress) (*driverModel.ApduControlConnect, error) {
+func (m *Connection) sendDeviceConnectionRequest(ctx context.Context, targetAddress driverModel.KnxAddress) (*driverModel.ApduControlConnect, error) {
+	var response *driverModel.ApduControlConnect
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDeviceConnectionRequestUnqueued(ctx, targetAddress)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDeviceConnectionRequestUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress) (*driverModel.ApduControlConnect, error) {
 	// Send a connection request to the individual KNX device
 	deviceConnectionRequest := driverModel.NewTunnelingRequest(
 		driverModel.NewTunnelingRequestDataBlock(m.CommunicationChannelId, m.getNewSequenceCounter()),
@@ -336,7 +363,8 @@ func (m *Connection) sendDeviceConnectionRequest(targetAddress driverModel.KnxAd
 
 	result := make(chan *driverModel.ApduControlConnect)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		deviceConnectionRequest,
 		// The Gateway is now supposed to send an Ack to this request.
 		func(message interface{}) bool {
@@ -402,7 +430,18 @@ func (m *Connection) sendDeviceConnectionRequest(targetAddress driverModel.KnxAd
 	}
 }
 
-func (m *Connection) sendDeviceDisconnectionRequest(targetAddress driverModel.KnxAddress) (*driverModel.ApduControlDisconnect, error) {
+func (m *Connection) sendDeviceDisconnectionRequest(ctx context.Context, targetAddress driverModel.KnxAddress) (*driverModel.ApduControlDisconnect, error) {
+	var response *driverModel.ApduControlDisconnect
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDeviceDisconnectionRequestUnqueued(ctx, targetAddress)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDeviceDisconnectionRequestUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress) (*driverModel.ApduControlDisconnect, error) {
 	// Send a connection request to the individual KNX device
 	deviceDisconnectionRequest := driverModel.NewTunnelingRequest(
 		driverModel.NewTunnelingRequestDataBlock(m.CommunicationChannelId, m.getNewSequenceCounter()),
@@ -426,7 +465,8 @@ func (m *Connection) sendDeviceDisconnectionRequest(targetAddress driverModel.Kn
 
 	result := make(chan *driverModel.ApduControlDisconnect)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		deviceDisconnectionRequest,
 		// The Gateway is now supposed to send an Ack to this request.
 		func(message interface{}) bool {
@@ -493,7 +533,18 @@ func (m *Connection) sendDeviceDisconnectionRequest(targetAddress driverModel.Kn
 	}
 }
 
-func (m *Connection) sendDeviceAuthentication(targetAddress driverModel.KnxAddress, authenticationLevel uint8, buildingKey []byte) (*driverModel.ApduDataExtAuthorizeResponse, error) {
+func (m *Connection) sendDeviceAuthentication(ctx context.Context, targetAddress driverModel.KnxAddress, authenticationLevel uint8, buildingKey []byte) (*driverModel.ApduDataExtAuthorizeResponse, error) {
+	var response *driverModel.ApduDataExtAuthorizeResponse
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDeviceAuthenticationUnqueued(ctx, targetAddress, authenticationLevel, buildingKey)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDeviceAuthenticationUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress, authenticationLevel uint8, buildingKey []byte) (*driverModel.ApduDataExtAuthorizeResponse, error) {
 	// Check if there is already a connection available,
 	// if not, create a new one.
 	connection, ok := m.DeviceConnections[targetAddress]
@@ -530,7 +581,8 @@ func (m *Connection) sendDeviceAuthentication(targetAddress driverModel.KnxAddre
 
 	result := make(chan *driverModel.ApduDataExtAuthorizeResponse)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		deviceAuthenticationRequest,
 		// The Gateway is now supposed to send an Ack to this request.
 		func(message interface{}) bool {
@@ -582,7 +634,7 @@ func (m *Connection) sendDeviceAuthentication(targetAddress driverModel.KnxAddre
 			apduAuthorizeResponse := driverModel.CastApduDataExtAuthorizeResponse(apduDataOther.ExtendedApdu)
 
 			// Acknowledge the receipt
-			_ = m.sendDeviceAck(targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
+			_ = m.sendDeviceAck(ctx, targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
 				// If the error flag is set, there was an error authenticating
 				if lDataInd.DataFrame.ErrorFlag {
 					errorResult <- errors.New("error authenticating at device: " + KnxAddressToString(&targetAddress))
@@ -617,7 +669,18 @@ func (m *Connection) sendDeviceAuthentication(targetAddress driverModel.KnxAddre
 	}
 }
 
-func (m *Connection) sendDeviceDeviceDescriptorReadRequest(targetAddress driverModel.KnxAddress) (*driverModel.ApduDataDeviceDescriptorResponse, error) {
+func (m *Connection) sendDeviceDeviceDescriptorReadRequest(ctx context.Context, targetAddress driverModel.KnxAddress) (*driverModel.ApduDataDeviceDescriptorResponse, error) {
+	var response *driverModel.ApduDataDeviceDescriptorResponse
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDeviceDeviceDescriptorReadRequestUnqueued(ctx, targetAddress)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDeviceDeviceDescriptorReadRequestUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress) (*driverModel.ApduDataDeviceDescriptorResponse, error) {
 	// Next, read the device descriptor so we know how we have to communicate with the device.
 	counter := m.getNextCounter(targetAddress)
 	deviceDescriptorReadRequest := driverModel.NewTunnelingRequest(
@@ -644,7 +707,8 @@ func (m *Connection) sendDeviceDeviceDescriptorReadRequest(targetAddress driverM
 
 	result := make(chan *driverModel.ApduDataDeviceDescriptorResponse)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		deviceDescriptorReadRequest,
 		func(message interface{}) bool {
 			tunnelingRequest := driverModel.CastTunnelingRequest(message)
@@ -686,7 +750,7 @@ func (m *Connection) sendDeviceDeviceDescriptorReadRequest(targetAddress driverM
 			deviceDescriptorResponse := driverModel.CastApduDataDeviceDescriptorResponse(dataContainer.DataApdu)
 
 			// Acknowledge the receipt
-			_ = m.sendDeviceAck(targetAddress, dataFrame.Apdu.Counter, func(err error) {
+			_ = m.sendDeviceAck(ctx, targetAddress, dataFrame.Apdu.Counter, func(err error) {
 				// If the error flag is set, there was an error authenticating
 				if lDataInd.DataFrame.ErrorFlag {
 					errorResult <- errors.New("error reading device descriptor from device: " + KnxAddressToString(&targetAddress))
@@ -721,7 +785,18 @@ func (m *Connection) sendDeviceDeviceDescriptorReadRequest(targetAddress driverM
 	}
 }
 
-func (m *Connection) sendDevicePropertyReadRequest(targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8, propertyIndex uint16, numElements uint8) (*driverModel.ApduDataExtPropertyValueResponse, error) {
+func (m *Connection) sendDevicePropertyReadRequest(ctx context.Context, targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8, propertyIndex uint16, numElements uint8) (*driverModel.ApduDataExtPropertyValueResponse, error) {
+	var response *driverModel.ApduDataExtPropertyValueResponse
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDevicePropertyReadRequestUnqueued(ctx, targetAddress, objectId, propertyId, propertyIndex, numElements)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDevicePropertyReadRequestUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8, propertyIndex uint16, numElements uint8) (*driverModel.ApduDataExtPropertyValueResponse, error) {
 	// Next, read the device descriptor so we know how we have to communicate with the device.
 	// Send the property read request and wait for a confirmation that this property is readable.
 	counter := m.getNextCounter(targetAddress)
@@ -754,7 +829,8 @@ func (m *Connection) sendDevicePropertyReadRequest(targetAddress driverModel.Knx
 
 	result := make(chan *driverModel.ApduDataExtPropertyValueResponse)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		propertyReadRequest,
 		func(message interface{}) bool {
 			tunnelingRequest := driverModel.CastTunnelingRequest(message)
@@ -801,7 +877,7 @@ func (m *Connection) sendDevicePropertyReadRequest(targetAddress driverModel.Knx
 			propertyValueResponse := driverModel.CastApduDataExtPropertyValueResponse(dataApduOther.ExtendedApdu)
 
 			// Acknowledge the receipt
-			_ = m.sendDeviceAck(targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
+			_ = m.sendDeviceAck(ctx, targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
 				// If the error flag is set, there was an error authenticating
 				if lDataInd.DataFrame.ErrorFlag {
 					errorResult <- errors.New("error reading property value from device: " + KnxAddressToString(&targetAddress))
@@ -836,7 +912,18 @@ func (m *Connection) sendDevicePropertyReadRequest(targetAddress driverModel.Knx
 	}
 }
 
-func (m *Connection) sendDevicePropertyDescriptionReadRequest(targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8) (*driverModel.ApduDataExtPropertyDescriptionResponse, error) {
+func (m *Connection) sendDevicePropertyDescriptionReadRequest(ctx context.Context, targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8) (*driverModel.ApduDataExtPropertyDescriptionResponse, error) {
+	var response *driverModel.ApduDataExtPropertyDescriptionResponse
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDevicePropertyDescriptionReadRequestUnqueued(ctx, targetAddress, objectId, propertyId)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDevicePropertyDescriptionReadRequestUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress, objectId uint8, propertyId uint8) (*driverModel.ApduDataExtPropertyDescriptionResponse, error) {
 	// Next, read the device descriptor so we know how we have to communicate with the device.
 	// Send the property read request and wait for a confirmation that this property is readable.
 	counter := m.getNextCounter(targetAddress)
@@ -869,7 +956,8 @@ func (m *Connection) sendDevicePropertyDescriptionReadRequest(targetAddress driv
 
 	result := make(chan *driverModel.ApduDataExtPropertyDescriptionResponse)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		propertyReadRequest,
 		func(message interface{}) bool {
 			tunnelingRequest := driverModel.CastTunnelingRequest(message)
@@ -916,7 +1004,7 @@ func (m *Connection) sendDevicePropertyDescriptionReadRequest(targetAddress driv
 			propertyDescriptionResponse := driverModel.CastApduDataExtPropertyDescriptionResponse(dataApduOther.ExtendedApdu)
 
 			// Acknowledge the receipt
-			_ = m.sendDeviceAck(targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
+			_ = m.sendDeviceAck(ctx, targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
 				// If the error flag is set, there was an error authenticating
 				if lDataInd.DataFrame.ErrorFlag {
 					errorResult <- errors.Errorf("error reading property description from device: %s", KnxAddressToString(&targetAddress))
@@ -951,7 +1039,18 @@ func (m *Connection) sendDevicePropertyDescriptionReadRequest(targetAddress driv
 	}
 }
 
-func (m *Connection) sendDeviceMemoryReadRequest(targetAddress driverModel.KnxAddress, address uint16, numBytes uint8) (*driverModel.ApduDataMemoryResponse, error) {
+func (m *Connection) sendDeviceMemoryReadRequest(ctx context.Context, targetAddress driverModel.KnxAddress, address uint16, numBytes uint8) (*driverModel.ApduDataMemoryResponse, error) {
+	var response *driverModel.ApduDataMemoryResponse
+	if err := m.tm.Execute(ctx, m.requestQueue.ReadPriority, func(ctx context.Context) (err error) {
+		response, err = m.sendDeviceMemoryReadRequestUnqueued(ctx, targetAddress, address, numBytes)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (m *Connection) sendDeviceMemoryReadRequestUnqueued(ctx context.Context, targetAddress driverModel.KnxAddress, address uint16, numBytes uint8) (*driverModel.ApduDataMemoryResponse, error) {
 	// Next, read the device descriptor so we know how we have to communicate with the device.
 	counter := m.getNextCounter(targetAddress)
 
@@ -983,7 +1082,8 @@ func (m *Connection) sendDeviceMemoryReadRequest(targetAddress driverModel.KnxAd
 
 	result := make(chan *driverModel.ApduDataMemoryResponse)
 	errorResult := make(chan error)
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		propertyReadRequest,
 		func(message interface{}) bool {
 			tunnelingRequest := driverModel.CastTunnelingRequest(message)
@@ -1026,7 +1126,7 @@ func (m *Connection) sendDeviceMemoryReadRequest(targetAddress driverModel.KnxAd
 			dataApduMemoryResponse := driverModel.CastApduDataMemoryResponse(dataContainer.DataApdu)
 
 			// Acknowledge the receipt
-			_ = m.sendDeviceAck(targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
+			_ = m.sendDeviceAck(ctx, targetAddress, dataFrameExt.Apdu.Counter, func(err error) {
 				// If the error flag is set, there was an error authenticating
 				if lDataInd.DataFrame.ErrorFlag {
 					errorResult <- errors.Errorf("error reading memory from device: %s", KnxAddressToString(&targetAddress))
@@ -1061,7 +1161,7 @@ func (m *Connection) sendDeviceMemoryReadRequest(targetAddress driverModel.KnxAd
 	}
 }
 
-func (m *Connection) sendDeviceAck(targetAddress driverModel.KnxAddress, counter uint8, callback func(err error)) error {
+func (m *Connection) sendDeviceAck(ctx context.Context, targetAddress driverModel.KnxAddress, counter uint8, callback func(err error)) error {
 	ack := driverModel.NewTunnelingRequest(
 		driverModel.NewTunnelingRequestDataBlock(m.CommunicationChannelId, m.getNewSequenceCounter()),
 		driverModel.NewLDataReq(
@@ -1082,7 +1182,8 @@ func (m *Connection) sendDeviceAck(targetAddress driverModel.KnxAddress, counter
 		),
 	)
 
-	err := m.messageCodec.SendRequest(
+	err := m.messageCodec.SendRequestWithContext(
+		ctx,
 		ack,
 		func(message interface{}) bool {
 			tunnelingRequest := driverModel.CastTunnelingRequest(message)
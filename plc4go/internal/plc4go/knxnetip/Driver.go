@@ -19,15 +19,22 @@
 package knxnetip
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"net/url"
+	"time"
 )
 
+var optionSchema = options.OptionSchema{
+	{Name: "buildingKey", Type: options.OptionTypeString, Description: "Hex encoded key of the KNX building, needed for decoding secure telegrams"},
+	{Name: "group-address-num-levels", Type: options.OptionTypeInteger, Default: "3", AllowedValues: []string{"1", "2", "3"}, Description: "Number of levels of the group addresses"},
+}.Merge(spi.RequestQueueOptionSchema)
+
 type Driver struct {
 	fieldHandler spi.PlcFieldHandler
 }
@@ -55,7 +62,16 @@ func (m Driver) CheckQuery(query string) error {
 	return err
 }
 
+func (m Driver) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
 func (m Driver) GetConnection(transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
+	return m.GetConnectionWithContext(context.Background(), transportUrl, transports, options)
+}
+
+func (m Driver) GetConnectionWithContext(ctx context.Context, transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
+	logger := spi.LoggerFromContext(ctx)
 	// Get an the transport specified in the url
 	transport, ok := transports[transportUrl.Scheme]
 	if !ok {
@@ -77,10 +93,17 @@ func (m Driver) GetConnection(transportUrl url.URL, transports map[string]transp
 		return ch
 	}
 
+	requestQueue, err := spi.ParseRequestQueueConfiguration(options, time.Second*10)
+	if err != nil {
+		ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+		ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(err, "invalid options"))
+		return ch
+	}
+
 	// Create the new connection
-	connection := NewConnection(transportInstance, options, m.fieldHandler)
-	log.Info().Stringer("connection", connection).Msg("created connection, connecting now")
-	return connection.Connect()
+	connection := NewConnection(transportInstance, options, m.fieldHandler, requestQueue, logger)
+	logger.Info().Stringer("connection", connection).Msg("created connection, connecting now")
+	return connection.ConnectWithContext(ctx)
 }
 
 func (m Driver) SupportsDiscovery() bool {
@@ -24,7 +24,6 @@ import (
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 )
 
 type MessageCodec struct {
@@ -40,11 +39,30 @@ func NewMessageCodec(transportInstance transports.TransportInstance, messageInte
 	}
 	codec.DefaultCodecRequiredInterface = codec
 	codec.CustomMessageHandling = CustomMessageHandling
+	codec.CorrelationKeyExtractor = getCorrelationKey
 	return codec
 }
 
+// Key tunneling ACKs are correlated with the tunneling request they acknowledge by
+type tunnelingAckKey struct {
+	communicationChannelId uint8
+	sequenceCounter        uint8
+}
+
+// The gateway acknowledges tunneling requests with the same sequence counter. Other responses can only be
+// recognized by their content.
+func getCorrelationKey(message interface{}) interface{} {
+	if tunnelingResponse := model.CastTunnelingResponse(message); tunnelingResponse != nil && tunnelingResponse.TunnelingResponseDataBlock != nil {
+		return tunnelingAckKey{
+			communicationChannelId: tunnelingResponse.TunnelingResponseDataBlock.CommunicationChannelId,
+			sequenceCounter:        tunnelingResponse.TunnelingResponseDataBlock.SequenceCounter,
+		}
+	}
+	return nil
+}
+
 func (m *MessageCodec) Send(message interface{}) error {
-	log.Trace().Msg("Sending message")
+	m.GetLogger().Trace().Msg("Sending message")
 	// Cast the message to the correct type of struct
 	knxMessage := model.CastKnxNetIpMessage(message)
 	// Serialize the request
@@ -55,7 +73,7 @@ func (m *MessageCodec) Send(message interface{}) error {
 	}
 
 	// Send it to the PLC
-	err = m.TransportInstance.Write(wb.GetBytes())
+	err = m.WriteBytes(wb.GetBytes())
 	if err != nil {
 		return errors.Wrap(err, "error sending request ")
 	}
@@ -63,44 +81,46 @@ func (m *MessageCodec) Send(message interface{}) error {
 }
 
 func (m *MessageCodec) Receive() (interface{}, error) {
-	log.Trace().Msg("receiving")
+	m.GetLogger().Trace().Msg("receiving")
 	// We need at least 6 bytes in order to know how big the packet is in total
 	if num, err := m.TransportInstance.GetNumReadableBytes(); (err == nil) && (num >= 6) {
-		log.Debug().Msgf("we got %d readable bytes", num)
+		m.GetLogger().Debug().Msgf("we got %d readable bytes", num)
 		data, err := m.TransportInstance.PeekReadableBytes(6)
 		if err != nil {
-			log.Warn().Err(err).Msg("error peeking")
+			m.GetLogger().Warn().Err(err).Msg("error peeking")
 			// TODO: Possibly clean up ...
 			return nil, nil
 		}
 		// Get the size of the entire packet
 		packetSize := (uint32(data[4]) << 8) + uint32(data[5])
 		if num < packetSize {
-			log.Debug().Msgf("Not enough bytes. Got: %d Need: %d\n", num, packetSize)
+			m.GetLogger().Debug().Msgf("Not enough bytes. Got: %d Need: %d\n", num, packetSize)
 			return nil, nil
 		}
-		data, err = m.TransportInstance.Read(packetSize)
+		data, err = m.ReadBytes(packetSize)
 		if err != nil {
-			log.Warn().Err(err).Msg("error reading")
+			m.GetLogger().Warn().Err(err).Msg("error reading")
 			// TODO: Possibly clean up ...
 			return nil, nil
 		}
 		rb := utils.NewReadBuffer(data)
 		knxMessage, err := model.KnxNetIpMessageParse(rb)
 		if err != nil {
-			log.Warn().Err(err).Msg("error parsing message")
+			m.GetLogger().Warn().Err(err).Msg("error parsing message")
 			// TODO: Possibly clean up ...
 			return nil, nil
 		}
 		return knxMessage, nil
 	} else if err != nil {
-		log.Warn().Err(err).Msg("Got error reading")
-		return nil, nil
+		m.GetLogger().Warn().Err(err).Msg("Got error reading")
+		return nil, errors.Wrap(err, "error reading from transport")
 	}
 	return nil, nil
 }
 
 func CustomMessageHandling(codec *spi.DefaultCodecRequiredInterface, message interface{}) bool {
+	localCodec := (*codec).(*MessageCodec)
+
 	// If this message is a simple KNXNet/IP UDP Ack, ignore it for now
 	tunnelingResponse := model.CastTunnelingResponse(message)
 	if tunnelingResponse != nil {
@@ -118,11 +138,10 @@ func CustomMessageHandling(codec *spi.DefaultCodecRequiredInterface, message int
 		)
 		err := (*codec).Send(response)
 		if err != nil {
-			log.Warn().Err(err).Msg("got an error sending ACK from transport")
+			localCodec.GetLogger().Warn().Err(err).Msg("got an error sending ACK from transport")
 		}
 	}
 
-	localCodec := (*codec).(*MessageCodec)
 	// Handle the packet itself
 	// Give a message interceptor a chance to intercept
 	if (*localCodec).messageInterceptor != nil {
@@ -19,9 +19,11 @@
 package knxnetip
 
 import (
+	"context"
 	"errors"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
 	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	internalValues "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
@@ -41,8 +43,8 @@ func NewReader(connection *Connection) *Reader {
 	}
 }
 
-func (m Reader) Read(readRequest apiModel.PlcReadRequest) <-chan apiModel.PlcReadRequestResult {
-	resultChan := make(chan apiModel.PlcReadRequestResult)
+func (m Reader) Read(ctx context.Context, readRequest apiModel.PlcReadRequest) <-chan apiModel.PlcReadRequestResult {
+	resultChan := make(chan apiModel.PlcReadRequestResult, 1)
 	go func() {
 		responseCodes := map[string]apiModel.PlcResponseCode{}
 		plcValues := map[string]apiValues.PlcValue{}
@@ -96,50 +98,18 @@ func (m Reader) Read(readRequest apiModel.PlcReadRequest) <-chan apiModel.PlcRea
 
 		// Process the direct properties.
 		// Connect to each knx device and read all of the properties on that particular device.
+		timeout := m.connection.requestQueue.GetRequestTimeout(readRequest.GetTimeout())
+		retries := m.connection.requestQueue.GetRetries(readRequest.GetRetries())
 		for deviceAddress, fields := range deviceAddresses {
 			// Collect all the properties on this device
 			for fieldName, field := range fields {
-				switch field.(type) {
-				case DevicePropertyAddressPlcField:
-					propertyField := field.(DevicePropertyAddressPlcField)
-
-					results := m.connection.DeviceReadProperty(deviceAddress, propertyField.ObjectId, propertyField.PropertyId, propertyField.PropertyIndex, propertyField.NumElements)
-					select {
-					case result := <-results:
-						if result.err == nil {
-							responseCodes[fieldName] = apiModel.PlcResponseCode_OK
-							plcValues[fieldName] = *result.value
-						} else {
-							responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
-							plcValues[fieldName] = nil
-						}
-					case <-time.After(m.connection.defaultTtl):
-						responseCodes[fieldName] = apiModel.PlcResponseCode_REMOTE_BUSY
-						plcValues[fieldName] = nil
-					}
-				case DeviceMemoryAddressPlcField:
-					memoryField := field.(DeviceMemoryAddressPlcField)
-					results := m.connection.DeviceReadMemory(deviceAddress, memoryField.Address, memoryField.NumElements, memoryField.FieldType)
-					select {
-					case result := <-results:
-						if result.err == nil {
-							responseCodes[fieldName] = apiModel.PlcResponseCode_OK
-							plcValues[fieldName] = *result.value
-						} else {
-							responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
-							plcValues[fieldName] = nil
-						}
-					case <-time.After(m.connection.defaultTtl):
-						responseCodes[fieldName] = apiModel.PlcResponseCode_REMOTE_BUSY
-						plcValues[fieldName] = nil
-					}
-				}
+				responseCodes[fieldName], plcValues[fieldName] = m.readDeviceField(ctx, deviceAddress, field, timeout, retries)
 			}
 		}
 
 		// Get the group address values from the cache
 		for fieldName, field := range groupAddresses {
-			responseCode, plcValue := m.readGroupAddress(field)
+			responseCode, plcValue := m.readGroupAddress(ctx, field)
 			responseCodes[fieldName] = responseCode
 			plcValues[fieldName] = plcValue
 		}
@@ -155,7 +125,47 @@ func (m Reader) Read(readRequest apiModel.PlcReadRequest) <-chan apiModel.PlcRea
 	return resultChan
 }
 
-func (m Reader) readGroupAddress(field GroupAddressField) (apiModel.PlcResponseCode, apiValues.PlcValue) {
+// readDeviceField reads a property or memory field of a device and repeats the read as long as it times out
+func (m Reader) readDeviceField(ctx context.Context, deviceAddress driverModel.KnxAddress, field DeviceField, timeout time.Duration, retries int) (apiModel.PlcResponseCode, apiValues.PlcValue) {
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		var results <-chan KnxReadResult
+		switch field.(type) {
+		case DevicePropertyAddressPlcField:
+			propertyField := field.(DevicePropertyAddressPlcField)
+			results = m.connection.DeviceReadProperty(attemptCtx, deviceAddress, propertyField.ObjectId, propertyField.PropertyId, propertyField.PropertyIndex, propertyField.NumElements)
+		case DeviceMemoryAddressPlcField:
+			memoryField := field.(DeviceMemoryAddressPlcField)
+			results = m.connection.DeviceReadMemory(attemptCtx, deviceAddress, memoryField.Address, memoryField.NumElements, memoryField.FieldType)
+		default:
+			cancel()
+			return apiModel.PlcResponseCode_INVALID_ADDRESS, nil
+		}
+		var value apiValues.PlcValue
+		var err error
+		select {
+		case result := <-results:
+			if result.err == nil {
+				value = *result.value
+			}
+			err = result.err
+		case <-attemptCtx.Done():
+			err = attemptCtx.Err()
+		}
+		timedOut := plcerrors.IsTimeoutError(err) || attemptCtx.Err() == context.DeadlineExceeded
+		cancel()
+		switch {
+		case err == nil:
+			return apiModel.PlcResponseCode_OK, value
+		case ctx.Err() != nil || !timedOut:
+			return apiModel.PlcResponseCode_INTERNAL_ERROR, nil
+		case attempt >= retries:
+			return apiModel.PlcResponseCode_REQUEST_TIMEOUT, nil
+		}
+	}
+}
+
+func (m Reader) readGroupAddress(ctx context.Context, field GroupAddressField) (apiModel.PlcResponseCode, apiValues.PlcValue) {
 	rawAddresses, err := m.resolveAddresses(field)
 	if err != nil {
 		return apiModel.PlcResponseCode_INVALID_ADDRESS, nil
@@ -179,7 +189,7 @@ func (m Reader) readGroupAddress(field GroupAddressField) (apiModel.PlcResponseC
 		// Otherwise respond with values from the cache.
 		if !ok {
 			addr := []int8{int8(numericAddress >> 8), int8(numericAddress & 0xFF)}
-			rrc := m.connection.ReadGroupAddress(addr, field.GetFieldType())
+			rrc := m.connection.ReadGroupAddress(ctx, addr, field.GetFieldType())
 			select {
 			case readResult := <-rrc:
 				if readResult.value != nil {
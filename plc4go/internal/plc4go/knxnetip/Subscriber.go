@@ -19,60 +19,174 @@
 package knxnetip
 
 import (
+	"context"
 	driverModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
 	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	values2 "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"sync"
 	"time"
 )
 
 type Subscriber struct {
-	connection           *Connection
-	subscriptionRequests []internalModel.DefaultPlcSubscriptionRequest
+	connection         *Connection
+	subscriptions      map[uint64]*subscription
+	nextSubscriptionId uint64
+	lock               sync.Mutex
+}
+
+// A single subscription request and the fields of it, which haven't been unsubscribed yet
+type subscription struct {
+	request      internalModel.DefaultPlcSubscriptionRequest
+	activeFields map[string]bool
 }
 
 func NewSubscriber(connection *Connection) *Subscriber {
 	return &Subscriber{
-		connection:           connection,
-		subscriptionRequests: []internalModel.DefaultPlcSubscriptionRequest{},
+		connection:    connection,
+		subscriptions: map[uint64]*subscription{},
 	}
 }
 
-func (m *Subscriber) Subscribe(subscriptionRequest apiModel.PlcSubscriptionRequest) <-chan apiModel.PlcSubscriptionRequestResult {
-	result := make(chan apiModel.PlcSubscriptionRequestResult)
+func (m *Subscriber) Subscribe(ctx context.Context, subscriptionRequest apiModel.PlcSubscriptionRequest) <-chan apiModel.PlcSubscriptionRequestResult {
+	result := make(chan apiModel.PlcSubscriptionRequestResult, 1)
 	go func() {
+		// Subscribing doesn't involve the KNX network, so this only times out waiting for concurrent (un)subscriptions
+		timeout := m.connection.requestQueue.GetRequestTimeout(subscriptionRequest.GetTimeout())
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		m.lock.Lock()
+		if ctx.Err() != nil {
+			m.lock.Unlock()
+			result <- apiModel.PlcSubscriptionRequestResult{
+				Request:  subscriptionRequest,
+				Response: internalModel.NewTimedOutPlcSubscriptionResponse(subscriptionRequest),
+				Err:      nil,
+			}
+			return
+		}
+
 		// Add this subscriber to the connection.
 		m.connection.addSubscriber(m)
 
 		// Save the subscription request
-		m.subscriptionRequests = append(m.subscriptionRequests, subscriptionRequest.(internalModel.DefaultPlcSubscriptionRequest))
+		m.nextSubscriptionId++
+		subscriptionId := m.nextSubscriptionId
+		newSubscription := &subscription{
+			request:      subscriptionRequest.(internalModel.DefaultPlcSubscriptionRequest),
+			activeFields: map[string]bool{},
+		}
+		m.subscriptions[subscriptionId] = newSubscription
 
 		// Just populate all requests with an OK
 		responseCodes := map[string]apiModel.PlcResponseCode{}
+		handles := map[string]apiModel.PlcSubscriptionHandle{}
 		for _, fieldName := range subscriptionRequest.GetFieldNames() {
+			newSubscription.activeFields[fieldName] = true
 			responseCodes[fieldName] = apiModel.PlcResponseCode_OK
+			handles[fieldName] = internalModel.NewDefaultPlcSubscriptionHandle(m, subscriptionId, fieldName)
 		}
+		m.lock.Unlock()
 
 		result <- apiModel.PlcSubscriptionRequestResult{
 			Request:  subscriptionRequest,
-			Response: internalModel.NewDefaultPlcSubscriptionResponse(subscriptionRequest, responseCodes),
+			Response: internalModel.NewDefaultPlcSubscriptionResponse(subscriptionRequest, responseCodes, handles),
 			Err:      nil,
 		}
 	}()
 	return result
 }
 
-func (m *Subscriber) Unsubscribe(unsubscriptionRequest apiModel.PlcUnsubscriptionRequest) <-chan apiModel.PlcUnsubscriptionRequestResult {
-	result := make(chan apiModel.PlcUnsubscriptionRequestResult)
+func (m *Subscriber) Unsubscribe(_ context.Context, unsubscriptionRequest apiModel.PlcUnsubscriptionRequest) <-chan apiModel.PlcUnsubscriptionRequestResult {
+	result := make(chan apiModel.PlcUnsubscriptionRequestResult, 1)
+	go func() {
+		var fieldNames []string
+		responseCodes := map[string]apiModel.PlcResponseCode{}
+		addResponseCode := func(fieldName string, responseCode apiModel.PlcResponseCode) {
+			if existingResponseCode, ok := responseCodes[fieldName]; !ok {
+				fieldNames = append(fieldNames, fieldName)
+			} else if existingResponseCode == apiModel.PlcResponseCode_OK {
+				// If any of the subscriptions for this field was removed, the field counts as unsubscribed
+				return
+			}
+			responseCodes[fieldName] = responseCode
+		}
 
-	// TODO: As soon as we establish a connection, we start getting data...
-	// subscriptions are more an internal handling of which values to pass where.
+		m.lock.Lock()
+		// Handles identify the field of exactly one subscription request
+		for _, handle := range unsubscriptionRequest.GetSubscriptionHandles() {
+			subscriptionHandle, ok := handle.(*internalModel.DefaultPlcSubscriptionHandle)
+			if !ok || subscriptionHandle.GetSubscriber() != m {
+				addResponseCode(handle.GetFieldName(), apiModel.PlcResponseCode_INVALID_ADDRESS)
+				continue
+			}
+			existingSubscription, ok := m.subscriptions[subscriptionHandle.GetSubscriptionId()]
+			if !ok || !existingSubscription.activeFields[handle.GetFieldName()] {
+				addResponseCode(handle.GetFieldName(), apiModel.PlcResponseCode_NOT_FOUND)
+				continue
+			}
+			delete(existingSubscription.activeFields, handle.GetFieldName())
+			addResponseCode(handle.GetFieldName(), apiModel.PlcResponseCode_OK)
+		}
+		// Field names remove the field from every subscription request using that name
+		for _, fieldName := range unsubscriptionRequest.GetFieldNames() {
+			found := false
+			for _, existingSubscription := range m.subscriptions {
+				if existingSubscription.activeFields[fieldName] {
+					delete(existingSubscription.activeFields, fieldName)
+					found = true
+				}
+			}
+			if found {
+				addResponseCode(fieldName, apiModel.PlcResponseCode_OK)
+			} else {
+				addResponseCode(fieldName, apiModel.PlcResponseCode_NOT_FOUND)
+			}
+		}
+		// Forget about subscription requests without any remaining fields
+		for subscriptionId, existingSubscription := range m.subscriptions {
+			if len(existingSubscription.activeFields) == 0 {
+				delete(m.subscriptions, subscriptionId)
+			}
+		}
+		empty := len(m.subscriptions) == 0
+		m.lock.Unlock()
+
+		// If nobody is interested anymore, stop passing value changes to this subscriber
+		if empty {
+			m.connection.removeSubscriber(m)
+		}
 
+		result <- apiModel.PlcUnsubscriptionRequestResult{
+			Request:  unsubscriptionRequest,
+			Response: internalModel.NewDefaultPlcUnsubscriptionResponse(unsubscriptionRequest, fieldNames, responseCodes),
+			Err:      nil,
+		}
+	}()
 	return result
 }
 
+// Get copies of the currently active subscriptions, so they can be processed without holding the lock
+func (m *Subscriber) getActiveSubscriptions() []subscription {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	activeSubscriptions := make([]subscription, 0, len(m.subscriptions))
+	for _, existingSubscription := range m.subscriptions {
+		activeFields := make(map[string]bool, len(existingSubscription.activeFields))
+		for fieldName := range existingSubscription.activeFields {
+			activeFields[fieldName] = true
+		}
+		activeSubscriptions = append(activeSubscriptions, subscription{
+			request:      existingSubscription.request,
+			activeFields: activeFields,
+		})
+	}
+	return activeSubscriptions
+}
+
 /*
  * Callback for incoming value change events from the KNX bus
  */
@@ -86,7 +200,8 @@ func (m *Subscriber) handleValueChange(destinationAddress []int8, payload []int8
 	}
 
 	// Go through all subscription-requests and process each separately
-	for _, subscriptionRequest := range m.subscriptionRequests {
+	for _, activeSubscription := range m.getActiveSubscriptions() {
+		subscriptionRequest := activeSubscription.request
 		fields := map[string]apiModel.PlcField{}
 		types := map[string]internalModel.SubscriptionType{}
 		intervals := map[string]time.Duration{}
@@ -97,6 +212,10 @@ func (m *Subscriber) handleValueChange(destinationAddress []int8, payload []int8
 		// Check if this datagram matches any address in this subscription request
 		// As depending on the address used for fields, the decoding is different, we need to decode on-demand here.
 		for _, fieldName := range subscriptionRequest.GetFieldNames() {
+			// Skip fields which have already been unsubscribed
+			if !activeSubscription.activeFields[fieldName] {
+				continue
+			}
 			field, err := CastToFieldFromPlcField(subscriptionRequest.GetField(fieldName))
 			if err != nil {
 				continue
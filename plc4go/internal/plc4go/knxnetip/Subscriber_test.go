@@ -0,0 +1,63 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package knxnetip
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"testing"
+	"time"
+)
+
+func TestSubscriber_Timeout(t *testing.T) {
+	connection := &Connection{requestQueue: spi.RequestQueueConfiguration{RequestTimeout: time.Minute}}
+	subscriber := NewSubscriber(connection)
+	builder := internalModel.NewDefaultPlcSubscriptionRequestBuilder(NewFieldHandler(), NewValueHandler(), subscriber)
+	builder.AddChangeOfStateQuery("light", "1/2/3")
+	builder.SetTimeout(time.Millisecond)
+	subscriptionRequest, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Keep a concurrent (un)subscription busy for longer than the request may take
+	subscriber.lock.Lock()
+	result := subscriber.Subscribe(context.Background(), subscriptionRequest)
+	time.Sleep(10 * time.Millisecond)
+	subscriber.lock.Unlock()
+
+	subscriptionResult := <-result
+	if subscriptionResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", subscriptionResult.Err)
+	}
+	if responseCode := subscriptionResult.Response.GetResponseCode("light"); responseCode != apiModel.PlcResponseCode_REQUEST_TIMEOUT {
+		t.Errorf("Expected REQUEST_TIMEOUT, got %v", responseCode)
+	}
+	if len(subscriber.subscriptions) != 0 || len(connection.subscribers) != 0 {
+		t.Errorf("A timed out subscription must not be registered")
+	}
+
+	// Without waiting the subscription uses the connection default and succeeds
+	subscriptionResult = <-subscriber.Subscribe(context.Background(), subscriptionRequest)
+	if responseCode := subscriptionResult.Response.GetResponseCode("light"); responseCode != apiModel.PlcResponseCode_OK {
+		t.Errorf("Expected OK, got %v", responseCode)
+	}
+}
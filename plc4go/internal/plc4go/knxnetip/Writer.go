@@ -19,6 +19,7 @@
 package knxnetip
 
 import (
+	"context"
 	"errors"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
@@ -36,8 +37,8 @@ func NewWriter(messageCodec spi.MessageCodec) Writer {
 	}
 }
 
-func (m Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
-	result := make(chan model.PlcWriteRequestResult)
+func (m Writer) Write(ctx context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
+	result := make(chan model.PlcWriteRequestResult, 1)
 	// If we are requesting only one field, use a
 	if len(writeRequest.GetFieldNames()) == 1 {
 		fieldName := writeRequest.GetFieldNames()[0]
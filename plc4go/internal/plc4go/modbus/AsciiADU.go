@@ -0,0 +1,167 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// ModbusAsciiADU is a Modbus ASCII frame: a colon, the address, the PDU and the LRC as hex digits, and CR LF
+type ModbusAsciiADU struct {
+	Address uint8
+	Pdu     *model.ModbusPDU
+}
+
+func NewModbusAsciiADU(address uint8, pdu *model.ModbusPDU) *ModbusAsciiADU {
+	return &ModbusAsciiADU{Address: address, Pdu: pdu}
+}
+
+func ModbusAsciiADUParse(io *utils.ReadBuffer, response bool) (*ModbusAsciiADU, error) {
+	start, err := io.ReadUint8(8)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing start of frame")
+	}
+	if start != ':' {
+		return nil, errors.Errorf("Error parsing start of frame: expected ':', got 0x%02x", start)
+	}
+	var hexDigits []uint8
+	for {
+		digit, err := io.ReadUint8(8)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error parsing frame")
+		}
+		if digit == '\r' {
+			break
+		}
+		hexDigits = append(hexDigits, digit)
+	}
+	if lineFeed, err := io.ReadUint8(8); err != nil || lineFeed != '\n' {
+		return nil, errors.New("Error parsing end of frame: expected CR LF")
+	}
+	data := make([]uint8, hex.DecodedLen(len(hexDigits)))
+	if _, err := hex.Decode(data, hexDigits); err != nil {
+		return nil, errors.Wrap(err, "Error decoding frame")
+	}
+	// Address, function code and LRC
+	if len(data) < 3 {
+		return nil, errors.Errorf("Error parsing frame: only %d bytes", len(data))
+	}
+	if lrc := getAsciiLrc(data[:len(data)-1]); lrc != data[len(data)-1] {
+		return nil, errors.Errorf("Error parsing frame: invalid LRC 0x%02x, expected 0x%02x", data[len(data)-1], lrc)
+	}
+
+	pdu, err := model.ModbusPDUParse(utils.NewReadBuffer(data[1:len(data)-1]), response)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing 'pdu' field")
+	}
+	return NewModbusAsciiADU(data[0], pdu), nil
+}
+
+func (m *ModbusAsciiADU) Serialize(io utils.WriteBuffer) error {
+	wb := utils.NewWriteBuffer()
+	if err := wb.WriteUint8(8, m.Address); err != nil {
+		return errors.Wrap(err, "Error serializing 'address' field")
+	}
+	if err := m.Pdu.Serialize(*wb); err != nil {
+		return errors.Wrap(err, "Error serializing 'pdu' field")
+	}
+	data := wb.GetBytes()
+	frame := ":" + strings.ToUpper(hex.EncodeToString(append(data, getAsciiLrc(data)))) + "\r\n"
+	for _, character := range []uint8(frame) {
+		if err := io.WriteUint8(8, character); err != nil {
+			return errors.Wrap(err, "Error serializing frame")
+		}
+	}
+	return nil
+}
+
+func (m *ModbusAsciiADU) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if tok, ok := token.(xml.StartElement); ok {
+			switch tok.Name.Local {
+			case "address":
+				if err := d.DecodeElement(&m.Address, &tok); err != nil {
+					return err
+				}
+			case "pdu":
+				if err := d.DecodeElement(&m.Pdu, &tok); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (m *ModbusAsciiADU) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	className := "org.apache.plc4x.java.modbus.readwrite.ModbusAsciiADU"
+	if err := e.EncodeToken(xml.StartElement{Name: start.Name, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "className"}, Value: className},
+	}}); err != nil {
+		return err
+	}
+	if err := e.EncodeElement(m.Address, xml.StartElement{Name: xml.Name{Local: "address"}}); err != nil {
+		return err
+	}
+	if err := e.EncodeElement(m.Pdu, xml.StartElement{Name: xml.Name{Local: "pdu"}}); err != nil {
+		return err
+	}
+	return e.EncodeToken(xml.EndElement{Name: start.Name})
+}
+
+// getAsciiLrc calculates the longitudinal redundancy check of Modbus ASCII frames: the two's complement of the sum of
+// all bytes
+func getAsciiLrc(data []uint8) uint8 {
+	sum := uint8(0)
+	for _, b := range data {
+		sum += b
+	}
+	return -sum
+}
+
+// AsciiParserHelper extends the generated parser helper by the Modbus ASCII frames, for the parser serializer
+// testsuites
+type AsciiParserHelper struct {
+}
+
+func (m AsciiParserHelper) Parse(typeName string, arguments []string, io *utils.ReadBuffer) (interface{}, error) {
+	switch typeName {
+	case "ModbusAsciiADU":
+		response, err := utils.StrToBool(arguments[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "Error parsing")
+		}
+		return ModbusAsciiADUParse(io, response)
+	}
+	return readwrite.ModbusParserHelper{}.Parse(typeName, arguments, io)
+}
@@ -0,0 +1,127 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	"github.com/pkg/errors"
+)
+
+// Colon, address, 253 bytes of PDU and LRC as hex digits, CR LF
+const maxAsciiFrameLength = 1 + 2*(1+253+1) + 2
+
+// AsciiMessageCodec frames the ADUs of the reader and writer the Modbus ASCII way
+type AsciiMessageCodec struct {
+	*spi.DefaultCodec
+	pendingRequest pendingRequest
+}
+
+func NewAsciiMessageCodec(transportInstance transports.TransportInstance) *AsciiMessageCodec {
+	codec := &AsciiMessageCodec{
+		DefaultCodec: spi.NewDefaultCodec(transportInstance),
+	}
+	codec.DefaultCodecRequiredInterface = codec
+	codec.CorrelationKeyExtractor = getCorrelationKey
+	return codec
+}
+
+func (m *AsciiMessageCodec) Send(message interface{}) error {
+	m.GetLogger().Trace().Msg("Sending message")
+	tcpAdu := model.CastModbusTcpADU(message)
+	if tcpAdu == nil {
+		return errors.Errorf("unsupported message type %T", message)
+	}
+	// Serialize the request
+	wb := utils.NewWriteBuffer()
+	err := NewModbusAsciiADU(tcpAdu.UnitIdentifier, tcpAdu.Pdu).Serialize(*wb)
+	if err != nil {
+		return errors.Wrap(err, "error serializing request")
+	}
+
+	m.pendingRequest.set(tcpAdu)
+	// Send it to the PLC
+	err = m.WriteBytes(wb.GetBytes())
+	if err != nil {
+		return errors.Wrap(err, "error sending request")
+	}
+	return nil
+}
+
+func (m *AsciiMessageCodec) Receive() (interface{}, error) {
+	m.GetLogger().Trace().Msg("receiving")
+	num, err := m.TransportInstance.GetNumReadableBytes()
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("Got error reading")
+		return nil, errors.Wrap(err, "error reading from transport")
+	}
+	if num == 0 {
+		return nil, nil
+	}
+	data, err := m.TransportInstance.PeekReadableBytes(num)
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("error peeking")
+		return nil, nil
+	}
+	// Everything before the colon can't be part of a frame
+	if start := bytes.IndexByte(data, ':'); start != 0 {
+		if start < 0 {
+			start = len(data)
+		}
+		m.GetLogger().Warn().Int("numBytes", start).Msg("Dropping bytes before the start of the frame")
+		if _, err := m.ReadBytes(uint32(start)); err != nil {
+			m.GetLogger().Warn().Err(err).Msg("error reading")
+			return nil, nil
+		}
+		return m.Receive()
+	}
+	end := bytes.Index(data, []uint8("\r\n"))
+	if end < 0 {
+		if len(data) >= maxAsciiFrameLength {
+			m.GetLogger().Warn().Int("numBytes", len(data)).Msg("Dropping bytes without the end of the frame")
+			_, _ = m.ReadBytes(num)
+		}
+		return nil, nil
+	}
+	data, err = m.ReadBytes(uint32(end + 2))
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("error reading")
+		return nil, nil
+	}
+	asciiAdu, err := ModbusAsciiADUParse(utils.NewReadBuffer(data), true)
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Str("frame", string(data)).Msg("Dropping invalid frame")
+		// Continue with the frames received after the dropped one
+		return m.Receive()
+	}
+
+	// Error PDUs don't keep the function code, so it's taken from the frame (validated by parsing it)
+	functionCode, _ := hex.DecodeString(string(data[3:5]))
+	tcpAdu := m.pendingRequest.answer(asciiAdu.Address, functionCode[0], asciiAdu.Pdu)
+	if tcpAdu == nil {
+		m.GetLogger().Warn().Str("frame", string(data)).Msg("Dropping frame not answering the pending request")
+		return m.Receive()
+	}
+	return tcpAdu, nil
+}
@@ -0,0 +1,102 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/test"
+	"testing"
+)
+
+func TestAsciiLrc(t *testing.T) {
+	if lrc := getAsciiLrc([]uint8{0x11, 0x03, 0x00, 0x6B, 0x00, 0x03}); lrc != 0x7E {
+		t.Errorf("Expected LRC 0x7E, got 0x%02X", lrc)
+	}
+}
+
+func TestAsciiMessageCodec_SendReceive(t *testing.T) {
+	transportInstance := test.NewTransportInstance(test.NewTransport())
+	codec := NewAsciiMessageCodec(transportInstance)
+
+	request := model.NewModbusTcpADU(7, 0x11, model.NewModbusPDUReadHoldingRegistersRequest(0x006B, 3))
+	if err := codec.Send(request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sent, _ := transportInstance.DrainWriteBuffer(transportInstance.GetNumDrainableBytes())
+	if expected := ":1103006B00037E\r\n"; string(sent) != expected {
+		t.Errorf("Expected frame %q, got %q", expected, sent)
+	}
+
+	// Noise before the frame is dropped, incomplete frames are left alone
+	_ = transportInstance.FillReadBuffer([]uint8("\x00\n:110306AE41"))
+	if message, err := codec.Receive(); message != nil || err != nil {
+		t.Fatalf("Expected nothing to be received, got %v (%v)", message, err)
+	}
+	_ = transportInstance.FillReadBuffer([]uint8("56524340CC\r\n"))
+	message, err := codec.Receive()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tcpAdu := model.CastModbusTcpADU(message)
+	if tcpAdu == nil {
+		t.Fatalf("Expected a ModbusTcpADU, got %T", message)
+	}
+	// The response is correlated with the request
+	if tcpAdu.TransactionIdentifier != 7 || tcpAdu.UnitIdentifier != 0x11 {
+		t.Errorf("Unexpected header %+v", tcpAdu)
+	}
+	readResponse := model.CastModbusPDUReadHoldingRegistersResponse(tcpAdu.Pdu)
+	if readResponse == nil || len(readResponse.Value) != 6 || uint8(readResponse.Value[0]) != 0xAE {
+		t.Errorf("Unexpected response %+v", tcpAdu.Pdu.Child)
+	}
+	if num, _ := transportInstance.GetNumReadableBytes(); num != 0 {
+		t.Errorf("Expected the frame to be consumed, %d bytes left", num)
+	}
+}
+
+func TestAsciiMessageCodec_ReceiveDropsInvalidFrames(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"invalid lrc", ":010302002ACF\r\n"},
+		{"invalid hex digits", ":0103020X2ACF\r\n"},
+		{"other slave", ":020302002ACF\r\n"},
+		{"other function", ":010402002ACF\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transportInstance := test.NewTransportInstance(test.NewTransport())
+			codec := NewAsciiMessageCodec(transportInstance)
+			if err := codec.Send(model.NewModbusTcpADU(1, 1, model.NewModbusPDUReadHoldingRegistersRequest(0, 1))); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// The frame following the dropped one is received right away (an exception response in lower case)
+			_ = transportInstance.FillReadBuffer([]uint8(tt.frame + ":0183027a\r\n"))
+			message, err := codec.Receive()
+			if tcpAdu := model.CastModbusTcpADU(message); err != nil || tcpAdu == nil || model.CastModbusPDUError(tcpAdu.Pdu) == nil {
+				t.Errorf("Expected an error response, got %v (%v)", message, err)
+			}
+			if num, _ := transportInstance.GetNumReadableBytes(); num != 0 {
+				t.Errorf("Expected the frames to be consumed, %d bytes left", num)
+			}
+		})
+	}
+}
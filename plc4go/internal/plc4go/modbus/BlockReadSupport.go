@@ -0,0 +1,88 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package modbus
+
+import (
+	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/interceptors"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"math"
+)
+
+const (
+	// A single read request is able to read at most 125 registers
+	MaxRegistersPerRead = 125
+)
+
+// BlockReadSupport allows reading consecutive input- or holding-registers with a single request
+type BlockReadSupport struct {
+}
+
+func NewBlockReadSupport() BlockReadSupport {
+	return BlockReadSupport{}
+}
+
+func (m BlockReadSupport) GetFieldBlock(field apiModel.PlcField) (interceptors.FieldBlock, bool) {
+	modbusField, err := CastToModbusFieldFromPlcField(field)
+	if err != nil {
+		return interceptors.FieldBlock{}, false
+	}
+	// Coils and discrete inputs are bit-addressed, so only registers are merged
+	if modbusField.FieldType != InputRegister && modbusField.FieldType != HoldingRegister {
+		return interceptors.FieldBlock{}, false
+	}
+	numWords := uint32(math.Ceil(float64(modbusField.Quantity*uint16(modbusField.Datatype.DataTypeSize())) / float64(2)))
+	if numWords == 0 {
+		return interceptors.FieldBlock{}, false
+	}
+	return interceptors.FieldBlock{
+		Area:  modbusField.FieldType.GetName(),
+		Start: uint32(modbusField.Address) * 2,
+		Size:  numWords * 2,
+	}, true
+}
+
+func (m BlockReadSupport) GetMaxBlockSize(_ string) uint32 {
+	return MaxRegistersPerRead * 2
+}
+
+func (m BlockReadSupport) NewBlockField(field apiModel.PlcField, block interceptors.FieldBlock) apiModel.PlcField {
+	modbusField := field.(PlcField)
+	return PlcField{
+		FieldType: modbusField.FieldType,
+		Address:   uint16(block.Start / 2),
+		Quantity:  uint16(block.Size),
+		Datatype:  readWriteModel.ModbusDataType_BYTE,
+	}
+}
+
+func (m BlockReadSupport) DecodeFieldValue(field apiModel.PlcField, data []byte) (values.PlcValue, error) {
+	modbusField, err := CastToModbusFieldFromPlcField(field)
+	if err != nil {
+		return nil, err
+	}
+	value, err := readWriteModel.DataItemParse(utils.NewReadBuffer(data), modbusField.Datatype, modbusField.Quantity)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing data item")
+	}
+	return value, nil
+}
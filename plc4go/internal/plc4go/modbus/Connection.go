@@ -19,6 +19,7 @@
 package modbus
 
 import (
+	"context"
 	"fmt"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
@@ -28,7 +29,7 @@ import (
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"time"
 )
 
@@ -63,31 +64,50 @@ type Connection struct {
 	fieldHandler       spi.PlcFieldHandler
 	valueHandler       spi.PlcValueHandler
 	requestInterceptor internalModel.RequestInterceptor
-}
-
-func NewConnection(unitIdentifier uint8, messageCodec spi.MessageCodec, options map[string][]string, fieldHandler spi.PlcFieldHandler) Connection {
+	// Splits reads into one request per field, or reads consecutive registers at once, if reads are optimized
+	readRequestInterceptor internalModel.ReadRequestInterceptor
+	requestQueue           spi.RequestQueueConfiguration
+	// Most devices only handle one request at a time, so all requests of this connection are queued in here
+	tm     *spi.RequestTransactionManager
+	logger zerolog.Logger
+}
+
+func NewConnection(unitIdentifier uint8, messageCodec spi.MessageCodec, options map[string][]string, fieldHandler spi.PlcFieldHandler, requestQueue spi.RequestQueueConfiguration, optimizeReads bool, logger zerolog.Logger) Connection {
+	requestInterceptor := interceptors.NewSingleItemRequestInterceptor(logger)
+	var readRequestInterceptor internalModel.ReadRequestInterceptor = requestInterceptor
+	if optimizeReads {
+		readRequestInterceptor = interceptors.NewReadOptimizingRequestInterceptor(NewBlockReadSupport(), 1, logger)
+	}
 	return Connection{
-		unitIdentifier:     unitIdentifier,
-		messageCodec:       messageCodec,
-		options:            options,
-		fieldHandler:       fieldHandler,
-		valueHandler:       NewValueHandler(),
-		requestInterceptor: interceptors.NewSingleItemRequestInterceptor(),
+		unitIdentifier:         unitIdentifier,
+		messageCodec:           messageCodec,
+		options:                options,
+		fieldHandler:           fieldHandler,
+		valueHandler:           NewValueHandler(),
+		requestInterceptor:     requestInterceptor,
+		readRequestInterceptor: readRequestInterceptor,
+		requestQueue:           requestQueue,
+		tm:                     requestQueue.NewRequestTransactionManager(1, spi.WithLogger(logger)),
+		logger:                 logger,
 	}
 }
 
 func (m Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
-	log.Trace().Msg("Connecting")
-	ch := make(chan plc4go.PlcConnectionConnectResult)
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m Connection) ConnectWithContext(ctx context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	m.logger.Trace().Msg("Connecting")
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
 	go func() {
-		err := m.messageCodec.Connect()
+		err := m.messageCodec.ConnectWithContext(ctx)
 		ch <- plc4go.NewPlcConnectionConnectResult(m, err)
 	}()
 	return ch
 }
 
 func (m Connection) BlockingClose() {
-	log.Trace().Msg("Closing blocked")
+	m.logger.Trace().Msg("Closing blocked")
 	closeResults := m.Close()
 	select {
 	case <-closeResults:
@@ -98,79 +118,113 @@ func (m Connection) BlockingClose() {
 }
 
 func (m Connection) Close() <-chan plc4go.PlcConnectionCloseResult {
-	log.Trace().Msg("Close")
-	// TODO: Implement ...
-	ch := make(chan plc4go.PlcConnectionCloseResult)
+	return m.CloseWithContext(context.Background())
+}
+
+func (m Connection) CloseWithContext(_ context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	m.logger.Trace().Msg("Close")
+	// TODO: Implement a graceful disconnect on protocol level ...
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
 	go func() {
-		ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+		// Fail everything still waiting for its turn
+		m.tm.Close()
+		err := m.messageCodec.Disconnect()
+		if err != nil {
+			err = errors.Wrap(err, "error disconnecting")
+		}
+		ch <- plc4go.NewPlcConnectionCloseResult(m, err)
 	}()
 	return ch
 }
 
 func (m Connection) IsConnected() bool {
-	panic("implement me")
+	return m.messageCodec.IsRunning()
 }
 
 func (m Connection) Ping() <-chan plc4go.PlcConnectionPingResult {
-	log.Trace().Msg("Pinging")
-	result := make(chan plc4go.PlcConnectionPingResult)
+	return m.PingWithContext(context.Background())
+}
+
+func (m Connection) PingWithContext(ctx context.Context) <-chan plc4go.PlcConnectionPingResult {
+	m.logger.Trace().Msg("Pinging")
+	result := make(chan plc4go.PlcConnectionPingResult, 1)
 	go func() {
 		diagnosticRequestPdu := readWriteModel.NewModbusPDUDiagnosticRequest(0, 0x42)
 		pingRequest := readWriteModel.NewModbusTcpADU(1, m.unitIdentifier, diagnosticRequestPdu)
-		if err := m.messageCodec.SendRequest(
-			pingRequest,
-			func(message interface{}) bool {
-				responseAdu := readWriteModel.CastModbusTcpADU(message)
-				return responseAdu.TransactionIdentifier == 1 && responseAdu.UnitIdentifier == m.unitIdentifier
-			},
-			func(message interface{}) error {
-				log.Trace().Msgf("Received Message")
-				if message != nil {
-					// If we got a valid response (even if it will probably contain an error, we know the remote is available)
-					log.Trace().Msg("got valid response")
-					result <- plc4go.NewPlcConnectionPingResult(nil)
-				} else {
-					log.Trace().Msg("got no response")
-					result <- plc4go.NewPlcConnectionPingResult(errors.New("no response"))
-				}
-				return nil
-			},
-			func(err error) error {
-				log.Trace().Msgf("Received Error")
-				result <- plc4go.NewPlcConnectionPingResult(errors.Wrap(err, "got error processing request"))
-				return nil
-			},
-			time.Second*1); err != nil {
-			result <- plc4go.NewPlcConnectionPingResult(err)
-		}
+		result <- plc4go.NewPlcConnectionPingResult(m.tm.Execute(ctx, spi.RequestPriorityHigh, func(ctx context.Context) error {
+			pingResult := make(chan error, 1)
+			if err := m.messageCodec.SendRequestWithCorrelationKey(
+				ctx,
+				pingRequest,
+				pingRequest.TransactionIdentifier,
+				func(message interface{}) bool {
+					responseAdu := readWriteModel.CastModbusTcpADU(message)
+					return responseAdu.TransactionIdentifier == 1 && responseAdu.UnitIdentifier == m.unitIdentifier
+				},
+				func(message interface{}) error {
+					m.logger.Trace().Msgf("Received Message")
+					if message != nil {
+						// If we got a valid response (even if it will probably contain an error, we know the remote is available)
+						m.logger.Trace().Msg("got valid response")
+						pingResult <- nil
+					} else {
+						m.logger.Trace().Msg("got no response")
+						pingResult <- errors.New("no response")
+					}
+					return nil
+				},
+				func(err error) error {
+					m.logger.Trace().Msgf("Received Error")
+					pingResult <- errors.Wrap(err, "got error processing request")
+					return nil
+				},
+				m.requestQueue.RequestTimeout); err != nil {
+				return err
+			}
+			return <-pingResult
+		}))
 	}()
 	return result
 }
 
+func (m Connection) GetRequestQueueMetrics() apiModel.PlcRequestQueueMetrics {
+	return m.tm.GetMetrics()
+}
+
+func (m Connection) GetConnectionMetrics() apiModel.PlcConnectionMetrics {
+	return spi.GetConnectionMetrics(m.messageCodec).GetMetrics()
+}
+
 func (m Connection) GetMetadata() apiModel.PlcConnectionMetadata {
 	return ConnectionMetadata{}
 }
 
 func (m Connection) ReadRequestBuilder() apiModel.PlcReadRequestBuilder {
+	reader := NewReader(m.unitIdentifier, m.messageCodec, m.tm, m.requestQueue)
 	return internalModel.NewDefaultPlcReadRequestBuilderWithInterceptor(m.fieldHandler,
-		NewReader(m.unitIdentifier, m.messageCodec), m.requestInterceptor)
+		spi.NewMeteredReader(reader, spi.GetConnectionMetrics(m.messageCodec)), m.readRequestInterceptor)
 }
 
 func (m Connection) WriteRequestBuilder() apiModel.PlcWriteRequestBuilder {
-	return internalModel.NewDefaultPlcWriteRequestBuilder(
-		m.fieldHandler, m.valueHandler, NewWriter(m.unitIdentifier, m.messageCodec))
+	writer := NewWriter(m.unitIdentifier, m.messageCodec, m.tm, m.requestQueue)
+	return internalModel.NewDefaultPlcWriteRequestBuilderWithInterceptor(m.fieldHandler, m.valueHandler,
+		spi.NewMeteredWriter(writer, spi.GetConnectionMetrics(m.messageCodec)), m.requestInterceptor)
 }
 
 func (m Connection) SubscriptionRequestBuilder() apiModel.PlcSubscriptionRequestBuilder {
-	panic("implement me")
+	return internalModel.NewFailingPlcSubscriptionRequestBuilder(internalModel.ErrNotSupported)
 }
 
 func (m Connection) UnsubscriptionRequestBuilder() apiModel.PlcUnsubscriptionRequestBuilder {
-	panic("implement me")
+	return internalModel.NewFailingPlcUnsubscriptionRequestBuilder(internalModel.ErrNotSupported)
 }
 
 func (m Connection) BrowseRequestBuilder() apiModel.PlcBrowseRequestBuilder {
-	panic("implement me")
+	return internalModel.NewFailingPlcBrowseRequestBuilder(internalModel.ErrNotSupported)
+}
+
+func (m Connection) GetMessageCodec() spi.MessageCodec {
+	return m.messageCodec
 }
 
 func (m Connection) GetTransportInstance() transports.TransportInstance {
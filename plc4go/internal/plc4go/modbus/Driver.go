@@ -19,37 +19,91 @@
 package modbus
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"net/url"
 	"strconv"
+	"time"
+)
+
+var optionSchema = options.OptionSchema{
+	{Name: "unit-identifier", Type: options.OptionTypeInteger, Default: "1", Description: "Unit identifier (slave address with RTU or ASCII framing) of the addressed device"},
+	{Name: "optimize-reads", Type: options.OptionTypeBoolean, Default: "false", Description: "Read consecutive registers with a single request instead of one request per field"},
+}.Merge(spi.RequestQueueOptionSchema)
+
+// Framing defines how the PDUs are framed on the wire
+type Framing uint8
+
+const (
+	// FramingTcp uses the Modbus TCP header (MBAP)
+	FramingTcp Framing = iota
+	// FramingRtu uses the slave address and a CRC, as on serial lines
+	FramingRtu
+	// FramingAscii encodes the slave address, the PDU and an LRC as hex digits, between a colon and CR LF
+	FramingAscii
 )
 
 type Driver struct {
+	framing      Framing
 	fieldHandler spi.PlcFieldHandler
 }
 
 func NewDriver() *Driver {
 	return &Driver{
+		framing:      FramingTcp,
+		fieldHandler: NewFieldHandler(),
+	}
+}
+
+// NewRtuDriver returns a driver for Modbus RTU devices, connected by serial lines or by RTU-over-TCP converters
+func NewRtuDriver() *Driver {
+	return &Driver{
+		framing:      FramingRtu,
+		fieldHandler: NewFieldHandler(),
+	}
+}
+
+// NewAsciiDriver returns a driver for Modbus ASCII devices, connected by serial lines or by converters to TCP
+func NewAsciiDriver() *Driver {
+	return &Driver{
+		framing:      FramingAscii,
 		fieldHandler: NewFieldHandler(),
 	}
 }
 
 func (m Driver) GetProtocolCode() string {
+	switch m.framing {
+	case FramingRtu:
+		return "modbus-rtu"
+	case FramingAscii:
+		return "modbus-ascii"
+	}
 	return "modbus"
 }
 
 func (m Driver) GetProtocolName() string {
+	switch m.framing {
+	case FramingRtu:
+		return "Modbus RTU"
+	case FramingAscii:
+		return "Modbus ASCII"
+	}
 	return "Modbus"
 }
 
 func (m Driver) GetDefaultTransport() string {
+	switch m.framing {
+	case FramingRtu, FramingAscii:
+		return "serial"
+	}
 	return "tcp"
 }
 
@@ -58,12 +112,21 @@ func (m Driver) CheckQuery(query string) error {
 	return err
 }
 
+func (m Driver) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
 func (m Driver) GetConnection(transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
-	log.Debug().Stringer("transportUrl", &transportUrl).Msgf("Get connection for transport url with %d transport(s) and %d option(s)", len(transports), len(options))
+	return m.GetConnectionWithContext(context.Background(), transportUrl, transports, options)
+}
+
+func (m Driver) GetConnectionWithContext(ctx context.Context, transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
+	logger := spi.LoggerFromContext(ctx)
+	logger.Debug().Stringer("transportUrl", &transportUrl).Msgf("Get connection for transport url with %d transport(s) and %d option(s)", len(transports), len(options))
 	// Get an the transport specified in the url
 	transport, ok := transports[transportUrl.Scheme]
 	if !ok {
-		log.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't find a transport for scheme %s", transportUrl.Scheme)
+		logger.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't find a transport for scheme %s", transportUrl.Scheme)
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
 			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Errorf("couldn't find transport for given transport url %#v", transportUrl))
@@ -71,11 +134,16 @@ func (m Driver) GetConnection(transportUrl url.URL, transports map[string]transp
 		return ch
 	}
 	// Provide a default-port to the transport, which is used, if the user doesn't provide on in the connection string.
-	options["defaultTcpPort"] = []string{"502"}
+	// Modbus/TCP Security has a port of its own.
+	if transportUrl.Scheme == "tls" {
+		options["defaultTcpPort"] = []string{"802"}
+	} else {
+		options["defaultTcpPort"] = []string{"502"}
+	}
 	// Have the transport create a new transport-instance.
 	transportInstance, err := transport.CreateTransportInstance(transportUrl, options)
 	if err != nil {
-		log.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't create a transport instance for port %#v", options["defaultTcpPort"])
+		logger.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't create a transport instance for port %#v", options["defaultTcpPort"])
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
 			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.New("couldn't initialize transport configuration for given transport url "+transportUrl.String()))
@@ -92,14 +160,14 @@ func (m Driver) GetConnection(transportUrl url.URL, transports map[string]transp
 			adu := msg.(model.ModbusTcpADU)
 			serialized, err := json.Marshal(adu)
 			if err != nil {
-				log.Error().Err(err).Msg("got error serializing adu")
+				logger.Error().Err(err).Msg("got error serializing adu")
 			} else {
-				log.Debug().Msgf("got message in the default handler %s\n", serialized)
+				logger.Debug().Msgf("got message in the default handler %s\n", serialized)
 			}
 		}
 	}()
-	codec := NewMessageCodec(transportInstance)
-	log.Debug().Msgf("working with codec %#v", codec)
+	codec := m.newMessageCodec(transportInstance, logger)
+	logger.Debug().Msgf("working with codec %#v", codec)
 
 	// If a unit-identifier was provided in the connection string use this, otherwise use the default of 1
 	unitIdentifier := uint8(1)
@@ -110,12 +178,46 @@ func (m Driver) GetConnection(transportUrl url.URL, transports map[string]transp
 			unitIdentifier = uint8(intValue)
 		}
 	}
-	log.Debug().Uint8("unitIdentifier", unitIdentifier).Msgf("using unit identifier %d", unitIdentifier)
+	logger.Debug().Uint8("unitIdentifier", unitIdentifier).Msgf("using unit identifier %d", unitIdentifier)
+
+	requestQueue, err := spi.ParseRequestQueueConfiguration(options, time.Second*1)
+	if err != nil {
+		ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+		ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(err, "invalid options"))
+		return ch
+	}
+
+	optimizeReads := false
+	if value, ok := options["optimize-reads"]; ok && len(value) > 0 {
+		optimizeReads, err = strconv.ParseBool(value[0])
+		if err != nil {
+			ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrapf(err, "error parsing optimize-reads %s", value[0]))
+			return ch
+		}
+	}
 
 	// Create the new connection
-	connection := NewConnection(unitIdentifier, codec, options, m.fieldHandler)
-	log.Info().Stringer("connection", connection).Msg("created connection, connecting now")
-	return connection.Connect()
+	connection := NewConnection(unitIdentifier, codec, options, m.fieldHandler, requestQueue, optimizeReads, logger)
+	logger.Info().Stringer("connection", connection).Msg("created connection, connecting now")
+	return connection.ConnectWithContext(ctx)
+}
+
+// newMessageCodec creates the codec for the framing of the driver
+func (m Driver) newMessageCodec(transportInstance transports.TransportInstance, logger zerolog.Logger) spi.MessageCodec {
+	switch m.framing {
+	case FramingRtu:
+		codec := NewRtuMessageCodec(transportInstance)
+		codec.SetLogger(logger)
+		return codec
+	case FramingAscii:
+		codec := NewAsciiMessageCodec(transportInstance)
+		codec.SetLogger(logger)
+		return codec
+	}
+	codec := NewMessageCodec(transportInstance)
+	codec.SetLogger(logger)
+	return codec
 }
 
 func (m Driver) SupportsDiscovery() bool {
@@ -0,0 +1,52 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"sync"
+)
+
+// pendingRequest remembers the request sent last for the framings without a transaction identifier (RTU and ASCII).
+// Received frames get the transaction identifier of that request, so they are correlated like the Modbus TCP ones.
+// With that, only one request may be pending at a time.
+type pendingRequest struct {
+	request *model.ModbusTcpADU
+	lock    sync.Mutex
+}
+
+func (m *pendingRequest) set(request *model.ModbusTcpADU) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.request = request
+}
+
+// answer returns the response received from the given address as ADU of the pending request, or nil if it doesn't
+// answer the pending request (the function code is passed separately, as it isn't kept by error PDUs)
+func (m *pendingRequest) answer(address uint8, functionCode uint8, pdu *model.ModbusPDU) *model.ModbusTcpADU {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	request := m.request
+	if request == nil || request.UnitIdentifier != address || request.Pdu.Child.FunctionFlag() != functionCode&0x7F {
+		return nil
+	}
+	m.request = nil
+	return model.NewModbusTcpADU(request.TransactionIdentifier, address, pdu)
+}
@@ -19,6 +19,7 @@
 package modbus
 
 import (
+	"context"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	plc4goModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
@@ -26,29 +27,34 @@ import (
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"math"
 	"sync/atomic"
-	"time"
 )
 
 type Reader struct {
 	transactionIdentifier int32
 	unitIdentifier        uint8
 	messageCodec          spi.MessageCodec
+	tm                    *spi.RequestTransactionManager
+	requestQueue          spi.RequestQueueConfiguration
+	logger                zerolog.Logger
 }
 
-func NewReader(unitIdentifier uint8, messageCodec spi.MessageCodec) *Reader {
+func NewReader(unitIdentifier uint8, messageCodec spi.MessageCodec, tm *spi.RequestTransactionManager, requestQueue spi.RequestQueueConfiguration) *Reader {
 	return &Reader{
 		transactionIdentifier: 0,
 		unitIdentifier:        unitIdentifier,
 		messageCodec:          messageCodec,
+		tm:                    tm,
+		requestQueue:          requestQueue,
+		logger:                *tm.GetLogger(),
 	}
 }
 
-func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
-	log.Trace().Msg("Reading")
-	result := make(chan model.PlcReadRequestResult)
+func (m *Reader) Read(ctx context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	m.logger.Trace().Msg("Reading")
+	result := make(chan model.PlcReadRequestResult, 1)
 	go func() {
 		if len(readRequest.GetFieldNames()) != 1 {
 			result <- model.PlcReadRequestResult{
@@ -56,7 +62,7 @@ func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequ
 				Response: nil,
 				Err:      errors.New("modbus only supports single-item requests"),
 			}
-			log.Debug().Msgf("modbus only supports single-item requests. Got %d fields", len(readRequest.GetFieldNames()))
+			m.logger.Debug().Msgf("modbus only supports single-item requests. Got %d fields", len(readRequest.GetFieldNames()))
 			return
 		}
 		// If we are requesting only one field, use a
@@ -69,11 +75,11 @@ func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequ
 				Response: nil,
 				Err:      errors.Wrap(err, "invalid field item type"),
 			}
-			log.Debug().Msgf("Invalid field item type %T", field)
+			m.logger.Debug().Msgf("Invalid field item type %T", field)
 			return
 		}
 		numWords := uint16(math.Ceil(float64(modbusField.Quantity*uint16(modbusField.Datatype.DataTypeSize())) / float64(2)))
-		log.Debug().Msgf("Working with %d words", numWords)
+		m.logger.Debug().Msgf("Working with %d words", numWords)
 		var pdu *readWriteModel.ModbusPDU = nil
 		switch modbusField.FieldType {
 		case Coil:
@@ -97,71 +103,76 @@ func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequ
 				Response: nil,
 				Err:      errors.Errorf("unsupported field type %x", modbusField.FieldType),
 			}
-			log.Debug().Msgf("Unsupported field type %x", modbusField.FieldType)
+			m.logger.Debug().Msgf("Unsupported field type %x", modbusField.FieldType)
 			return
 		}
 
-		// Calculate a new transaction identifier
-		transactionIdentifier := atomic.AddInt32(&m.transactionIdentifier, 1)
-		if transactionIdentifier > math.MaxUint8 {
-			transactionIdentifier = 1
-			atomic.StoreInt32(&m.transactionIdentifier, 1)
-		}
-		log.Debug().Msgf("Calculated transaction identifier %x", transactionIdentifier)
-
-		// Assemble the finished ADU
-		log.Trace().Msg("Assemble ADU")
-		requestAdu := readWriteModel.ModbusTcpADU{
-			TransactionIdentifier: uint16(transactionIdentifier),
-			UnitIdentifier:        m.unitIdentifier,
-			Pdu:                   pdu,
-		}
+		// Repeat the read, if it times out, as reading twice doesn't hurt
+		timeout := m.requestQueue.GetRequestTimeout(readRequest.GetTimeout())
+		retries := m.requestQueue.GetRetries(readRequest.GetRetries())
+		result <- plc4goModel.ReadWithRetries(ctx, m.logger, readRequest, retries, func() model.PlcReadRequestResult {
+			// Calculate a new transaction identifier
+			transactionIdentifier := atomic.AddInt32(&m.transactionIdentifier, 1)
+			if transactionIdentifier > math.MaxUint8 {
+				transactionIdentifier = 1
+				atomic.StoreInt32(&m.transactionIdentifier, 1)
+			}
+			m.logger.Debug().Msgf("Calculated transaction identifier %x", transactionIdentifier)
 
-		// Send the ADU over the wire
-		log.Trace().Msg("Send ADU")
-		if err = m.messageCodec.SendRequest(
-			requestAdu,
-			func(message interface{}) bool {
-				responseAdu := readWriteModel.CastModbusTcpADU(message)
-				return responseAdu.TransactionIdentifier == uint16(transactionIdentifier) &&
-					responseAdu.UnitIdentifier == requestAdu.UnitIdentifier
-			},
-			func(message interface{}) error {
-				// Convert the response into an ADU
-				log.Trace().Msg("convert response to ADU")
-				responseAdu := readWriteModel.CastModbusTcpADU(message)
-				// Convert the modbus response into a PLC4X response
-				log.Trace().Msg("convert response to PLC4X response")
-				readResponse, err := m.ToPlc4xReadResponse(*responseAdu, readRequest)
+			// Assemble the finished ADU
+			m.logger.Trace().Msg("Assemble ADU")
+			requestAdu := readWriteModel.ModbusTcpADU{
+				TransactionIdentifier: uint16(transactionIdentifier),
+				UnitIdentifier:        m.unitIdentifier,
+				Pdu:                   pdu,
+			}
 
-				if err != nil {
-					result <- model.PlcReadRequestResult{
-						Request: readRequest,
-						Err:     errors.Wrap(err, "Error decoding response"),
-					}
-					// TODO: should we return the error here?
-					return nil
+			// Send the ADU over the wire, as soon as it's our turn on this connection
+			var readResponse model.PlcReadResponse
+			transaction := m.tm.StartRequest()
+			transaction.SetPriority(m.requestQueue.ReadPriority)
+			transaction.SubmitWithContext(ctx, func() {
+				transaction.GetLogger().Trace().Msg("Send ADU")
+				if err := m.messageCodec.SendRequestWithCorrelationKey(
+					transaction.GetContext(),
+					requestAdu,
+					requestAdu.TransactionIdentifier,
+					func(message interface{}) bool {
+						responseAdu := readWriteModel.CastModbusTcpADU(message)
+						return responseAdu.TransactionIdentifier == uint16(transactionIdentifier) &&
+							responseAdu.UnitIdentifier == requestAdu.UnitIdentifier
+					},
+					func(message interface{}) error {
+						// Convert the response into an ADU
+						transaction.GetLogger().Trace().Msg("convert response to ADU")
+						responseAdu := readWriteModel.CastModbusTcpADU(message)
+						// Convert the modbus response into a PLC4X response
+						transaction.GetLogger().Trace().Msg("convert response to PLC4X response")
+						response, err := m.ToPlc4xReadResponse(*responseAdu, readRequest)
+						if err != nil {
+							return transaction.FailRequest(errors.Wrap(err, "Error decoding response"))
+						}
+						readResponse = response
+						return transaction.EndRequest()
+					},
+					func(err error) error {
+						return transaction.FailRequest(errors.Wrap(err, "got timeout while waiting for response"))
+					},
+					timeout); err != nil {
+					_ = transaction.FailRequest(errors.Wrap(err, "error sending message"))
 				}
-				result <- model.PlcReadRequestResult{
-					Request:  readRequest,
-					Response: readResponse,
-				}
-				return nil
-			},
-			func(err error) error {
-				result <- model.PlcReadRequestResult{
+			})
+			if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+				return model.PlcReadRequestResult{
 					Request: readRequest,
-					Err:     errors.Wrap(err, "got timeout while waiting for response"),
+					Err:     err,
 				}
-				return nil
-			},
-			time.Second*1); err != nil {
-			result <- model.PlcReadRequestResult{
+			}
+			return model.PlcReadRequestResult{
 				Request:  readRequest,
-				Response: nil,
-				Err:      errors.Wrap(err, "error sending message"),
+				Response: readResponse,
 			}
-		}
+		})
 	}()
 	return result
 }
@@ -191,7 +202,7 @@ func (m *Reader) ToPlc4xReadResponse(responseAdu readWriteModel.ModbusTcpADU, re
 	}
 
 	// Get the field from the request
-	log.Trace().Msg("get a field from request")
+	m.logger.Trace().Msg("get a field from request")
 	fieldName := readRequest.GetFieldNames()[0]
 	field, err := CastToModbusFieldFromPlcField(readRequest.GetField(fieldName))
 	if err != nil {
@@ -199,7 +210,7 @@ func (m *Reader) ToPlc4xReadResponse(responseAdu readWriteModel.ModbusTcpADU, re
 	}
 
 	// Decode the data according to the information from the request
-	log.Trace().Msg("decode data")
+	m.logger.Trace().Msg("decode data")
 	rb := utils.NewReadBuffer(data)
 	value, err := readWriteModel.DataItemParse(rb, field.Datatype, field.Quantity)
 	if err != nil {
@@ -211,6 +222,6 @@ func (m *Reader) ToPlc4xReadResponse(responseAdu readWriteModel.ModbusTcpADU, re
 	responseCodes[fieldName] = model.PlcResponseCode_OK
 
 	// Return the response
-	log.Trace().Msg("Returning the response")
+	m.logger.Trace().Msg("Returning the response")
 	return plc4goModel.NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues), nil
 }
@@ -0,0 +1,215 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	"github.com/pkg/errors"
+)
+
+// Address, function code and CRC of an exception response
+const minRtuFrameLength = 5
+
+// Address, 253 bytes of PDU and CRC
+const maxRtuFrameLength = 256
+
+// RtuMessageCodec frames the ADUs of the reader and writer the Modbus RTU way: the slave address, the PDU and a CRC
+type RtuMessageCodec struct {
+	*spi.DefaultCodec
+	pendingRequest pendingRequest
+}
+
+func NewRtuMessageCodec(transportInstance transports.TransportInstance) *RtuMessageCodec {
+	codec := &RtuMessageCodec{
+		DefaultCodec: spi.NewDefaultCodec(transportInstance),
+	}
+	codec.DefaultCodecRequiredInterface = codec
+	codec.CorrelationKeyExtractor = getCorrelationKey
+	return codec
+}
+
+func (m *RtuMessageCodec) Send(message interface{}) error {
+	m.GetLogger().Trace().Msg("Sending message")
+	tcpAdu := model.CastModbusTcpADU(message)
+	if tcpAdu == nil {
+		return errors.Errorf("unsupported message type %T", message)
+	}
+	// Serialize the request
+	wb := utils.NewWriteBuffer()
+	err := tcpAdu.Pdu.Serialize(*wb)
+	if err != nil {
+		return errors.Wrap(err, "error serializing request")
+	}
+	frame := appendRtuCrc(append([]uint8{tcpAdu.UnitIdentifier}, wb.GetBytes()...))
+
+	m.pendingRequest.set(tcpAdu)
+	// Send it to the PLC
+	err = m.WriteBytes(frame)
+	if err != nil {
+		return errors.Wrap(err, "error sending request")
+	}
+	return nil
+}
+
+func (m *RtuMessageCodec) Receive() (interface{}, error) {
+	m.GetLogger().Trace().Msg("receiving")
+	num, err := m.TransportInstance.GetNumReadableBytes()
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("Got error reading")
+		return nil, errors.Wrap(err, "error reading from transport")
+	}
+	if num < minRtuFrameLength {
+		return nil, nil
+	}
+	data, err := m.TransportInstance.PeekReadableBytes(num)
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("error peeking")
+		return nil, nil
+	}
+	frameLength, ok := getRtuResponseLength(data)
+	if !ok {
+		frameLength, ok = m.getUnknownFrameLength(data)
+		if !ok {
+			return nil, nil
+		}
+	}
+	if num < frameLength {
+		m.GetLogger().Debug().Msgf("Not enough bytes. Got: %d Need: %d", num, frameLength)
+		return nil, nil
+	}
+	data, err = m.ReadBytes(frameLength)
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("error reading")
+		return nil, nil
+	}
+	if !checkRtuCrc(data) {
+		m.GetLogger().Warn().Hex("frame", data).Msg("Dropping frame with invalid CRC")
+		m.discard()
+		return m.Receive()
+	}
+	pdu, err := model.ModbusPDUParse(utils.NewReadBuffer(data[1:frameLength-2]), true)
+	if err != nil {
+		m.GetLogger().Warn().Err(err).Msg("error parsing")
+		// Continue with the frames received after the dropped one
+		return m.Receive()
+	}
+
+	tcpAdu := m.pendingRequest.answer(data[0], data[1], pdu)
+	if tcpAdu == nil {
+		m.GetLogger().Warn().Hex("frame", data).Msg("Dropping frame not answering the pending request")
+		return m.Receive()
+	}
+	return tcpAdu, nil
+}
+
+// getUnknownFrameLength is used for responses, whose length can't be derived from their content. On lines separating
+// frames by silence, the frame is complete as soon as the line becomes silent. Otherwise all buffered bytes are taken
+// as soon as their CRC matches.
+func (m *RtuMessageCodec) getUnknownFrameLength(data []uint8) (uint32, bool) {
+	if silenceDetectingTransportInstance, ok := m.TransportInstance.(transports.SilenceDetectingTransportInstance); ok {
+		if err := silenceDetectingTransportInstance.WaitForSilence(); err != nil {
+			m.GetLogger().Warn().Err(err).Msg("error waiting for silence")
+			return 0, false
+		}
+		num, err := m.TransportInstance.GetNumReadableBytes()
+		if err != nil {
+			return 0, false
+		}
+		return num, true
+	}
+	if checkRtuCrc(data) {
+		return uint32(len(data)), true
+	}
+	if len(data) >= maxRtuFrameLength {
+		m.GetLogger().Warn().Hex("data", data).Msg("Dropping data without a valid frame")
+		m.discard()
+	}
+	return 0, false
+}
+
+// discard drops everything received up to now, in order to start over with the next frame
+func (m *RtuMessageCodec) discard() {
+	if silenceDetectingTransportInstance, ok := m.TransportInstance.(transports.SilenceDetectingTransportInstance); ok {
+		if err := silenceDetectingTransportInstance.WaitForSilence(); err != nil {
+			m.GetLogger().Warn().Err(err).Msg("error waiting for silence")
+		}
+	}
+	if num, err := m.TransportInstance.GetNumReadableBytes(); err == nil && num > 0 {
+		_, _ = m.ReadBytes(num)
+	}
+}
+
+// getRtuResponseLength returns the length of the response frame starting with the given bytes, if it can be derived
+// from the function code (and byte count)
+func getRtuResponseLength(data []uint8) (uint32, bool) {
+	functionCode := data[1]
+	if functionCode&0x80 != 0 {
+		return minRtuFrameLength, true
+	}
+	switch functionCode {
+	case 0x01, 0x02, 0x03, 0x04, 0x0C, 0x11, 0x14, 0x15, 0x17:
+		// Address, function code, byte count, data and CRC
+		return 3 + uint32(data[2]) + 2, true
+	case 0x05, 0x06, 0x08, 0x0B, 0x0F, 0x10:
+		return 8, true
+	case 0x07:
+		return 5, true
+	case 0x16:
+		return 10, true
+	case 0x18:
+		// Address, function code, two bytes byte count, data and CRC
+		return 4 + (uint32(data[2])<<8 | uint32(data[3])) + 2, true
+	}
+	return 0, false
+}
+
+// getRtuCrc calculates the CRC-16 of Modbus RTU frames
+func getRtuCrc(data []uint8) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&0x0001 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// appendRtuCrc appends the CRC to the frame (low byte first)
+func appendRtuCrc(frame []uint8) []uint8 {
+	crc := getRtuCrc(frame)
+	return append(frame, uint8(crc), uint8(crc>>8))
+}
+
+// checkRtuCrc checks the CRC at the end of the frame
+func checkRtuCrc(frame []uint8) bool {
+	if len(frame) < 3 {
+		return false
+	}
+	crc := getRtuCrc(frame[:len(frame)-2])
+	return frame[len(frame)-2] == uint8(crc) && frame[len(frame)-1] == uint8(crc>>8)
+}
@@ -0,0 +1,170 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"bytes"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/test"
+	"testing"
+)
+
+// silenceDetectingTransportInstance pretends the line to be silent whenever asked
+type silenceDetectingTransportInstance struct {
+	*test.TransportInstance
+}
+
+func (m silenceDetectingTransportInstance) WaitForSilence() error {
+	return nil
+}
+
+func TestRtuCrc(t *testing.T) {
+	tests := []struct {
+		frame    []uint8
+		expected []uint8
+	}{
+		{[]uint8{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A}, []uint8{0xC5, 0xCD}},
+		{[]uint8{0x01, 0x03, 0x00, 0x00, 0x00, 0x01}, []uint8{0x84, 0x0A}},
+		{[]uint8{0x11, 0x03, 0x00, 0x6B, 0x00, 0x03}, []uint8{0x76, 0x87}},
+	}
+	for _, tt := range tests {
+		frame := appendRtuCrc(append([]uint8{}, tt.frame...))
+		if !bytes.Equal(frame[len(tt.frame):], tt.expected) {
+			t.Errorf("Expected CRC %x for %x, got %x", tt.expected, tt.frame, frame[len(tt.frame):])
+		}
+		if !checkRtuCrc(frame) {
+			t.Errorf("Expected the CRC of %x to be valid", frame)
+		}
+		frame[0]++
+		if checkRtuCrc(frame) {
+			t.Errorf("Expected the CRC of %x to be invalid", frame)
+		}
+	}
+}
+
+func TestRtuMessageCodec_SendReceive(t *testing.T) {
+	transportInstance := test.NewTransportInstance(test.NewTransport())
+	codec := NewRtuMessageCodec(transportInstance)
+
+	request := model.NewModbusTcpADU(7, 0x11, model.NewModbusPDUReadHoldingRegistersRequest(0x006B, 3))
+	if err := codec.Send(request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sent, _ := transportInstance.DrainWriteBuffer(transportInstance.GetNumDrainableBytes())
+	if expected := []uint8{0x11, 0x03, 0x00, 0x6B, 0x00, 0x03, 0x76, 0x87}; !bytes.Equal(sent, expected) {
+		t.Errorf("Expected frame %x, got %x", expected, sent)
+	}
+
+	response := appendRtuCrc([]uint8{0x11, 0x03, 0x06, 0xAE, 0x41, 0x56, 0x52, 0x43, 0x40})
+	// Incomplete frames are left alone
+	_ = transportInstance.FillReadBuffer(response[:6])
+	if message, err := codec.Receive(); message != nil || err != nil {
+		t.Fatalf("Expected nothing to be received, got %v (%v)", message, err)
+	}
+	_ = transportInstance.FillReadBuffer(response[6:])
+	message, err := codec.Receive()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tcpAdu := model.CastModbusTcpADU(message)
+	if tcpAdu == nil {
+		t.Fatalf("Expected a ModbusTcpADU, got %T", message)
+	}
+	// The response is correlated with the request
+	if tcpAdu.TransactionIdentifier != 7 || tcpAdu.UnitIdentifier != 0x11 {
+		t.Errorf("Unexpected header %+v", tcpAdu)
+	}
+	readResponse := model.CastModbusPDUReadHoldingRegistersResponse(tcpAdu.Pdu)
+	if readResponse == nil || len(readResponse.Value) != 6 || uint8(readResponse.Value[0]) != 0xAE {
+		t.Errorf("Unexpected response %+v", tcpAdu.Pdu.Child)
+	}
+	if num, _ := transportInstance.GetNumReadableBytes(); num != 0 {
+		t.Errorf("Expected the frame to be consumed, %d bytes left", num)
+	}
+}
+
+func TestRtuMessageCodec_ReceiveDropsInvalidFrames(t *testing.T) {
+	transportInstance := test.NewTransportInstance(test.NewTransport())
+	codec := NewRtuMessageCodec(transportInstance)
+	if err := codec.Send(model.NewModbusTcpADU(1, 1, model.NewModbusPDUReadHoldingRegistersRequest(0, 1))); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		frame []uint8
+	}{
+		{"invalid crc", []uint8{0x01, 0x03, 0x02, 0x00, 0x2A, 0x00, 0x00}},
+		{"other slave", appendRtuCrc([]uint8{0x02, 0x03, 0x02, 0x00, 0x2A})},
+		{"other function", appendRtuCrc([]uint8{0x01, 0x04, 0x02, 0x00, 0x2A})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = transportInstance.FillReadBuffer(tt.frame)
+			if message, err := codec.Receive(); message != nil || err != nil {
+				t.Errorf("Expected the frame to be dropped, got %v (%v)", message, err)
+			}
+			if num, _ := transportInstance.GetNumReadableBytes(); num != 0 {
+				t.Errorf("Expected the frame to be consumed, %d bytes left", num)
+			}
+		})
+	}
+
+	// Exception responses answer the request as well
+	_ = transportInstance.FillReadBuffer(appendRtuCrc([]uint8{0x01, 0x83, 0x02}))
+	message, err := codec.Receive()
+	if tcpAdu := model.CastModbusTcpADU(message); err != nil || tcpAdu == nil || model.CastModbusPDUError(tcpAdu.Pdu) == nil {
+		t.Errorf("Expected an error response, got %v (%v)", message, err)
+	}
+}
+
+func TestRtuMessageCodec_ReceiveUnknownLength(t *testing.T) {
+	// Read device identification has no byte count
+	response := appendRtuCrc([]uint8{0x01, 0x2B, 0x0E, 0x01, 0x01, 0x00, 0x00, 0x01, 0x00, 0x03, 0x41, 0x42, 0x43})
+
+	t.Run("stream", func(t *testing.T) {
+		transportInstance := test.NewTransportInstance(test.NewTransport())
+		codec := NewRtuMessageCodec(transportInstance)
+		_ = transportInstance.FillReadBuffer(response[:8])
+		if message, err := codec.Receive(); message != nil || err != nil {
+			t.Fatalf("Expected nothing to be received, got %v (%v)", message, err)
+		}
+		// Without silence detection, the frame is complete as soon as the CRC matches
+		_ = transportInstance.FillReadBuffer(response[8:])
+		if _, err := codec.Receive(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if num, _ := transportInstance.GetNumReadableBytes(); num != 0 {
+			t.Errorf("Expected the frame to be consumed, %d bytes left", num)
+		}
+	})
+
+	t.Run("serial line", func(t *testing.T) {
+		transportInstance := test.NewTransportInstance(test.NewTransport())
+		codec := NewRtuMessageCodec(silenceDetectingTransportInstance{transportInstance})
+		_ = transportInstance.FillReadBuffer(response)
+		if _, err := codec.Receive(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if num, _ := transportInstance.GetNumReadableBytes(); num != 0 {
+			t.Errorf("Expected the frame to be consumed, %d bytes left", num)
+		}
+	})
+}
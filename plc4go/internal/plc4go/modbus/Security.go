@@ -0,0 +1,67 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"github.com/pkg/errors"
+	"unicode/utf8"
+)
+
+// RoleOid identifies the certificate extension, in which Modbus/TCP Security transfers the role of a client
+var RoleOid = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 50316, 802, 1}
+
+// GetRole returns the role from the Modbus role extension of the certificate. Certificates without the extension
+// have no role (an empty string).
+func GetRole(certificate *x509.Certificate) (string, error) {
+	role := ""
+	found := false
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(RoleOid) {
+			continue
+		}
+		if found {
+			return "", errors.New("certificate has more than one role extension")
+		}
+		found = true
+		var value asn1.RawValue
+		rest, err := asn1.Unmarshal(extension.Value, &value)
+		if err != nil {
+			return "", errors.Wrap(err, "error parsing role extension")
+		}
+		// The role is a single UTF8String
+		if len(rest) > 0 || value.Class != asn1.ClassUniversal || value.Tag != asn1.TagUTF8String || !utf8.Valid(value.Bytes) {
+			return "", errors.New("error parsing role extension: expected a UTF8String")
+		}
+		role = string(value.Bytes)
+	}
+	return role, nil
+}
+
+// GetClientRole returns the role of the client of a Modbus/TCP Security server connection, in order to authorize
+// its requests. The server has to request (and verify) client certificates.
+func GetClientRole(connectionState tls.ConnectionState) (string, error) {
+	if len(connectionState.PeerCertificates) == 0 {
+		return "", errors.New("client didn't provide a certificate")
+	}
+	return GetRole(connectionState.PeerCertificates[0])
+}
@@ -0,0 +1,101 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package modbus
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// newCertificate creates a self-signed certificate with the given extensions
+func newCertificate(t *testing.T, extensions ...pkix.Extension) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "client"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: extensions,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing certificate: %v", err)
+	}
+	return certificate
+}
+
+func newRoleExtension(t *testing.T, role string, params string) pkix.Extension {
+	value, err := asn1.MarshalWithParams(role, params)
+	if err != nil {
+		t.Fatalf("Error encoding role: %v", err)
+	}
+	return pkix.Extension{Id: RoleOid, Value: value}
+}
+
+func TestGetRole(t *testing.T) {
+	certificate := newCertificate(t, newRoleExtension(t, "Operator", "utf8"))
+	if role, err := GetRole(certificate); err != nil || role != "Operator" {
+		t.Errorf("Expected role Operator, got %q (%v)", role, err)
+	}
+	if role, err := GetClientRole(tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}}); err != nil || role != "Operator" {
+		t.Errorf("Expected client role Operator, got %q (%v)", role, err)
+	}
+
+	if role, err := GetRole(newCertificate(t)); err != nil || role != "" {
+		t.Errorf("Expected no role, got %q (%v)", role, err)
+	}
+	if _, err := GetClientRole(tls.ConnectionState{}); err == nil {
+		t.Error("Expected an error without client certificate")
+	}
+}
+
+func TestGetRole_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		certificate *x509.Certificate
+	}{
+		{"printable string", newCertificate(t, newRoleExtension(t, "Operator", "printable"))},
+		{"trailing data", newCertificate(t, pkix.Extension{Id: RoleOid, Value: append(newRoleExtension(t, "Operator", "utf8").Value, 0x00)})},
+		// (not created, as newer versions of the x509 package refuse to parse certificates with duplicate extensions)
+		{"multiple roles", &x509.Certificate{Extensions: []pkix.Extension{newRoleExtension(t, "Operator", "utf8"), newRoleExtension(t, "Engineer", "utf8")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if role, err := GetRole(tt.certificate); err == nil {
+				t.Errorf("Expected an error, got role %q", role)
+			}
+		})
+	}
+}
@@ -19,34 +19,40 @@
 package modbus
 
 import (
+	"context"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	plc4goModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"math"
 	"sync/atomic"
-	"time"
 )
 
 type Writer struct {
 	transactionIdentifier int32
 	unitIdentifier        uint8
 	messageCodec          spi.MessageCodec
+	tm                    *spi.RequestTransactionManager
+	requestQueue          spi.RequestQueueConfiguration
+	logger                zerolog.Logger
 }
 
-func NewWriter(unitIdentifier uint8, messageCodec spi.MessageCodec) Writer {
-	return Writer{
+func NewWriter(unitIdentifier uint8, messageCodec spi.MessageCodec, tm *spi.RequestTransactionManager, requestQueue spi.RequestQueueConfiguration) *Writer {
+	return &Writer{
 		transactionIdentifier: 0,
 		unitIdentifier:        unitIdentifier,
 		messageCodec:          messageCodec,
+		tm:                    tm,
+		requestQueue:          requestQueue,
+		logger:                *tm.GetLogger(),
 	}
 }
 
-func (m Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
-	result := make(chan model.PlcWriteRequestResult)
+func (m *Writer) Write(ctx context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
+	result := make(chan model.PlcWriteRequestResult, 1)
 	go func() {
 		// If we are requesting only one field, use a
 		if len(writeRequest.GetFieldNames()) != 1 {
@@ -115,60 +121,70 @@ func (m Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteR
 			return
 		}
 
-		// Calculate a new unit identifier
-		transactionIdentifier := atomic.AddInt32(&m.transactionIdentifier, 1)
-		if transactionIdentifier > math.MaxUint8 {
-			transactionIdentifier = 0
-			atomic.StoreInt32(&m.transactionIdentifier, 0)
-		}
-
-		// Assemble the finished ADU
-		requestAdu := readWriteModel.ModbusTcpADU{
-			TransactionIdentifier: uint16(transactionIdentifier),
-			UnitIdentifier:        m.unitIdentifier,
-			Pdu:                   pdu,
-		}
+		timeout := m.requestQueue.GetRequestTimeout(writeRequest.GetTimeout())
+		result <- plc4goModel.WriteWithoutRetries(m.logger, writeRequest, func() model.PlcWriteRequestResult {
+			// Calculate a new unit identifier
+			transactionIdentifier := atomic.AddInt32(&m.transactionIdentifier, 1)
+			if transactionIdentifier > math.MaxUint8 {
+				transactionIdentifier = 0
+				atomic.StoreInt32(&m.transactionIdentifier, 0)
+			}
 
-		// Send the ADU over the wire
-		err = m.messageCodec.SendRequest(
-			requestAdu,
-			func(message interface{}) bool {
-				responseAdu := readWriteModel.CastModbusTcpADU(message)
-				return responseAdu.TransactionIdentifier == uint16(transactionIdentifier) &&
-					responseAdu.UnitIdentifier == requestAdu.UnitIdentifier
-			},
-			func(message interface{}) error {
-				// Convert the response into an ADU
-				responseAdu := readWriteModel.CastModbusTcpADU(message)
-				// Convert the modbus response into a PLC4X response
-				readResponse, err := m.ToPlc4xWriteResponse(requestAdu, *responseAdu, writeRequest)
+			// Assemble the finished ADU
+			requestAdu := readWriteModel.ModbusTcpADU{
+				TransactionIdentifier: uint16(transactionIdentifier),
+				UnitIdentifier:        m.unitIdentifier,
+				Pdu:                   pdu,
+			}
 
-				if err != nil {
-					result <- model.PlcWriteRequestResult{
-						Request: writeRequest,
-						Err:     errors.Wrap(err, "Error decoding response"),
-					}
-				} else {
-					result <- model.PlcWriteRequestResult{
-						Request:  writeRequest,
-						Response: readResponse,
-					}
+			// Send the ADU over the wire, as soon as it's our turn on this connection
+			var writeResponse model.PlcWriteResponse
+			transaction := m.tm.StartRequest()
+			transaction.SetPriority(m.requestQueue.WritePriority)
+			transaction.SubmitWithContext(ctx, func() {
+				if err := m.messageCodec.SendRequestWithCorrelationKey(
+					transaction.GetContext(),
+					requestAdu,
+					requestAdu.TransactionIdentifier,
+					func(message interface{}) bool {
+						responseAdu := readWriteModel.CastModbusTcpADU(message)
+						return responseAdu.TransactionIdentifier == uint16(transactionIdentifier) &&
+							responseAdu.UnitIdentifier == requestAdu.UnitIdentifier
+					},
+					func(message interface{}) error {
+						// Convert the response into an ADU
+						responseAdu := readWriteModel.CastModbusTcpADU(message)
+						// Convert the modbus response into a PLC4X response
+						response, err := m.ToPlc4xWriteResponse(requestAdu, *responseAdu, writeRequest)
+						if err != nil {
+							return transaction.FailRequest(errors.Wrap(err, "Error decoding response"))
+						}
+						writeResponse = response
+						return transaction.EndRequest()
+					},
+					func(err error) error {
+						return transaction.FailRequest(errors.Wrap(err, "got timeout while waiting for response"))
+					},
+					timeout); err != nil {
+					_ = transaction.FailRequest(errors.Wrap(err, "error sending message"))
 				}
-				return nil
-			},
-			func(err error) error {
-				result <- model.PlcWriteRequestResult{
+			})
+			if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+				return model.PlcWriteRequestResult{
 					Request: writeRequest,
-					Err:     errors.New("got timeout while waiting for response"),
+					Err:     err,
 				}
-				return nil
-			},
-			time.Second*1)
+			}
+			return model.PlcWriteRequestResult{
+				Request:  writeRequest,
+				Response: writeResponse,
+			}
+		})
 	}()
 	return result
 }
 
-func (m Writer) ToPlc4xWriteResponse(requestAdu readWriteModel.ModbusTcpADU, responseAdu readWriteModel.ModbusTcpADU, writeRequest model.PlcWriteRequest) (model.PlcWriteResponse, error) {
+func (m *Writer) ToPlc4xWriteResponse(requestAdu readWriteModel.ModbusTcpADU, responseAdu readWriteModel.ModbusTcpADU, writeRequest model.PlcWriteRequest) (model.PlcWriteResponse, error) {
 	responseCodes := map[string]model.PlcResponseCode{}
 	fieldName := writeRequest.GetFieldNames()[0]
 
@@ -211,13 +227,13 @@ func (m Writer) ToPlc4xWriteResponse(requestAdu readWriteModel.ModbusTcpADU, res
 		case readWriteModel.ModbusErrorCode_GATEWAY_TARGET_DEVICE_FAILED_TO_RESPOND:
 			responseCodes[fieldName] = model.PlcResponseCode_REMOTE_ERROR
 		default:
-			log.Debug().Msgf("Unmapped exception code %x", resp.ExceptionCode)
+			m.logger.Debug().Msgf("Unmapped exception code %x", resp.ExceptionCode)
 		}
 	default:
 		return nil, errors.Errorf("unsupported response type %T", responseAdu.Pdu.Child)
 	}
 
 	// Return the response
-	log.Trace().Msg("Returning the response")
+	m.logger.Trace().Msg("Returning the response")
 	return plc4goModel.NewDefaultPlcWriteResponse(writeRequest, responseCodes), nil
 }
@@ -0,0 +1,126 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package s7
+
+import (
+	"fmt"
+	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/s7/readwrite/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/interceptors"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Bytes of a read-var request, which don't belong to any item (S7 header and read-var parameter)
+	readVarRequestOverhead = 10 + 2
+	// Bytes of a read-var response, which don't belong to any item (S7 header including the error and read-var parameter)
+	readVarResponseOverhead = 12 + 2
+	// Bytes of the address of an item in a read-var request
+	readVarRequestItemSize = 12
+	// Bytes of the header of an item in a read-var response (return code, transport size and length)
+	readVarResponseItemOverhead = 4
+)
+
+// BlockReadSupport allows reading consecutive bytes of a memory area with a single item, as long as they fit into
+// the negotiated PDU. It also makes sure the items of a request and of its response fit into the PDU.
+type BlockReadSupport struct {
+	pduSize uint16
+}
+
+func NewBlockReadSupport(pduSize uint16) BlockReadSupport {
+	return BlockReadSupport{
+		pduSize: pduSize,
+	}
+}
+
+func (m BlockReadSupport) GetFieldBlock(field apiModel.PlcField) (interceptors.FieldBlock, bool) {
+	s7Field, ok := field.(PlcField)
+	// Strings have their own encoding and bits can't be sliced from bytes
+	if !ok || s7Field.FieldType != FIELD || s7Field.Datatype == readWriteModel.TransportSize_BOOL {
+		return interceptors.FieldBlock{}, false
+	}
+	size := uint32(s7Field.Datatype.SizeInBytes()) * uint32(s7Field.NumElements)
+	if size == 0 {
+		return interceptors.FieldBlock{}, false
+	}
+	return interceptors.FieldBlock{
+		Area:  fmt.Sprintf("%s:%d", s7Field.MemoryArea, s7Field.BlockNumber),
+		Start: uint32(s7Field.ByteOffset),
+		Size:  size,
+	}, true
+}
+
+func (m BlockReadSupport) GetMaxBlockSize(_ string) uint32 {
+	if m.pduSize <= readVarResponseOverhead+readVarResponseItemOverhead {
+		return 0
+	}
+	return uint32(m.pduSize) - readVarResponseOverhead - readVarResponseItemOverhead
+}
+
+func (m BlockReadSupport) GetMaxRequestSize() uint32 {
+	return uint32(m.pduSize)
+}
+
+func (m BlockReadSupport) GetRequestOverhead() (uint32, uint32) {
+	return readVarRequestOverhead, readVarResponseOverhead
+}
+
+func (m BlockReadSupport) GetItemSize(field apiModel.PlcField) (uint32, uint32) {
+	address, err := encodeS7Address(field)
+	if err != nil {
+		// The reader reports the field as invalid, without reading it
+		return readVarRequestItemSize, readVarResponseItemOverhead
+	}
+	addressAny, ok := address.Child.(*readWriteModel.S7AddressAny)
+	if !ok {
+		return readVarRequestItemSize, readVarResponseItemOverhead
+	}
+	dataSize := uint32(addressAny.NumberOfElements) * uint32(addressAny.TransportSize.SizeInBytes())
+	// Items are padded to an even number of bytes, unless they are the last one
+	if dataSize%2 != 0 {
+		dataSize++
+	}
+	return readVarRequestItemSize, readVarResponseItemOverhead + dataSize
+}
+
+func (m BlockReadSupport) NewBlockField(field apiModel.PlcField, block interceptors.FieldBlock) apiModel.PlcField {
+	s7Field := field.(PlcField)
+	return PlcField{
+		FieldType:   BLOCK_FIELD,
+		MemoryArea:  s7Field.MemoryArea,
+		BlockNumber: s7Field.BlockNumber,
+		ByteOffset:  uint16(block.Start),
+		NumElements: uint16(block.Size),
+		Datatype:    readWriteModel.TransportSize_BYTE,
+	}
+}
+
+func (m BlockReadSupport) DecodeFieldValue(field apiModel.PlcField, data []byte) (values.PlcValue, error) {
+	s7Field, ok := field.(PlcField)
+	if !ok {
+		return nil, errors.Errorf("couldn't cast %T to PlcField", field)
+	}
+	value, err := readWriteModel.DataItemParse(utils.NewReadBuffer(data), s7Field.Datatype.DataProtocolId(), int32(s7Field.GetQuantity()))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing data item")
+	}
+	return value, nil
+}
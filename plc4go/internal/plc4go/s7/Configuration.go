@@ -20,11 +20,25 @@
 package s7
 
 import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"strconv"
+	"time"
 )
 
+var optionSchema = options.OptionSchema{
+	{Name: "local-rack", Type: options.OptionTypeInteger, Default: "1", Description: "Rack of this client"},
+	{Name: "local-slot", Type: options.OptionTypeInteger, Default: "1", Description: "Slot of this client"},
+	{Name: "remote-rack", Type: options.OptionTypeInteger, Default: "0", Description: "Rack of the PLC"},
+	{Name: "remote-slot", Type: options.OptionTypeInteger, Default: "0", Description: "Slot of the PLC"},
+	{Name: "pdu-size", Type: options.OptionTypeInteger, Default: "1024", Description: "Maximum PDU size proposed to the PLC"},
+	{Name: "max-amq-caller", Type: options.OptionTypeInteger, Default: "8", Description: "Maximum number of unconfirmed requests sent by this client"},
+	{Name: "max-amq-callee", Type: options.OptionTypeInteger, Default: "8", Description: "Maximum number of unconfirmed requests accepted from the PLC"},
+	{Name: "controller-type", Type: options.OptionTypeString, AllowedValues: []string{"ANY", "S7_300", "S7_400", "S7_1200", "S7_1500", "LOGO"}, Description: "Type of the PLC (detected automatically, if not provided)"},
+}.Merge(spi.RequestQueueOptionSchema)
+
 type Configuration struct {
 	localRack      int32
 	localSlot      int32
@@ -34,6 +48,7 @@ type Configuration struct {
 	maxAmqCaller   uint16
 	maxAmqCallee   uint16
 	controllerType ControllerType
+	requestQueue   spi.RequestQueueConfiguration
 }
 
 func ParseFromOptions(options map[string][]string) (Configuration, error) {
@@ -54,7 +69,7 @@ func ParseFromOptions(options map[string][]string) (Configuration, error) {
 		}
 		configuration.localRack = int32(atoi)
 	}
-	if localSlotString := getFromOptions(options, "local-rack"); localSlotString != "" {
+	if localSlotString := getFromOptions(options, "local-slot"); localSlotString != "" {
 		atoi, err := strconv.Atoi(localSlotString)
 		if err != nil {
 			return Configuration{}, errors.Wrap(err, "Error parsing local-slot")
@@ -68,7 +83,7 @@ func ParseFromOptions(options map[string][]string) (Configuration, error) {
 		}
 		configuration.remoteRack = int32(atoi)
 	}
-	if remoteSlotString := getFromOptions(options, "remote-rack"); remoteSlotString != "" {
+	if remoteSlotString := getFromOptions(options, "remote-slot"); remoteSlotString != "" {
 		atoi, err := strconv.Atoi(remoteSlotString)
 		if err != nil {
 			return Configuration{}, errors.Wrap(err, "Error parsing remote-slot")
@@ -118,6 +133,11 @@ func ParseFromOptions(options map[string][]string) (Configuration, error) {
 		}
 		configuration.maxAmqCallee = uint16(atoi)
 	}
+	requestQueue, err := spi.ParseRequestQueueConfiguration(options, time.Second*1)
+	if err != nil {
+		return Configuration{}, err
+	}
+	configuration.requestQueue = requestQueue
 	return configuration, nil
 }
 
@@ -19,9 +19,11 @@
 package s7
 
 import (
+	"context"
 	"fmt"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/s7/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/interceptors"
 	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
@@ -29,7 +31,7 @@ import (
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"reflect"
 	"strings"
 	"sync"
@@ -86,10 +88,11 @@ type Connection struct {
 	valueHandler  spi.PlcValueHandler
 	defaultTtl    time.Duration
 	tm            *spi.RequestTransactionManager
+	logger        zerolog.Logger
 }
 
-func NewConnection(messageCodec spi.MessageCodec, configuration Configuration, driverContext DriverContext, fieldHandler spi.PlcFieldHandler, tm *spi.RequestTransactionManager) Connection {
-	return Connection{
+func NewConnection(messageCodec spi.MessageCodec, configuration Configuration, driverContext DriverContext, fieldHandler spi.PlcFieldHandler, tm *spi.RequestTransactionManager) *Connection {
+	return &Connection{
 		tpduGenerator: TpduGenerator{currentTpduId: 10},
 		messageCodec:  messageCodec,
 		configuration: configuration,
@@ -98,32 +101,39 @@ func NewConnection(messageCodec spi.MessageCodec, configuration Configuration, d
 		valueHandler:  NewValueHandler(),
 		defaultTtl:    time.Second * 10,
 		tm:            tm,
+		logger:        *tm.GetLogger(),
 	}
 }
 
 func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
-	log.Trace().Msg("Connecting")
-	ch := make(chan plc4go.PlcConnectionConnectResult)
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *Connection) ConnectWithContext(ctx context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	m.logger.Trace().Msg("Connecting")
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
 	go func() {
-		err := m.messageCodec.Connect()
+		err := m.messageCodec.ConnectWithContext(ctx)
 		if err != nil {
 			ch <- plc4go.NewPlcConnectionConnectResult(m, err)
+			return
 		}
 
 		// Only on active connections we do a connection
 		if m.driverContext.PassiveMode {
-			log.Info().Msg("S7 Driver running in PASSIVE mode.")
+			m.logger.Info().Msg("S7 Driver running in PASSIVE mode.")
 			ch <- plc4go.NewPlcConnectionConnectResult(m, nil)
 			return
 		}
 		// Only the TCP transport supports login.
-		log.Info().Msg("S7 Driver running in ACTIVE mode.")
-		log.Debug().Msg("Sending COTP Connection Request")
+		m.logger.Info().Msg("S7 Driver running in ACTIVE mode.")
+		m.logger.Debug().Msg("Sending COTP Connection Request")
 		// Open the session on ISO Transport Protocol first.
 
 		result := make(chan *readWriteModel.COTPPacketConnectionResponse)
 		errorResult := make(chan error)
-		err = m.messageCodec.SendRequest(
+		err = m.messageCodec.SendRequestWithContext(
+			ctx,
 			readWriteModel.NewTPKTPacket(m.createCOTPConnectionRequest()),
 			func(message interface{}) bool {
 				tpktPacket := readWriteModel.CastTPKTPacket(message)
@@ -142,7 +152,7 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 			func(err error) error {
 				// If this is a timeout, do a check if the connection requires a reconnection
 				if _, isTimeout := err.(plcerrors.TimeoutError); isTimeout {
-					log.Warn().Msg("Timeout during Connection establishing, closing channel...")
+					m.logger.Warn().Msg("Timeout during Connection establishing, closing channel...")
 					m.Close()
 				}
 				errorResult <- errors.Wrap(err, "got error processing request")
@@ -152,16 +162,18 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 		)
 		if err != nil {
 			ch <- plc4go.NewPlcConnectionConnectResult(m, err)
+			return
 		}
 		select {
 		case cotpPacketConnectionResponse := <-result:
-			log.Debug().Msg("Got COTP Connection Response")
-			log.Debug().Msg("Sending S7 Connection Request")
+			m.logger.Debug().Msg("Got COTP Connection Response")
+			m.logger.Debug().Msg("Sending S7 Connection Request")
 
 			// Send an S7 login message.
 			result2 := make(chan *readWriteModel.S7ParameterSetupCommunication)
 			errorResult2 := make(chan error)
-			err = m.messageCodec.SendRequest(
+			err = m.messageCodec.SendRequestWithContext(
+				ctx,
 				m.createS7ConnectionRequest(cotpPacketConnectionResponse),
 				func(message interface{}) bool {
 					tpktPacket := readWriteModel.CastTPKTPacket(message)
@@ -189,7 +201,7 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 				func(err error) error {
 					// If this is a timeout, do a check if the connection requires a reconnection
 					if _, isTimeout := err.(plcerrors.TimeoutError); isTimeout {
-						log.Warn().Msg("Timeout during Connection establishing, closing channel...")
+						m.logger.Warn().Msg("Timeout during Connection establishing, closing channel...")
 						m.Close()
 					}
 					errorResult2 <- errors.Wrap(err, "got error processing request")
@@ -199,10 +211,11 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 			)
 			if err != nil {
 				ch <- plc4go.NewPlcConnectionConnectResult(m, err)
+				return
 			}
 			select {
 			case setupCommunication := <-result2:
-				log.Debug().Msg("Got S7 Connection Response")
+				m.logger.Debug().Msg("Got S7 Connection Response")
 				// Save some data from the response.
 				m.driverContext.MaxAmqCaller = setupCommunication.MaxAmqCaller
 				m.driverContext.MaxAmqCallee = setupCommunication.MaxAmqCallee
@@ -211,7 +224,8 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 				// Update the number of concurrent requests to the negotiated number.
 				// I have never seen anything else than equal values for caller and
 				// callee, but if they were different, we're only limiting the outgoing
-				// requests.
+				// requests. (This relies on all requests of this connection sharing
+				// one TPDU generator, as responses are correlated by the TPDU reference)
 				m.tm.SetNumberOfConcurrentRequests(int(m.driverContext.MaxAmqCallee))
 
 				// If the controller type is explicitly set, were finished with the login
@@ -224,10 +238,11 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 				}
 
 				// Prepare a message to request the remote to identify itself.
-				log.Debug().Msg("Sending S7 Identification Request")
+				m.logger.Debug().Msg("Sending S7 Identification Request")
 				result3 := make(chan *readWriteModel.S7PayloadUserData)
 				errorResult3 := make(chan error)
-				err = m.messageCodec.SendRequest(
+				err = m.messageCodec.SendRequestWithContext(
+					ctx,
 					m.createIdentifyRemoteMessage(),
 					func(message interface{}) bool {
 						tpktPacket := readWriteModel.CastTPKTPacket(message)
@@ -254,7 +269,7 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 					func(err error) error {
 						// If this is a timeout, do a check if the connection requires a reconnection
 						if _, isTimeout := err.(plcerrors.TimeoutError); isTimeout {
-							log.Warn().Msg("Timeout during Connection establishing, closing channel...")
+							m.logger.Warn().Msg("Timeout during Connection establishing, closing channel...")
 							m.Close()
 						}
 						errorResult3 <- errors.Wrap(err, "got error processing request")
@@ -264,10 +279,11 @@ func (m *Connection) Connect() <-chan plc4go.PlcConnectionConnectResult {
 				)
 				if err != nil {
 					ch <- plc4go.NewPlcConnectionConnectResult(m, err)
+					return
 				}
 				select {
 				case payloadUserData := <-result3:
-					log.Debug().Msg("Got S7 Identification Response")
+					m.logger.Debug().Msg("Got S7 Identification Response")
 					m.extractControllerTypeAndFireConnected(payloadUserData, ch)
 				case err := <-errorResult3:
 					ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(err, "Error during connection"))
@@ -309,7 +325,7 @@ func (m *Connection) extractControllerTypeAndFireConnected(payloadUserData *read
 				case "4":
 					controllerType = ControllerType_S7_400
 				default:
-					log.Info().Msgf("Looking up unknown article number %s", articleNumber)
+					m.logger.Info().Msgf("Looking up unknown article number %s", articleNumber)
 					controllerType = ControllerType_ANY
 				}
 				m.driverContext.ControllerType = controllerType
@@ -321,7 +337,7 @@ func (m *Connection) extractControllerTypeAndFireConnected(payloadUserData *read
 	}
 }
 
-func (m Connection) createIdentifyRemoteMessage() *readWriteModel.TPKTPacket {
+func (m *Connection) createIdentifyRemoteMessage() *readWriteModel.TPKTPacket {
 	identifyRemoteMessage := readWriteModel.NewS7MessageUserData(
 		1,
 		readWriteModel.NewS7ParameterUserData(
@@ -356,7 +372,7 @@ func (m Connection) createIdentifyRemoteMessage() *readWriteModel.TPKTPacket {
 	return readWriteModel.NewTPKTPacket(cotpPacketData)
 }
 
-func (m Connection) createS7ConnectionRequest(cotpPacketConnectionResponse *readWriteModel.COTPPacketConnectionResponse) *readWriteModel.TPKTPacket {
+func (m *Connection) createS7ConnectionRequest(cotpPacketConnectionResponse *readWriteModel.COTPPacketConnectionResponse) *readWriteModel.TPKTPacket {
 	for _, parameter := range cotpPacketConnectionResponse.Parent.Parameters {
 		switch parameter.Child.(type) {
 		case *readWriteModel.COTPParameterCalledTsap:
@@ -366,13 +382,13 @@ func (m Connection) createS7ConnectionRequest(cotpPacketConnectionResponse *read
 			cotpParameterCallingTsap := parameter.Child.(*readWriteModel.COTPParameterCallingTsap)
 			if cotpParameterCallingTsap.TsapId != m.driverContext.CallingTsapId {
 				m.driverContext.CallingTsapId = cotpParameterCallingTsap.TsapId
-				log.Warn().Msgf("Switching calling TSAP id to '%x'", m.driverContext.CallingTsapId)
+				m.logger.Warn().Msgf("Switching calling TSAP id to '%x'", m.driverContext.CallingTsapId)
 			}
 		case *readWriteModel.COTPParameterTpduSize:
 			cotpParameterTpduSize := parameter.Child.(*readWriteModel.COTPParameterTpduSize)
 			m.driverContext.CotpTpduSize = cotpParameterTpduSize.TpduSize
 		default:
-			log.Warn().Msgf("Got unknown parameter type '%v'", reflect.TypeOf(parameter))
+			m.logger.Warn().Msgf("Got unknown parameter type '%v'", reflect.TypeOf(parameter))
 		}
 	}
 
@@ -384,7 +400,7 @@ func (m Connection) createS7ConnectionRequest(cotpPacketConnectionResponse *read
 	return readWriteModel.NewTPKTPacket(cotpPacketData)
 }
 
-func (m Connection) createCOTPConnectionRequest() *readWriteModel.COTPPacket {
+func (m *Connection) createCOTPConnectionRequest() *readWriteModel.COTPPacket {
 	return readWriteModel.NewCOTPPacketConnectionRequest(
 		0x0000,
 		0x000F,
@@ -398,8 +414,8 @@ func (m Connection) createCOTPConnectionRequest() *readWriteModel.COTPPacket {
 	)
 }
 
-func (m Connection) BlockingClose() {
-	log.Trace().Msg("Closing blocked")
+func (m *Connection) BlockingClose() {
+	m.logger.Trace().Msg("Closing blocked")
 	closeResults := m.Close()
 	select {
 	case <-closeResults:
@@ -410,63 +426,96 @@ func (m Connection) BlockingClose() {
 }
 
 func (m *Connection) Close() <-chan plc4go.PlcConnectionCloseResult {
-	log.Trace().Msg("Close")
-	// TODO: Implement ...
-	ch := make(chan plc4go.PlcConnectionCloseResult)
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *Connection) CloseWithContext(_ context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	m.logger.Trace().Msg("Close")
+	// TODO: Implement a graceful disconnect on protocol level ...
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
 	go func() {
-		ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+		// Fail everything still waiting for its turn
+		m.tm.Close()
+		err := m.messageCodec.Disconnect()
+		if err != nil {
+			err = errors.Wrap(err, "error disconnecting")
+		}
+		ch <- plc4go.NewPlcConnectionCloseResult(m, err)
 	}()
 	return ch
 }
 
-func (m Connection) IsConnected() bool {
-	panic("implement me")
+func (m *Connection) GetRequestQueueMetrics() apiModel.PlcRequestQueueMetrics {
+	return m.tm.GetMetrics()
 }
 
-func (m Connection) Ping() <-chan plc4go.PlcConnectionPingResult {
-	panic("Not implemented")
+func (m *Connection) GetConnectionMetrics() apiModel.PlcConnectionMetrics {
+	return spi.GetConnectionMetrics(m.messageCodec).GetMetrics()
 }
 
-func (m Connection) GetMetadata() apiModel.PlcConnectionMetadata {
+func (m *Connection) IsConnected() bool {
+	return m.messageCodec.IsRunning()
+}
+
+func (m *Connection) Ping() <-chan plc4go.PlcConnectionPingResult {
+	return m.PingWithContext(context.Background())
+}
+
+func (m *Connection) PingWithContext(_ context.Context) <-chan plc4go.PlcConnectionPingResult {
+	result := make(chan plc4go.PlcConnectionPingResult, 1)
+	result <- plc4go.NewPlcConnectionPingResult(plc4go.ErrPingNotSupported)
+	return result
+}
+
+func (m *Connection) GetMetadata() apiModel.PlcConnectionMetadata {
 	return ConnectionMetadata{}
 }
 
-func (m Connection) ReadRequestBuilder() apiModel.PlcReadRequestBuilder {
-	return internalModel.NewDefaultPlcReadRequestBuilder(m.fieldHandler, NewReader(&m.tpduGenerator, m.messageCodec, m.tm))
+func (m *Connection) ReadRequestBuilder() apiModel.PlcReadRequestBuilder {
+	// Fields next to each other are read as one item, as far as the negotiated PDU size allows
+	readRequestInterceptor := interceptors.NewReadOptimizingRequestInterceptor(NewBlockReadSupport(m.driverContext.PduSize), 0, m.logger)
+	reader := NewReader(&m.tpduGenerator, m.messageCodec, m.tm, m.configuration.requestQueue)
+	return internalModel.NewDefaultPlcReadRequestBuilderWithInterceptor(m.fieldHandler,
+		spi.NewMeteredReader(reader, spi.GetConnectionMetrics(m.messageCodec)), readRequestInterceptor)
 }
 
-func (m Connection) WriteRequestBuilder() apiModel.PlcWriteRequestBuilder {
+func (m *Connection) WriteRequestBuilder() apiModel.PlcWriteRequestBuilder {
+	writer := NewWriter(&m.tpduGenerator, m.messageCodec, m.tm, m.configuration.requestQueue)
 	return internalModel.NewDefaultPlcWriteRequestBuilder(
-		m.fieldHandler, m.valueHandler, NewWriter(&m.tpduGenerator, m.messageCodec, m.tm))
+		m.fieldHandler, m.valueHandler, spi.NewMeteredWriter(writer, spi.GetConnectionMetrics(m.messageCodec)))
+}
+
+func (m *Connection) SubscriptionRequestBuilder() apiModel.PlcSubscriptionRequestBuilder {
+	return internalModel.NewFailingPlcSubscriptionRequestBuilder(internalModel.ErrNotSupported)
 }
 
-func (m Connection) SubscriptionRequestBuilder() apiModel.PlcSubscriptionRequestBuilder {
-	panic("implement me")
+func (m *Connection) UnsubscriptionRequestBuilder() apiModel.PlcUnsubscriptionRequestBuilder {
+	return internalModel.NewFailingPlcUnsubscriptionRequestBuilder(internalModel.ErrNotSupported)
 }
 
-func (m Connection) UnsubscriptionRequestBuilder() apiModel.PlcUnsubscriptionRequestBuilder {
-	panic("implement me")
+func (m *Connection) BrowseRequestBuilder() apiModel.PlcBrowseRequestBuilder {
+	return internalModel.NewFailingPlcBrowseRequestBuilder(internalModel.ErrNotSupported)
 }
 
-func (m Connection) BrowseRequestBuilder() apiModel.PlcBrowseRequestBuilder {
-	panic("implement me")
+func (m *Connection) GetMessageCodec() spi.MessageCodec {
+	return m.messageCodec
 }
 
-func (m Connection) GetTransportInstance() transports.TransportInstance {
+func (m *Connection) GetTransportInstance() transports.TransportInstance {
 	if mc, ok := m.messageCodec.(spi.TransportInstanceExposer); ok {
 		return mc.GetTransportInstance()
 	}
 	return nil
 }
 
-func (m Connection) GetPlcFieldHandler() spi.PlcFieldHandler {
+func (m *Connection) GetPlcFieldHandler() spi.PlcFieldHandler {
 	return m.fieldHandler
 }
 
-func (m Connection) GetPlcValueHandler() spi.PlcValueHandler {
+func (m *Connection) GetPlcValueHandler() spi.PlcValueHandler {
 	return m.valueHandler
 }
 
-func (m Connection) String() string {
+func (m *Connection) String() string {
 	return fmt.Sprintf("s7.Connection")
 }
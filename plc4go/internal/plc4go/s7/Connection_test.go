@@ -0,0 +1,94 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package s7
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	"github.com/pkg/errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCodec remembers the correlation keys of the requests sent and keeps them in flight till all expected requests
+// have been sent
+type fakeCodec struct {
+	spi.MessageCodec
+	inFlight        sync.WaitGroup
+	lock            sync.Mutex
+	correlationKeys []interface{}
+}
+
+func (m *fakeCodec) SendRequestWithCorrelationKey(_ context.Context, _ interface{}, correlationKey interface{}, _ spi.AcceptsMessage, _ spi.HandleMessage, _ spi.HandleError, _ time.Duration) error {
+	m.lock.Lock()
+	m.correlationKeys = append(m.correlationKeys, correlationKey)
+	m.lock.Unlock()
+	m.inFlight.Done()
+	m.inFlight.Wait()
+	return errors.New("not connected")
+}
+
+func TestConnection_ConcurrentRequestsUseDistinctTpduReferences(t *testing.T) {
+	configuration, err := ParseFromOptions(map[string][]string{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	driverContext, err := NewDriverContext(configuration)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	codec := &fakeCodec{}
+	codec.inFlight.Add(2)
+	connection := NewConnection(codec, configuration, driverContext, NewFieldHandler(), configuration.requestQueue.NewRequestTransactionManager(2))
+
+	var done sync.WaitGroup
+	for _, query := range []string{"%DB1.DBW0:INT", "%DB2.DBW0:INT"} {
+		builder := connection.ReadRequestBuilder()
+		builder.AddQuery("field", query)
+		readRequest, err := builder.Build()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			<-readRequest.Execute()
+		}()
+	}
+	done.Wait()
+
+	if len(codec.correlationKeys) != 2 || codec.correlationKeys[0] == codec.correlationKeys[1] {
+		t.Errorf("Expected two distinct TPDU references, got %v", codec.correlationKeys)
+	}
+}
+
+func TestConnection_UnsupportedRequests(t *testing.T) {
+	connection := NewConnection(&fakeCodec{}, Configuration{}, DriverContext{}, NewFieldHandler(), spi.NewRequestTransactionManager(1))
+	if _, err := connection.SubscriptionRequestBuilder().Build(); errors.Cause(err) != internalModel.ErrNotSupported {
+		t.Errorf("Expected subscribing to be reported as not supported, got %v", err)
+	}
+	if _, err := connection.UnsubscriptionRequestBuilder().Build(); errors.Cause(err) != internalModel.ErrNotSupported {
+		t.Errorf("Expected unsubscribing to be reported as not supported, got %v", err)
+	}
+	if _, err := connection.BrowseRequestBuilder().Build(); errors.Cause(err) != internalModel.ErrNotSupported {
+		t.Errorf("Expected browsing to be reported as not supported, got %v", err)
+	}
+}
@@ -19,24 +19,23 @@
 package s7
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"net/url"
 )
 
 type Driver struct {
 	fieldHandler spi.PlcFieldHandler
-	tm           spi.RequestTransactionManager
 }
 
 func NewDriver() plc4go.PlcDriver {
 	return &Driver{
 		fieldHandler: NewFieldHandler(),
-		tm:           spi.NewRequestTransactionManager(1),
 	}
 }
 
@@ -57,12 +56,21 @@ func (m *Driver) CheckQuery(query string) error {
 	return err
 }
 
+func (m *Driver) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
 func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
-	log.Debug().Stringer("transportUrl", &transportUrl).Msgf("Get connection for transport url with %d transport(s) and %d option(s)", len(transports), len(options))
+	return m.GetConnectionWithContext(context.Background(), transportUrl, transports, options)
+}
+
+func (m *Driver) GetConnectionWithContext(ctx context.Context, transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan plc4go.PlcConnectionConnectResult {
+	logger := spi.LoggerFromContext(ctx)
+	logger.Debug().Stringer("transportUrl", &transportUrl).Msgf("Get connection for transport url with %d transport(s) and %d option(s)", len(transports), len(options))
 	// Get an the transport specified in the url
 	transport, ok := transports[transportUrl.Scheme]
 	if !ok {
-		log.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't find a transport for scheme %s", transportUrl.Scheme)
+		logger.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't find a transport for scheme %s", transportUrl.Scheme)
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
 			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Errorf("couldn't find transport for given transport url %#v", transportUrl))
@@ -74,7 +82,7 @@ func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]trans
 	// Have the transport create a new transport-instance.
 	transportInstance, err := transport.CreateTransportInstance(transportUrl, options)
 	if err != nil {
-		log.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't create a transport instance for port %#v", options["defaultTcpPort"])
+		logger.Error().Stringer("transportUrl", &transportUrl).Msgf("We couldn't create a transport instance for port %#v", options["defaultTcpPort"])
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
 			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.New("couldn't initialize transport configuration for given transport url "+transportUrl.String()))
@@ -83,11 +91,12 @@ func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]trans
 	}
 
 	codec := NewMessageCodec(transportInstance)
-	log.Debug().Msgf("working with codec %#v", codec)
+	codec.SetLogger(logger)
+	logger.Debug().Msgf("working with codec %#v", codec)
 
 	configuration, err := ParseFromOptions(options)
 	if err != nil {
-		log.Error().Err(err).Msgf("Invalid options")
+		logger.Error().Err(err).Msgf("Invalid options")
 		ch := make(chan plc4go.PlcConnectionConnectResult)
 		go func() {
 			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(err, "Invalid options"))
@@ -97,10 +106,13 @@ func (m *Driver) GetConnection(transportUrl url.URL, transports map[string]trans
 
 	driverContext, err := NewDriverContext(configuration)
 
+	// Every connection gets its own request queue, it's opened up to the negotiated amq size after connecting.
+	tm := configuration.requestQueue.NewRequestTransactionManager(1, spi.WithLogger(logger))
+
 	// Create the new connection
-	connection := NewConnection(codec, configuration, driverContext, m.fieldHandler, &m.tm)
-	log.Info().Stringer("connection", connection).Msg("created connection, connecting now")
-	return connection.Connect()
+	connection := NewConnection(codec, configuration, driverContext, m.fieldHandler, tm)
+	logger.Info().Stringer("connection", connection).Msg("created connection, connecting now")
+	return connection.ConnectWithContext(ctx)
 }
 
 func (m *Driver) SupportsDiscovery() bool {
@@ -36,6 +36,7 @@ type FieldType uint8
 const (
 	FIELD        FieldType = 0x00
 	STRING_FIELD FieldType = 0x01
+	BLOCK_FIELD  FieldType = 0x02
 )
 
 func (i FieldType) GetName() string {
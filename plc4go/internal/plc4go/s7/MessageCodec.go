@@ -24,7 +24,6 @@ import (
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 )
 
 type MessageCodec struct {
@@ -38,11 +37,24 @@ func NewMessageCodec(transportInstance transports.TransportInstance) *MessageCod
 		expectationCounter: 1,
 	}
 	codec.DefaultCodecRequiredInterface = codec
+	codec.CorrelationKeyExtractor = getCorrelationKey
 	return codec
 }
 
+// Responses are correlated with their requests by the TPDU reference of the S7 message
+func getCorrelationKey(message interface{}) interface{} {
+	cotpPacket := model.CastCOTPPacket(message)
+	if tpktPacket := model.CastTPKTPacket(message); tpktPacket != nil {
+		cotpPacket = tpktPacket.Payload
+	}
+	if cotpPacket == nil || cotpPacket.Payload == nil {
+		return nil
+	}
+	return cotpPacket.Payload.TpduReference
+}
+
 func (m *MessageCodec) Send(message interface{}) error {
-	log.Trace().Msg("Sending message")
+	m.GetLogger().Trace().Msg("Sending message")
 	// Cast the message to the correct type of struct
 	tpktPacket := model.CastTPKTPacket(message)
 	// Serialize the request
@@ -53,7 +65,7 @@ func (m *MessageCodec) Send(message interface{}) error {
 	}
 
 	// Send it to the PLC
-	err = m.TransportInstance.Write(wb.GetBytes())
+	err = m.WriteBytes(wb.GetBytes())
 	if err != nil {
 		return errors.Wrap(err, "error sending request")
 	}
@@ -61,13 +73,13 @@ func (m *MessageCodec) Send(message interface{}) error {
 }
 
 func (m *MessageCodec) Receive() (interface{}, error) {
-	log.Trace().Msg("receiving")
+	m.GetLogger().Trace().Msg("receiving")
 	// We need at least 6 bytes in order to know how big the packet is in total
 	if num, err := m.TransportInstance.GetNumReadableBytes(); (err == nil) && (num >= 6) {
-		log.Debug().Msgf("we got %d readable bytes", num)
+		m.GetLogger().Debug().Msgf("we got %d readable bytes", num)
 		data, err := m.TransportInstance.PeekReadableBytes(6)
 		if err != nil {
-			log.Warn().Err(err).Msg("error peeking")
+			m.GetLogger().Warn().Err(err).Msg("error peeking")
 			// TODO: Possibly clean up ...
 			return nil, nil
 		}
@@ -75,10 +87,10 @@ func (m *MessageCodec) Receive() (interface{}, error) {
 		// TODO: wrong size for s7
 		packetSize := (uint32(data[4]) << 8) + uint32(data[5]) + 6
 		if num < packetSize {
-			log.Debug().Msgf("Not enough bytes. Got: %d Need: %d\n", num, packetSize)
+			m.GetLogger().Debug().Msgf("Not enough bytes. Got: %d Need: %d\n", num, packetSize)
 			return nil, nil
 		}
-		data, err = m.TransportInstance.Read(packetSize)
+		data, err = m.ReadBytes(packetSize)
 		if err != nil {
 			// TODO: Possibly clean up ...
 			return nil, nil
@@ -86,14 +98,14 @@ func (m *MessageCodec) Receive() (interface{}, error) {
 		rb := utils.NewReadBuffer(data)
 		tcpAdu, err := model.COTPPacketParse(rb, uint16(packetSize))
 		if err != nil {
-			log.Warn().Err(err).Msg("error parsing")
+			m.GetLogger().Warn().Err(err).Msg("error parsing")
 			// TODO: Possibly clean up ...
 			return nil, nil
 		}
 		return tcpAdu, nil
 	} else if err != nil {
-		log.Warn().Err(err).Msg("Got error reading")
-		return nil, nil
+		m.GetLogger().Warn().Err(err).Msg("Got error reading")
+		return nil, errors.Wrap(err, "error reading from transport")
 	}
 	// TODO: maybe we return here a not enough error error
 	return nil, nil
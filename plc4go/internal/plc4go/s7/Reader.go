@@ -19,6 +19,7 @@
 package s7
 
 import (
+	"context"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/s7/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	plc4goModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
@@ -27,27 +28,30 @@ import (
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
-	"time"
+	"github.com/rs/zerolog"
 )
 
 type Reader struct {
 	tpduGenerator *TpduGenerator
 	messageCodec  spi.MessageCodec
 	tm            *spi.RequestTransactionManager
+	requestQueue  spi.RequestQueueConfiguration
+	logger        zerolog.Logger
 }
 
-func NewReader(tpduGenerator *TpduGenerator, messageCodec spi.MessageCodec, tm *spi.RequestTransactionManager) *Reader {
+func NewReader(tpduGenerator *TpduGenerator, messageCodec spi.MessageCodec, tm *spi.RequestTransactionManager, requestQueue spi.RequestQueueConfiguration) *Reader {
 	return &Reader{
 		tpduGenerator: tpduGenerator,
 		messageCodec:  messageCodec,
 		tm:            tm,
+		requestQueue:  requestQueue,
+		logger:        *tm.GetLogger(),
 	}
 }
 
-func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
-	log.Trace().Msg("Reading")
-	result := make(chan model.PlcReadRequestResult)
+func (m *Reader) Read(ctx context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	m.logger.Trace().Msg("Reading")
+	result := make(chan model.PlcReadRequestResult, 1)
 	go func() {
 
 		requestItems := make([]*readWriteModel.S7VarRequestParameterItem, len(readRequest.GetFieldNames()))
@@ -73,82 +77,85 @@ func (m *Reader) Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequ
 			nil,
 		)
 
-		tpduId := m.tpduGenerator.getAndIncrement()
+		// Repeat the read, if it times out, as reading twice doesn't hurt
+		timeout := m.requestQueue.GetRequestTimeout(readRequest.GetTimeout())
+		retries := m.requestQueue.GetRetries(readRequest.GetRetries())
+		result <- plc4goModel.ReadWithRetries(ctx, m.logger, readRequest, retries, func() model.PlcReadRequestResult {
+			tpduId := m.tpduGenerator.getAndIncrement()
 
-		request := s7MessageRequest
-		// Create a new Request with correct tpuId (is not known before)
-		s7MessageRequest = readWriteModel.NewS7MessageRequest(tpduId, request.Parameter, request.Payload)
+			request := s7MessageRequest
+			// Create a new Request with correct tpuId (is not known before)
+			s7MessageRequest = readWriteModel.NewS7MessageRequest(tpduId, request.Parameter, request.Payload)
 
-		// Assemble the finished paket
-		log.Trace().Msg("Assemble paket")
-		// TODO: why do we use a uint16 above and the cotp a uint8?
-		tpktPacket := readWriteModel.NewTPKTPacket(
-			readWriteModel.NewCOTPPacketData(true,
-				uint8(tpduId),
-				nil,
-				s7MessageRequest,
-			),
-		)
-		// Start a new request-transaction (Is ended in the response-handler)
-		transaction := m.tm.StartRequest()
-		transaction.Submit(func() {
-
-			// Send the  over the wire
-			log.Trace().Msg("Send ")
-			if err := m.messageCodec.SendRequest(
-				tpktPacket,
-				func(message interface{}) bool {
-					tpktPacket := readWriteModel.CastTPKTPacket(message)
-					if tpktPacket == nil {
-						return false
-					}
-					cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
-					if cotpPacketData == nil {
-						return false
-					}
-					payload := cotpPacketData.Parent.Payload
-					if payload == nil {
-						return false
-					}
-					return payload.TpduReference == tpduId
-				},
-				func(message interface{}) error {
-					// Convert the response into an
-					log.Trace().Msg("convert response to ")
-					tpktPacket := readWriteModel.CastTPKTPacket(message)
-					cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
-					payload := cotpPacketData.Parent.Payload
-					// Convert the s7 response into a PLC4X response
-					log.Trace().Msg("convert response to PLC4X response")
-					readResponse, err := m.ToPlc4xReadResponse(*payload, readRequest)
+			// Assemble the finished paket
+			m.logger.Trace().Msg("Assemble paket")
+			// TODO: why do we use a uint16 above and the cotp a uint8?
+			tpktPacket := readWriteModel.NewTPKTPacket(
+				readWriteModel.NewCOTPPacketData(true,
+					uint8(tpduId),
+					nil,
+					s7MessageRequest,
+				),
+			)
+			// Start a new request-transaction (Is ended in the response-handler)
+			transaction := m.tm.StartRequest()
+			transaction.SetPriority(m.requestQueue.ReadPriority)
+			var readResponse model.PlcReadResponse
+			transaction.SubmitWithContext(ctx, func() {
+				// Send the  over the wire
+				transaction.GetLogger().Trace().Msg("Send ")
+				if err := m.messageCodec.SendRequestWithCorrelationKey(
+					transaction.GetContext(),
+					tpktPacket,
+					tpduId,
+					func(message interface{}) bool {
+						tpktPacket := readWriteModel.CastTPKTPacket(message)
+						if tpktPacket == nil {
+							return false
+						}
+						cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
+						if cotpPacketData == nil {
+							return false
+						}
+						payload := cotpPacketData.Parent.Payload
+						if payload == nil {
+							return false
+						}
+						return payload.TpduReference == tpduId
+					},
+					func(message interface{}) error {
+						// Convert the response into an
+						transaction.GetLogger().Trace().Msg("convert response to ")
+						tpktPacket := readWriteModel.CastTPKTPacket(message)
+						cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
+						payload := cotpPacketData.Parent.Payload
+						// Convert the s7 response into a PLC4X response
+						transaction.GetLogger().Trace().Msg("convert response to PLC4X response")
+						response, err := m.ToPlc4xReadResponse(*payload, readRequest)
 
-					if err != nil {
-						result <- model.PlcReadRequestResult{
-							Request: readRequest,
-							Err:     errors.Wrap(err, "Error decoding response"),
+						if err != nil {
+							return transaction.FailRequest(errors.Wrap(err, "Error decoding response"))
 						}
+						readResponse = response
 						return transaction.EndRequest()
-					}
-					result <- model.PlcReadRequestResult{
-						Request:  readRequest,
-						Response: readResponse,
-					}
-					return transaction.EndRequest()
-				},
-				func(err error) error {
-					result <- model.PlcReadRequestResult{
-						Request: readRequest,
-						Err:     errors.Wrap(err, "got timeout while waiting for response"),
-					}
-					return transaction.EndRequest()
-				},
-				time.Second*1); err != nil {
-				result <- model.PlcReadRequestResult{
-					Request:  readRequest,
-					Response: nil,
-					Err:      errors.Wrap(err, "error sending message"),
+					},
+					func(err error) error {
+						return transaction.FailRequest(errors.Wrap(err, "got timeout while waiting for response"))
+					},
+					timeout); err != nil {
+					_ = transaction.FailRequest(errors.Wrap(err, "error sending message"))
 				}
-				_ = transaction.EndRequest()
+			})
+			// Requests cancelled or timed out by the transaction manager never reach the handlers above
+			if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+				return model.PlcReadRequestResult{
+					Request: readRequest,
+					Err:     err,
+				}
+			}
+			return model.PlcReadRequestResult{
+				Request:  readRequest,
+				Response: readResponse,
 			}
 		})
 	}()
@@ -177,16 +184,16 @@ func (m *Reader) ToPlc4xReadResponse(response readWriteModel.S7Message, readRequ
 	if (errorClass != 0) || (errorCode != 0) {
 		// This is usually the case if PUT/GET wasn't enabled on the PLC
 		if (errorClass == 129) && (errorCode == 4) {
-			log.Warn().Msg("Got an error response from the PLC. This particular response code usually indicates " +
+			m.logger.Warn().Msg("Got an error response from the PLC. This particular response code usually indicates " +
 				"that PUT/GET is not enabled on the PLC.")
 			for _, fieldName := range readRequest.GetFieldNames() {
 				responseCodes[fieldName] = model.PlcResponseCode_ACCESS_DENIED
 				plcValues[fieldName] = spiValues.NewPlcNULL()
 			}
-			log.Trace().Msg("Returning the response")
+			m.logger.Trace().Msg("Returning the response")
 			return plc4goModel.NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues), nil
 		} else {
-			log.Warn().Msgf("Got an unknown error response from the PLC. Error Class: %d, Error Code %d. "+
+			m.logger.Warn().Msgf("Got an unknown error response from the PLC. Error Class: %d, Error Code %d. "+
 				"We probably need to implement explicit handling for this, so please file a bug-report "+
 				"on https://issues.apache.org/jira/projects/PLC4X and ideally attach a WireShark dump "+
 				"containing a capture of the communication.",
@@ -216,10 +223,13 @@ func (m *Reader) ToPlc4xReadResponse(response readWriteModel.S7Message, readRequ
 
 		responseCode := decodeResponseCode(payloadItem.ReturnCode)
 		// Decode the data according to the information from the request
-		log.Trace().Msg("decode data")
+		m.logger.Trace().Msg("decode data")
 		rb := utils.NewReadBuffer(utils.Int8ArrayToUint8Array(payloadItem.Data))
 		responseCodes[fieldName] = responseCode
-		if responseCode == model.PlcResponseCode_OK {
+		if responseCode == model.PlcResponseCode_OK && field.FieldType == BLOCK_FIELD {
+			// Blocks are sliced into the values of the fields they contain by the interceptor
+			plcValues[fieldName] = spiValues.NewPlcByteArray(rb.GetBytes())
+		} else if responseCode == model.PlcResponseCode_OK {
 			plcValue, err := readWriteModel.DataItemParse(rb, field.Datatype.DataProtocolId(), int32(field.GetQuantity()))
 			if err != nil {
 				return nil, errors.Wrap(err, "Error parsing data item")
@@ -229,7 +239,7 @@ func (m *Reader) ToPlc4xReadResponse(response readWriteModel.S7Message, readRequ
 	}
 
 	// Return the response
-	log.Trace().Msg("Returning the response")
+	m.logger.Trace().Msg("Returning the response")
 	return plc4goModel.NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues), nil
 }
 
@@ -19,6 +19,7 @@
 package s7
 
 import (
+	"context"
 	readWriteModel "github.com/apache/plc4x/plc4go/internal/plc4go/s7/readwrite/model"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	plc4goModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
@@ -26,26 +27,29 @@ import (
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
-	"time"
+	"github.com/rs/zerolog"
 )
 
 type Writer struct {
 	tpduGenerator *TpduGenerator
 	messageCodec  spi.MessageCodec
 	tm            *spi.RequestTransactionManager
+	requestQueue  spi.RequestQueueConfiguration
+	logger        zerolog.Logger
 }
 
-func NewWriter(tpduGenerator *TpduGenerator, messageCodec spi.MessageCodec, tm *spi.RequestTransactionManager) Writer {
+func NewWriter(tpduGenerator *TpduGenerator, messageCodec spi.MessageCodec, tm *spi.RequestTransactionManager, requestQueue spi.RequestQueueConfiguration) Writer {
 	return Writer{
 		tpduGenerator: tpduGenerator,
 		messageCodec:  messageCodec,
 		tm:            tm,
+		requestQueue:  requestQueue,
+		logger:        *tm.GetLogger(),
 	}
 }
 
-func (m Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
-	result := make(chan model.PlcWriteRequestResult)
+func (m Writer) Write(ctx context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
+	result := make(chan model.PlcWriteRequestResult, 1)
 	go func() {
 		parameterItems := make([]*readWriteModel.S7VarRequestParameterItem, len(writeRequest.GetFieldNames()))
 		payloadItems := make([]*readWriteModel.S7VarPayloadDataItem, len(writeRequest.GetFieldNames()))
@@ -73,85 +77,87 @@ func (m Writer) Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteR
 			}
 			payloadItems[i] = value
 		}
-		tpduId := m.tpduGenerator.getAndIncrement()
-
-		// Create a new Request with correct tpuId (is not known before)
-		s7MessageRequest := readWriteModel.NewS7MessageRequest(
-			tpduId,
-			readWriteModel.NewS7ParameterWriteVarRequest(parameterItems),
-			readWriteModel.NewS7PayloadWriteVarRequest(payloadItems),
-		)
-
-		// Assemble the finished paket
-		log.Trace().Msg("Assemble paket")
-		// TODO: why do we use a uint16 above and the cotp a uint8?
-		tpktPacket := readWriteModel.NewTPKTPacket(
-			readWriteModel.NewCOTPPacketData(
-				true,
-				uint8(tpduId),
-				nil,
-				s7MessageRequest,
-			),
-		)
-
-		// Start a new request-transaction (Is ended in the response-handler)
-		transaction := m.tm.StartRequest()
-		transaction.Submit(func() {
-			// Send the  over the wire
-			if err := m.messageCodec.SendRequest(
-				tpktPacket,
-				func(message interface{}) bool {
-					tpktPacket := readWriteModel.CastTPKTPacket(message)
-					if tpktPacket == nil {
-						return false
-					}
-					cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
-					if cotpPacketData == nil {
-						return false
-					}
-					payload := cotpPacketData.Parent.Payload
-					if payload == nil {
-						return false
-					}
-					return payload.TpduReference == tpduId
-				},
-				func(message interface{}) error {
-					// Convert the response into an
-					log.Trace().Msg("convert response to ")
-					tpktPacket := readWriteModel.CastTPKTPacket(message)
-					cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
-					payload := cotpPacketData.Parent.Payload
-					// Convert the s7 response into a PLC4X response
-					log.Trace().Msg("convert response to PLC4X response")
-					readResponse, err := m.ToPlc4xWriteResponse(*payload, writeRequest)
-
-					if err != nil {
-						result <- model.PlcWriteRequestResult{
-							Request: writeRequest,
-							Err:     errors.Wrap(err, "Error decoding response"),
+		timeout := m.requestQueue.GetRequestTimeout(writeRequest.GetTimeout())
+		result <- plc4goModel.WriteWithoutRetries(m.logger, writeRequest, func() model.PlcWriteRequestResult {
+			tpduId := m.tpduGenerator.getAndIncrement()
+
+			// Create a new Request with correct tpuId (is not known before)
+			s7MessageRequest := readWriteModel.NewS7MessageRequest(
+				tpduId,
+				readWriteModel.NewS7ParameterWriteVarRequest(parameterItems),
+				readWriteModel.NewS7PayloadWriteVarRequest(payloadItems),
+			)
+
+			// Assemble the finished paket
+			m.logger.Trace().Msg("Assemble paket")
+			// TODO: why do we use a uint16 above and the cotp a uint8?
+			tpktPacket := readWriteModel.NewTPKTPacket(
+				readWriteModel.NewCOTPPacketData(
+					true,
+					uint8(tpduId),
+					nil,
+					s7MessageRequest,
+				),
+			)
+
+			// Start a new request-transaction (Is ended in the response-handler)
+			transaction := m.tm.StartRequest()
+			transaction.SetPriority(m.requestQueue.WritePriority)
+			var writeResponse model.PlcWriteResponse
+			transaction.SubmitWithContext(ctx, func() {
+				// Send the  over the wire
+				if err := m.messageCodec.SendRequestWithCorrelationKey(
+					transaction.GetContext(),
+					tpktPacket,
+					tpduId,
+					func(message interface{}) bool {
+						tpktPacket := readWriteModel.CastTPKTPacket(message)
+						if tpktPacket == nil {
+							return false
 						}
+						cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
+						if cotpPacketData == nil {
+							return false
+						}
+						payload := cotpPacketData.Parent.Payload
+						if payload == nil {
+							return false
+						}
+						return payload.TpduReference == tpduId
+					},
+					func(message interface{}) error {
+						// Convert the response into an
+						transaction.GetLogger().Trace().Msg("convert response to ")
+						tpktPacket := readWriteModel.CastTPKTPacket(message)
+						cotpPacketData := readWriteModel.CastCOTPPacketData(tpktPacket.Payload)
+						payload := cotpPacketData.Parent.Payload
+						// Convert the s7 response into a PLC4X response
+						transaction.GetLogger().Trace().Msg("convert response to PLC4X response")
+						response, err := m.ToPlc4xWriteResponse(*payload, writeRequest)
+
+						if err != nil {
+							return transaction.FailRequest(errors.Wrap(err, "Error decoding response"))
+						}
+						writeResponse = response
 						return transaction.EndRequest()
-					}
-					result <- model.PlcWriteRequestResult{
-						Request:  writeRequest,
-						Response: readResponse,
-					}
-					return transaction.EndRequest()
-				},
-				func(err error) error {
-					result <- model.PlcWriteRequestResult{
-						Request: writeRequest,
-						Err:     errors.New("got timeout while waiting for response"),
-					}
-					return transaction.EndRequest()
-				},
-				time.Second*1); err != nil {
-				result <- model.PlcWriteRequestResult{
-					Request:  writeRequest,
-					Response: nil,
-					Err:      errors.Wrap(err, "error sending message"),
+					},
+					func(err error) error {
+						return transaction.FailRequest(errors.Wrap(err, "got timeout while waiting for response"))
+					},
+					timeout); err != nil {
+					_ = transaction.FailRequest(errors.Wrap(err, "error sending message"))
 				}
-				_ = transaction.EndRequest()
+			})
+			// Requests cancelled or timed out by the transaction manager never reach the handlers above
+			if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+				return model.PlcWriteRequestResult{
+					Request: writeRequest,
+					Err:     err,
+				}
+			}
+			return model.PlcWriteRequestResult{
+				Request:  writeRequest,
+				Response: writeResponse,
 			}
 		})
 	}()
@@ -179,15 +185,15 @@ func (m Writer) ToPlc4xWriteResponse(response readWriteModel.S7Message, writeReq
 	if (errorClass != 0) || (errorCode != 0) {
 		// This is usually the case if PUT/GET wasn't enabled on the PLC
 		if (errorClass == 129) && (errorCode == 4) {
-			log.Warn().Msg("Got an error response from the PLC. This particular response code usually indicates " +
+			m.logger.Warn().Msg("Got an error response from the PLC. This particular response code usually indicates " +
 				"that PUT/GET is not enabled on the PLC.")
 			for _, fieldName := range writeRequest.GetFieldNames() {
 				responseCodes[fieldName] = model.PlcResponseCode_ACCESS_DENIED
 			}
-			log.Trace().Msg("Returning the response")
+			m.logger.Trace().Msg("Returning the response")
 			return plc4goModel.NewDefaultPlcWriteResponse(writeRequest, responseCodes), nil
 		} else {
-			log.Warn().Msgf("Got an unknown error response from the PLC. Error Class: %d, Error Code %d. "+
+			m.logger.Warn().Msgf("Got an unknown error response from the PLC. Error Class: %d, Error Code %d. "+
 				"We probably need to implement explicit handling for this, so please file a bug-report "+
 				"on https://issues.apache.org/jira/projects/PLC4X and ideally attach a WireShark dump "+
 				"containing a capture of the communication.",
@@ -215,12 +221,12 @@ func (m Writer) ToPlc4xWriteResponse(response readWriteModel.S7Message, writeReq
 
 		responseCode := decodeResponseCode(payloadItem.ReturnCode)
 		// Decode the data according to the information from the request
-		log.Trace().Msg("decode data")
+		m.logger.Trace().Msg("decode data")
 		responseCodes[fieldName] = responseCode
 	}
 
 	// Return the response
-	log.Trace().Msg("Returning the response")
+	m.logger.Trace().Msg("Returning the response")
 	return plc4goModel.NewDefaultPlcWriteResponse(writeRequest, responseCodes), nil
 }
 
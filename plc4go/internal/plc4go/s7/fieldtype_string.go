@@ -27,11 +27,12 @@ func _() {
 	var x [1]struct{}
 	_ = x[FIELD-0]
 	_ = x[STRING_FIELD-1]
+	_ = x[BLOCK_FIELD-2]
 }
 
-const _FieldType_name = "FIELDSTRING_FIELD"
+const _FieldType_name = "FIELDSTRING_FIELDBLOCK_FIELD"
 
-var _FieldType_index = [...]uint8{0, 5, 17}
+var _FieldType_index = [...]uint8{0, 5, 17, 28}
 
 func (i FieldType) String() string {
 	if i >= FieldType(len(_FieldType_index)-1) {
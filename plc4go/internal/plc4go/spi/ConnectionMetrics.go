@@ -0,0 +1,204 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Operations the requests of a connection are counted by
+const (
+	OperationRead        = "read"
+	OperationWrite       = "write"
+	OperationSubscribe   = "subscribe"
+	OperationUnsubscribe = "unsubscribe"
+	OperationBrowse      = "browse"
+)
+
+// Upper bounds of the buckets request latencies are counted in
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 25,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+	time.Millisecond * 2500,
+	time.Second * 5,
+	time.Second * 10,
+}
+
+// ConnectionMetrics counts the requests and the traffic of a connection. All methods may be called on a nil
+// ConnectionMetrics, which simply doesn't count anything.
+type ConnectionMetrics struct {
+	// Accessed atomically, as they are counted for every read and write on the transport
+	// (first in the struct, so they are 64-bit aligned on 32-bit platforms too)
+	bytesSent       uint64
+	bytesReceived   uint64
+	droppedMessages uint64
+
+	lock      sync.Mutex
+	requests  map[string]*requestMetrics
+	exchanges requestMetrics
+}
+
+type requestMetrics struct {
+	requests     uint64
+	errors       uint64
+	timeouts     uint64
+	bucketCounts []uint64
+	latencySum   time.Duration
+}
+
+func NewConnectionMetrics() *ConnectionMetrics {
+	return &ConnectionMetrics{
+		requests: map[string]*requestMetrics{},
+	}
+}
+
+// ConnectionMetricsExposer is implemented by message codecs counting the traffic of their connection
+type ConnectionMetricsExposer interface {
+	GetConnectionMetrics() *ConnectionMetrics
+}
+
+// GetConnectionMetrics returns the metrics of the given message codec or nil, if it doesn't count any
+func GetConnectionMetrics(messageCodec MessageCodec) *ConnectionMetrics {
+	if exposer, ok := messageCodec.(ConnectionMetricsExposer); ok {
+		return exposer.GetConnectionMetrics()
+	}
+	return nil
+}
+
+// RecordRequest counts a finished request of the given operation. Requests finished with a timeout error are
+// counted as timeouts as well as errors.
+func (m *ConnectionMetrics) RecordRequest(operation string, latency time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	metrics, ok := m.requests[operation]
+	if !ok {
+		metrics = &requestMetrics{}
+		m.requests[operation] = metrics
+	}
+	metrics.record(latency, err)
+}
+
+// RecordExchange counts a finished request/response exchange of the message codec
+func (m *ConnectionMetrics) RecordExchange(latency time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.exchanges.record(latency, err)
+}
+
+func (m *ConnectionMetrics) RecordBytesSent(numBytes int) {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.bytesSent, uint64(numBytes))
+}
+
+func (m *ConnectionMetrics) RecordBytesReceived(numBytes int) {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.bytesReceived, uint64(numBytes))
+}
+
+// RecordDroppedMessage counts an unhandled message, which had to be dropped, as nobody consumed it in time
+func (m *ConnectionMetrics) RecordDroppedMessage() {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.droppedMessages, 1)
+}
+
+// GetMetrics returns a snapshot of everything counted so far
+func (m *ConnectionMetrics) GetMetrics() model.PlcConnectionMetrics {
+	if m == nil {
+		return model.PlcConnectionMetrics{Requests: map[string]model.PlcRequestMetrics{}}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	requests := map[string]model.PlcRequestMetrics{}
+	for operation, metrics := range m.requests {
+		requests[operation] = metrics.snapshot()
+	}
+	return model.PlcConnectionMetrics{
+		Requests:        requests,
+		Exchanges:       m.exchanges.snapshot(),
+		BytesSent:       atomic.LoadUint64(&m.bytesSent),
+		BytesReceived:   atomic.LoadUint64(&m.bytesReceived),
+		DroppedMessages: atomic.LoadUint64(&m.droppedMessages),
+	}
+}
+
+func (m *requestMetrics) record(latency time.Duration, err error) {
+	m.requests++
+	if err != nil {
+		m.errors++
+		if plcerrors.IsTimeoutError(err) {
+			m.timeouts++
+		}
+	}
+	if m.bucketCounts == nil {
+		m.bucketCounts = make([]uint64, len(DefaultLatencyBuckets))
+	}
+	// The counts are stored per bucket and only summed up for the snapshot
+	if bucket := sort.Search(len(DefaultLatencyBuckets), func(i int) bool {
+		return latency <= DefaultLatencyBuckets[i]
+	}); bucket < len(DefaultLatencyBuckets) {
+		m.bucketCounts[bucket]++
+	}
+	m.latencySum += latency
+}
+
+func (m *requestMetrics) snapshot() model.PlcRequestMetrics {
+	counts := make([]uint64, len(DefaultLatencyBuckets))
+	var cumulativeCount uint64
+	for i := range counts {
+		if m.bucketCounts != nil {
+			cumulativeCount += m.bucketCounts[i]
+		}
+		counts[i] = cumulativeCount
+	}
+	return model.PlcRequestMetrics{
+		Requests: m.requests,
+		Errors:   m.errors,
+		Timeouts: m.timeouts,
+		Latency: model.PlcLatencyHistogram{
+			Buckets: append([]time.Duration(nil), DefaultLatencyBuckets...),
+			Counts:  counts,
+			Count:   m.requests,
+			Sum:     m.latencySum,
+		},
+	}
+}
@@ -0,0 +1,108 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func TestConnectionMetrics_RecordRequest(t *testing.T) {
+	metrics := NewConnectionMetrics()
+	metrics.RecordRequest(OperationRead, time.Millisecond*3, nil)
+	metrics.RecordRequest(OperationRead, time.Millisecond*40, errors.New("failed"))
+	metrics.RecordRequest(OperationRead, time.Minute, plcerrors.NewTimeoutError(time.Minute))
+	metrics.RecordRequest(OperationWrite, time.Millisecond, nil)
+
+	read := metrics.GetMetrics().Requests[OperationRead]
+	if read.Requests != 3 || read.Errors != 2 || read.Timeouts != 1 {
+		t.Errorf("Expected 3 requests, 2 errors and 1 timeout, got %+v", read)
+	}
+	// The counts are cumulative and the minute is above the highest bucket
+	expectedCounts := map[time.Duration]uint64{
+		time.Millisecond:      0,
+		time.Millisecond * 5:  1,
+		time.Millisecond * 25: 1,
+		time.Millisecond * 50: 2,
+		time.Second * 10:      2,
+	}
+	for i, bucket := range read.Latency.Buckets {
+		if expected, ok := expectedCounts[bucket]; ok && read.Latency.Counts[i] != expected {
+			t.Errorf("Expected %d latencies up to %v, got %d", expected, bucket, read.Latency.Counts[i])
+		}
+	}
+	if read.Latency.Count != 3 || read.Latency.Sum != time.Minute+time.Millisecond*43 {
+		t.Errorf("Unexpected count %d or sum %v", read.Latency.Count, read.Latency.Sum)
+	}
+	if write := metrics.GetMetrics().Requests[OperationWrite]; write.Requests != 1 || write.Latency.Counts[0] != 1 {
+		t.Errorf("Expected the write to be counted in the first bucket, got %+v", write)
+	}
+}
+
+func TestConnectionMetrics_Nil(t *testing.T) {
+	var metrics *ConnectionMetrics
+	metrics.RecordRequest(OperationRead, time.Millisecond, nil)
+	metrics.RecordExchange(time.Millisecond, nil)
+	metrics.RecordBytesSent(1)
+	if snapshot := metrics.GetMetrics(); len(snapshot.Requests) != 0 || snapshot.BytesSent != 0 {
+		t.Errorf("Expected empty metrics, got %+v", snapshot)
+	}
+}
+
+type timingOutReader struct {
+}
+
+func (m timingOutReader) Read(_ context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	result := make(chan model.PlcReadRequestResult, 1)
+	result <- model.PlcReadRequestResult{
+		Request:  readRequest,
+		Response: timedOutResponse{},
+	}
+	return result
+}
+
+type timedOutResponse struct {
+	model.PlcReadResponse
+}
+
+func (m timedOutResponse) GetFieldNames() []string {
+	return []string{"field"}
+}
+
+func (m timedOutResponse) GetResponseCode(_ string) model.PlcResponseCode {
+	return model.PlcResponseCode_REQUEST_TIMEOUT
+}
+
+func TestMeteredReader_CountsTimedOutFields(t *testing.T) {
+	metrics := NewConnectionMetrics()
+	reader := NewMeteredReader(timingOutReader{}, metrics)
+	if readResult := <-reader.Read(context.Background(), nil); readResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", readResult.Err)
+	}
+	if read := metrics.GetMetrics().Requests[OperationRead]; read.Requests != 1 || read.Timeouts != 1 {
+		t.Errorf("Expected 1 timed out read, got %+v", read)
+	}
+	if _, ok := NewMeteredReader(timingOutReader{}, nil).(timingOutReader); !ok {
+		t.Errorf("Expected the reader not to be decorated without metrics")
+	}
+}
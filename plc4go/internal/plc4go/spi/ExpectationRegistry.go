@@ -0,0 +1,179 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"sync"
+	"time"
+)
+
+// ExpectationRegistry keeps track of the expectations of a codec. Expectations with a correlation key are found by
+// that key in constant time, the others have to be asked one after another, if they accept a message.
+// It is safe for concurrent use.
+type ExpectationRegistry struct {
+	// Expectations without correlation key (in the order they were added)
+	unkeyed []Expectation
+	// Expectations with correlation key (usually only one per key)
+	keyed map[interface{}][]Expectation
+	size  int
+	lock  sync.Mutex
+}
+
+func NewExpectationRegistry() *ExpectationRegistry {
+	return &ExpectationRegistry{
+		keyed: map[interface{}][]Expectation{},
+	}
+}
+
+func (m *ExpectationRegistry) Add(expectation Expectation) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if key := expectation.GetCorrelationKey(); key != nil {
+		m.keyed[key] = append(m.keyed[key], expectation)
+	} else {
+		m.unkeyed = append(m.unkeyed, expectation)
+	}
+	m.size++
+}
+
+// Remove the given expectation. Returns false, if it wasn't registered (anymore).
+func (m *ExpectationRegistry) Remove(expectation Expectation) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var removed bool
+	if key := expectation.GetCorrelationKey(); key != nil {
+		var remaining []Expectation
+		remaining, removed = removeExpectation(m.keyed[key], expectation)
+		m.setKeyed(key, remaining)
+	} else {
+		m.unkeyed, removed = removeExpectation(m.unkeyed, expectation)
+	}
+	if removed {
+		m.size--
+	}
+	return removed
+}
+
+// Len returns the number of registered expectations
+func (m *ExpectationRegistry) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.size
+}
+
+// MatchKeyed removes and returns all expectations with the given correlation key accepting the message
+func (m *ExpectationRegistry) MatchKeyed(key interface{}, message interface{}) []Expectation {
+	if key == nil {
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	remaining, accepting := m.partition(m.keyed[key], func(expectation Expectation) bool {
+		return expectation.GetAcceptsMessage()(message)
+	})
+	m.setKeyed(key, remaining)
+	return accepting
+}
+
+// MatchUnkeyed removes and returns all expectations without correlation key accepting the message
+func (m *ExpectationRegistry) MatchUnkeyed(message interface{}) []Expectation {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var accepting []Expectation
+	m.unkeyed, accepting = m.partition(m.unkeyed, func(expectation Expectation) bool {
+		return expectation.GetAcceptsMessage()(message)
+	})
+	return accepting
+}
+
+// RemoveDone removes and returns all expectations, which have been cancelled (their context is done) or which
+// have expired at the given point in time
+func (m *ExpectationRegistry) RemoveDone(now time.Time) (cancelled []Expectation, expired []Expectation) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	isDone := func(expectation Expectation) bool {
+		if expectation.GetContext().Err() != nil {
+			cancelled = append(cancelled, expectation)
+			return true
+		}
+		if now.After(expectation.GetExpiration()) {
+			expired = append(expired, expectation)
+			return true
+		}
+		return false
+	}
+	m.unkeyed, _ = m.partition(m.unkeyed, isDone)
+	for key, expectations := range m.keyed {
+		remaining, _ := m.partition(expectations, isDone)
+		m.setKeyed(key, remaining)
+	}
+	return cancelled, expired
+}
+
+// NextExpiration returns the point in time the next expectation expires
+func (m *ExpectationRegistry) NextExpiration() (time.Time, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var next time.Time
+	check := func(expectation Expectation) {
+		if next.IsZero() || expectation.GetExpiration().Before(next) {
+			next = expectation.GetExpiration()
+		}
+	}
+	for _, expectation := range m.unkeyed {
+		check(expectation)
+	}
+	for _, expectations := range m.keyed {
+		for _, expectation := range expectations {
+			check(expectation)
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// partition splits the expectations into those not matching and those matching (has to be called holding the lock)
+func (m *ExpectationRegistry) partition(expectations []Expectation, matches func(expectation Expectation) bool) (remaining []Expectation, matching []Expectation) {
+	for _, expectation := range expectations {
+		if matches(expectation) {
+			matching = append(matching, expectation)
+		} else {
+			remaining = append(remaining, expectation)
+		}
+	}
+	m.size -= len(matching)
+	return remaining, matching
+}
+
+// setKeyed updates the expectations for the given key (has to be called holding the lock)
+func (m *ExpectationRegistry) setKeyed(key interface{}, expectations []Expectation) {
+	if len(expectations) == 0 {
+		delete(m.keyed, key)
+	} else {
+		m.keyed[key] = expectations
+	}
+}
+
+func removeExpectation(expectations []Expectation, expectation Expectation) ([]Expectation, bool) {
+	for index, existingExpectation := range expectations {
+		if existingExpectation == expectation {
+			return append(expectations[:index:index], expectations[index+1:]...), true
+		}
+	}
+	return expectations, false
+}
@@ -0,0 +1,70 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// LoggingOptionSchema describes the options the driver manager accepts for every connection to configure its logging
+var LoggingOptionSchema = options.OptionSchema{
+	{Name: "log-level", Type: options.OptionTypeString, AllowedValues: []string{"trace", "debug", "info", "warn", "error", "disabled"}, Description: "Minimum level of the messages logged for the connection (defaults to the level of the logger of the driver manager)"},
+}
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of the context carrying the logger. Drivers use the logger of the context passed
+// to GetConnectionWithContext for the connection and everything belonging to it (codec, transport instance,
+// request transactions, ...).
+func ContextWithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext returns the logger carried by the context or the global logger, if it doesn't carry one
+func LoggerFromContext(ctx context.Context) zerolog.Logger {
+	return LoggerFromContextOr(ctx, log.Logger)
+}
+
+// LoggerFromContextOr returns the logger carried by the context or the given logger, if it doesn't carry one
+func LoggerFromContextOr(ctx context.Context, logger zerolog.Logger) zerolog.Logger {
+	if contextLogger, ok := ctx.Value(loggerContextKey{}).(zerolog.Logger); ok {
+		return contextLogger
+	}
+	return logger
+}
+
+// ApplyLoggingOptions returns the logger with the level given by the log-level option (if any)
+func ApplyLoggingOptions(logger zerolog.Logger, options map[string][]string) (zerolog.Logger, error) {
+	values := options["log-level"]
+	if len(values) == 0 {
+		return logger, nil
+	}
+	if values[0] == "disabled" {
+		return logger.Level(zerolog.Disabled), nil
+	}
+	level, err := zerolog.ParseLevel(values[0])
+	if err != nil {
+		return logger, errors.Wrapf(err, "error parsing log-level %s", values[0])
+	}
+	return logger.Level(level), nil
+}
@@ -19,19 +19,26 @@
 package spi
 
 import (
+	"context"
 	"fmt"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Expectation interface {
+	GetContext() context.Context
 	GetExpiration() time.Time
 	GetAcceptsMessage() AcceptsMessage
 	GetHandleMessage() HandleMessage
 	GetHandleError() HandleError
+	// Key the response is correlated with (nil, if only AcceptsMessage decides)
+	GetCorrelationKey() interface{}
 	fmt.Stringer
 }
 
@@ -44,11 +51,21 @@ type HandleMessage func(message interface{}) error
 // Function for handling the message, returns an error if anything goes wrong
 type HandleError func(err error) error
 
+// Function extracting the key a message is correlated with its request by (e.g. the Modbus transaction id).
+// Returns nil for messages without correlation key. Keys have to be comparable.
+type CorrelationKeyExtractor func(message interface{}) interface{}
+
 type DefaultExpectation struct {
+	Context        context.Context
 	Expiration     time.Time
 	AcceptsMessage AcceptsMessage
 	HandleMessage  HandleMessage
 	HandleError    HandleError
+	CorrelationKey interface{}
+}
+
+func (m *DefaultExpectation) GetContext() context.Context {
+	return m.Context
 }
 
 func (m *DefaultExpectation) GetExpiration() time.Time {
@@ -67,12 +84,21 @@ func (m *DefaultExpectation) GetHandleError() HandleError {
 	return m.HandleError
 }
 
+func (m *DefaultExpectation) GetCorrelationKey() interface{} {
+	return m.CorrelationKey
+}
+
 func (m *DefaultExpectation) String() string {
+	if m.CorrelationKey != nil {
+		return fmt.Sprintf("Expectation(key %v, expires at %v)", m.CorrelationKey, m.Expiration)
+	}
 	return fmt.Sprintf("Expectation(expires at %v)", m.Expiration)
 }
 
 type MessageCodec interface {
 	Connect() error
+	// Variant of Connect which aborts as soon as the given context is done
+	ConnectWithContext(ctx context.Context) error
 	Disconnect() error
 
 	// Sends a given message
@@ -80,37 +106,100 @@ type MessageCodec interface {
 	// Wait for a given timespan for a message to come in, which returns 'true' for 'acceptMessage'
 	// and is then forwarded to the 'handleMessage' function
 	Expect(acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error
+	// Variant of Expect which removes the expectation and calls 'handleError' with the contexts error
+	// as soon as the given context is done
+	ExpectWithContext(ctx context.Context, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error
 	// A combination that sends a message first and then waits for a response
 	SendRequest(message interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error
-
+	// Variant of SendRequest which doesn't send anything if the given context is already done and
+	// which removes the expectation as soon as the context is done
+	SendRequestWithContext(ctx context.Context, message interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error
+	// Variant of SendRequestWithContext, which looks up the expectation by the correlation key of incoming messages
+	// (see CorrelationKeyExtractor) instead of asking every expectation. 'acceptsMessage' is asked as well.
+	SendRequestWithCorrelationKey(ctx context.Context, message interface{}, correlationKey interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error
+
+	// Returns the channel messages nobody expects are passed on to. Messages are dropped (and counted as such), if
+	// its buffer is full, as nobody consumes them in time.
 	GetDefaultIncomingMessageChannel() chan interface{}
+
+	// Checks if the codec is currently processing incoming messages
+	IsRunning() bool
+}
+
+// Function notified as soon as a codec considers the underlying transport to be broken
+type TransportFailureListener func(err error)
+
+// TransportFailureNotifier is implemented by codecs, which are able to detect failures of the underlying transport
+type TransportFailureNotifier interface {
+	AddTransportFailureListener(listener TransportFailureListener)
 }
 
+// After this number of consecutive timed out expectations, the transport is considered broken
+const DefaultMaxTimeoutStreak = 3
+
+// Interval in which transport instances, which can't wait for data, are polled
+const pollInterval = time.Millisecond * 10
+
+// Number of unhandled messages buffered for the consumer of the default incoming message channel
+const defaultIncomingMessageBufferSize = 100
+
 // DefaultCodecRequiredInterface adds required methods to MessageCodec that are needed when using DefaultCodec
 type DefaultCodecRequiredInterface interface {
 	MessageCodec
 	TimeoutExpectations(now time.Time)
 	HandleMessages(message interface{}) bool
+	// Receive acts as framer: it returns the next complete message from the bytes readable on the transport or
+	// nil, if more bytes are needed. Errors returned are considered fatal for the transport.
 	Receive() (interface{}, error)
 }
 
-// DefaultCodec is a default codec implementation which has so sensitive defaults for message handling and a built-in worker
+// DefaultCodec is a default codec implementation which has so sensitive defaults for message handling and built-in
+// workers: A reader goroutine blocks on the transport, frames the incoming bytes into messages (see Receive) and
+// passes them on to the dispatcher goroutine, which matches them against the expectations.
 type DefaultCodec struct {
 	DefaultCodecRequiredInterface
-	TransportInstance             transports.TransportInstance
+	TransportInstance transports.TransportInstance
+	// Receives the messages which haven't been handled by an expectation or CustomMessageHandling. It's buffered
+	// (see defaultIncomingMessageBufferSize), but never blocks the dispatcher: As soon as the buffer is full, further
+	// messages are dropped, logged at warn level and counted as DroppedMessages in the connection metrics.
 	DefaultIncomingMessageChannel chan interface{}
-	Expectations                  []Expectation
-	Running                       bool
+	Expectations                  *ExpectationRegistry
 	CustomWorkLoop                func(codec *DefaultCodecRequiredInterface)
 	CustomMessageHandling         func(codec *DefaultCodecRequiredInterface, message interface{}) bool
+	// Extracts the correlation key of incoming messages (if not set, only AcceptsMessage decides)
+	CorrelationKeyExtractor CorrelationKeyExtractor
+	// Number of consecutive timed out expectations after which the transport is reported as broken (0 disables this)
+	MaxTimeoutStreak int
+
+	// Counts the bytes read and written by the codec and its request/response exchanges
+	metrics *ConnectionMetrics
+	logger  zerolog.Logger
+
+	running bool
+	// Closed as soon as the codec is disconnected
+	stopChan chan struct{}
+	// Messages framed by the reader goroutine for the dispatcher goroutine
+	incomingMessages chan interface{}
+	stateLock        sync.Mutex
+
+	// Wakes up the dispatcher, whenever the expectations changed
+	expectationsChanged chan struct{}
+
+	// Accessed atomically, as the handlers run in their own goroutines
+	timeoutStreak             int32
+	transportFailureListeners []TransportFailureListener
+	listenerLock              sync.Mutex
 }
 
 func NewDefaultCodec(transportInstance transports.TransportInstance) *DefaultCodec {
 	return &DefaultCodec{
 		TransportInstance:             transportInstance,
-		DefaultIncomingMessageChannel: make(chan interface{}),
-		Expectations:                  []Expectation{},
-		Running:                       false,
+		DefaultIncomingMessageChannel: make(chan interface{}, defaultIncomingMessageBufferSize),
+		Expectations:                  NewExpectationRegistry(),
+		MaxTimeoutStreak:              DefaultMaxTimeoutStreak,
+		metrics:                       NewConnectionMetrics(),
+		logger:                        log.Logger,
+		expectationsChanged:           make(chan struct{}, 1),
 	}
 }
 
@@ -118,151 +207,371 @@ func (m *DefaultCodec) GetTransportInstance() transports.TransportInstance {
 	return m.TransportInstance
 }
 
+// SetLogger replaces the global logger with the one of the connection. Has to be called before connecting.
+// The transport instance is passed the logger as well, if it supports it.
+func (m *DefaultCodec) SetLogger(logger zerolog.Logger) {
+	m.logger = logger
+	if loggingTransportInstance, ok := m.TransportInstance.(transports.LoggingTransportInstance); ok {
+		loggingTransportInstance.SetLogger(logger)
+	}
+}
+
+func (m *DefaultCodec) GetLogger() *zerolog.Logger {
+	return &m.logger
+}
+
+func (m *DefaultCodec) GetConnectionMetrics() *ConnectionMetrics {
+	return m.metrics
+}
+
+// WriteBytes writes the given bytes to the transport. Codecs should use this instead of writing to the transport
+// directly, so the bytes are counted in the metrics of the connection.
+func (m *DefaultCodec) WriteBytes(data []uint8) error {
+	if err := m.TransportInstance.Write(data); err != nil {
+		return err
+	}
+	m.metrics.RecordBytesSent(len(data))
+	return nil
+}
+
+// ReadBytes reads the given number of bytes from the transport. Codecs should use this instead of reading from the
+// transport directly, so the bytes are counted in the metrics of the connection.
+func (m *DefaultCodec) ReadBytes(numBytes uint32) ([]uint8, error) {
+	data, err := m.TransportInstance.Read(numBytes)
+	m.metrics.RecordBytesReceived(len(data))
+	return data, err
+}
+
 func (m *DefaultCodec) GetDefaultIncomingMessageChannel() chan interface{} {
 	return m.DefaultIncomingMessageChannel
 }
 
+func (m *DefaultCodec) IsRunning() bool {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+	return m.running
+}
+
+func (m *DefaultCodec) AddTransportFailureListener(listener TransportFailureListener) {
+	m.listenerLock.Lock()
+	defer m.listenerLock.Unlock()
+	m.transportFailureListeners = append(m.transportFailureListeners, listener)
+}
+
+func (m *DefaultCodec) notifyTransportFailure(err error) {
+	m.listenerLock.Lock()
+	listeners := make([]TransportFailureListener, len(m.transportFailureListeners))
+	copy(listeners, m.transportFailureListeners)
+	m.listenerLock.Unlock()
+	for _, listener := range listeners {
+		go listener(err)
+	}
+}
+
 func (m *DefaultCodec) Connect() error {
-	log.Info().Msg("Connecting")
-	err := m.TransportInstance.Connect()
-	if err == nil {
-		if !m.Running {
-			if m.CustomWorkLoop != nil {
-				go m.CustomWorkLoop(&m.DefaultCodecRequiredInterface)
-			} else {
-				go m.Work(&m.DefaultCodecRequiredInterface)
-			}
+	return m.ConnectWithContext(context.Background())
+}
 
+func (m *DefaultCodec) ConnectWithContext(ctx context.Context) error {
+	m.logger.Info().Msg("Connecting")
+	err := m.TransportInstance.ConnectWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+	if !m.running {
+		m.running = true
+		m.stopChan = make(chan struct{})
+		if m.CustomWorkLoop != nil {
+			go m.CustomWorkLoop(&m.DefaultCodecRequiredInterface)
+		} else {
+			m.incomingMessages = make(chan interface{})
+			go m.readLoop(m.stopChan, m.incomingMessages)
+			go m.Work(&m.DefaultCodecRequiredInterface)
 		}
-		m.Running = true
 	}
-	return err
+	return nil
 }
 
 func (m *DefaultCodec) Disconnect() error {
-	log.Info().Msg("Disconnecting")
-	m.Running = false
+	m.logger.Info().Msg("Disconnecting")
+	m.stop()
 	return m.TransportInstance.Close()
 }
 
+// stop signals the workers to stop. It returns false, if the codec was already stopped.
+func (m *DefaultCodec) stop() bool {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+	if !m.running {
+		return false
+	}
+	m.running = false
+	close(m.stopChan)
+	return true
+}
+
+func (m *DefaultCodec) getWorkerChannels() (stopChan chan struct{}, incomingMessages chan interface{}) {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+	return m.stopChan, m.incomingMessages
+}
+
 func (m *DefaultCodec) Expect(acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error {
-	expectation := &DefaultExpectation{
+	return m.ExpectWithContext(context.Background(), acceptsMessage, handleMessage, handleError, ttl)
+}
+
+func (m *DefaultCodec) ExpectWithContext(ctx context.Context, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error {
+	m.addExpectation(m.newExpectation(ctx, nil, acceptsMessage, handleMessage, handleError, ttl))
+	return nil
+}
+
+func (m *DefaultCodec) newExpectation(ctx context.Context, correlationKey interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) Expectation {
+	return &DefaultExpectation{
+		Context:        ctx,
 		Expiration:     time.Now().Add(ttl),
 		AcceptsMessage: acceptsMessage,
 		HandleMessage:  handleMessage,
 		HandleError:    handleError,
+		CorrelationKey: correlationKey,
+	}
+}
+
+func (m *DefaultCodec) addExpectation(expectation Expectation) {
+	m.Expectations.Add(expectation)
+	m.notifyExpectationsChanged()
+	// Wake up the dispatcher as soon as the context is done, so the expectation is removed right away
+	if done := expectation.GetContext().Done(); done != nil {
+		stopChan, _ := m.getWorkerChannels()
+		go func() {
+			select {
+			case <-done:
+				m.notifyExpectationsChanged()
+			case <-stopChan:
+			}
+		}()
+	}
+}
+
+func (m *DefaultCodec) notifyExpectationsChanged() {
+	select {
+	case m.expectationsChanged <- struct{}{}:
+	default:
+		// The dispatcher has already been notified
 	}
-	m.Expectations = append(m.Expectations, expectation)
-	return nil
 }
 
 func (m *DefaultCodec) SendRequest(message interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error {
-	log.Trace().Msg("Sending request")
+	return m.SendRequestWithContext(context.Background(), message, acceptsMessage, handleMessage, handleError, ttl)
+}
+
+func (m *DefaultCodec) SendRequestWithContext(ctx context.Context, message interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error {
+	return m.SendRequestWithCorrelationKey(ctx, message, nil, acceptsMessage, handleMessage, handleError, ttl)
+}
+
+func (m *DefaultCodec) SendRequestWithCorrelationKey(ctx context.Context, message interface{}, correlationKey interface{}, acceptsMessage AcceptsMessage, handleMessage HandleMessage, handleError HandleError, ttl time.Duration) error {
+	// If the context is already done, there's no need to bother the remote
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "Not sending the request")
+	}
+	// Count the exchange as soon as it's finished one way or the other (if the response can't be handled,
+	// the error handler is called as well, so only successfully handled responses are counted in there)
+	sentAt := time.Now()
+	meteredHandleMessage := func(message interface{}) error {
+		err := handleMessage(message)
+		if err == nil {
+			m.metrics.RecordExchange(time.Since(sentAt), nil)
+		}
+		return err
+	}
+	meteredHandleError := func(err error) error {
+		m.metrics.RecordExchange(time.Since(sentAt), err)
+		return handleError(err)
+	}
+	// As incoming messages are processed right away, the expectation has to be in place before the
+	// response could possibly come in.
+	expectation := m.newExpectation(ctx, correlationKey, acceptsMessage, meteredHandleMessage, meteredHandleError, ttl)
+	m.addExpectation(expectation)
+	m.logger.Trace().Msg("Sending request")
 	// Send the actual message
 	err := m.Send(message)
 	if err != nil {
+		m.Expectations.Remove(expectation)
+		m.metrics.RecordExchange(time.Since(sentAt), err)
 		return errors.Wrap(err, "Error sending the request")
 	}
-	return m.Expect(acceptsMessage, handleMessage, handleError, ttl)
+	return nil
 }
 
 func (m *DefaultCodec) TimeoutExpectations(now time.Time) {
-	for index, expectation := range m.Expectations {
-		// Check if this expectation has expired.
-		if now.After(expectation.GetExpiration()) {
-			// Remove this expectation from the list.
-			m.Expectations = append(m.Expectations[:index], m.Expectations[index+1:]...)
-			// Call the error handler.
-			// TODO: decouple from worker thread
-			err := expectation.GetHandleError()(plcerrors.NewTimeoutError(now.Sub(expectation.GetExpiration())))
-			if err != nil {
-				log.Error().Err(err).Msg("Got an error handling error on expectation")
-			}
+	cancelledExpectations, expiredExpectations := m.Expectations.RemoveDone(now)
+	for _, expectation := range cancelledExpectations {
+		go m.handleError(expectation, expectation.GetContext().Err())
+	}
+	for _, expectation := range expiredExpectations {
+		go m.handleError(expectation, plcerrors.NewTimeoutError(now.Sub(expectation.GetExpiration())))
+		// If the remote stopped answering altogether, consider the transport broken.
+		timeoutStreak := atomic.AddInt32(&m.timeoutStreak, 1)
+		if m.MaxTimeoutStreak > 0 && timeoutStreak == int32(m.MaxTimeoutStreak) {
+			m.logger.Warn().Int32("timeoutStreak", timeoutStreak).Msg("Too many consecutive timeouts")
+			m.notifyTransportFailure(errors.Errorf("%d consecutive requests timed out", timeoutStreak))
 		}
 	}
 }
 
 func (m *DefaultCodec) HandleMessages(message interface{}) bool {
-	messageHandled := false
-	for index, expectation := range m.Expectations {
-		// Check if the current message matches the expectations
-		// If it does, let it handle the message.
-		if accepts := expectation.GetAcceptsMessage()(message); accepts {
-			log.Debug().Stringer("expectation", expectation).Msg("accepts message")
-			// TODO: decouple from worker thread
-			err := expectation.GetHandleMessage()(message)
-			if err != nil {
+	return m.handleKeyedMessage(message) || m.handleUnkeyedMessage(message)
+}
+
+// handleKeyedMessage passes the message to the expectations registered with its correlation key
+func (m *DefaultCodec) handleKeyedMessage(message interface{}) bool {
+	if m.CorrelationKeyExtractor == nil {
+		return false
+	}
+	correlationKey := m.CorrelationKeyExtractor(message)
+	if correlationKey == nil {
+		return false
+	}
+	return m.handleMessage(m.Expectations.MatchKeyed(correlationKey, message), message)
+}
+
+// handleUnkeyedMessage passes the message to the expectations without correlation key accepting it
+func (m *DefaultCodec) handleUnkeyedMessage(message interface{}) bool {
+	return m.handleMessage(m.Expectations.MatchUnkeyed(message), message)
+}
+
+// handleMessage lets the expectations handle the message in their own goroutines, so a slow handler can't stall
+// the processing of incoming messages
+func (m *DefaultCodec) handleMessage(expectations []Expectation, message interface{}) bool {
+	for _, expectation := range expectations {
+		m.logger.Debug().Stringer("expectation", expectation).Msg("accepts message")
+		atomic.StoreInt32(&m.timeoutStreak, 0)
+		go func(expectation Expectation) {
+			if err := expectation.GetHandleMessage()(message); err != nil {
 				// Pass the error to the error handler.
-				// TODO: decouple from worker thread
-				err := expectation.GetHandleError()(err)
-				if err != nil {
-					log.Error().Err(err).Msg("Got an error handling error on expectation")
-				}
-				continue
+				m.handleError(expectation, err)
 			}
-			messageHandled = true
-			// If this is the last element of the list remove it differently than if it's before that
-			if (index + 1) == len(m.Expectations) {
-				m.Expectations = m.Expectations[:index]
-			} else if (index + 1) < len(m.Expectations) {
-				m.Expectations = append(m.Expectations[:index], m.Expectations[index+1:]...)
+		}(expectation)
+	}
+	return len(expectations) > 0
+}
+
+func (m *DefaultCodec) handleError(expectation Expectation, err error) {
+	if err := expectation.GetHandleError()(err); err != nil {
+		m.logger.Error().Err(err).Msg("Got an error handling error on expectation")
+	}
+}
+
+// readLoop blocks on the transport and passes all messages framed by Receive to the dispatcher
+func (m *DefaultCodec) readLoop(stopChan chan struct{}, incomingMessages chan interface{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+		message, err := m.DefaultCodecRequiredInterface.Receive()
+		if err == nil && message == nil {
+			// Wait for the bytes needed to complete the message
+			err = m.waitForMoreBytes(stopChan)
+		}
+		if err != nil {
+			// If we're disconnecting, the error is just the result of closing the transport
+			if m.stop() {
+				m.logger.Error().Err(err).Msg("got an error reading from transport")
+				m.notifyTransportFailure(errors.Wrap(err, "error reading from transport"))
 			}
+			return
+		}
+		if message == nil {
+			continue
+		}
+		select {
+		case incomingMessages <- message:
+		case <-stopChan:
+			return
 		}
 	}
-	return messageHandled
 }
 
+func (m *DefaultCodec) waitForMoreBytes(stopChan chan struct{}) error {
+	if waitingTransportInstance, ok := m.TransportInstance.(transports.WaitingTransportInstance); ok {
+		numReadableBytes, err := m.TransportInstance.GetNumReadableBytes()
+		if err != nil {
+			return err
+		}
+		return waitingTransportInstance.WaitForReadableBytes(numReadableBytes + 1)
+	}
+	// Transport instances not able to wait for data have to be polled
+	select {
+	case <-time.After(pollInterval):
+	case <-stopChan:
+	}
+	return nil
+}
+
+// Work dispatches the incoming messages to the expectations and times out expectations, till the codec is stopped
 func (m *DefaultCodec) Work(codec *DefaultCodecRequiredInterface) {
+	stopChan, incomingMessages := m.getWorkerChannels()
 	defer func() {
 		if err := recover(); err != nil {
-			log.Fatal().Msgf("recovered from %v", err)
+			m.logger.Error().Msgf("recovered from %v", err)
+			if m.IsRunning() {
+				m.logger.Info().Msg("Keep running")
+				m.Work(codec)
+			}
 		}
-		log.Info().Msg("Keep running")
-		m.Work(codec)
 	}()
-	// Start an endless loop
-mainLoop:
-	for m.Running {
-		// Check for any expired expectations.
-		// (Doing this outside the loop lets us expire expectations even if no input is coming in)
-		now := time.Now()
-
-		// Guard against empty expectations
-		if len(m.Expectations) <= 0 {
-			log.Trace().Msg("we got no expectation")
-			// Sleep for 10ms
-			time.Sleep(time.Millisecond * 10)
-			continue mainLoop
+	for {
+		m.TimeoutExpectations(time.Now())
+
+		// Sleep till the next expectation expires, unless something happens before
+		var timeoutChan <-chan time.Time
+		var timer *time.Timer
+		if nextExpiration, ok := m.Expectations.NextExpiration(); ok {
+			timer = time.NewTimer(time.Until(nextExpiration))
+			timeoutChan = timer.C
 		}
-		m.TimeoutExpectations(now)
-
-		// Check for incoming messages.
-		message, err := m.Receive()
-		if err != nil {
-			log.Error().Err(err).Msg("got an error reading from transport")
-			time.Sleep(time.Millisecond * 10)
-			continue mainLoop
+		select {
+		case <-stopChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-m.expectationsChanged:
+		case <-timeoutChan:
+		case message := <-incomingMessages:
+			m.dispatch(codec, message)
 		}
-		if message == nil {
-			// Sleep for 10ms before checking again, in order to not
-			// consume 100% CPU Power.
-			time.Sleep(time.Millisecond * 10)
-			continue mainLoop
+		if timer != nil {
+			timer.Stop()
 		}
+	}
+}
 
-		if m.CustomMessageHandling != nil {
-			if m.CustomMessageHandling(codec, message) {
-				continue mainLoop
-			}
+func (m *DefaultCodec) dispatch(codec *DefaultCodecRequiredInterface, message interface{}) {
+	// Responses carrying a correlation key are known to be meant for one of the expectations
+	if m.handleKeyedMessage(message) {
+		return
+	}
+
+	if m.CustomMessageHandling != nil {
+		if m.CustomMessageHandling(codec, message) {
+			return
 		}
+	}
 
-		// Go through all expectations
-		messageHandled := m.HandleMessages(message)
+	// Go through the other expectations
+	if m.handleUnkeyedMessage(message) {
+		return
+	}
 
-		// If the message has not been handled and a default handler is provided, call this ...
-		if !messageHandled {
-			// TODO: how do we prevent endless blocking if there is no reader on this channel?
-			m.DefaultIncomingMessageChannel <- message
-		}
+	// If the message has not been handled, pass it on to the default channel (without blocking the dispatcher)
+	select {
+	case m.DefaultIncomingMessageChannel <- message:
+	default:
+		m.metrics.RecordDroppedMessage()
+		m.logger.Warn().Msgf("Dropping unhandled message, as nobody is consuming them: %v", message)
 	}
 }
@@ -0,0 +1,314 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/test"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+// byteCodec treats every single byte as a message
+type byteCodec struct {
+	*DefaultCodec
+}
+
+func newByteCodec() (*byteCodec, *test.TransportInstance) {
+	transportInstance := test.NewTransportInstance(test.NewTransport())
+	codec := &byteCodec{
+		DefaultCodec: NewDefaultCodec(transportInstance),
+	}
+	codec.DefaultCodecRequiredInterface = codec
+	return codec, transportInstance
+}
+
+func (m *byteCodec) Send(message interface{}) error {
+	return m.WriteBytes([]uint8{message.(uint8)})
+}
+
+func (m *byteCodec) Receive() (interface{}, error) {
+	if num, err := m.TransportInstance.GetNumReadableBytes(); err != nil || num == 0 {
+		return nil, err
+	}
+	data, err := m.ReadBytes(1)
+	if err != nil {
+		return nil, err
+	}
+	return data[0], nil
+}
+
+func TestDefaultCodec_HandlesResponses(t *testing.T) {
+	codec, transportInstance := newByteCodec()
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer codec.Disconnect()
+
+	responses := make(chan interface{}, 1)
+	err := codec.SendRequest(uint8(1),
+		func(message interface{}) bool {
+			return message.(uint8) == 2
+		},
+		func(message interface{}) error {
+			responses <- message
+			return nil
+		},
+		func(err error) error {
+			t.Errorf("Unexpected error: %v", err)
+			return nil
+		},
+		time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// An unsolicited message is read even though it isn't expected by anyone
+	_ = transportInstance.FillReadBuffer([]uint8{3, 2})
+	select {
+	case response := <-responses:
+		if response.(uint8) != 2 {
+			t.Errorf("Expected response 2, got %v", response)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Response not handled")
+	}
+	select {
+	case message := <-codec.GetDefaultIncomingMessageChannel():
+		if message.(uint8) != 3 {
+			t.Errorf("Expected unhandled message 3, got %v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Unhandled message not forwarded")
+	}
+}
+
+func TestDefaultCodec_TimesOutExpectations(t *testing.T) {
+	codec, _ := newByteCodec()
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer codec.Disconnect()
+
+	errs := make(chan error, 1)
+	start := time.Now()
+	err := codec.Expect(
+		func(message interface{}) bool {
+			return true
+		},
+		func(message interface{}) error {
+			return nil
+		},
+		func(err error) error {
+			errs <- err
+			return nil
+		},
+		time.Millisecond*20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case err := <-errs:
+		var timeoutError plcerrors.TimeoutError
+		if !errors.As(err, &timeoutError) {
+			t.Errorf("Expected a timeout error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Millisecond*500 {
+			t.Errorf("Timeout reported too late after %v", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expectation didn't time out")
+	}
+}
+
+func TestDefaultCodec_Disconnect(t *testing.T) {
+	codec, _ := newByteCodec()
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !codec.IsRunning() {
+		t.Errorf("Expected the codec to be running")
+	}
+	if err := codec.Disconnect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if codec.IsRunning() {
+		t.Errorf("Expected the codec to be stopped")
+	}
+}
+
+func TestDefaultCodec_CorrelatesByKey(t *testing.T) {
+	codec, transportInstance := newByteCodec()
+	// Every message is its own correlation key
+	codec.CorrelationKeyExtractor = func(message interface{}) interface{} {
+		return message
+	}
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer codec.Disconnect()
+
+	responses := make(chan interface{}, 2)
+	for _, key := range []uint8{7, 8} {
+		err := codec.SendRequestWithCorrelationKey(context.Background(), key, key,
+			func(message interface{}) bool {
+				return true
+			},
+			func(message interface{}) error {
+				responses <- message
+				return nil
+			},
+			func(err error) error {
+				t.Errorf("Unexpected error: %v", err)
+				return nil
+			},
+			time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if codec.Expectations.Len() != 2 {
+		t.Fatalf("Expected 2 expectations, got %d", codec.Expectations.Len())
+	}
+	// Although both expectations accept any message, each one only gets the message with its key
+	_ = transportInstance.FillReadBuffer([]uint8{8})
+	if response := <-responses; response.(uint8) != 8 {
+		t.Errorf("Expected response 8, got %v", response)
+	}
+	if codec.Expectations.Len() != 1 {
+		t.Errorf("Expected 1 remaining expectation, got %d", codec.Expectations.Len())
+	}
+}
+
+func TestDefaultCodec_SlowHandlerDoesntStall(t *testing.T) {
+	codec, transportInstance := newByteCodec()
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer codec.Disconnect()
+
+	release := make(chan struct{})
+	defer close(release)
+	handled := make(chan interface{}, 1)
+	expect := func(expected uint8, handleMessage HandleMessage) {
+		err := codec.Expect(
+			func(message interface{}) bool {
+				return message.(uint8) == expected
+			},
+			handleMessage,
+			func(err error) error {
+				return nil
+			},
+			time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expect(1, func(message interface{}) error {
+		<-release
+		return nil
+	})
+	expect(2, func(message interface{}) error {
+		handled <- message
+		return nil
+	})
+	_ = transportInstance.FillReadBuffer([]uint8{1, 2})
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatalf("Second message not handled while the first handler is busy")
+	}
+}
+
+func TestDefaultCodec_CountsMetrics(t *testing.T) {
+	codec, transportInstance := newByteCodec()
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer codec.Disconnect()
+
+	errs := make(chan error, 2)
+	sendRequest := func(request uint8, ttl time.Duration) {
+		err := codec.SendRequest(request,
+			func(message interface{}) bool {
+				return message.(uint8) == request+1
+			},
+			func(message interface{}) error {
+				errs <- nil
+				return nil
+			},
+			func(err error) error {
+				errs <- err
+				return nil
+			},
+			ttl)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	sendRequest(1, time.Second)
+	sendRequest(3, time.Millisecond*10)
+	_ = transportInstance.FillReadBuffer([]uint8{2})
+	for i := 0; i < 2; i++ {
+		select {
+		case <-errs:
+		case <-time.After(time.Second):
+			t.Fatalf("Exchange not finished")
+		}
+	}
+
+	// The successful exchange is counted right after its handler returned
+	deadline := time.Now().Add(time.Second)
+	metrics := codec.GetConnectionMetrics().GetMetrics()
+	for metrics.Exchanges.Requests < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		metrics = codec.GetConnectionMetrics().GetMetrics()
+	}
+	if metrics.Exchanges.Requests != 2 || metrics.Exchanges.Errors != 1 || metrics.Exchanges.Timeouts != 1 {
+		t.Errorf("Expected 2 exchanges with 1 timeout, got %+v", metrics.Exchanges)
+	}
+	if metrics.BytesSent != 2 || metrics.BytesReceived != 1 {
+		t.Errorf("Expected 2 bytes sent and 1 received, got %d and %d", metrics.BytesSent, metrics.BytesReceived)
+	}
+}
+
+func TestDefaultCodec_CountsDroppedMessages(t *testing.T) {
+	codec, transportInstance := newByteCodec()
+	if err := codec.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer codec.Disconnect()
+
+	// Nobody consumes the unhandled messages, so the ones not fitting into the buffer are dropped
+	unhandledMessages := make([]uint8, defaultIncomingMessageBufferSize+2)
+	_ = transportInstance.FillReadBuffer(unhandledMessages)
+	deadline := time.Now().Add(time.Second)
+	metrics := codec.GetConnectionMetrics().GetMetrics()
+	for metrics.DroppedMessages < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		metrics = codec.GetConnectionMetrics().GetMetrics()
+	}
+	if metrics.DroppedMessages != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", metrics.DroppedMessages)
+	}
+	if len(codec.GetDefaultIncomingMessageChannel()) != defaultIncomingMessageBufferSize {
+		t.Errorf("Expected the buffer to be full, got %d messages", len(codec.GetDefaultIncomingMessageChannel()))
+	}
+}
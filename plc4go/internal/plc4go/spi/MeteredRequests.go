@@ -0,0 +1,184 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"time"
+)
+
+// The decorators in here count the requests executed by the reader, writer, subscriber or browser of a connection
+// in its ConnectionMetrics. Each decorator returns the decorated instance, if there are no metrics to count in.
+
+// fieldResponse is implemented by all responses reporting a response code per field
+type fieldResponse interface {
+	GetFieldNames() []string
+	GetResponseCode(name string) model.PlcResponseCode
+}
+
+// requestError returns the error a request is counted with. Requests succeeding with fields which timed out are
+// counted as timeouts as well.
+func requestError(err error, response interface{}, latency time.Duration) error {
+	if err != nil {
+		return err
+	}
+	if response, ok := response.(fieldResponse); ok {
+		for _, fieldName := range response.GetFieldNames() {
+			if response.GetResponseCode(fieldName) == model.PlcResponseCode_REQUEST_TIMEOUT {
+				return plcerrors.NewTimeoutError(latency)
+			}
+		}
+	}
+	return nil
+}
+
+type meteredReader struct {
+	reader  PlcReader
+	metrics *ConnectionMetrics
+}
+
+func NewMeteredReader(reader PlcReader, metrics *ConnectionMetrics) PlcReader {
+	if metrics == nil {
+		return reader
+	}
+	return &meteredReader{
+		reader:  reader,
+		metrics: metrics,
+	}
+}
+
+func (m *meteredReader) Read(ctx context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	start := time.Now()
+	results := m.reader.Read(ctx, readRequest)
+	result := make(chan model.PlcReadRequestResult, 1)
+	go func() {
+		readResult := <-results
+		latency := time.Since(start)
+		m.metrics.RecordRequest(OperationRead, latency, requestError(readResult.Err, readResult.Response, latency))
+		result <- readResult
+	}()
+	return result
+}
+
+type meteredWriter struct {
+	writer  PlcWriter
+	metrics *ConnectionMetrics
+}
+
+func NewMeteredWriter(writer PlcWriter, metrics *ConnectionMetrics) PlcWriter {
+	if metrics == nil {
+		return writer
+	}
+	return &meteredWriter{
+		writer:  writer,
+		metrics: metrics,
+	}
+}
+
+func (m *meteredWriter) Write(ctx context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
+	start := time.Now()
+	results := m.writer.Write(ctx, writeRequest)
+	result := make(chan model.PlcWriteRequestResult, 1)
+	go func() {
+		writeResult := <-results
+		latency := time.Since(start)
+		m.metrics.RecordRequest(OperationWrite, latency, requestError(writeResult.Err, writeResult.Response, latency))
+		result <- writeResult
+	}()
+	return result
+}
+
+type meteredSubscriber struct {
+	subscriber PlcSubscriber
+	metrics    *ConnectionMetrics
+}
+
+func NewMeteredSubscriber(subscriber PlcSubscriber, metrics *ConnectionMetrics) PlcSubscriber {
+	if metrics == nil {
+		return subscriber
+	}
+	return &meteredSubscriber{
+		subscriber: subscriber,
+		metrics:    metrics,
+	}
+}
+
+func (m *meteredSubscriber) Subscribe(ctx context.Context, subscriptionRequest model.PlcSubscriptionRequest) <-chan model.PlcSubscriptionRequestResult {
+	start := time.Now()
+	results := m.subscriber.Subscribe(ctx, subscriptionRequest)
+	result := make(chan model.PlcSubscriptionRequestResult, 1)
+	go func() {
+		subscriptionResult := <-results
+		latency := time.Since(start)
+		m.metrics.RecordRequest(OperationSubscribe, latency, requestError(subscriptionResult.Err, subscriptionResult.Response, latency))
+		result <- subscriptionResult
+	}()
+	return result
+}
+
+func (m *meteredSubscriber) Unsubscribe(ctx context.Context, unsubscriptionRequest model.PlcUnsubscriptionRequest) <-chan model.PlcUnsubscriptionRequestResult {
+	start := time.Now()
+	results := m.subscriber.Unsubscribe(ctx, unsubscriptionRequest)
+	result := make(chan model.PlcUnsubscriptionRequestResult, 1)
+	go func() {
+		unsubscriptionResult := <-results
+		latency := time.Since(start)
+		m.metrics.RecordRequest(OperationUnsubscribe, latency, requestError(unsubscriptionResult.Err, unsubscriptionResult.Response, latency))
+		result <- unsubscriptionResult
+	}()
+	return result
+}
+
+type meteredBrowser struct {
+	browser PlcBrowser
+	metrics *ConnectionMetrics
+}
+
+func NewMeteredBrowser(browser PlcBrowser, metrics *ConnectionMetrics) PlcBrowser {
+	if metrics == nil {
+		return browser
+	}
+	return &meteredBrowser{
+		browser: browser,
+		metrics: metrics,
+	}
+}
+
+func (m *meteredBrowser) Browse(ctx context.Context, browseRequest model.PlcBrowseRequest) <-chan model.PlcBrowseRequestResult {
+	return m.BrowseWithInterceptor(ctx, browseRequest, nil)
+}
+
+func (m *meteredBrowser) BrowseWithInterceptor(ctx context.Context, browseRequest model.PlcBrowseRequest, interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult {
+	start := time.Now()
+	var results <-chan model.PlcBrowseRequestResult
+	if interceptor != nil {
+		results = m.browser.BrowseWithInterceptor(ctx, browseRequest, interceptor)
+	} else {
+		results = m.browser.Browse(ctx, browseRequest)
+	}
+	result := make(chan model.PlcBrowseRequestResult, 1)
+	go func() {
+		browseResult := <-results
+		m.metrics.RecordRequest(OperationBrowse, time.Since(start), browseResult.Err)
+		result <- browseResult
+	}()
+	return result
+}
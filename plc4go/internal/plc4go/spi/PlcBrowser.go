@@ -18,11 +18,14 @@
 //
 package spi
 
-import "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+)
 
 type PlcBrowser interface {
 	// Non-Blocking request, which will return a full result as soon as the operation is finished
-	Browse(browseRequest model.PlcBrowseRequest) <-chan model.PlcBrowseRequestResult
+	Browse(ctx context.Context, browseRequest model.PlcBrowseRequest) <-chan model.PlcBrowseRequestResult
 
 	// Variant of the Browser, which allows immediately intercepting found resources
 	// This is ideal, if additional information has to be queried on such found resources
@@ -30,5 +33,5 @@ type PlcBrowser interface {
 	// and increase throughput. It can also be used for simple filtering.
 	// If the interceptor function returns 'true' the result is added to the overall result
 	// if it's 'false' is is not.
-	BrowseWithInterceptor(browseRequest model.PlcBrowseRequest, interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult
+	BrowseWithInterceptor(ctx context.Context, browseRequest model.PlcBrowseRequest, interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult
 }
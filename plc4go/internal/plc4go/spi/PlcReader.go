@@ -18,8 +18,11 @@
 //
 package spi
 
-import "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+)
 
 type PlcReader interface {
-	Read(readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult
+	Read(ctx context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult
 }
@@ -18,8 +18,11 @@
 //
 package spi
 
-import "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+)
 
 type PlcWriter interface {
-	Write(writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult
+	Write(ctx context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult
 }
@@ -0,0 +1,124 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// RequestQueueOptionSchema describes the options every driver accepts for tuning the request queue and the request
+// handling of its connections
+var RequestQueueOptionSchema = options.OptionSchema{
+	{Name: "read-priority", Type: options.OptionTypeString, Default: "normal", AllowedValues: []string{"low", "normal", "high"}, Description: "Priority of read requests in the request queue"},
+	{Name: "write-priority", Type: options.OptionTypeString, Default: "high", AllowedValues: []string{"low", "normal", "high"}, Description: "Priority of write requests in the request queue"},
+	{Name: "rate-limit", Type: options.OptionTypeFloat, Default: "0", Description: "Maximum number of requests per second sent to the PLC (0 for no limit)"},
+	{Name: "rate-limit-burst", Type: options.OptionTypeInteger, Default: "1", Description: "Number of requests which may be sent at once after a pause, despite the rate limit"},
+	{Name: "request-timeout", Type: options.OptionTypeInteger, Description: "Time in milliseconds to wait for the response to a request (the default depends on the driver)"},
+	{Name: "retries", Type: options.OptionTypeInteger, Default: "0", Description: "Number of times a read request is repeated after timing out (writes are never repeated)"},
+}
+
+// RequestQueueConfiguration holds the request queue settings parsed from the connection-string options
+type RequestQueueConfiguration struct {
+	ReadPriority   RequestPriority
+	WritePriority  RequestPriority
+	RateLimit      float64
+	RateLimitBurst int
+	// Defaults for requests, which don't override them
+	RequestTimeout time.Duration
+	Retries        int
+}
+
+// ParseRequestQueueConfiguration parses the request queue options, using the given timeout if no request-timeout is provided
+func ParseRequestQueueConfiguration(options map[string][]string, defaultRequestTimeout time.Duration) (RequestQueueConfiguration, error) {
+	configuration := RequestQueueConfiguration{
+		ReadPriority:   RequestPriorityNormal,
+		WritePriority:  RequestPriorityHigh,
+		RateLimit:      0,
+		RateLimitBurst: 1,
+		RequestTimeout: defaultRequestTimeout,
+		Retries:        0,
+	}
+	var err error
+	if values := options["read-priority"]; len(values) > 0 {
+		if configuration.ReadPriority, err = ParseRequestPriority(values[0]); err != nil {
+			return RequestQueueConfiguration{}, errors.Wrap(err, "error parsing read-priority")
+		}
+	}
+	if values := options["write-priority"]; len(values) > 0 {
+		if configuration.WritePriority, err = ParseRequestPriority(values[0]); err != nil {
+			return RequestQueueConfiguration{}, errors.Wrap(err, "error parsing write-priority")
+		}
+	}
+	if values := options["rate-limit"]; len(values) > 0 {
+		if configuration.RateLimit, err = strconv.ParseFloat(values[0], 64); err != nil {
+			return RequestQueueConfiguration{}, errors.Wrapf(err, "error parsing rate-limit %s", values[0])
+		}
+	}
+	if values := options["rate-limit-burst"]; len(values) > 0 {
+		if configuration.RateLimitBurst, err = strconv.Atoi(values[0]); err != nil {
+			return RequestQueueConfiguration{}, errors.Wrapf(err, "error parsing rate-limit-burst %s", values[0])
+		}
+	}
+	if values := options["request-timeout"]; len(values) > 0 {
+		requestTimeout, err := strconv.Atoi(values[0])
+		if err != nil {
+			return RequestQueueConfiguration{}, errors.Wrapf(err, "error parsing request-timeout %s", values[0])
+		}
+		if requestTimeout <= 0 {
+			return RequestQueueConfiguration{}, errors.Errorf("request-timeout must be positive, got %d", requestTimeout)
+		}
+		configuration.RequestTimeout = time.Duration(requestTimeout) * time.Millisecond
+	}
+	if values := options["retries"]; len(values) > 0 {
+		if configuration.Retries, err = strconv.Atoi(values[0]); err != nil {
+			return RequestQueueConfiguration{}, errors.Wrapf(err, "error parsing retries %s", values[0])
+		}
+		if configuration.Retries < 0 {
+			return RequestQueueConfiguration{}, errors.Errorf("retries must not be negative, got %d", configuration.Retries)
+		}
+	}
+	return configuration, nil
+}
+
+// GetRequestTimeout returns the timeout of a request, which uses the connection default, unless it overrides it (override > 0)
+func (m RequestQueueConfiguration) GetRequestTimeout(override time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	return m.RequestTimeout
+}
+
+// GetRetries returns how often a timed out request is repeated, which is the connection default, unless the request
+// overrides it (override >= 0)
+func (m RequestQueueConfiguration) GetRetries(override int) int {
+	if override >= 0 {
+		return override
+	}
+	return m.Retries
+}
+
+// NewRequestTransactionManager creates the request queue for a connection using this configuration (and the given
+// additional options)
+func (m RequestQueueConfiguration) NewRequestTransactionManager(numberOfConcurrentRequests int, options ...WithRequestTransactionManagerOption) *RequestTransactionManager {
+	options = append([]WithRequestTransactionManagerOption{WithRateLimit(m.RateLimit, m.RateLimitBurst)}, options...)
+	return NewRequestTransactionManager(numberOfConcurrentRequests, options...)
+}
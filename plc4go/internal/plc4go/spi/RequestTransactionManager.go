@@ -16,194 +16,225 @@
 // specific language governing permissions and limitations
 // under the License.
 //
-
 package spi
 
 import (
 	"container/list"
+	"context"
+	"fmt"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"reflect"
+	"math"
+	"strings"
 	"sync"
 	"time"
 )
 
-/** Executor that performs all operations */
-var executor Executor // shared instance
-
-func init() {
-	executor = *NewFixedSizeExecutor(4)
-	executor.start()
-}
-
+// Runnable is the operation a RequestTransaction performs as soon as it's its turn
 type Runnable func()
 
-type Worker struct {
-	shutdown    bool
-	runnable    Runnable
-	interrupted bool
-	executor    *Executor
+// CompletionFuture reports the outcome of a RequestTransaction
+type CompletionFuture struct {
+	transaction *RequestTransaction
+	done        chan struct{}
+	// Both only written before done is closed
+	err       error
+	cancelled bool
 }
 
-func (w Worker) work() {
-	defer func() {
-		if recovered := recover(); recovered != nil {
-			log.Fatal().Msgf("Recovering from panic()=%v", recovered)
-		}
-		if !w.shutdown {
-			// TODO: if we are not in shutdown we continue
-			w.work()
-		}
-	}()
-	for !w.shutdown {
-		select {
-		case workItem := <-w.executor.queue:
-			if workItem.completionFuture.cancelRequested || (w.shutdown && w.interrupted) {
-				// TODO: do we need to complete with a error?
-			} else {
-				workItem.runnable()
-				workItem.completionFuture.complete()
-			}
-		default:
-			time.Sleep(time.Millisecond * 10)
-		}
+func newCompletionFuture(transaction *RequestTransaction) *CompletionFuture {
+	return &CompletionFuture{
+		transaction: transaction,
+		done:        make(chan struct{}),
 	}
 }
 
-type WorkItem struct {
-	runnable         Runnable
-	completionFuture *CompletionFuture
+// Cancel removes the transaction from the worklog, or if it's already running, aborts it and frees its slot.
+// Returns false, if the transaction was already finished.
+func (f *CompletionFuture) Cancel() bool {
+	return f.transaction.parent.endRequest(f.transaction, errors.New("transaction cancelled"), true) == nil
 }
 
-type Executor struct {
-	running     bool
-	shutdown    bool
-	stateChange sync.Mutex
-	worker      []*Worker
-	queue       chan WorkItem
-}
-
-func NewFixedSizeExecutor(numberOfWorkers int) *Executor {
-	workers := make([]*Worker, numberOfWorkers)
-	for i := 0; i < numberOfWorkers; i++ {
-		workers[i] = &Worker{
-			shutdown:    false,
-			runnable:    nil,
-			interrupted: false,
-			executor:    nil,
-		}
+// AwaitCompletion blocks till the transaction is finished or the given context is done. It returns nil if the
+// transaction was ended normally and the error it was failed with otherwise.
+func (f *CompletionFuture) AwaitCompletion(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	executor = Executor{
-		queue:  make(chan WorkItem, 100),
-		worker: workers,
-	}
-	for i := 0; i < numberOfWorkers; i++ {
-		worker := workers[i]
-		worker.executor = &executor
-	}
-	return &executor
 }
 
-func (e *Executor) submit(runnable Runnable) *CompletionFuture {
-	completionFuture := &CompletionFuture{}
-	e.queue <- WorkItem{
-		runnable:         runnable,
-		completionFuture: completionFuture,
+func (f *CompletionFuture) IsDone() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
 	}
-	return completionFuture
 }
 
-func (e *Executor) start() {
-	e.stateChange.Lock()
-	defer e.stateChange.Unlock()
-	if e.running {
-		return
-	}
-	e.running = true
-	e.shutdown = false
-	for i := 0; i < len(e.worker); i++ {
-		worker := e.worker[i]
-		go worker.work()
-	}
+func (f *CompletionFuture) IsCancelled() bool {
+	return f.IsDone() && f.cancelled
 }
 
-func (e *Executor) stop() {
-	e.stateChange.Lock()
-	defer e.stateChange.Unlock()
-	if !e.running {
-		return
-	}
-	e.shutdown = true
-	close(e.queue)
-	for i := 0; i < len(e.worker); i++ {
-		worker := e.worker[i]
-		worker.shutdown = true
-		worker.interrupted = true
-	}
-	e.running = false
-}
+// RequestPriority decides which worklog a transaction is queued in. Transactions of a higher priority are always
+// started before the ones of a lower priority, within one priority it's first come, first served.
+type RequestPriority int
 
-type CompletionFuture struct {
-	cancelRequested    bool
-	interruptRequested bool
-	completed          bool
-	errored            bool
-}
+const (
+	RequestPriorityLow RequestPriority = iota
+	RequestPriorityNormal
+	RequestPriorityHigh
 
-func (f CompletionFuture) cancel(interrupt bool) {
-	f.cancelRequested = true
-	f.interruptRequested = interrupt
-	f.errored = true
-}
+	numberOfRequestPriorities = int(RequestPriorityHigh) + 1
+)
 
-func (f CompletionFuture) complete() {
-	f.completed = true
+func (m RequestPriority) String() string {
+	switch m {
+	case RequestPriorityLow:
+		return "low"
+	case RequestPriorityNormal:
+		return "normal"
+	case RequestPriorityHigh:
+		return "high"
+	}
+	return fmt.Sprintf("RequestPriority(%d)", int(m))
 }
 
-func (f CompletionFuture) AwaitCompletion() {
-	for !f.completed || !f.errored {
-		time.Sleep(time.Millisecond * 10)
+func ParseRequestPriority(priority string) (RequestPriority, error) {
+	for i := 0; i < numberOfRequestPriorities; i++ {
+		if strings.EqualFold(RequestPriority(i).String(), priority) {
+			return RequestPriority(i), nil
+		}
 	}
+	return RequestPriorityNormal, errors.Errorf("unknown request priority %s", priority)
 }
 
+type requestTransactionState int
+
+const (
+	requestTransactionCreated requestTransactionState = iota
+	requestTransactionQueued
+	requestTransactionRunning
+	requestTransactionFinished
+)
+
 type RequestTransaction struct {
 	parent        *RequestTransactionManager
 	transactionId int32
-
-	/** The initial operation to perform to kick off the request */
+	// Overrides the default timeout of the manager, if set
+	timeout  time.Duration
+	priority RequestPriority
+	// Logger of the manager with the transaction id attached
+	logger zerolog.Logger
+
+	// All of these are guarded by the lock of the parent
+	state            requestTransactionState
 	operation        Runnable
+	ctx              context.Context
+	cancelCtx        context.CancelFunc
+	worklogElement   *list.Element
+	queuedAt         time.Time
+	timeoutTimer     *time.Timer
 	completionFuture *CompletionFuture
 }
 
+type WithRequestTransactionManagerOption func(manager *RequestTransactionManager)
+
+// WithRateLimit limits how many transactions are started per second, no matter how many slots are free. Up to burst
+// transactions can be started at once, after a pause. A rate of 0 disables the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) WithRequestTransactionManagerOption {
+	return func(manager *RequestTransactionManager) {
+		if burst < 1 {
+			burst = 1
+		}
+		manager.rateLimit = requestsPerSecond
+		manager.rateLimitBurst = burst
+		manager.tokens = float64(burst)
+	}
+}
+
+// WithRequestTimeout defines how long a transaction may run, before it is failed and its slot is handed to the
+// next one in the worklog. Time spent waiting in the worklog doesn't count. A value of 0 disables the timeout.
+func WithRequestTimeout(requestTimeout time.Duration) WithRequestTransactionManagerOption {
+	return func(manager *RequestTransactionManager) {
+		manager.requestTimeout = requestTimeout
+	}
+}
+
+// WithLogger replaces the global logger with the one of the connection the manager belongs to
+func WithLogger(logger zerolog.Logger) WithRequestTransactionManagerOption {
+	return func(manager *RequestTransactionManager) {
+		manager.logger = logger
+	}
+}
+
+// RequestTransactionManager serializes the requests sent over one connection. Transactions are started in the order
+// they were submitted and at most numberOfConcurrentRequests of them are running at the same time.
 type RequestTransactionManager struct {
-	runningRequests []RequestTransaction
+	lock sync.Mutex
 	// How many Transactions are allowed to run at the same time?
 	numberOfConcurrentRequests int
+	requestTimeout             time.Duration
 	// Assigns each request a Unique Transaction Id, especially important for failure handling
 	transactionId   int32
-	transationMutex sync.RWMutex
-	// Important, this is a FIFO Queue for Fairness!
-	worklog      list.List
-	worklogMutex sync.RWMutex
+	runningRequests map[int32]*RequestTransaction
+	// Important, these are FIFO Queues for Fairness! (one per priority)
+	worklogs [numberOfRequestPriorities]list.List
+	closed   bool
+
+	// Token bucket for the rate limit
+	rateLimit      float64
+	rateLimitBurst int
+	tokens         float64
+	lastRefill     time.Time
+	rateLimitTimer *time.Timer
+
+	logger zerolog.Logger
+
+	// Metrics
+	startedRequests uint64
+	totalWaitTime   time.Duration
+	maxWaitTime     time.Duration
 }
 
-func NewRequestTransactionManager(numberOfConcurrentRequests int) RequestTransactionManager {
-	return RequestTransactionManager{
+func NewRequestTransactionManager(numberOfConcurrentRequests int, options ...WithRequestTransactionManagerOption) *RequestTransactionManager {
+	if numberOfConcurrentRequests < 1 {
+		numberOfConcurrentRequests = 1
+	}
+	manager := &RequestTransactionManager{
 		numberOfConcurrentRequests: numberOfConcurrentRequests,
-		transactionId:              0,
-		worklog:                    *list.New(),
+		runningRequests:            map[int32]*RequestTransaction{},
+		lastRefill:                 time.Now(),
+		logger:                     log.Logger,
 	}
+	for _, option := range options {
+		option(manager)
+	}
+	return manager
 }
 
-func (r *RequestTransactionManager) getNumberOfConcurrentRequests() int {
+func (r *RequestTransactionManager) GetNumberOfConcurrentRequests() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
 	return r.numberOfConcurrentRequests
 }
 
 func (r *RequestTransactionManager) SetNumberOfConcurrentRequests(numberOfConcurrentRequests int) {
+	if numberOfConcurrentRequests < 1 {
+		numberOfConcurrentRequests = 1
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
 	// If we reduced the number of concurrent requests and more requests are in-flight
 	// than should be, at least log a warning.
 	if numberOfConcurrentRequests < len(r.runningRequests) {
-		log.Warn().Msg("The number of concurrent requests was reduced and currently more requests are in flight.")
+		r.logger.Warn().Msg("The number of concurrent requests was reduced and currently more requests are in flight.")
 	}
 
 	r.numberOfConcurrentRequests = numberOfConcurrentRequests
@@ -212,124 +243,312 @@ func (r *RequestTransactionManager) SetNumberOfConcurrentRequests(numberOfConcur
 	r.processWorklog()
 }
 
-func (r *RequestTransactionManager) submit(context func(RequestTransaction)) {
+func (r *RequestTransactionManager) GetNumberOfActiveRequests() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.runningRequests)
+}
+
+func (r *RequestTransactionManager) GetLogger() *zerolog.Logger {
+	return &r.logger
+}
+
+func (r *RequestTransactionManager) GetNumberOfQueuedRequests() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	queued := 0
+	for i := range r.worklogs {
+		queued += r.worklogs[i].Len()
+	}
+	return queued
+}
+
+// GetMetrics returns a snapshot of the worklogs and the time transactions spent waiting in them
+func (r *RequestTransactionManager) GetMetrics() model.PlcRequestQueueMetrics {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	queueDepth := map[string]int{}
+	for i := range r.worklogs {
+		queueDepth[RequestPriority(i).String()] = r.worklogs[i].Len()
+	}
+	return model.PlcRequestQueueMetrics{
+		QueueDepth:      queueDepth,
+		ActiveRequests:  len(r.runningRequests),
+		StartedRequests: r.startedRequests,
+		TotalWaitTime:   r.totalWaitTime,
+		MaxWaitTime:     r.maxWaitTime,
+	}
+}
+
+func (r *RequestTransactionManager) StartRequest() *RequestTransaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	currentTransactionId := r.transactionId
+	r.transactionId += 1
+	transaction := &RequestTransaction{
+		parent:        r,
+		transactionId: currentTransactionId,
+		priority:      RequestPriorityNormal,
+		logger:        r.logger.With().Int32("transactionId", currentTransactionId).Logger(),
+	}
+	transaction.completionFuture = newCompletionFuture(transaction)
+	return transaction
+}
+
+// Execute runs the given operation as a transaction and blocks till it's finished. The transaction is ended with
+// whatever error the operation returns.
+func (r *RequestTransactionManager) Execute(ctx context.Context, priority RequestPriority, operation func(ctx context.Context) error) error {
 	transaction := r.StartRequest()
-	context(transaction)
-	// r.submitHandle(transaction);
+	transaction.SetPriority(priority)
+	transaction.SubmitWithContext(ctx, func() {
+		if err := operation(transaction.GetContext()); err != nil {
+			_ = transaction.FailRequest(err)
+			return
+		}
+		_ = transaction.EndRequest()
+	})
+	return transaction.GetCompletionFuture().AwaitCompletion(context.Background())
+}
+
+// Close fails all queued and running transactions. Any transaction submitted afterwards is failed right away.
+func (r *RequestTransactionManager) Close() {
+	r.lock.Lock()
+	r.closed = true
+	if r.rateLimitTimer != nil {
+		r.rateLimitTimer.Stop()
+		r.rateLimitTimer = nil
+	}
+	var transactions []*RequestTransaction
+	for i := range r.worklogs {
+		for element := r.worklogs[i].Front(); element != nil; element = element.Next() {
+			transactions = append(transactions, element.Value.(*RequestTransaction))
+		}
+	}
+	for _, transaction := range r.runningRequests {
+		transactions = append(transactions, transaction)
+	}
+	r.lock.Unlock()
+	for _, transaction := range transactions {
+		_ = r.endRequest(transaction, errors.New("request transaction manager closed"), false)
+	}
 }
 
-func (r *RequestTransactionManager) submitHandle(handle RequestTransaction) {
-	if handle.operation == nil {
+func (r *RequestTransactionManager) submitHandle(transaction *RequestTransaction, ctx context.Context, operation Runnable) {
+	if operation == nil {
 		panic("invalid handle")
 	}
-	// Add this Request with this handle i the Worklog
+	r.lock.Lock()
+	if transaction.state != requestTransactionCreated {
+		r.lock.Unlock()
+		transaction.logger.Warn().Msg("Transaction was already submitted")
+		return
+	}
+	transaction.operation = operation
+	transaction.ctx, transaction.cancelCtx = context.WithCancel(ctx)
+	if r.closed {
+		r.lock.Unlock()
+		_ = r.endRequest(transaction, errors.New("request transaction manager closed"), false)
+		return
+	}
 	// Put Transaction into Worklog
-	r.worklogMutex.Lock()
-	r.worklog.PushFront(handle)
-	r.worklogMutex.Unlock()
+	transaction.state = requestTransactionQueued
+	transaction.queuedAt = time.Now()
+	transaction.worklogElement = r.worklogs[transaction.priority].PushBack(transaction)
 	// Try to Process the Worklog
 	r.processWorklog()
+	r.lock.Unlock()
+
+	// Give up on the transaction as soon as the one submitting it isn't interested anymore.
+	// (The context is cancelled in any case, as soon as the transaction is finished)
+	go func() {
+		<-transaction.ctx.Done()
+		_ = r.endRequest(transaction, errors.Wrap(transaction.ctx.Err(), "transaction aborted"), false)
+	}()
 }
 
+// processWorklog starts as many transactions as there are free slots and the rate limit allows, highest priority
+// first. Has to be called with the lock held.
 func (r *RequestTransactionManager) processWorklog() {
-	r.worklogMutex.RLock()
-	defer r.worklogMutex.RUnlock()
-	for len(r.runningRequests) < r.getNumberOfConcurrentRequests() && r.worklog.Len() > 0 {
-		next := r.worklog.Front().Value.(RequestTransaction)
-		r.runningRequests = append(r.runningRequests, next)
-		completionFuture := executor.submit(next.operation)
-		next.completionFuture = completionFuture
+	for len(r.runningRequests) < r.numberOfConcurrentRequests {
+		worklog := r.nextWorklog()
+		if worklog == nil || !r.takeToken() {
+			return
+		}
+		next := worklog.Remove(worklog.Front()).(*RequestTransaction)
+		next.worklogElement = nil
+		waitTime := time.Since(next.queuedAt)
+		r.startedRequests++
+		r.totalWaitTime += waitTime
+		if waitTime > r.maxWaitTime {
+			r.maxWaitTime = waitTime
+		}
+		next.state = requestTransactionRunning
+		r.runningRequests[next.transactionId] = next
+		timeout := r.requestTimeout
+		if next.timeout > 0 {
+			timeout = next.timeout
+		}
+		if timeout > 0 {
+			transaction := next
+			transaction.timeoutTimer = time.AfterFunc(timeout, func() {
+				_ = r.endRequest(transaction, plcerrors.NewTimeoutError(timeout), false)
+			})
+		}
+		go next.run()
 	}
 }
 
-func (r *RequestTransactionManager) StartRequest() RequestTransaction {
-	r.transationMutex.Lock()
-	defer r.transationMutex.Unlock()
-	currentTransactionId := r.transactionId
-	r.transactionId += 1
-	return RequestTransaction{r, currentTransactionId, nil, nil}
-}
-
-func (r *RequestTransactionManager) getNumberOfActiveRequests() int {
-	return len(r.runningRequests)
+// nextWorklog returns the non-empty worklog with the highest priority
+func (r *RequestTransactionManager) nextWorklog() *list.List {
+	for i := numberOfRequestPriorities - 1; i >= 0; i-- {
+		if r.worklogs[i].Len() > 0 {
+			return &r.worklogs[i]
+		}
+	}
+	return nil
 }
 
-func (r *RequestTransactionManager) failRequest(transaction RequestTransaction) error {
-	// Try to fail it!
-	transaction.completionFuture.cancel(true)
-	// End it
-	return r.endRequest(transaction)
+// takeToken takes a token from the bucket, if the rate limit allows starting another transaction right now.
+// Otherwise the worklog is processed again, as soon as the next token is available.
+func (r *RequestTransactionManager) takeToken() bool {
+	if r.rateLimit <= 0 {
+		return true
+	}
+	now := time.Now()
+	r.tokens = math.Min(float64(r.rateLimitBurst), r.tokens+now.Sub(r.lastRefill).Seconds()*r.rateLimit)
+	r.lastRefill = now
+	if r.tokens >= 1 {
+		r.tokens--
+		return true
+	}
+	if r.rateLimitTimer == nil && !r.closed {
+		delay := time.Duration((1 - r.tokens) / r.rateLimit * float64(time.Second))
+		r.rateLimitTimer = time.AfterFunc(delay, func() {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.rateLimitTimer = nil
+			r.processWorklog()
+		})
+	}
+	return false
 }
 
-func (r *RequestTransactionManager) endRequest(transaction RequestTransaction) error {
-	found := false
-	index := -1
-	for i, runningRequest := range r.runningRequests {
-		// TODO: check this implementation
-		if (&runningRequest) == (&transaction) {
-			found = true
-			index = i
-			break
-		}
+func (r *RequestTransactionManager) failRequest(transaction *RequestTransaction, err error) error {
+	if err == nil {
+		err = errors.New("transaction failed")
 	}
-	if !found {
+	return r.endRequest(transaction, err, false)
+}
+
+func (r *RequestTransactionManager) endRequest(transaction *RequestTransaction, err error, cancelled bool) error {
+	r.lock.Lock()
+	switch transaction.state {
+	case requestTransactionQueued:
+		r.worklogs[transaction.priority].Remove(transaction.worklogElement)
+		transaction.worklogElement = nil
+	case requestTransactionRunning:
+		delete(r.runningRequests, transaction.transactionId)
+		if transaction.timeoutTimer != nil {
+			transaction.timeoutTimer.Stop()
+			transaction.timeoutTimer = nil
+		}
+	case requestTransactionFinished:
+		r.lock.Unlock()
 		return errors.New("Unknown Transaction or Transaction already finished!")
 	}
-	r.runningRequests = append(r.runningRequests[:index], r.runningRequests[index+1:]...)
+	transaction.state = requestTransactionFinished
+	cancelCtx := transaction.cancelCtx
 	// Process the worklog, a slot should be free now
 	r.processWorklog()
+	r.lock.Unlock()
+
+	if cancelCtx != nil {
+		cancelCtx()
+	}
+	future := transaction.completionFuture
+	future.err = err
+	future.cancelled = cancelled
+	close(future.done)
+	if err != nil {
+		transaction.logger.Debug().Err(err).Msg("Transaction failed")
+	}
 	return nil
 }
 
-func (t RequestTransaction) start() {
+// SetTimeout overrides the default timeout of the manager for this transaction. Has to be called before Submit.
+func (t *RequestTransaction) SetTimeout(timeout time.Duration) {
+	t.timeout = timeout
 }
 
-func (t RequestTransaction) failRequest(err error) error {
-	return t.parent.failRequest(t)
+// SetPriority decides which worklog the transaction is queued in. Has to be called before Submit.
+func (t *RequestTransaction) SetPriority(priority RequestPriority) {
+	if priority < RequestPriorityLow || int(priority) >= numberOfRequestPriorities {
+		priority = RequestPriorityNormal
+	}
+	t.priority = priority
 }
 
-func (t RequestTransaction) EndRequest() error {
-	// Remove it from Running Requests
-	return t.parent.endRequest(t)
+// GetLogger returns the logger of the manager with the id of the transaction attached
+func (t *RequestTransaction) GetLogger() *zerolog.Logger {
+	return &t.logger
 }
 
-func (t RequestTransaction) setOperation(operation Runnable) {
-	t.operation = operation
+// GetContext returns the context the operation should use for its I/O. It is cancelled as soon as the transaction
+// is finished, no matter if it ended, failed, timed out or was cancelled.
+func (t *RequestTransaction) GetContext() context.Context {
+	t.parent.lock.Lock()
+	defer t.parent.lock.Unlock()
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
 }
 
-func (t RequestTransaction) getCompletionFuture() *CompletionFuture {
+func (t *RequestTransaction) GetCompletionFuture() *CompletionFuture {
 	return t.completionFuture
 }
 
-func (t RequestTransaction) setCompletionFuture(completionFuture *CompletionFuture) {
-	t.completionFuture = completionFuture
+func (t *RequestTransaction) Submit(operation Runnable) {
+	t.SubmitWithContext(context.Background(), operation)
 }
 
-func (t RequestTransaction) Submit(operation Runnable) {
-	log.Trace().Msgf("Submission of transaction %d", t.transactionId)
-	t.setOperation(NewTransactionOperation(t.transactionId, operation))
-	t.parent.submitHandle(t)
+// SubmitWithContext puts the transaction at the end of the worklog. If the context is done before the transaction
+// is finished, the transaction is failed.
+func (t *RequestTransaction) SubmitWithContext(ctx context.Context, operation Runnable) {
+	t.logger.Trace().Msgf("Submission of transaction %d", t.transactionId)
+	t.parent.submitHandle(t, ctx, NewTransactionOperation(t.logger, t.transactionId, operation))
 }
 
-func (t *RequestTransaction) equals(o *RequestTransaction) bool {
-	if t == o {
-		return true
-	}
-	if o == nil || reflect.TypeOf(t).Kind() != reflect.TypeOf(o).Kind() {
-		return false
-	}
-	that := o
-	return t.transactionId == that.transactionId
+func (t *RequestTransaction) FailRequest(err error) error {
+	return t.parent.failRequest(t, err)
+}
+
+func (t *RequestTransaction) EndRequest() error {
+	// Remove it from Running Requests
+	return t.parent.endRequest(t, nil, false)
+}
+
+func (t *RequestTransaction) run() {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			t.logger.Error().Msgf("Recovering from panic()=%v", recovered)
+			_ = t.FailRequest(errors.Errorf("transaction panicked: %v", recovered))
+		}
+	}()
+	t.parent.lock.Lock()
+	operation := t.operation
+	t.parent.lock.Unlock()
+	operation()
 }
 
-func (t *RequestTransaction) hashCode() int32 {
-	return t.transactionId
+func (t *RequestTransaction) String() string {
+	return fmt.Sprintf("RequestTransaction{transactionId: %d}", t.transactionId)
 }
 
-func NewTransactionOperation(transactionId int32, delegate Runnable) Runnable {
+func NewTransactionOperation(logger zerolog.Logger, transactionId int32, delegate Runnable) Runnable {
 	return func() {
-		log.Trace().Int32("transactionId", transactionId).Msgf("Start execution of transaction %d", transactionId)
+		logger.Trace().Int32("transactionId", transactionId).Msgf("Start execution of transaction %d", transactionId)
 		delegate()
-		log.Trace().Int32("transactionId", transactionId).Msgf("Completed execution of transaction %d", transactionId)
+		logger.Trace().Int32("transactionId", transactionId).Msgf("Completed execution of transaction %d", transactionId)
 	}
 }
@@ -0,0 +1,220 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package spi
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/pkg/errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestTransactionManager_RunsInOrder(t *testing.T) {
+	tm := NewRequestTransactionManager(1)
+
+	var lock sync.Mutex
+	var order []int
+	var transactions []*RequestTransaction
+	for i := 0; i < 5; i++ {
+		i := i
+		transaction := tm.StartRequest()
+		transactions = append(transactions, transaction)
+		transaction.Submit(func() {
+			lock.Lock()
+			order = append(order, i)
+			lock.Unlock()
+			// Ended asynchronously, like a response handler would do
+			time.AfterFunc(time.Millisecond, func() {
+				_ = transaction.EndRequest()
+			})
+		})
+	}
+	for _, transaction := range transactions {
+		if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for i, value := range order {
+		if i != value {
+			t.Fatalf("Expected transactions to run in submission order, got %v", order)
+		}
+	}
+	if err := transactions[0].EndRequest(); err == nil {
+		t.Errorf("Expected ending a finished transaction to fail")
+	}
+}
+
+func TestRequestTransactionManager_LimitsConcurrency(t *testing.T) {
+	tm := NewRequestTransactionManager(2)
+
+	started := make(chan *RequestTransaction, 3)
+	for i := 0; i < 3; i++ {
+		transaction := tm.StartRequest()
+		transaction.Submit(func() {
+			started <- transaction
+		})
+	}
+	first, second := <-started, <-started
+	select {
+	case <-started:
+		t.Fatalf("Expected only two transactions to run at the same time")
+	case <-time.After(time.Millisecond * 20):
+	}
+	if active, queued := tm.GetNumberOfActiveRequests(), tm.GetNumberOfQueuedRequests(); active != 2 || queued != 1 {
+		t.Errorf("Expected 2 active and 1 queued transactions, got %d and %d", active, queued)
+	}
+	_ = first.EndRequest()
+	third := <-started
+	_ = second.EndRequest()
+	_ = third.EndRequest()
+
+	// Raising the limit has to start waiting transactions right away
+	tm.SetNumberOfConcurrentRequests(1)
+	blocker := tm.StartRequest()
+	blocker.Submit(func() {})
+	waiting := tm.StartRequest()
+	waiting.Submit(func() {
+		_ = waiting.EndRequest()
+	})
+	tm.SetNumberOfConcurrentRequests(2)
+	if err := waiting.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRequestTransactionManager_CancelsQueuedTransactions(t *testing.T) {
+	tm := NewRequestTransactionManager(1)
+
+	blocker := tm.StartRequest()
+	blocker.Submit(func() {})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := tm.StartRequest()
+	cancelled.SubmitWithContext(ctx, func() {
+		t.Errorf("A cancelled transaction must never run")
+	})
+	future := tm.StartRequest()
+	future.Submit(func() {
+		t.Errorf("A cancelled transaction must never run")
+	})
+
+	cancel()
+	if err := cancelled.GetCompletionFuture().AwaitCompletion(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancellation error, got %v", err)
+	}
+	if !future.GetCompletionFuture().Cancel() || !future.GetCompletionFuture().IsCancelled() {
+		t.Errorf("Expected the transaction to be cancelled")
+	}
+	if queued := tm.GetNumberOfQueuedRequests(); queued != 0 {
+		t.Errorf("Expected no queued transactions, got %d", queued)
+	}
+	_ = blocker.EndRequest()
+	time.Sleep(time.Millisecond * 10)
+}
+
+func TestRequestTransactionManager_TimesOutTransactions(t *testing.T) {
+	tm := NewRequestTransactionManager(1, WithRequestTimeout(time.Millisecond*10))
+
+	// Never ended, so this one has to time out and hand over its slot
+	lost := tm.StartRequest()
+	lost.Submit(func() {})
+	next := tm.StartRequest()
+	next.Submit(func() {
+		_ = next.EndRequest()
+	})
+
+	err := lost.GetCompletionFuture().AwaitCompletion(context.Background())
+	if _, ok := err.(plcerrors.TimeoutError); !ok {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if lost.GetContext().Err() == nil {
+		t.Errorf("Expected the context of the timed out transaction to be cancelled")
+	}
+	if err := next.GetCompletionFuture().AwaitCompletion(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRequestTransactionManager_Close(t *testing.T) {
+	tm := NewRequestTransactionManager(1)
+
+	running := tm.StartRequest()
+	running.Submit(func() {})
+	queued := tm.StartRequest()
+	queued.Submit(func() {})
+	tm.Close()
+
+	for _, transaction := range []*RequestTransaction{running, queued} {
+		if err := transaction.GetCompletionFuture().AwaitCompletion(context.Background()); err == nil {
+			t.Errorf("Expected %v to be failed", transaction)
+		}
+	}
+	if err := tm.Execute(context.Background(), RequestPriorityNormal, func(_ context.Context) error { return nil }); err == nil {
+		t.Errorf("Expected transactions submitted after closing to be failed")
+	}
+}
+
+func TestRequestTransactionManager_Priorities(t *testing.T) {
+	tm := NewRequestTransactionManager(1)
+
+	blocker := tm.StartRequest()
+	blocker.Submit(func() {})
+	started := make(chan RequestPriority, 3)
+	var transactions []*RequestTransaction
+	for _, priority := range []RequestPriority{RequestPriorityLow, RequestPriorityNormal, RequestPriorityHigh} {
+		priority := priority
+		transaction := tm.StartRequest()
+		transaction.SetPriority(priority)
+		transaction.Submit(func() {
+			started <- priority
+			_ = transaction.EndRequest()
+		})
+		transactions = append(transactions, transaction)
+	}
+	if metrics := tm.GetMetrics(); metrics.GetTotalQueueDepth() != 3 || metrics.QueueDepth["high"] != 1 || metrics.ActiveRequests != 1 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+	_ = blocker.EndRequest()
+
+	for _, expected := range []RequestPriority{RequestPriorityHigh, RequestPriorityNormal, RequestPriorityLow} {
+		if priority := <-started; priority != expected {
+			t.Errorf("Expected a %s priority transaction to be started, got %s", expected, priority)
+		}
+	}
+	metrics := tm.GetMetrics()
+	if metrics.StartedRequests != 4 || metrics.MaxWaitTime <= 0 || metrics.GetAverageWaitTime() > metrics.MaxWaitTime {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestRequestTransactionManager_RateLimit(t *testing.T) {
+	tm := NewRequestTransactionManager(10, WithRateLimit(50, 2))
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := tm.Execute(context.Background(), RequestPriorityNormal, func(_ context.Context) error { return nil }); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// The first two are covered by the burst, the other two have to wait 20ms each
+	if elapsed := time.Since(start); elapsed < time.Millisecond*35 {
+		t.Errorf("Expected the rate limit to delay the transactions, took only %v", elapsed)
+	}
+}
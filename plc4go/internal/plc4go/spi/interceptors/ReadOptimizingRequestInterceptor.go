@@ -0,0 +1,351 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package interceptors
+
+import (
+	"fmt"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sort"
+)
+
+// FieldBlock is the range of bytes a field occupies in one memory area of the PLC
+type FieldBlock struct {
+	// Fields can only be read together, if they are located in the same area
+	Area string
+	// Offset of the first byte within the area
+	Start uint32
+	// Number of bytes
+	Size uint32
+}
+
+func (m FieldBlock) end() uint32 {
+	return m.Start + m.Size
+}
+
+// BlockReadSupport is implemented by drivers, which are able to read a range of bytes at once and to decode the values
+// of single fields from it.
+type BlockReadSupport interface {
+	// GetFieldBlock returns the bytes occupied by the given field (false, if the field can't be read as part of a block)
+	GetFieldBlock(field apiModel.PlcField) (FieldBlock, bool)
+	// GetMaxBlockSize returns the maximum number of bytes which can be read with one block read in the given area
+	GetMaxBlockSize(area string) uint32
+	// NewBlockField creates a field reading the raw bytes of the given block, which contains the given field
+	NewBlockField(field apiModel.PlcField, block FieldBlock) apiModel.PlcField
+	// DecodeFieldValue decodes the value of a field from the bytes it occupies
+	DecodeFieldValue(field apiModel.PlcField, data []byte) (values.PlcValue, error)
+}
+
+// RequestSizeSupport is implemented by the BlockReadSupport of drivers, whose requests and responses are limited in
+// size (like by a negotiated PDU size). The items are then spread over as many sub-requests as needed to stay within it.
+type RequestSizeSupport interface {
+	// GetMaxRequestSize returns the maximum number of bytes of a request as well as of its response
+	GetMaxRequestSize() uint32
+	// GetRequestOverhead returns the number of bytes of a request and of its response, which don't belong to any item
+	GetRequestOverhead() (requestSize uint32, responseSize uint32)
+	// GetItemSize returns the number of bytes reading the given field occupies in a request and in its response
+	GetItemSize(field apiModel.PlcField) (requestSize uint32, responseSize uint32)
+}
+
+// ReadOptimizingRequestInterceptor merges fields located next to each other into block reads, so reading lots of
+// consecutive fields doesn't result in one round trip per field. Fields which can't be merged are read as they are.
+type ReadOptimizingRequestInterceptor struct {
+	blockReadSupport BlockReadSupport
+	// Maximum number of fields (or blocks) per sub-request (0 for no limit)
+	maxItemsPerRequest int
+	logger             zerolog.Logger
+}
+
+func NewReadOptimizingRequestInterceptor(blockReadSupport BlockReadSupport, maxItemsPerRequest int, logger zerolog.Logger) ReadOptimizingRequestInterceptor {
+	return ReadOptimizingRequestInterceptor{
+		blockReadSupport:   blockReadSupport,
+		maxItemsPerRequest: maxItemsPerRequest,
+		logger:             logger,
+	}
+}
+
+// readItem is one field of a sub-request: Either a field of the original request or a block of several of them
+type readItem struct {
+	fieldName string
+	field     apiModel.PlcField
+	// Only set for blocks
+	block        FieldBlock
+	members      []string
+	memberBlocks map[string]FieldBlock
+}
+
+func (m readItem) isBlock() bool {
+	return len(m.members) > 0
+}
+
+// plan calculates which items are read with which sub-request. As it only depends on the request, it is calculated
+// again when processing the responses instead of being kept around.
+func (m ReadOptimizingRequestInterceptor) plan(readRequest apiModel.PlcReadRequest) [][]readItem {
+	type blockedField struct {
+		fieldName string
+		block     FieldBlock
+	}
+	var items []readItem
+	blockedFieldsByArea := map[string][]blockedField{}
+	var areas []string
+	for _, fieldName := range readRequest.GetFieldNames() {
+		field := readRequest.GetField(fieldName)
+		block, ok := m.blockReadSupport.GetFieldBlock(field)
+		if !ok {
+			items = append(items, readItem{fieldName: fieldName, field: field})
+			continue
+		}
+		if _, ok := blockedFieldsByArea[block.Area]; !ok {
+			areas = append(areas, block.Area)
+		}
+		blockedFieldsByArea[block.Area] = append(blockedFieldsByArea[block.Area], blockedField{fieldName, block})
+	}
+
+	blockNames := newBlockNames(readRequest.GetFieldNames())
+	for _, area := range areas {
+		blockedFields := blockedFieldsByArea[area]
+		sort.SliceStable(blockedFields, func(i, j int) bool {
+			return blockedFields[i].block.Start < blockedFields[j].block.Start
+		})
+		maxBlockSize := m.blockReadSupport.GetMaxBlockSize(area)
+		var current *readItem
+		for _, blockedField := range blockedFields {
+			// Merge the field into the current block, as long as they are contiguous or overlap and the block doesn't get too big
+			if current != nil && blockedField.block.Start <= current.block.end() {
+				end := current.block.end()
+				if blockedField.block.end() > end {
+					end = blockedField.block.end()
+				}
+				if end-current.block.Start <= maxBlockSize {
+					current.block.Size = end - current.block.Start
+					current.members = append(current.members, blockedField.fieldName)
+					current.memberBlocks[blockedField.fieldName] = blockedField.block
+					continue
+				}
+			}
+			if current != nil {
+				items = append(items, m.finishBlock(readRequest, *current, blockNames))
+			}
+			current = &readItem{
+				block:        blockedField.block,
+				members:      []string{blockedField.fieldName},
+				memberBlocks: map[string]FieldBlock{blockedField.fieldName: blockedField.block},
+			}
+		}
+		if current != nil {
+			items = append(items, m.finishBlock(readRequest, *current, blockNames))
+		}
+	}
+
+	return m.split(items)
+}
+
+// split spreads the items over sub-requests, respecting the maximum number of items and the maximum size of the
+// requests and their responses. Items too big on their own get a sub-request of their own.
+func (m ReadOptimizingRequestInterceptor) split(items []readItem) [][]readItem {
+	requestSizeSupport, sizeLimited := m.blockReadSupport.(RequestSizeSupport)
+	var subRequestItems [][]readItem
+	var current []readItem
+	var requestSize, responseSize uint32
+	for _, item := range items {
+		var itemRequestSize, itemResponseSize uint32
+		if sizeLimited {
+			itemRequestSize, itemResponseSize = requestSizeSupport.GetItemSize(item.field)
+		}
+		if len(current) > 0 {
+			full := m.maxItemsPerRequest > 0 && len(current) >= m.maxItemsPerRequest
+			if sizeLimited {
+				maxRequestSize := requestSizeSupport.GetMaxRequestSize()
+				full = full || requestSize+itemRequestSize > maxRequestSize || responseSize+itemResponseSize > maxRequestSize
+			}
+			if full {
+				subRequestItems = append(subRequestItems, current)
+				current = nil
+			}
+		}
+		if len(current) == 0 && sizeLimited {
+			requestSize, responseSize = requestSizeSupport.GetRequestOverhead()
+		}
+		current = append(current, item)
+		requestSize += itemRequestSize
+		responseSize += itemResponseSize
+	}
+	if len(current) > 0 {
+		subRequestItems = append(subRequestItems, current)
+	}
+	return subRequestItems
+}
+
+// finishBlock creates the field for reading a block. Blocks containing only one field simply read that field.
+func (m ReadOptimizingRequestInterceptor) finishBlock(readRequest apiModel.PlcReadRequest, item readItem, blockNames *blockNames) readItem {
+	firstField := readRequest.GetField(item.members[0])
+	if len(item.members) == 1 {
+		return readItem{fieldName: item.members[0], field: firstField}
+	}
+	item.fieldName = blockNames.next()
+	item.field = m.blockReadSupport.NewBlockField(firstField, item.block)
+	return item
+}
+
+func (m ReadOptimizingRequestInterceptor) InterceptReadRequest(readRequest apiModel.PlcReadRequest) []apiModel.PlcReadRequest {
+	subRequestItems := m.plan(readRequest)
+	// If nothing could be merged, there's nothing to optimize
+	if len(subRequestItems) == 1 && len(subRequestItems[0]) == len(readRequest.GetFieldNames()) {
+		m.logger.Debug().Msg("Nothing to merge, no optimization required")
+		return []apiModel.PlcReadRequest{readRequest}
+	}
+	defaultReadRequest := readRequest.(model.DefaultPlcReadRequest)
+	var readRequests []apiModel.PlcReadRequest
+	for _, items := range subRequestItems {
+		fields := map[string]apiModel.PlcField{}
+		var fieldNames []string
+		for _, item := range items {
+			if item.isBlock() {
+				m.logger.Debug().Strs("fieldNames", item.members).Msgf("Reading fields as block %s", item.fieldName)
+			}
+			fields[item.fieldName] = item.field
+			fieldNames = append(fieldNames, item.fieldName)
+		}
+		subReadRequest := model.NewDefaultPlcReadRequest(
+			fields,
+			fieldNames,
+			defaultReadRequest.Reader,
+			defaultReadRequest.ReadRequestInterceptor)
+		subReadRequest.Timeout = defaultReadRequest.Timeout
+		subReadRequest.Retries = defaultReadRequest.Retries
+		readRequests = append(readRequests, subReadRequest)
+	}
+	return readRequests
+}
+
+func (m ReadOptimizingRequestInterceptor) ProcessReadResponses(readRequest apiModel.PlcReadRequest, readResults []apiModel.PlcReadRequestResult) apiModel.PlcReadRequestResult {
+	subRequestItems := m.plan(readRequest)
+	if len(subRequestItems) == 1 && len(subRequestItems[0]) == len(readRequest.GetFieldNames()) && len(readResults) == 1 {
+		m.logger.Debug().Msg("Nothing merged, no slicing required")
+		return readResults[0]
+	}
+	if len(subRequestItems) != len(readResults) {
+		return apiModel.PlcReadRequestResult{
+			Request: readRequest,
+			Err:     errors.Errorf("expected %d results, got %d", len(subRequestItems), len(readResults)),
+		}
+	}
+	m.logger.Trace().Msg("Slicing blocks")
+	responseCodes := map[string]apiModel.PlcResponseCode{}
+	val := map[string]values.PlcValue{}
+	var errs []error
+	for i, readResult := range readResults {
+		if readResult.Err != nil {
+			m.logger.Debug().Err(readResult.Err).Msgf("Error during read")
+			errs = append(errs, readResult.Err)
+		}
+		for _, item := range subRequestItems[i] {
+			fieldNames := []string{item.fieldName}
+			if item.isBlock() {
+				fieldNames = item.members
+			}
+			if readResult.Err != nil || readResult.Response == nil {
+				// Still report every field of the failed sub-request
+				for _, fieldName := range fieldNames {
+					responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
+					val[fieldName] = nil
+				}
+				continue
+			}
+			responseCode := readResult.Response.GetResponseCode(item.fieldName)
+			value := readResult.Response.GetValue(item.fieldName)
+			if !item.isBlock() {
+				responseCodes[item.fieldName] = responseCode
+				val[item.fieldName] = value
+				continue
+			}
+			if responseCode != apiModel.PlcResponseCode_OK || value == nil {
+				for _, fieldName := range fieldNames {
+					responseCodes[fieldName] = responseCode
+					val[fieldName] = nil
+				}
+				continue
+			}
+			data := blockBytes(value)
+			for _, fieldName := range fieldNames {
+				memberBlock := item.memberBlocks[fieldName]
+				offset := memberBlock.Start - item.block.Start
+				if offset+memberBlock.Size > uint32(len(data)) {
+					m.logger.Error().Str("fieldName", fieldName).Msgf("Block %s is too short", item.fieldName)
+					responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
+					val[fieldName] = nil
+					continue
+				}
+				fieldValue, err := m.blockReadSupport.DecodeFieldValue(readRequest.GetField(fieldName), data[offset:offset+memberBlock.Size])
+				if err != nil {
+					m.logger.Error().Err(err).Str("fieldName", fieldName).Msg("Error decoding field value")
+					responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
+					val[fieldName] = nil
+					continue
+				}
+				responseCodes[fieldName] = apiModel.PlcResponseCode_OK
+				val[fieldName] = fieldValue
+			}
+		}
+	}
+	return apiModel.PlcReadRequestResult{
+		Request:  readRequest,
+		Response: model.NewDefaultPlcReadResponse(readRequest, responseCodes, val),
+		Err:      aggregateErrors(errs),
+	}
+}
+
+// blockBytes returns the raw bytes of a block value. Drivers not able to return them as is return a list of bytes.
+func blockBytes(value values.PlcValue) []byte {
+	if raw := value.GetRaw(); raw != nil {
+		return raw
+	}
+	var data []byte
+	for _, item := range value.GetList() {
+		data = append(data, item.GetUint8())
+	}
+	return data
+}
+
+// blockNames hands out names for the block fields, which don't clash with the names of the requested fields
+type blockNames struct {
+	fieldNames map[string]bool
+	counter    int
+}
+
+func newBlockNames(fieldNames []string) *blockNames {
+	names := &blockNames{fieldNames: map[string]bool{}}
+	for _, fieldName := range fieldNames {
+		names.fieldNames[fieldName] = true
+	}
+	return names
+}
+
+func (m *blockNames) next() string {
+	for {
+		m.counter++
+		name := fmt.Sprintf("block-%d", m.counter)
+		if !m.fieldNames[name] {
+			return name
+		}
+	}
+}
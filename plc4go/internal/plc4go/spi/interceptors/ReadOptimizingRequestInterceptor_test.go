@@ -0,0 +1,266 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package interceptors
+
+import (
+	"fmt"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	spiValues "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"reflect"
+	"testing"
+)
+
+type testField struct {
+	// Fields without an area can't be merged
+	block FieldBlock
+}
+
+func (m testField) GetAddressString() string {
+	return fmt.Sprintf("%s:%d[%d]", m.block.Area, m.block.Start, m.block.Size)
+}
+
+func (m testField) GetTypeName() string {
+	return "BYTE"
+}
+
+func (m testField) GetQuantity() uint16 {
+	return uint16(m.block.Size)
+}
+
+// testBlockReadSupport merges fields of the areas "a" and "b" and decodes the values as raw bytes
+type testBlockReadSupport struct {
+}
+
+func (m testBlockReadSupport) GetFieldBlock(field apiModel.PlcField) (FieldBlock, bool) {
+	block := field.(testField).block
+	return block, block.Area != ""
+}
+
+func (m testBlockReadSupport) GetMaxBlockSize(_ string) uint32 {
+	return 10
+}
+
+func (m testBlockReadSupport) NewBlockField(_ apiModel.PlcField, block FieldBlock) apiModel.PlcField {
+	return testField{block: block}
+}
+
+func (m testBlockReadSupport) DecodeFieldValue(_ apiModel.PlcField, data []byte) (values.PlcValue, error) {
+	return spiValues.NewPlcByteArray(data), nil
+}
+
+// sizeLimitedBlockReadSupport limits requests to 20 bytes, of which 2 are the header and 1 (request) or 2 plus the
+// data (response) belong to each item
+type sizeLimitedBlockReadSupport struct {
+	testBlockReadSupport
+}
+
+func (m sizeLimitedBlockReadSupport) GetMaxRequestSize() uint32 {
+	return 20
+}
+
+func (m sizeLimitedBlockReadSupport) GetRequestOverhead() (uint32, uint32) {
+	return 2, 2
+}
+
+func (m sizeLimitedBlockReadSupport) GetItemSize(field apiModel.PlcField) (uint32, uint32) {
+	return 1, 2 + field.(testField).block.Size
+}
+
+func newTestReadRequest(fields map[string]apiModel.PlcField, fieldNames []string) model.DefaultPlcReadRequest {
+	return model.NewDefaultPlcReadRequest(fields, fieldNames, nil, nil)
+}
+
+func TestReadOptimizingRequestInterceptor_Merge(t *testing.T) {
+	interceptor := NewReadOptimizingRequestInterceptor(testBlockReadSupport{}, 1, zerolog.Nop())
+	readRequest := newTestReadRequest(map[string]apiModel.PlcField{
+		"first":        testField{block: FieldBlock{Area: "a", Start: 4, Size: 2}},
+		"second":       testField{block: FieldBlock{Area: "a", Start: 0, Size: 4}},
+		"overlapping":  testField{block: FieldBlock{Area: "a", Start: 5, Size: 3}},
+		"far-away":     testField{block: FieldBlock{Area: "a", Start: 20, Size: 2}},
+		"other-area":   testField{block: FieldBlock{Area: "b", Start: 8, Size: 2}},
+		"not-mergable": testField{},
+		// Would exceed the maximum block size, if merged with the first three fields
+		"too-big": testField{block: FieldBlock{Area: "a", Start: 8, Size: 4}},
+	}, []string{"first", "second", "overlapping", "far-away", "other-area", "not-mergable", "too-big"})
+
+	subRequests := interceptor.InterceptReadRequest(readRequest)
+	var blocks []FieldBlock
+	var subResults []apiModel.PlcReadRequestResult
+	for _, subRequest := range subRequests {
+		if len(subRequest.GetFieldNames()) != 1 {
+			t.Fatalf("Expected a single field per sub-request, got %v", subRequest.GetFieldNames())
+		}
+		fieldName := subRequest.GetFieldNames()[0]
+		field := subRequest.GetField(fieldName).(testField)
+		blocks = append(blocks, field.block)
+		// Every byte contains its own offset, so the slicing can be checked
+		var data []byte
+		for i := field.block.Start; i < field.block.Start+field.block.Size; i++ {
+			data = append(data, byte(i))
+		}
+		value := spiValues.NewPlcByteArray(data)
+		subResults = append(subResults, apiModel.PlcReadRequestResult{
+			Request: subRequest,
+			Response: model.NewDefaultPlcReadResponse(subRequest,
+				map[string]apiModel.PlcResponseCode{fieldName: apiModel.PlcResponseCode_OK},
+				map[string]values.PlcValue{fieldName: value}),
+		})
+	}
+	expectedBlocks := []FieldBlock{
+		{},
+		{Area: "a", Start: 0, Size: 8},
+		{Area: "a", Start: 8, Size: 4},
+		{Area: "a", Start: 20, Size: 2},
+		{Area: "b", Start: 8, Size: 2},
+	}
+	if !reflect.DeepEqual(blocks, expectedBlocks) {
+		t.Fatalf("Expected blocks %v, got %v", expectedBlocks, blocks)
+	}
+
+	result := interceptor.ProcessReadResponses(readRequest, subResults)
+	if result.Err != nil {
+		t.Fatalf("Unexpected error %v", result.Err)
+	}
+	expectedValues := map[string][]byte{
+		"first":       {4, 5},
+		"second":      {0, 1, 2, 3},
+		"overlapping": {5, 6, 7},
+		"far-away":    {20, 21},
+		"other-area":  {8, 9},
+		"too-big":     {8, 9, 10, 11},
+	}
+	for fieldName, expectedValue := range expectedValues {
+		if responseCode := result.Response.GetResponseCode(fieldName); responseCode != apiModel.PlcResponseCode_OK {
+			t.Errorf("Expected OK for field %s, got %v", fieldName, responseCode)
+		}
+		if value := result.Response.GetValue(fieldName).GetRaw(); !reflect.DeepEqual(value, expectedValue) {
+			t.Errorf("Expected %v for field %s, got %v", expectedValue, fieldName, value)
+		}
+	}
+}
+
+func TestReadOptimizingRequestInterceptor_MultipleItemsPerRequest(t *testing.T) {
+	interceptor := NewReadOptimizingRequestInterceptor(testBlockReadSupport{}, 0, zerolog.Nop())
+	readRequest := newTestReadRequest(map[string]apiModel.PlcField{
+		"first":      testField{block: FieldBlock{Area: "a", Start: 0, Size: 2}},
+		"second":     testField{block: FieldBlock{Area: "a", Start: 2, Size: 2}},
+		"other-area": testField{block: FieldBlock{Area: "b", Start: 0, Size: 2}},
+	}, []string{"first", "second", "other-area"})
+
+	subRequests := interceptor.InterceptReadRequest(readRequest)
+	if len(subRequests) != 1 || len(subRequests[0].GetFieldNames()) != 2 {
+		t.Fatalf("Expected one sub-request with a block and a field, got %v", subRequests)
+	}
+}
+
+func TestReadOptimizingRequestInterceptor_RequestSizeLimit(t *testing.T) {
+	interceptor := NewReadOptimizingRequestInterceptor(sizeLimitedBlockReadSupport{}, 0, zerolog.Nop())
+	readRequest := newTestReadRequest(map[string]apiModel.PlcField{
+		"first":        testField{block: FieldBlock{Area: "a", Start: 0, Size: 4}},
+		"second":       testField{block: FieldBlock{Area: "a", Start: 4, Size: 4}},
+		"other-area":   testField{block: FieldBlock{Area: "b", Start: 0, Size: 6}},
+		"not-mergable": testField{block: FieldBlock{Size: 2}},
+	}, []string{"first", "second", "other-area", "not-mergable"})
+
+	// The not mergable field (2 bytes) and the block (8 bytes) fit into one response, the other area (6 bytes) doesn't
+	subRequests := interceptor.InterceptReadRequest(readRequest)
+	var sizes [][]uint32
+	for _, subRequest := range subRequests {
+		var fieldSizes []uint32
+		for _, fieldName := range subRequest.GetFieldNames() {
+			fieldSizes = append(fieldSizes, subRequest.GetField(fieldName).(testField).block.Size)
+		}
+		sizes = append(sizes, fieldSizes)
+	}
+	expectedSizes := [][]uint32{{2, 8}, {6}}
+	if !reflect.DeepEqual(sizes, expectedSizes) {
+		t.Fatalf("Expected sub-requests with fields of %v bytes, got %v", expectedSizes, sizes)
+	}
+}
+
+func TestReadOptimizingRequestInterceptor_RequestSizeLimitWithoutMerging(t *testing.T) {
+	interceptor := NewReadOptimizingRequestInterceptor(sizeLimitedBlockReadSupport{}, 0, zerolog.Nop())
+	readRequest := newTestReadRequest(map[string]apiModel.PlcField{
+		"first":  testField{block: FieldBlock{Area: "a", Start: 0, Size: 8}},
+		"second": testField{block: FieldBlock{Area: "a", Start: 20, Size: 8}},
+	}, []string{"first", "second"})
+
+	// Nothing can be merged, but the fields don't fit into a single request
+	subRequests := interceptor.InterceptReadRequest(readRequest)
+	if len(subRequests) != 2 {
+		t.Fatalf("Expected 2 sub-requests, got %v", subRequests)
+	}
+}
+
+func TestReadOptimizingRequestInterceptor_NothingToMerge(t *testing.T) {
+	interceptor := NewReadOptimizingRequestInterceptor(testBlockReadSupport{}, 0, zerolog.Nop())
+	readRequest := newTestReadRequest(map[string]apiModel.PlcField{
+		"first":  testField{block: FieldBlock{Area: "a", Start: 0, Size: 2}},
+		"second": testField{block: FieldBlock{Area: "a", Start: 4, Size: 2}},
+	}, []string{"first", "second"})
+
+	subRequests := interceptor.InterceptReadRequest(readRequest)
+	if len(subRequests) != 1 || !reflect.DeepEqual(subRequests[0], readRequest) {
+		t.Fatalf("Expected the request to be passed on as it is, got %v", subRequests)
+	}
+}
+
+func TestReadOptimizingRequestInterceptor_BlockErrors(t *testing.T) {
+	interceptor := NewReadOptimizingRequestInterceptor(testBlockReadSupport{}, 1, zerolog.Nop())
+	readRequest := newTestReadRequest(map[string]apiModel.PlcField{
+		"first":  testField{block: FieldBlock{Area: "a", Start: 0, Size: 2}},
+		"second": testField{block: FieldBlock{Area: "a", Start: 2, Size: 2}},
+		"third":  testField{block: FieldBlock{Area: "b", Start: 0, Size: 2}},
+		"fourth": testField{block: FieldBlock{Area: "b", Start: 2, Size: 2}},
+	}, []string{"first", "second", "third", "fourth"})
+
+	subRequests := interceptor.InterceptReadRequest(readRequest)
+	if len(subRequests) != 2 {
+		t.Fatalf("Expected 2 sub-requests, got %d", len(subRequests))
+	}
+	blockName := subRequests[1].GetFieldNames()[0]
+	subResults := []apiModel.PlcReadRequestResult{
+		{Request: subRequests[0], Err: errors.New("error sending message")},
+		{
+			Request: subRequests[1],
+			Response: model.NewDefaultPlcReadResponse(subRequests[1],
+				map[string]apiModel.PlcResponseCode{blockName: apiModel.PlcResponseCode_ACCESS_DENIED},
+				map[string]values.PlcValue{blockName: nil}),
+		},
+	}
+	result := interceptor.ProcessReadResponses(readRequest, subResults)
+	if result.Err == nil {
+		t.Errorf("Expected the error of the failed block to be reported")
+	}
+	expectedCodes := map[string]apiModel.PlcResponseCode{
+		"first":  apiModel.PlcResponseCode_INTERNAL_ERROR,
+		"second": apiModel.PlcResponseCode_INTERNAL_ERROR,
+		"third":  apiModel.PlcResponseCode_ACCESS_DENIED,
+		"fourth": apiModel.PlcResponseCode_ACCESS_DENIED,
+	}
+	for fieldName, expectedCode := range expectedCodes {
+		if responseCode := result.Response.GetResponseCode(fieldName); responseCode != expectedCode {
+			t.Errorf("Expected %v for field %s, got %v", expectedCode, fieldName, responseCode)
+		}
+	}
+}
@@ -24,34 +24,39 @@ import (
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
 	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 )
 
 type SingleItemRequestInterceptor struct {
+	logger zerolog.Logger
 }
 
-func NewSingleItemRequestInterceptor() SingleItemRequestInterceptor {
-	return SingleItemRequestInterceptor{}
+func NewSingleItemRequestInterceptor(logger zerolog.Logger) SingleItemRequestInterceptor {
+	return SingleItemRequestInterceptor{
+		logger: logger,
+	}
 }
 
 func (m SingleItemRequestInterceptor) InterceptReadRequest(readRequest apiModel.PlcReadRequest) []apiModel.PlcReadRequest {
 	// If this request just has one field, go the shortcut
 	if len(readRequest.GetFieldNames()) == 1 {
-		log.Debug().Msg("We got only one request, no splitting required")
+		m.logger.Debug().Msg("We got only one request, no splitting required")
 		return []apiModel.PlcReadRequest{readRequest}
 	}
-	log.Trace().Msg("Splitting requests")
+	m.logger.Trace().Msg("Splitting requests")
 	// In all other cases, create a new read request containing only one item
 	defaultReadRequest := readRequest.(model.DefaultPlcReadRequest)
 	var readRequests []apiModel.PlcReadRequest
 	for _, fieldName := range readRequest.GetFieldNames() {
-		log.Debug().Str("fieldName", fieldName).Msg("Splitting into own request")
+		m.logger.Debug().Str("fieldName", fieldName).Msg("Splitting into own request")
 		field := readRequest.GetField(fieldName)
 		subReadRequest := model.NewDefaultPlcReadRequest(
 			map[string]apiModel.PlcField{fieldName: field},
 			[]string{fieldName},
 			defaultReadRequest.Reader,
 			defaultReadRequest.ReadRequestInterceptor)
+		subReadRequest.Timeout = defaultReadRequest.Timeout
+		subReadRequest.Retries = defaultReadRequest.Retries
 		readRequests = append(readRequests, subReadRequest)
 	}
 	return readRequests
@@ -59,26 +64,25 @@ func (m SingleItemRequestInterceptor) InterceptReadRequest(readRequest apiModel.
 
 func (m SingleItemRequestInterceptor) ProcessReadResponses(readRequest apiModel.PlcReadRequest, readResults []apiModel.PlcReadRequestResult) apiModel.PlcReadRequestResult {
 	if len(readResults) == 1 {
-		log.Debug().Msg("We got only one response, no merging required")
+		m.logger.Debug().Msg("We got only one response, no merging required")
 		return readResults[0]
 	}
-	log.Trace().Msg("Merging requests")
+	m.logger.Trace().Msg("Merging requests")
 	responseCodes := map[string]apiModel.PlcResponseCode{}
 	val := map[string]values.PlcValue{}
-	var err error = nil
+	var errs []error
 	for _, readResult := range readResults {
 		if readResult.Err != nil {
-			log.Debug().Err(readResult.Err).Msgf("Error during read")
-			if err == nil {
-				// Lazy initialization of multi error
-				err = utils.MultiError{MainError: errors.New("while aggregating results"), Errors: []error{readResult.Err}}
-			} else {
-				multiError := err.(utils.MultiError)
-				multiError.Errors = append(multiError.Errors, readResult.Err)
+			m.logger.Debug().Err(readResult.Err).Msgf("Error during read")
+			errs = append(errs, readResult.Err)
+			// Still report every field of the failed sub-request
+			for _, fieldName := range readResult.Request.GetFieldNames() {
+				responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
+				val[fieldName] = nil
 			}
 		} else if readResult.Response != nil {
 			if len(readResult.Response.GetRequest().GetFieldNames()) > 1 {
-				log.Fatal().Int("numberOfFields", len(readResult.Response.GetRequest().GetFieldNames())).Msg("We should only get 1")
+				m.logger.Fatal().Int("numberOfFields", len(readResult.Response.GetRequest().GetFieldNames())).Msg("We should only get 1")
 			}
 			for _, fieldName := range readResult.Response.GetRequest().GetFieldNames() {
 				responseCodes[fieldName] = readResult.Response.GetResponseCode(fieldName)
@@ -89,15 +93,69 @@ func (m SingleItemRequestInterceptor) ProcessReadResponses(readRequest apiModel.
 	return apiModel.PlcReadRequestResult{
 		Request:  readRequest,
 		Response: model.NewDefaultPlcReadResponse(readRequest, responseCodes, val),
-		Err:      err,
+		Err:      aggregateErrors(errs),
 	}
 }
 
 func (m SingleItemRequestInterceptor) InterceptWriteRequest(writeRequest apiModel.PlcWriteRequest) []apiModel.PlcWriteRequest {
-	return []apiModel.PlcWriteRequest{writeRequest}
+	// If this request just has one field, go the shortcut
+	if len(writeRequest.GetFieldNames()) == 1 {
+		m.logger.Debug().Msg("We got only one request, no splitting required")
+		return []apiModel.PlcWriteRequest{writeRequest}
+	}
+	m.logger.Trace().Msg("Splitting requests")
+	// In all other cases, create a new write request containing only one item
+	defaultWriteRequest := writeRequest.(model.DefaultPlcWriteRequest)
+	var writeRequests []apiModel.PlcWriteRequest
+	for _, fieldName := range writeRequest.GetFieldNames() {
+		m.logger.Debug().Str("fieldName", fieldName).Msg("Splitting into own request")
+		field := writeRequest.GetField(fieldName)
+		value := writeRequest.GetValue(fieldName)
+		subWriteRequest := model.NewDefaultPlcWriteRequest(
+			map[string]apiModel.PlcField{fieldName: field},
+			[]string{fieldName},
+			map[string]values.PlcValue{fieldName: value},
+			defaultWriteRequest.Writer,
+			defaultWriteRequest.WriteRequestInterceptor)
+		subWriteRequest.Timeout = defaultWriteRequest.Timeout
+		writeRequests = append(writeRequests, subWriteRequest)
+	}
+	return writeRequests
+}
+
+func (m SingleItemRequestInterceptor) ProcessWriteResponses(writeRequest apiModel.PlcWriteRequest, writeResults []apiModel.PlcWriteRequestResult) apiModel.PlcWriteRequestResult {
+	if len(writeResults) == 1 {
+		m.logger.Debug().Msg("We got only one response, no merging required")
+		return writeResults[0]
+	}
+	m.logger.Trace().Msg("Merging requests")
+	responseCodes := map[string]apiModel.PlcResponseCode{}
+	var errs []error
+	for _, writeResult := range writeResults {
+		if writeResult.Err != nil {
+			m.logger.Debug().Err(writeResult.Err).Msgf("Error during write")
+			errs = append(errs, writeResult.Err)
+			// Still report every field of the failed sub-request
+			for _, fieldName := range writeResult.Request.GetFieldNames() {
+				responseCodes[fieldName] = apiModel.PlcResponseCode_INTERNAL_ERROR
+			}
+		} else if writeResult.Response != nil {
+			for _, fieldName := range writeResult.Response.GetRequest().GetFieldNames() {
+				responseCodes[fieldName] = writeResult.Response.GetResponseCode(fieldName)
+			}
+		}
+	}
+	return apiModel.PlcWriteRequestResult{
+		Request:  writeRequest,
+		Response: model.NewDefaultPlcWriteResponse(writeRequest, responseCodes),
+		Err:      aggregateErrors(errs),
+	}
 }
 
-func (m SingleItemRequestInterceptor) ProcessWriteResponses(writeRequest apiModel.PlcWriteRequest, writeResponses []apiModel.PlcWriteRequestResult) apiModel.PlcWriteRequestResult {
-	// TODO: unfinished implementation
-	return apiModel.PlcWriteRequestResult{}
+// aggregateErrors combines the errors of all sub-requests into one (nil if there were none)
+func aggregateErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return utils.MultiError{MainError: errors.New("while aggregating results"), Errors: errs}
 }
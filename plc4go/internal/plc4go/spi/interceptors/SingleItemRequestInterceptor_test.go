@@ -0,0 +1,97 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package interceptors
+
+import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	apiModel "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"testing"
+)
+
+func TestSingleItemRequestInterceptor_Write(t *testing.T) {
+	interceptor := NewSingleItemRequestInterceptor(zerolog.Nop())
+	writeRequest := model.NewDefaultPlcWriteRequest(
+		map[string]apiModel.PlcField{"a": nil, "b": nil, "c": nil},
+		[]string{"a", "b", "c"},
+		map[string]values.PlcValue{"a": nil, "b": nil, "c": nil},
+		nil,
+		interceptor)
+
+	subRequests := interceptor.InterceptWriteRequest(writeRequest)
+	if len(subRequests) != 3 {
+		t.Fatalf("Expected 3 sub-requests, got %d", len(subRequests))
+	}
+	var subResults []apiModel.PlcWriteRequestResult
+	for i, subRequest := range subRequests {
+		if fieldNames := subRequest.GetFieldNames(); len(fieldNames) != 1 || fieldNames[0] != writeRequest.GetFieldNames()[i] {
+			t.Fatalf("Unexpected fields of sub-request %d: %v", i, fieldNames)
+		}
+		if i == 0 {
+			subResults = append(subResults, apiModel.PlcWriteRequestResult{
+				Request:  subRequest,
+				Response: model.NewDefaultPlcWriteResponse(subRequest, map[string]apiModel.PlcResponseCode{"a": apiModel.PlcResponseCode_OK}),
+			})
+		} else {
+			subResults = append(subResults, apiModel.PlcWriteRequestResult{Request: subRequest, Err: errors.New("error sending message")})
+		}
+	}
+
+	result := interceptor.ProcessWriteResponses(writeRequest, subResults)
+	expectedCodes := map[string]apiModel.PlcResponseCode{
+		"a": apiModel.PlcResponseCode_OK,
+		"b": apiModel.PlcResponseCode_INTERNAL_ERROR,
+		"c": apiModel.PlcResponseCode_INTERNAL_ERROR,
+	}
+	for fieldName, expectedCode := range expectedCodes {
+		if responseCode := result.Response.GetResponseCode(fieldName); responseCode != expectedCode {
+			t.Errorf("Expected %v for field %s, got %v", expectedCode, fieldName, responseCode)
+		}
+	}
+	// Every error has to be kept, not only the first one
+	multiError, ok := result.Err.(utils.MultiError)
+	if !ok || len(multiError.Errors) != 2 {
+		t.Errorf("Expected a MultiError with 2 errors, got %v", result.Err)
+	}
+}
+
+func TestSingleItemRequestInterceptor_ReadErrors(t *testing.T) {
+	interceptor := NewSingleItemRequestInterceptor(zerolog.Nop())
+	readRequest := model.NewDefaultPlcReadRequest(
+		map[string]apiModel.PlcField{"a": nil, "b": nil},
+		[]string{"a", "b"},
+		nil,
+		interceptor)
+
+	var subResults []apiModel.PlcReadRequestResult
+	for _, subRequest := range interceptor.InterceptReadRequest(readRequest) {
+		subResults = append(subResults, apiModel.PlcReadRequestResult{Request: subRequest, Err: errors.New("error sending message")})
+	}
+	result := interceptor.ProcessReadResponses(readRequest, subResults)
+	multiError, ok := result.Err.(utils.MultiError)
+	if !ok || len(multiError.Errors) != 2 {
+		t.Errorf("Expected a MultiError with 2 errors, got %v", result.Err)
+	}
+	if responseCode := result.Response.GetResponseCode("b"); responseCode != apiModel.PlcResponseCode_INTERNAL_ERROR {
+		t.Errorf("Expected INTERNAL_ERROR for field b, got %v", responseCode)
+	}
+}
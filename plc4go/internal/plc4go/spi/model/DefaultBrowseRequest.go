@@ -19,6 +19,7 @@
 package model
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 )
@@ -65,11 +66,19 @@ func (d DefaultPlcBrowseRequest) GetQueryString(name string) string {
 }
 
 func (d DefaultPlcBrowseRequest) Execute() <-chan model.PlcBrowseRequestResult {
-	return d.browser.Browse(d)
+	return d.ExecuteWithContext(context.Background())
+}
+
+func (d DefaultPlcBrowseRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcBrowseRequestResult {
+	return d.browser.Browse(ctx, d)
 }
 
 func (d DefaultPlcBrowseRequest) ExecuteWithInterceptor(interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult {
-	return d.browser.BrowseWithInterceptor(d, interceptor)
+	return d.ExecuteWithInterceptorWithContext(context.Background(), interceptor)
+}
+
+func (d DefaultPlcBrowseRequest) ExecuteWithInterceptorWithContext(ctx context.Context, interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult {
+	return d.browser.BrowseWithInterceptor(ctx, d, interceptor)
 }
 
 type DefaultPlcBrowseResponse struct {
@@ -19,6 +19,7 @@
 package model
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
@@ -33,6 +34,8 @@ type DefaultPlcReadRequestBuilder struct {
 	queryNames             []string
 	fields                 map[string]model.PlcField
 	fieldNames             []string
+	timeout                time.Duration
+	retries                int
 	readRequestInterceptor ReadRequestInterceptor
 }
 
@@ -48,6 +51,7 @@ func NewDefaultPlcReadRequestBuilderWithInterceptor(fieldHandler spi.PlcFieldHan
 		queryNames:             make([]string, 0),
 		fields:                 map[string]model.PlcField{},
 		fieldNames:             make([]string, 0),
+		retries:                -1,
 		readRequestInterceptor: readRequestInterceptor,
 	}
 }
@@ -62,6 +66,14 @@ func (m *DefaultPlcReadRequestBuilder) AddField(name string, field model.PlcFiel
 	m.fields[name] = field
 }
 
+func (m *DefaultPlcReadRequestBuilder) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
+func (m *DefaultPlcReadRequestBuilder) SetRetries(retries int) {
+	m.retries = retries
+}
+
 func (m *DefaultPlcReadRequestBuilder) Build() (model.PlcReadRequest, error) {
 	for _, name := range m.queryNames {
 		query := m.queries[name]
@@ -74,6 +86,8 @@ func (m *DefaultPlcReadRequestBuilder) Build() (model.PlcReadRequest, error) {
 	return DefaultPlcReadRequest{
 		Fields:                 m.fields,
 		FieldNames:             m.fieldNames,
+		Timeout:                m.timeout,
+		Retries:                m.retries,
 		Reader:                 m.reader,
 		ReadRequestInterceptor: m.readRequestInterceptor,
 	}, nil
@@ -84,41 +98,46 @@ type DefaultPlcReadRequest struct {
 	FieldNames             []string
 	Reader                 spi.PlcReader
 	ReadRequestInterceptor ReadRequestInterceptor
+
+	// Overrides of the connection defaults (0 and -1 for using the defaults)
+	Timeout time.Duration
+	Retries int
 }
 
 func NewDefaultPlcReadRequest(fields map[string]model.PlcField, fieldNames []string, reader spi.PlcReader, readRequestInterceptor ReadRequestInterceptor) DefaultPlcReadRequest {
 	return DefaultPlcReadRequest{
 		Fields:                 fields,
 		FieldNames:             fieldNames,
+		Retries:                -1,
 		Reader:                 reader,
 		ReadRequestInterceptor: readRequestInterceptor,
 	}
 }
 
 func (m DefaultPlcReadRequest) Execute() <-chan model.PlcReadRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m DefaultPlcReadRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcReadRequestResult {
 	// Shortcut, if no interceptor is defined
 	if m.ReadRequestInterceptor == nil {
-		return m.Reader.Read(m)
+		return m.Reader.Read(ctx, m)
 	}
 
 	// Split the requests up into multiple ones.
 	readRequests := m.ReadRequestInterceptor.InterceptReadRequest(m)
-	// Shortcut for single-request-requests
-	if len(readRequests) == 1 {
-		return m.Reader.Read(readRequests[0])
-	}
+	// Even a single sub-request has to be processed, as the interceptor might have changed its fields
 	// Create a sub-result-channel slice
 	var subResultChannels []<-chan model.PlcReadRequestResult
 
-	// Iterate over all requests and add the result-channels to the list
+	// Iterate over all requests and add the result-channels to the list (the request queue of the connection decides
+	// when each of them is sent)
 	for _, subRequest := range readRequests {
-		subResultChannels = append(subResultChannels, m.Reader.Read(subRequest))
-		// TODO: Replace this with a real queueing of requests. Later on we need throttling. At the moment this avoids race condition as the read above writes to fast on the line which is a problem for the test
-		time.Sleep(time.Millisecond * 4)
+		subResultChannels = append(subResultChannels, m.Reader.Read(ctx, subRequest))
 	}
 
 	// Create a new result-channel, which completes as soon as all sub-result-channels have returned
-	resultChannel := make(chan model.PlcReadRequestResult)
+	resultChannel := make(chan model.PlcReadRequestResult, 1)
 	go func() {
 		var subResults []model.PlcReadRequestResult
 		// Iterate over all sub-results
@@ -146,6 +165,14 @@ func (m DefaultPlcReadRequest) GetField(name string) model.PlcField {
 	return nil
 }
 
+func (m DefaultPlcReadRequest) GetTimeout() time.Duration {
+	return m.Timeout
+}
+
+func (m DefaultPlcReadRequest) GetRetries() int {
+	return m.Retries
+}
+
 func (m DefaultPlcReadRequest) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: "PlcReadRequest"}}); err != nil {
 		return err
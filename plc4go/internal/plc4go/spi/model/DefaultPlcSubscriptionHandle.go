@@ -0,0 +1,56 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import (
+	"fmt"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+)
+
+type DefaultPlcSubscriptionHandle struct {
+	subscriber     spi.PlcSubscriber
+	subscriptionId uint64
+	fieldName      string
+}
+
+func NewDefaultPlcSubscriptionHandle(subscriber spi.PlcSubscriber, subscriptionId uint64, fieldName string) *DefaultPlcSubscriptionHandle {
+	return &DefaultPlcSubscriptionHandle{
+		subscriber:     subscriber,
+		subscriptionId: subscriptionId,
+		fieldName:      fieldName,
+	}
+}
+
+// GetSubscriber returns the subscriber responsible for this subscription
+func (m *DefaultPlcSubscriptionHandle) GetSubscriber() spi.PlcSubscriber {
+	return m.subscriber
+}
+
+// GetSubscriptionId returns the id the subscriber assigned to the subscription request this handle belongs to
+func (m *DefaultPlcSubscriptionHandle) GetSubscriptionId() uint64 {
+	return m.subscriptionId
+}
+
+func (m *DefaultPlcSubscriptionHandle) GetFieldName() string {
+	return m.fieldName
+}
+
+func (m *DefaultPlcSubscriptionHandle) String() string {
+	return fmt.Sprintf("PlcSubscriptionHandle{subscriptionId: %d, fieldName: %s}", m.subscriptionId, m.fieldName)
+}
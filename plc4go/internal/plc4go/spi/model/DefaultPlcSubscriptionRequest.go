@@ -19,6 +19,7 @@
 package model
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
@@ -45,6 +46,7 @@ type DefaultPlcSubscriptionRequestBuilder struct {
 	fieldNames   []string
 	types        map[string]SubscriptionType
 	intervals    map[string]time.Duration
+	timeout      time.Duration
 }
 
 func NewDefaultPlcSubscriptionRequestBuilder(fieldHandler spi.PlcFieldHandler, valueHandler spi.PlcValueHandler, subscriber spi.PlcSubscriber) *DefaultPlcSubscriptionRequestBuilder {
@@ -102,6 +104,10 @@ func (m *DefaultPlcSubscriptionRequestBuilder) AddItemHandler(eventHandler model
 	m.eventHandler = eventHandler
 }
 
+func (m *DefaultPlcSubscriptionRequestBuilder) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
 func (m *DefaultPlcSubscriptionRequestBuilder) Build() (model.PlcSubscriptionRequest, error) {
 	for _, name := range m.queryNames {
 		query := m.queries[name]
@@ -117,6 +123,7 @@ func (m *DefaultPlcSubscriptionRequestBuilder) Build() (model.PlcSubscriptionReq
 		fieldNames:   m.fieldNames,
 		types:        m.types,
 		intervals:    m.intervals,
+		timeout:      m.timeout,
 		subscriber:   m.subscriber,
 		eventHandler: m.eventHandler,
 	}, nil
@@ -127,12 +134,17 @@ type DefaultPlcSubscriptionRequest struct {
 	fieldNames   []string
 	types        map[string]SubscriptionType
 	intervals    map[string]time.Duration
+	timeout      time.Duration
 	eventHandler model.PlcSubscriptionEventHandler
 	subscriber   spi.PlcSubscriber
 }
 
 func (m DefaultPlcSubscriptionRequest) Execute() <-chan model.PlcSubscriptionRequestResult {
-	return m.subscriber.Subscribe(m)
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m DefaultPlcSubscriptionRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcSubscriptionRequestResult {
+	return m.subscriber.Subscribe(ctx, m)
 }
 
 func (m DefaultPlcSubscriptionRequest) GetFieldNames() []string {
@@ -155,6 +167,10 @@ func (m DefaultPlcSubscriptionRequest) GetInterval(name string) time.Duration {
 	return m.intervals[name]
 }
 
+func (m DefaultPlcSubscriptionRequest) GetTimeout() time.Duration {
+	return m.timeout
+}
+
 func (m DefaultPlcSubscriptionRequest) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: "PlcSubscriptionRequest"}}); err != nil {
 		return err
@@ -26,12 +26,14 @@ import (
 type DefaultPlcSubscriptionResponse struct {
 	request       model.PlcSubscriptionRequest
 	responseCodes map[string]model.PlcResponseCode
+	handles       map[string]model.PlcSubscriptionHandle
 }
 
-func NewDefaultPlcSubscriptionResponse(request model.PlcSubscriptionRequest, responseCodes map[string]model.PlcResponseCode) DefaultPlcSubscriptionResponse {
+func NewDefaultPlcSubscriptionResponse(request model.PlcSubscriptionRequest, responseCodes map[string]model.PlcResponseCode, handles map[string]model.PlcSubscriptionHandle) DefaultPlcSubscriptionResponse {
 	return DefaultPlcSubscriptionResponse{
 		request:       request,
 		responseCodes: responseCodes,
+		handles:       handles,
 	}
 }
 
@@ -54,6 +56,20 @@ func (m DefaultPlcSubscriptionResponse) GetResponseCode(name string) model.PlcRe
 	return m.responseCodes[name]
 }
 
+func (m DefaultPlcSubscriptionResponse) GetSubscriptionHandle(name string) model.PlcSubscriptionHandle {
+	return m.handles[name]
+}
+
+func (m DefaultPlcSubscriptionResponse) GetSubscriptionHandles() []model.PlcSubscriptionHandle {
+	var handles []model.PlcSubscriptionHandle
+	for _, name := range m.GetFieldNames() {
+		if handle, ok := m.handles[name]; ok {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
 func (m DefaultPlcSubscriptionResponse) GetValue(name string) interface{} {
 	panic("not implemented: implement me")
 }
@@ -0,0 +1,122 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+)
+
+type DefaultPlcUnsubscriptionRequestBuilder struct {
+	subscriber spi.PlcSubscriber
+	handles    []model.PlcSubscriptionHandle
+	fieldNames []string
+}
+
+func NewDefaultPlcUnsubscriptionRequestBuilder(subscriber spi.PlcSubscriber) *DefaultPlcUnsubscriptionRequestBuilder {
+	return &DefaultPlcUnsubscriptionRequestBuilder{
+		subscriber: subscriber,
+		handles:    make([]model.PlcSubscriptionHandle, 0),
+		fieldNames: make([]string, 0),
+	}
+}
+
+func (m *DefaultPlcUnsubscriptionRequestBuilder) AddHandles(handles ...model.PlcSubscriptionHandle) {
+	m.handles = append(m.handles, handles...)
+}
+
+func (m *DefaultPlcUnsubscriptionRequestBuilder) AddFieldNames(fieldNames ...string) {
+	m.fieldNames = append(m.fieldNames, fieldNames...)
+}
+
+func (m *DefaultPlcUnsubscriptionRequestBuilder) Build() (model.PlcUnsubscriptionRequest, error) {
+	if len(m.handles) == 0 && len(m.fieldNames) == 0 {
+		return nil, errors.New("No subscription handles or field names given")
+	}
+	for _, handle := range m.handles {
+		if handle == nil {
+			return nil, errors.New("Subscription handle must not be nil")
+		}
+	}
+	return DefaultPlcUnsubscriptionRequest{
+		subscriber: m.subscriber,
+		handles:    m.handles,
+		fieldNames: m.fieldNames,
+	}, nil
+}
+
+type DefaultPlcUnsubscriptionRequest struct {
+	subscriber spi.PlcSubscriber
+	handles    []model.PlcSubscriptionHandle
+	fieldNames []string
+}
+
+func (m DefaultPlcUnsubscriptionRequest) Execute() <-chan model.PlcUnsubscriptionRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m DefaultPlcUnsubscriptionRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcUnsubscriptionRequestResult {
+	return m.subscriber.Unsubscribe(ctx, m)
+}
+
+func (m DefaultPlcUnsubscriptionRequest) GetSubscriptionHandles() []model.PlcSubscriptionHandle {
+	return m.handles
+}
+
+func (m DefaultPlcUnsubscriptionRequest) GetFieldNames() []string {
+	return m.fieldNames
+}
+
+func (m DefaultPlcUnsubscriptionRequest) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: "PlcUnsubscriptionRequest"}}); err != nil {
+		return err
+	}
+
+	if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: "handles"}}); err != nil {
+		return err
+	}
+	for _, handle := range m.handles {
+		if err := e.EncodeElement(handle.GetFieldName(), xml.StartElement{Name: xml.Name{Local: "handle"}}); err != nil {
+			return err
+		}
+	}
+	if err := e.EncodeToken(xml.EndElement{Name: xml.Name{Local: "handles"}}); err != nil {
+		return err
+	}
+
+	if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: "fields"}}); err != nil {
+		return err
+	}
+	for _, fieldName := range m.fieldNames {
+		if err := e.EncodeElement(fieldName, xml.StartElement{Name: xml.Name{Local: "field"}}); err != nil {
+			return err
+		}
+	}
+	if err := e.EncodeToken(xml.EndElement{Name: xml.Name{Local: "fields"}}); err != nil {
+		return err
+	}
+
+	if err := e.EncodeToken(xml.EndElement{Name: xml.Name{Local: "PlcUnsubscriptionRequest"}}); err != nil {
+		return err
+	}
+	return nil
+}
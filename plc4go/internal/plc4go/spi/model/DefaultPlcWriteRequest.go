@@ -19,35 +19,44 @@
 package model
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	values2 "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"github.com/pkg/errors"
+	"time"
 )
 
 type DefaultPlcWriteRequestBuilder struct {
-	writer       spi.PlcWriter
-	fieldHandler spi.PlcFieldHandler
-	valueHandler spi.PlcValueHandler
-	queries      map[string]string
-	queryNames   []string
-	fields       map[string]model.PlcField
-	fieldNames   []string
-	values       map[string]interface{}
+	writer                  spi.PlcWriter
+	fieldHandler            spi.PlcFieldHandler
+	valueHandler            spi.PlcValueHandler
+	queries                 map[string]string
+	queryNames              []string
+	fields                  map[string]model.PlcField
+	fieldNames              []string
+	values                  map[string]interface{}
+	timeout                 time.Duration
+	writeRequestInterceptor WriteRequestInterceptor
 }
 
 func NewDefaultPlcWriteRequestBuilder(fieldHandler spi.PlcFieldHandler, valueHandler spi.PlcValueHandler, writer spi.PlcWriter) *DefaultPlcWriteRequestBuilder {
+	return NewDefaultPlcWriteRequestBuilderWithInterceptor(fieldHandler, valueHandler, writer, nil)
+}
+
+func NewDefaultPlcWriteRequestBuilderWithInterceptor(fieldHandler spi.PlcFieldHandler, valueHandler spi.PlcValueHandler, writer spi.PlcWriter, writeRequestInterceptor WriteRequestInterceptor) *DefaultPlcWriteRequestBuilder {
 	return &DefaultPlcWriteRequestBuilder{
-		writer:       writer,
-		fieldHandler: fieldHandler,
-		valueHandler: valueHandler,
-		queries:      map[string]string{},
-		queryNames:   make([]string, 0),
-		fields:       map[string]model.PlcField{},
-		fieldNames:   make([]string, 0),
-		values:       map[string]interface{}{},
+		writer:                  writer,
+		fieldHandler:            fieldHandler,
+		valueHandler:            valueHandler,
+		queries:                 map[string]string{},
+		queryNames:              make([]string, 0),
+		fields:                  map[string]model.PlcField{},
+		fieldNames:              make([]string, 0),
+		values:                  map[string]interface{}{},
+		writeRequestInterceptor: writeRequestInterceptor,
 	}
 }
 
@@ -63,6 +72,10 @@ func (m *DefaultPlcWriteRequestBuilder) AddField(name string, field model.PlcFie
 	m.values[name] = value
 }
 
+func (m *DefaultPlcWriteRequestBuilder) SetTimeout(timeout time.Duration) {
+	m.timeout = timeout
+}
+
 func (m *DefaultPlcWriteRequestBuilder) Build() (model.PlcWriteRequest, error) {
 	// Parse the queries as well as pro
 	for _, name := range m.queryNames {
@@ -79,39 +92,98 @@ func (m *DefaultPlcWriteRequestBuilder) Build() (model.PlcWriteRequest, error) {
 	for name, field := range m.fields {
 		value, err := m.valueHandler.NewPlcValue(field, m.values[name])
 		if err != nil {
-			return nil, errors.Wrapf(err, "Error parsing value of type: %s", field.GetTypeName())
+			return nil, errors.Wrapf(err, "Error parsing value of type: %s for field: %s", field.GetTypeName(), name)
 		}
 		plcValues[name] = value
 	}
 	return DefaultPlcWriteRequest{
-		fields:     m.fields,
-		fieldNames: m.fieldNames,
-		values:     plcValues,
-		writer:     m.writer,
+		Fields:                  m.fields,
+		FieldNames:              m.fieldNames,
+		Values:                  plcValues,
+		Writer:                  m.writer,
+		WriteRequestInterceptor: m.writeRequestInterceptor,
+		Timeout:                 m.timeout,
 	}, nil
 }
 
 type DefaultPlcWriteRequest struct {
-	fields     map[string]model.PlcField
-	fieldNames []string
-	values     map[string]values.PlcValue
-	writer     spi.PlcWriter
+	Fields                  map[string]model.PlcField
+	FieldNames              []string
+	Values                  map[string]values.PlcValue
+	Writer                  spi.PlcWriter
+	WriteRequestInterceptor WriteRequestInterceptor
+
+	// Override of the connection default (0 for using the default)
+	Timeout time.Duration
+}
+
+func NewDefaultPlcWriteRequest(fields map[string]model.PlcField, fieldNames []string, values map[string]values.PlcValue, writer spi.PlcWriter, writeRequestInterceptor WriteRequestInterceptor) DefaultPlcWriteRequest {
+	return DefaultPlcWriteRequest{
+		Fields:                  fields,
+		FieldNames:              fieldNames,
+		Values:                  values,
+		Writer:                  writer,
+		WriteRequestInterceptor: writeRequestInterceptor,
+	}
 }
 
 func (m DefaultPlcWriteRequest) Execute() <-chan model.PlcWriteRequestResult {
-	return m.writer.Write(m)
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m DefaultPlcWriteRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcWriteRequestResult {
+	// Shortcut, if no interceptor is defined
+	if m.WriteRequestInterceptor == nil {
+		return m.Writer.Write(ctx, m)
+	}
+
+	// Split the requests up into multiple ones.
+	writeRequests := m.WriteRequestInterceptor.InterceptWriteRequest(m)
+	// Shortcut for single-request-requests
+	if len(writeRequests) == 1 {
+		return m.Writer.Write(ctx, writeRequests[0])
+	}
+	// Create a sub-result-channel slice
+	var subResultChannels []<-chan model.PlcWriteRequestResult
+
+	// Iterate over all requests and add the result-channels to the list (the request queue of the connection decides
+	// when each of them is sent)
+	for _, subRequest := range writeRequests {
+		subResultChannels = append(subResultChannels, m.Writer.Write(ctx, subRequest))
+	}
+
+	// Create a new result-channel, which completes as soon as all sub-result-channels have returned
+	resultChannel := make(chan model.PlcWriteRequestResult, 1)
+	go func() {
+		var subResults []model.PlcWriteRequestResult
+		// Iterate over all sub-results
+		for _, subResultChannel := range subResultChannels {
+			subResult := <-subResultChannel
+			subResults = append(subResults, subResult)
+		}
+		// As soon as all are done, process the results
+		result := m.WriteRequestInterceptor.ProcessWriteResponses(m, subResults)
+		// Return the final result
+		resultChannel <- result
+	}()
+
+	return resultChannel
 }
 
 func (m DefaultPlcWriteRequest) GetFieldNames() []string {
-	return m.fieldNames
+	return m.FieldNames
 }
 
 func (m DefaultPlcWriteRequest) GetField(name string) model.PlcField {
-	return m.fields[name]
+	return m.Fields[name]
 }
 
 func (m DefaultPlcWriteRequest) GetValue(name string) values.PlcValue {
-	return m.values[name]
+	return m.Values[name]
+}
+
+func (m DefaultPlcWriteRequest) GetTimeout() time.Duration {
+	return m.Timeout
 }
 
 func (m DefaultPlcWriteRequest) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
@@ -122,9 +194,9 @@ func (m DefaultPlcWriteRequest) MarshalXML(e *xml.Encoder, start xml.StartElemen
 	if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: "fields"}}); err != nil {
 		return err
 	}
-	for _, fieldName := range m.fieldNames {
-		field := m.fields[fieldName]
-		value := m.values[fieldName]
+	for _, fieldName := range m.FieldNames {
+		field := m.Fields[fieldName]
+		value := m.Values[fieldName]
 		if err := e.EncodeToken(xml.StartElement{Name: xml.Name{Local: fieldName}}); err != nil {
 			return err
 		}
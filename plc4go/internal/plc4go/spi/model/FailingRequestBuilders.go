@@ -0,0 +1,170 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// The failing request builders are handed out by connections, which are unable to build requests at all (like
+// connections which have been closed already), or which don't support a kind of request (using ErrNotSupported).
+// Building a request fails with the error they have been created with.
+
+// ErrNotSupported is reported when building a kind of request the connection doesn't support
+var ErrNotSupported = errors.New("not supported by this connection")
+
+type FailingPlcReadRequestBuilder struct {
+	err error
+}
+
+func NewFailingPlcReadRequestBuilder(err error) *FailingPlcReadRequestBuilder {
+	return &FailingPlcReadRequestBuilder{err: err}
+}
+
+func (m *FailingPlcReadRequestBuilder) AddQuery(_ string, _ string) {
+}
+
+func (m *FailingPlcReadRequestBuilder) AddField(_ string, _ model.PlcField) {
+}
+
+func (m *FailingPlcReadRequestBuilder) SetTimeout(_ time.Duration) {
+}
+
+func (m *FailingPlcReadRequestBuilder) SetRetries(_ int) {
+}
+
+func (m *FailingPlcReadRequestBuilder) Build() (model.PlcReadRequest, error) {
+	return nil, m.err
+}
+
+type FailingPlcWriteRequestBuilder struct {
+	err error
+}
+
+func NewFailingPlcWriteRequestBuilder(err error) *FailingPlcWriteRequestBuilder {
+	return &FailingPlcWriteRequestBuilder{err: err}
+}
+
+func (m *FailingPlcWriteRequestBuilder) AddQuery(_ string, _ string, _ interface{}) {
+}
+
+func (m *FailingPlcWriteRequestBuilder) AddField(_ string, _ model.PlcField, _ interface{}) {
+}
+
+func (m *FailingPlcWriteRequestBuilder) SetTimeout(_ time.Duration) {
+}
+
+func (m *FailingPlcWriteRequestBuilder) Build() (model.PlcWriteRequest, error) {
+	return nil, m.err
+}
+
+type FailingPlcSubscriptionRequestBuilder struct {
+	err error
+}
+
+func NewFailingPlcSubscriptionRequestBuilder(err error) *FailingPlcSubscriptionRequestBuilder {
+	return &FailingPlcSubscriptionRequestBuilder{err: err}
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddCyclicQuery(_ string, _ string, _ time.Duration) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddCyclicField(_ string, _ model.PlcField, _ time.Duration) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddChangeOfStateQuery(_ string, _ string) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddChangeOfStateField(_ string, _ model.PlcField) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddEventQuery(_ string, _ string) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddEventField(_ string, _ model.PlcField) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) AddItemHandler(_ model.PlcSubscriptionEventHandler) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) SetTimeout(_ time.Duration) {
+}
+
+func (m *FailingPlcSubscriptionRequestBuilder) Build() (model.PlcSubscriptionRequest, error) {
+	return nil, m.err
+}
+
+type FailingPlcUnsubscriptionRequestBuilder struct {
+	err error
+}
+
+func NewFailingPlcUnsubscriptionRequestBuilder(err error) *FailingPlcUnsubscriptionRequestBuilder {
+	return &FailingPlcUnsubscriptionRequestBuilder{err: err}
+}
+
+func (m *FailingPlcUnsubscriptionRequestBuilder) AddHandles(_ ...model.PlcSubscriptionHandle) {
+}
+
+func (m *FailingPlcUnsubscriptionRequestBuilder) AddFieldNames(_ ...string) {
+}
+
+func (m *FailingPlcUnsubscriptionRequestBuilder) Build() (model.PlcUnsubscriptionRequest, error) {
+	return nil, m.err
+}
+
+type FailingPlcBrowseRequestBuilder struct {
+	err error
+}
+
+func NewFailingPlcBrowseRequestBuilder(err error) *FailingPlcBrowseRequestBuilder {
+	return &FailingPlcBrowseRequestBuilder{err: err}
+}
+
+func (m *FailingPlcBrowseRequestBuilder) AddItem(_ string, _ string) {
+}
+
+func (m *FailingPlcBrowseRequestBuilder) Build() (model.PlcBrowseRequest, error) {
+	return nil, m.err
+}
+
+// UnavailableConnectionMetadata is the metadata of connections, which are unable to do anything at all
+type UnavailableConnectionMetadata struct {
+}
+
+func (m UnavailableConnectionMetadata) GetConnectionAttributes() map[string]string {
+	return map[string]string{}
+}
+
+func (m UnavailableConnectionMetadata) CanRead() bool {
+	return false
+}
+
+func (m UnavailableConnectionMetadata) CanWrite() bool {
+	return false
+}
+
+func (m UnavailableConnectionMetadata) CanSubscribe() bool {
+	return false
+}
+
+func (m UnavailableConnectionMetadata) CanBrowse() bool {
+	return false
+}
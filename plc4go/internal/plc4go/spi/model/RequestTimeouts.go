@@ -0,0 +1,99 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/rs/zerolog"
+)
+
+// NewTimedOutPlcReadResponse creates a response reporting REQUEST_TIMEOUT for every field of the given request
+func NewTimedOutPlcReadResponse(readRequest model.PlcReadRequest) DefaultPlcReadResponse {
+	responseCodes := map[string]model.PlcResponseCode{}
+	plcValues := map[string]values.PlcValue{}
+	for _, fieldName := range readRequest.GetFieldNames() {
+		responseCodes[fieldName] = model.PlcResponseCode_REQUEST_TIMEOUT
+		plcValues[fieldName] = nil
+	}
+	return NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues)
+}
+
+// NewTimedOutPlcWriteResponse creates a response reporting REQUEST_TIMEOUT for every field of the given request
+func NewTimedOutPlcWriteResponse(writeRequest model.PlcWriteRequest) DefaultPlcWriteResponse {
+	responseCodes := map[string]model.PlcResponseCode{}
+	for _, fieldName := range writeRequest.GetFieldNames() {
+		responseCodes[fieldName] = model.PlcResponseCode_REQUEST_TIMEOUT
+	}
+	return NewDefaultPlcWriteResponse(writeRequest, responseCodes)
+}
+
+// NewTimedOutPlcSubscriptionResponse creates a response reporting REQUEST_TIMEOUT for every field of the given request
+func NewTimedOutPlcSubscriptionResponse(subscriptionRequest model.PlcSubscriptionRequest) DefaultPlcSubscriptionResponse {
+	responseCodes := map[string]model.PlcResponseCode{}
+	for _, fieldName := range subscriptionRequest.GetFieldNames() {
+		responseCodes[fieldName] = model.PlcResponseCode_REQUEST_TIMEOUT
+	}
+	return NewDefaultPlcSubscriptionResponse(subscriptionRequest, responseCodes, map[string]model.PlcSubscriptionHandle{})
+}
+
+// ReadWithRetries executes the given read and repeats it as long as it times out, at most the given number of
+// times. Only timeouts are retried, as a repeated read is harmless, but an error reported by the PLC won't go away.
+// If even the last attempt timed out, the result carries a response reporting REQUEST_TIMEOUT for every field
+// instead of the error. If the given context is done in the meantime, the result carries its error instead, so
+// callers can tell their own cancellation apart from a PLC not responding.
+func ReadWithRetries(ctx context.Context, logger zerolog.Logger, readRequest model.PlcReadRequest, retries int, read func() model.PlcReadRequestResult) model.PlcReadRequestResult {
+	for attempt := 0; ; attempt++ {
+		result := read()
+		if result.Err == nil || !plcerrors.IsTimeoutError(result.Err) {
+			return result
+		}
+		if err := ctx.Err(); err != nil {
+			logger.Debug().Err(result.Err).Int("attempts", attempt+1).Msg("Read aborted")
+			return model.PlcReadRequestResult{
+				Request: readRequest,
+				Err:     err,
+			}
+		}
+		if attempt >= retries {
+			logger.Debug().Err(result.Err).Int("attempts", attempt+1).Msg("Read timed out")
+			return model.PlcReadRequestResult{
+				Request:  readRequest,
+				Response: NewTimedOutPlcReadResponse(readRequest),
+			}
+		}
+		logger.Debug().Err(result.Err).Int("attempt", attempt+1).Msg("Read timed out, retrying")
+	}
+}
+
+// WriteWithoutRetries reports a timed out write with a response carrying REQUEST_TIMEOUT for every field instead of
+// the error. Writes are never repeated, as nobody knows, if the PLC already executed the timed out one.
+func WriteWithoutRetries(logger zerolog.Logger, writeRequest model.PlcWriteRequest, write func() model.PlcWriteRequestResult) model.PlcWriteRequestResult {
+	result := write()
+	if result.Err == nil || !plcerrors.IsTimeoutError(result.Err) {
+		return result
+	}
+	logger.Debug().Err(result.Err).Msg("Write timed out")
+	return model.PlcWriteRequestResult{
+		Request:  writeRequest,
+		Response: NewTimedOutPlcWriteResponse(writeRequest),
+	}
+}
@@ -0,0 +1,102 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/plcerrors"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+func TestReadWithRetries(t *testing.T) {
+	readRequest := NewDefaultPlcReadRequest(map[string]model.PlcField{"a": nil, "b": nil}, []string{"a", "b"}, nil, nil)
+	timedOut := model.PlcReadRequestResult{
+		Request: readRequest,
+		Err:     errors.Wrap(plcerrors.NewTimeoutError(time.Second), "got timeout while waiting for response"),
+	}
+
+	// Timeouts are retried till the read succeeds
+	attempts := 0
+	result := ReadWithRetries(context.Background(), zerolog.Nop(), readRequest, 2, func() model.PlcReadRequestResult {
+		attempts++
+		if attempts < 3 {
+			return timedOut
+		}
+		return model.PlcReadRequestResult{Request: readRequest, Response: NewDefaultPlcReadResponse(readRequest, nil, nil)}
+	})
+	if attempts != 3 || result.Err != nil || result.Response == nil {
+		t.Errorf("Expected success after 3 attempts, got %d attempts and error %v", attempts, result.Err)
+	}
+
+	// After the last retry the timeout is reported for every field
+	attempts = 0
+	result = ReadWithRetries(context.Background(), zerolog.Nop(), readRequest, 1, func() model.PlcReadRequestResult {
+		attempts++
+		return timedOut
+	})
+	if attempts != 2 || result.Err != nil {
+		t.Fatalf("Expected a response after 2 attempts, got %d attempts and error %v", attempts, result.Err)
+	}
+	for _, fieldName := range readRequest.GetFieldNames() {
+		if responseCode := result.Response.GetResponseCode(fieldName); responseCode != model.PlcResponseCode_REQUEST_TIMEOUT {
+			t.Errorf("Expected REQUEST_TIMEOUT for field %s, got %v", fieldName, responseCode)
+		}
+	}
+
+	// Cancelling the context stops retrying and is reported as such
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts = 0
+	result = ReadWithRetries(ctx, zerolog.Nop(), readRequest, 2, func() model.PlcReadRequestResult {
+		attempts++
+		cancel()
+		return timedOut
+	})
+	if attempts != 1 || result.Err != context.Canceled || result.Response != nil {
+		t.Errorf("Expected the cancellation after 1 attempt, got %d attempts and error %v", attempts, result.Err)
+	}
+
+	// Other errors are passed on without retrying
+	attempts = 0
+	result = ReadWithRetries(context.Background(), zerolog.Nop(), readRequest, 2, func() model.PlcReadRequestResult {
+		attempts++
+		return model.PlcReadRequestResult{Request: readRequest, Err: errors.New("error sending message")}
+	})
+	if attempts != 1 || result.Err == nil {
+		t.Errorf("Expected the error after 1 attempt, got %d attempts and error %v", attempts, result.Err)
+	}
+}
+
+func TestWriteWithoutRetries(t *testing.T) {
+	writeRequest := NewDefaultPlcWriteRequest(map[string]model.PlcField{"a": nil}, []string{"a"}, nil, nil, nil)
+	attempts := 0
+	result := WriteWithoutRetries(zerolog.Nop(), writeRequest, func() model.PlcWriteRequestResult {
+		attempts++
+		return model.PlcWriteRequestResult{Request: writeRequest, Err: plcerrors.NewTimeoutError(time.Second)}
+	})
+	if attempts != 1 || result.Err != nil {
+		t.Fatalf("Expected a response after 1 attempt, got %d attempts and error %v", attempts, result.Err)
+	}
+	if responseCode := result.Response.GetResponseCode("a"); responseCode != model.PlcResponseCode_REQUEST_TIMEOUT {
+		t.Errorf("Expected REQUEST_TIMEOUT, got %v", responseCode)
+	}
+}
@@ -20,6 +20,7 @@ package plcerrors
 
 import (
 	"fmt"
+	"github.com/pkg/errors"
 	"time"
 )
 
@@ -34,3 +35,9 @@ func NewTimeoutError(timeout time.Duration) TimeoutError {
 func (t TimeoutError) Error() string {
 	return fmt.Sprintf("got timeout after %v", t.timeout)
 }
+
+// IsTimeoutError returns true, if the given error or any of the errors it wraps is a TimeoutError
+func IsTimeoutError(err error) bool {
+	var timeoutError TimeoutError
+	return errors.As(err, &timeoutError)
+}
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	adsModel "github.com/apache/plc4x/plc4go/internal/plc4go/ads/readwrite"
 	knxModel "github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip/readwrite"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus"
 	modbusModel "github.com/apache/plc4x/plc4go/internal/plc4go/modbus/readwrite"
 	s7Model "github.com/apache/plc4x/plc4go/internal/plc4go/s7/readwrite"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
@@ -118,6 +119,8 @@ func RunParserSerializerTestsuite(t *testing.T, testPath string, skippedTestCase
 				switch testsuiteName {
 				case "Modbus":
 					helper = new(modbusModel.ModbusParserHelper)
+				case "Modbus ASCII":
+					helper = new(modbus.AsciiParserHelper)
 				case "Beckhoff ADS/AMS":
 					helper = new(adsModel.AdsParserHelper)
 				case "S7":
@@ -0,0 +1,125 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package transports
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"github.com/pkg/errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9.\-]+$`)
+
+// LocalAddressOptionSchema contains the options of socket based transports for choosing the local end of the socket
+var LocalAddressOptionSchema = options.OptionSchema{
+	{Name: "local-address", Type: options.OptionTypeString, Description: "Local IP address to bind to"},
+	{Name: "local-interface", Type: options.OptionTypeString, Description: "Network interface to bind to (by its first address of the IP version of the remote address)"},
+}
+
+// ParseHostPort returns the host (name, IPv4 address or IPv6 address with an optional zone) and the port of the
+// transport url. IPv6 addresses have to be put in brackets. Without a port, the default port is used.
+func ParseHostPort(transportUrl url.URL, defaultPort []string) (string, int, error) {
+	host := transportUrl.Hostname()
+	if host == "" {
+		return "", 0, errors.New("missing hostname or ip to connect")
+	}
+	if parseIP(host) == nil && !hostnameRegexp.MatchString(host) {
+		return "", 0, errors.Errorf("invalid hostname or ip %s", host)
+	}
+	port := transportUrl.Port()
+	if port == "" {
+		if len(defaultPort) == 0 {
+			return "", 0, errors.New("error setting port. No explicit or default port provided")
+		}
+		port = defaultPort[0]
+	}
+	portValue, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "error setting port %s", port)
+	}
+	return host, int(portValue), nil
+}
+
+// ParseLocalAddress returns the local address to bind to, as given by the local-address or local-interface option
+// (nil if there is none). The address of the interface is chosen by the IP version of the remote address.
+func ParseLocalAddress(options map[string][]string, remoteIP net.IP) (*net.IPAddr, error) {
+	if values := options["local-address"]; len(values) > 0 {
+		ip := parseIP(values[0])
+		if ip == nil {
+			return nil, errors.Errorf("invalid local-address %s", values[0])
+		}
+		address := &net.IPAddr{IP: ip}
+		if index := strings.IndexByte(values[0], '%'); index >= 0 {
+			address.Zone = values[0][index+1:]
+		}
+		return address, nil
+	}
+	if values := options["local-interface"]; len(values) > 0 {
+		networkInterface, err := net.InterfaceByName(values[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid local-interface %s", values[0])
+		}
+		addresses, err := networkInterface.Addrs()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting the addresses of local-interface %s", values[0])
+		}
+		for _, address := range addresses {
+			ipNet, ok := address.(*net.IPNet)
+			if !ok || (ipNet.IP.To4() != nil) != (remoteIP.To4() != nil) {
+				continue
+			}
+			localAddress := &net.IPAddr{IP: ipNet.IP}
+			// Link-local addresses are only unique along with the interface
+			if ipNet.IP.IsLinkLocalUnicast() {
+				localAddress.Zone = networkInterface.Name
+			}
+			return localAddress, nil
+		}
+		return nil, errors.Errorf("local-interface %s has no address for connecting to %s", values[0], remoteIP)
+	}
+	return nil, nil
+}
+
+// DeadlineReader sets the read deadline of the connection before every read, so reading fails when nothing is
+// received in time
+type DeadlineReader struct {
+	Conn    net.Conn
+	Timeout time.Duration
+}
+
+func (m DeadlineReader) Read(data []byte) (int, error) {
+	if err := m.Conn.SetReadDeadline(time.Now().Add(m.Timeout)); err != nil {
+		return 0, err
+	}
+	return m.Conn.Read(data)
+}
+
+// parseIP parses IP addresses, ignoring the zone of IPv6 addresses
+func parseIP(address string) net.IP {
+	if index := strings.IndexByte(address, '%'); index >= 0 {
+		address = address[:index]
+	}
+	return net.ParseIP(address)
+}
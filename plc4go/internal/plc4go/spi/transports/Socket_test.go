@@ -0,0 +1,101 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package transports
+
+import (
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestParseHostPort(t *testing.T) {
+	tests := []struct {
+		host         string
+		expectedHost string
+		expectedPort int
+	}{
+		{"10.0.0.1:5020", "10.0.0.1", 5020},
+		{"10.0.0.1", "10.0.0.1", 502},
+		{"plc-1.local", "plc-1.local", 502},
+		{"[2001:db8::1]:5020", "2001:db8::1", 5020},
+		{"[fe80::1%eth0]", "fe80::1%eth0", 502},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			host, port, err := ParseHostPort(url.URL{Host: tt.host}, []string{"502"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if host != tt.expectedHost || port != tt.expectedPort {
+				t.Errorf("Expected %s and %d, got %s and %d", tt.expectedHost, tt.expectedPort, host, port)
+			}
+		})
+	}
+
+	for _, host := range []string{"", "plc_1", "10.0.0.1:70000", "10.0.0.1:port"} {
+		if _, _, err := ParseHostPort(url.URL{Host: host}, []string{"502"}); err == nil {
+			t.Errorf("Expected an error for %q", host)
+		}
+	}
+	if _, _, err := ParseHostPort(url.URL{Host: "10.0.0.1"}, nil); err == nil {
+		t.Error("Expected an error without port")
+	}
+}
+
+func TestParseLocalAddress(t *testing.T) {
+	localAddress, err := ParseLocalAddress(map[string][]string{}, net.ParseIP("10.0.0.1"))
+	if err != nil || localAddress != nil {
+		t.Errorf("Expected no local address, got %v (%v)", localAddress, err)
+	}
+	localAddress, err = ParseLocalAddress(map[string][]string{"local-address": {"fe80::2%eth1"}}, net.ParseIP("fe80::1"))
+	if err != nil || localAddress.String() != "fe80::2%eth1" {
+		t.Errorf("Expected fe80::2%%eth1, got %v (%v)", localAddress, err)
+	}
+	if _, err := ParseLocalAddress(map[string][]string{"local-address": {"plc.local"}}, net.ParseIP("10.0.0.1")); err == nil {
+		t.Error("Expected an error for a local address not being an ip")
+	}
+
+	loopback := findLoopbackInterface(t)
+	localAddress, err = ParseLocalAddress(map[string][]string{"local-interface": {loopback}}, net.ParseIP("127.0.0.2"))
+	if err != nil || !localAddress.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("Expected 127.0.0.1, got %v (%v)", localAddress, err)
+	}
+	if _, err := ParseLocalAddress(map[string][]string{"local-interface": {"missing0"}}, net.ParseIP("10.0.0.1")); err == nil {
+		t.Error("Expected an error for an unknown interface")
+	}
+}
+
+// findLoopbackInterface returns the name of the interface with the IPv4 loopback address
+func findLoopbackInterface(t *testing.T) string {
+	networkInterfaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, networkInterface := range networkInterfaces {
+		addresses, _ := networkInterface.Addrs()
+		for _, address := range addresses {
+			if ipNet, ok := address.(*net.IPNet); ok && ipNet.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+				return networkInterface.Name
+			}
+		}
+	}
+	t.Skip("No loopback interface")
+	return ""
+}
@@ -18,13 +18,18 @@
 //
 package transports
 
-import "net/url"
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"net/url"
+)
 
 type Transport interface {
 	// Get the short code used to identify this transport (As used in the connection string)
 	GetTransportCode() string
 	// Get a human readable name for this transport
 	GetTransportName() string
+	// Get the options this transport supports in the query string of the connection string
+	GetOptionSchema() options.OptionSchema
 
 	CreateTransportInstance(transportUrl url.URL, options map[string][]string) (TransportInstance, error)
 }
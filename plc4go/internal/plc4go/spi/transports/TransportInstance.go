@@ -18,8 +18,15 @@
 //
 package transports
 
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
 type TransportInstance interface {
 	Connect() error
+	// Variant of Connect which aborts as soon as the given context is done
+	ConnectWithContext(ctx context.Context) error
 	Close() error
 
 	GetNumReadableBytes() (uint32, error)
@@ -29,6 +36,29 @@ type TransportInstance interface {
 	Write(data []uint8) error
 }
 
+// WaitingTransportInstance is implemented by transport instances, which are able to block until data comes in,
+// so they don't have to be polled
+type WaitingTransportInstance interface {
+	TransportInstance
+	// Blocks until at least the given number of bytes is readable (or the transport instance is closed)
+	WaitForReadableBytes(numBytes uint32) error
+}
+
+// LoggingTransportInstance is implemented by transport instances, which log with the logger of the connection they
+// belong to (instead of the global one)
+// SilenceDetectingTransportInstance is implemented by transports of lines, on which frames are separated by silence
+// (like Modbus RTU on serial lines)
+type SilenceDetectingTransportInstance interface {
+	TransportInstance
+	// Blocks until nothing has been sent or received for the inter-frame delay
+	WaitForSilence() error
+}
+
+type LoggingTransportInstance interface {
+	TransportInstance
+	SetLogger(logger zerolog.Logger)
+}
+
 type TestTransportInstance interface {
 	TransportInstance
 	FillReadBuffer(data []uint8) error
@@ -0,0 +1,197 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+//go:build 386 || amd64 || arm || arm64 || riscv64
+// +build 386 amd64 arm arm64 riscv64
+
+package serial
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Values of asm-generic/termbits.h and asm-generic/ioctls.h, which are used by all architectures this file is built
+// for (the syscall package doesn't define all of them for all of them)
+const (
+	termiosIgnbrk  = 0x1
+	termiosBrkint  = 0x2
+	termiosIgnpar  = 0x4
+	termiosParmrk  = 0x8
+	termiosInpck   = 0x10
+	termiosIstrip  = 0x20
+	termiosInlcr   = 0x40
+	termiosIgncr   = 0x80
+	termiosIcrnl   = 0x100
+	termiosIxon    = 0x400
+	termiosIxany   = 0x800
+	termiosIxoff   = 0x1000
+	termiosOpost   = 0x1
+	termiosCbaud   = 0x100f
+	termiosCsize   = 0x30
+	termiosCstopb  = 0x40
+	termiosCread   = 0x80
+	termiosParenb  = 0x100
+	termiosParodd  = 0x200
+	termiosClocal  = 0x800
+	termiosCrtscts = 0x80000000
+	termiosIsig    = 0x1
+	termiosIcanon  = 0x2
+	termiosEcho    = 0x8
+	termiosEchonl  = 0x40
+	termiosIexten  = 0x8000
+	termiosVtime   = 5
+	termiosVmin    = 6
+
+	ioctlTcflsh     = 0x540b
+	ioctlTiocexcl   = 0x540c
+	ioctlTiocsrs485 = 0x542f
+	tcioflush       = 2
+
+	serRs485Enabled   = 0x1
+	serRs485RtsOnSend = 0x2
+)
+
+var baudRates = map[uint32]uint32{
+	50:      0x1,
+	75:      0x2,
+	110:     0x3,
+	134:     0x4,
+	150:     0x5,
+	200:     0x6,
+	300:     0x7,
+	600:     0x8,
+	1200:    0x9,
+	1800:    0xa,
+	2400:    0xb,
+	4800:    0xc,
+	9600:    0xd,
+	19200:   0xe,
+	38400:   0xf,
+	57600:   0x1001,
+	115200:  0x1002,
+	230400:  0x1003,
+	460800:  0x1004,
+	500000:  0x1005,
+	576000:  0x1006,
+	921600:  0x1007,
+	1000000: 0x1008,
+	1152000: 0x1009,
+	1500000: 0x100a,
+	2000000: 0x100b,
+	2500000: 0x100c,
+	3000000: 0x100d,
+	3500000: 0x100e,
+	4000000: 0x100f,
+}
+
+var dataBits = map[uint8]uint32{
+	5: 0x0,
+	6: 0x10,
+	7: 0x20,
+	8: 0x30,
+}
+
+// serialRs485 is struct serial_rs485 of linux/serial.h
+type serialRs485 struct {
+	flags              uint32
+	delayRtsBeforeSend uint32
+	delayRtsAfterSend  uint32
+	padding            [5]uint32
+}
+
+// openPort opens the port for exclusive use in raw mode with the given line settings
+func openPort(portName string, configuration Configuration) (*os.File, error) {
+	baudRate, ok := baudRates[configuration.BaudRate]
+	if !ok {
+		return nil, errors.Errorf("unsupported baud-rate %d", configuration.BaudRate)
+	}
+	// The port is opened non-blocking, so reads can be interrupted by closing it
+	fd, err := syscall.Open(portName, syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening port")
+	}
+	if err := configurePort(fd, baudRate, configuration); err != nil {
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), portName), nil
+}
+
+func configurePort(fd int, baudRate uint32, configuration Configuration) error {
+	if err := ioctl(fd, ioctlTiocexcl, 0); err != nil {
+		return errors.Wrap(err, "error getting exclusive access")
+	}
+
+	var termios syscall.Termios
+	if err := ioctl(fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios))); err != nil {
+		return errors.Wrap(err, "error getting line settings")
+	}
+	// Raw mode: no line editing, echo, signals or translation of characters
+	termios.Iflag &^= termiosIgnbrk | termiosBrkint | termiosIgnpar | termiosParmrk | termiosInpck | termiosIstrip |
+		termiosInlcr | termiosIgncr | termiosIcrnl | termiosIxon | termiosIxany | termiosIxoff
+	termios.Oflag &^= termiosOpost
+	termios.Lflag &^= termiosIsig | termiosIcanon | termiosEcho | termiosEchonl | termiosIexten
+	termios.Cflag &^= termiosCbaud | termiosCsize | termiosCstopb | termiosParenb | termiosParodd | termiosCrtscts
+	termios.Cflag |= termiosCread | termiosClocal | baudRate | dataBits[configuration.DataBits]
+	if configuration.StopBits == 2 {
+		termios.Cflag |= termiosCstopb
+	}
+	switch configuration.Parity {
+	case ParityEven:
+		termios.Cflag |= termiosParenb
+		termios.Iflag |= termiosInpck
+	case ParityOdd:
+		termios.Cflag |= termiosParenb | termiosParodd
+		termios.Iflag |= termiosInpck
+	}
+	termios.Ispeed = baudRate
+	termios.Ospeed = baudRate
+	termios.Cc[termiosVmin] = 1
+	termios.Cc[termiosVtime] = 0
+	if err := ioctl(fd, syscall.TCSETS, uintptr(unsafe.Pointer(&termios))); err != nil {
+		return errors.Wrap(err, "error setting line settings")
+	}
+
+	if configuration.Rs485 {
+		rs485 := serialRs485{
+			flags:              serRs485Enabled | serRs485RtsOnSend,
+			delayRtsBeforeSend: uint32(configuration.Rs485DelayRtsBeforeSend.Milliseconds()),
+			delayRtsAfterSend:  uint32(configuration.Rs485DelayRtsAfterSend.Milliseconds()),
+		}
+		if err := ioctl(fd, ioctlTiocsrs485, uintptr(unsafe.Pointer(&rs485))); err != nil {
+			return errors.Wrap(err, "error enabling rs485 mode")
+		}
+	}
+
+	// Drop whatever was received before the port was opened
+	if err := ioctl(fd, ioctlTcflsh, tcioflush); err != nil {
+		return errors.Wrap(err, "error flushing port")
+	}
+	return nil
+}
+
+func ioctl(fd int, request uintptr, argument uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, argument); errno != 0 {
+		return errno
+	}
+	return nil
+}
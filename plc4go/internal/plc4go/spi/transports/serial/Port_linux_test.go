@@ -0,0 +1,122 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+//go:build 386 || amd64 || arm || arm64 || riscv64
+// +build 386 amd64 arm arm64 riscv64
+
+package serial
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPseudoTerminal returns the master side of a new pseudo-terminal and the path of its slave side
+func openPseudoTerminal(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("No pseudo-terminals available: %v", err)
+	}
+	var unlock int32
+	if err := ioctl(int(master.Fd()), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		t.Fatalf("Error unlocking pseudo-terminal: %v", err)
+	}
+	var number uint32
+	if err := ioctl(int(master.Fd()), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number))); err != nil {
+		t.Fatalf("Error getting pseudo-terminal number: %v", err)
+	}
+	return master, "/dev/pts/" + strconv.Itoa(int(number))
+}
+
+func TestTransportInstance_PseudoTerminal(t *testing.T) {
+	master, portName := openPseudoTerminal(t)
+	defer master.Close()
+
+	configuration := Configuration{BaudRate: 9600, DataBits: 8, Parity: ParityEven, StopBits: 1, InterFrameDelay: 20 * time.Millisecond}
+	transportInstance := NewTransportInstance(portName, configuration, NewTransport())
+	if err := transportInstance.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer transportInstance.Close()
+
+	// The line settings are applied to the port (Fd() isn't used, as it would switch the port to blocking mode)
+	rawConn, err := transportInstance.port.SyscallConn()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var termios syscall.Termios
+	err = rawConn.Control(func(fd uintptr) {
+		err = ioctl(int(fd), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	})
+	if err != nil {
+		t.Fatalf("Error getting line settings: %v", err)
+	}
+	// (pseudo-terminals always use 8 data bits without parity, so the parity can't be checked)
+	if termios.Cflag&termiosCbaud != baudRates[9600] || termios.Lflag&termiosIcanon != 0 || termios.Iflag&termiosIcrnl != 0 {
+		t.Errorf("Unexpected line settings %+v", termios)
+	}
+
+	// Receiving
+	if _, err := master.Write([]byte{0x01, 0x03, 0x00}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := transportInstance.WaitForReadableBytes(3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := transportInstance.Read(3)
+	if err != nil || string(data) != "\x01\x03\x00" {
+		t.Errorf("Expected the data sent, got %v (%v)", data, err)
+	}
+
+	// Sending waits for the inter-frame delay after receiving
+	start := time.Now()
+	if err := transportInstance.Write([]byte{0x01, 0x83, 0x02}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected the write to wait for the inter-frame delay, took %s", elapsed)
+	}
+	data = make([]byte, 3)
+	if _, err := io.ReadFull(master, data); err != nil || string(data) != "\x01\x83\x02" {
+		t.Errorf("Expected the data written, got %v (%v)", data, err)
+	}
+
+	// Waiting for silence picks up characters arriving in the meantime
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			_, _ = master.Write([]byte{byte(i)})
+		}
+	}()
+	start = time.Now()
+	if err := transportInstance.WaitForSilence(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Expected waiting for silence till after the last character, took %s", elapsed)
+	}
+	if num, err := transportInstance.GetNumReadableBytes(); err != nil || num != 3 {
+		t.Errorf("Expected 3 readable bytes, got %d (%v)", num, err)
+	}
+}
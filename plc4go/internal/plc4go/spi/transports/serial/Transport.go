@@ -0,0 +1,402 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package serial
+
+import (
+	"bufio"
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var optionSchema = options.OptionSchema{
+	{Name: "baud-rate", Type: options.OptionTypeInteger, Default: "19200", Description: "Speed of the serial line in bit/s"},
+	{Name: "data-bits", Type: options.OptionTypeInteger, Default: "8", AllowedValues: []string{"5", "6", "7", "8"}, Description: "Number of data bits per character"},
+	{Name: "parity", Type: options.OptionTypeString, Default: "none", AllowedValues: []string{"none", "even", "odd"}, Description: "Parity bit sent with every character"},
+	{Name: "stop-bits", Type: options.OptionTypeInteger, Default: "1", AllowedValues: []string{"1", "2"}, Description: "Number of stop bits per character"},
+	{Name: "inter-frame-delay", Type: options.OptionTypeInteger, Description: "Minimum silence between two frames in microseconds (defaults to the time of 3.5 characters, but at least 1750)"},
+	{Name: "rs485", Type: options.OptionTypeBoolean, Default: "false", Description: "Have the driver of the port switch an RS-485 transceiver to sending (using RTS) while writing"},
+	{Name: "rs485-delay-rts-before-send", Type: options.OptionTypeInteger, Default: "0", Description: "Time in milliseconds between switching the RS-485 transceiver to sending and sending"},
+	{Name: "rs485-delay-rts-after-send", Type: options.OptionTypeInteger, Default: "0", Description: "Time in milliseconds between sending and switching the RS-485 transceiver back to receiving"},
+}
+
+type Parity uint8
+
+const (
+	ParityNone Parity = iota
+	ParityEven
+	ParityOdd
+)
+
+func (m Parity) String() string {
+	switch m {
+	case ParityNone:
+		return "none"
+	case ParityEven:
+		return "even"
+	case ParityOdd:
+		return "odd"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseParity(parity string) (Parity, error) {
+	switch parity {
+	case "none":
+		return ParityNone, nil
+	case "even":
+		return ParityEven, nil
+	case "odd":
+		return ParityOdd, nil
+	default:
+		return ParityNone, errors.Errorf("unknown parity %s", parity)
+	}
+}
+
+// Configuration holds the line settings of a serial port parsed from the connection-string options
+type Configuration struct {
+	BaudRate uint32
+	DataBits uint8
+	Parity   Parity
+	StopBits uint8
+	// Minimum silence between two frames (the end of a frame is detected by the silence following it)
+	InterFrameDelay time.Duration
+	// If set, the driver of the port switches the RS-485 transceiver to sending (using RTS) while writing
+	Rs485                   bool
+	Rs485DelayRtsBeforeSend time.Duration
+	Rs485DelayRtsAfterSend  time.Duration
+}
+
+// ParseConfiguration parses the serial options, using the defaults of the option schema for the missing ones
+func ParseConfiguration(options map[string][]string) (Configuration, error) {
+	configuration := Configuration{
+		BaudRate: 19200,
+		DataBits: 8,
+		Parity:   ParityNone,
+		StopBits: 1,
+	}
+	if values := options["baud-rate"]; len(values) > 0 {
+		baudRate, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil || baudRate == 0 {
+			return Configuration{}, errors.Errorf("invalid baud-rate %s", values[0])
+		}
+		configuration.BaudRate = uint32(baudRate)
+	}
+	if values := options["data-bits"]; len(values) > 0 {
+		dataBits, err := strconv.ParseUint(values[0], 10, 8)
+		if err != nil || dataBits < 5 || dataBits > 8 {
+			return Configuration{}, errors.Errorf("invalid data-bits %s", values[0])
+		}
+		configuration.DataBits = uint8(dataBits)
+	}
+	if values := options["parity"]; len(values) > 0 {
+		parity, err := ParseParity(values[0])
+		if err != nil {
+			return Configuration{}, errors.Wrap(err, "error parsing parity")
+		}
+		configuration.Parity = parity
+	}
+	if values := options["stop-bits"]; len(values) > 0 {
+		stopBits, err := strconv.ParseUint(values[0], 10, 8)
+		if err != nil || stopBits < 1 || stopBits > 2 {
+			return Configuration{}, errors.Errorf("invalid stop-bits %s", values[0])
+		}
+		configuration.StopBits = uint8(stopBits)
+	}
+	configuration.InterFrameDelay = configuration.GetDefaultInterFrameDelay()
+	if values := options["inter-frame-delay"]; len(values) > 0 {
+		interFrameDelay, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return Configuration{}, errors.Wrapf(err, "error parsing inter-frame-delay %s", values[0])
+		}
+		configuration.InterFrameDelay = time.Duration(interFrameDelay) * time.Microsecond
+	}
+	if values := options["rs485"]; len(values) > 0 {
+		rs485, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return Configuration{}, errors.Wrapf(err, "error parsing rs485 %s", values[0])
+		}
+		configuration.Rs485 = rs485
+	}
+	if values := options["rs485-delay-rts-before-send"]; len(values) > 0 {
+		delay, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return Configuration{}, errors.Wrapf(err, "error parsing rs485-delay-rts-before-send %s", values[0])
+		}
+		configuration.Rs485DelayRtsBeforeSend = time.Duration(delay) * time.Millisecond
+	}
+	if values := options["rs485-delay-rts-after-send"]; len(values) > 0 {
+		delay, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return Configuration{}, errors.Wrapf(err, "error parsing rs485-delay-rts-after-send %s", values[0])
+		}
+		configuration.Rs485DelayRtsAfterSend = time.Duration(delay) * time.Millisecond
+	}
+	return configuration, nil
+}
+
+// GetCharacterTime returns how long it takes to transfer one character (including start, parity and stop bits)
+func (m Configuration) GetCharacterTime() time.Duration {
+	bits := 1 + uint32(m.DataBits) + uint32(m.StopBits)
+	if m.Parity != ParityNone {
+		bits++
+	}
+	return time.Duration(bits) * time.Second / time.Duration(m.BaudRate)
+}
+
+// GetDefaultInterFrameDelay returns the silence of 3.5 characters, which separates frames on RS-485 buses. Above
+// 19200 bit/s a fixed 1750 µs are used, as the timers of most devices can't handle shorter delays.
+func (m Configuration) GetDefaultInterFrameDelay() time.Duration {
+	if m.BaudRate > 19200 {
+		return 1750 * time.Microsecond
+	}
+	return m.GetCharacterTime() * 7 / 2
+}
+
+type Transport struct {
+}
+
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+func (m Transport) GetTransportCode() string {
+	return "serial"
+}
+
+func (m Transport) GetTransportName() string {
+	return "Serial Port Transport"
+}
+
+func (m Transport) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
+// CreateTransportInstance creates a transport instance for the port given as path of the transport url
+// (serial:///dev/ttyUSB0). Names without a path are looked up in /dev (serial://ttyUSB0).
+func (m Transport) CreateTransportInstance(transportUrl url.URL, options map[string][]string) (transports.TransportInstance, error) {
+	portName := transportUrl.Host + transportUrl.Path
+	if portName == "" {
+		return nil, errors.New("missing serial port to connect")
+	}
+	if !strings.HasPrefix(portName, "/") {
+		portName = "/dev/" + portName
+	}
+	configuration, err := ParseConfiguration(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing serial port configuration")
+	}
+	return NewTransportInstance(portName, configuration, &m), nil
+}
+
+type TransportInstance struct {
+	PortName      string
+	Configuration Configuration
+	transport     *Transport
+	port          *os.File
+	reader        *bufio.Reader
+	logger        zerolog.Logger
+
+	// Guards lastActivity
+	lock sync.Mutex
+	// When the line became silent after the last character sent or received
+	lastActivity time.Time
+}
+
+func NewTransportInstance(portName string, configuration Configuration, transport *Transport) *TransportInstance {
+	return &TransportInstance{
+		PortName:      portName,
+		Configuration: configuration,
+		transport:     transport,
+		logger:        log.Logger,
+	}
+}
+
+func (m *TransportInstance) SetLogger(logger zerolog.Logger) {
+	m.logger = logger
+}
+
+func (m *TransportInstance) Connect() error {
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *TransportInstance) ConnectWithContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "error opening serial port")
+	}
+	port, err := openPort(m.PortName, m.Configuration)
+	if err != nil {
+		return errors.Wrapf(err, "error opening serial port %s", m.PortName)
+	}
+	m.logger.Debug().
+		Str("portName", m.PortName).
+		Uint32("baudRate", m.Configuration.BaudRate).
+		Stringer("parity", m.Configuration.Parity).
+		Msg("Opened serial port")
+	m.port = port
+	m.reader = bufio.NewReader(activityReader{m})
+	return nil
+}
+
+func (m *TransportInstance) Close() error {
+	if m.port == nil {
+		return nil
+	}
+	err := m.port.Close()
+	if err != nil {
+		return errors.Wrap(err, "error closing serial port")
+	}
+	return nil
+}
+
+func (m *TransportInstance) GetNumReadableBytes() (uint32, error) {
+	if m.reader == nil {
+		return 0, nil
+	}
+	// If nothing is buffered and reading fails, the port is broken (closed or unplugged)
+	if _, err := m.reader.Peek(1); err != nil && m.reader.Buffered() == 0 {
+		return 0, errors.Wrap(err, "error reading from serial port")
+	}
+	return uint32(m.reader.Buffered()), nil
+}
+
+func (m *TransportInstance) WaitForReadableBytes(numBytes uint32) error {
+	if m.reader == nil {
+		return errors.New("error waiting for data. No reader available")
+	}
+	if int(numBytes) > m.reader.Size() {
+		return errors.Errorf("error waiting for data. %d bytes exceed the read buffer size of %d", numBytes, m.reader.Size())
+	}
+	// Peek blocks till the requested bytes are buffered
+	if _, err := m.reader.Peek(int(numBytes)); err != nil {
+		return errors.Wrap(err, "error waiting for data")
+	}
+	return nil
+}
+
+func (m *TransportInstance) PeekReadableBytes(numBytes uint32) ([]uint8, error) {
+	if m.reader == nil {
+		return nil, errors.New("error peeking from transport. No reader available")
+	}
+	return m.reader.Peek(int(numBytes))
+}
+
+func (m *TransportInstance) Read(numBytes uint32) ([]uint8, error) {
+	if m.reader == nil {
+		return nil, errors.New("error reading from transport. No reader available")
+	}
+	data := make([]uint8, numBytes)
+	for i := uint32(0); i < numBytes; i++ {
+		val, err := m.reader.ReadByte()
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading")
+		}
+		data[i] = val
+	}
+	return data, nil
+}
+
+// Write sends the data as one frame. It waits for the inter-frame delay to pass since the line became silent, so the
+// frame isn't taken as part of the previous one.
+func (m *TransportInstance) Write(data []uint8) error {
+	if m.port == nil {
+		return errors.New("error writing to transport. No writer available")
+	}
+	if silence := m.GetSilence(); silence < m.Configuration.InterFrameDelay {
+		time.Sleep(m.Configuration.InterFrameDelay - silence)
+	}
+	num, err := m.port.Write(data)
+	if err != nil {
+		return errors.Wrap(err, "error writing")
+	}
+	if num != len(data) {
+		return errors.New("error writing: not all bytes written")
+	}
+	// The port only buffers the data, so the line is busy till all characters have been transferred
+	m.recordActivity(time.Now().Add(m.Configuration.GetCharacterTime() * time.Duration(len(data))))
+	return nil
+}
+
+// WaitForSilence blocks until nothing has been sent or received for the inter-frame delay, which marks the end of a
+// frame
+func (m *TransportInstance) WaitForSilence() error {
+	if m.reader == nil {
+		return errors.New("error waiting for silence. No reader available")
+	}
+	for {
+		silence := m.GetSilence()
+		if silence >= m.Configuration.InterFrameDelay {
+			return nil
+		}
+		remaining := m.Configuration.InterFrameDelay - silence
+		if m.reader.Buffered() == m.reader.Size() {
+			time.Sleep(remaining)
+			continue
+		}
+		// Characters still arriving are only noticed when they are read, so try reading till the line should be silent
+		if err := m.port.SetReadDeadline(time.Now().Add(remaining)); err != nil {
+			return errors.Wrap(err, "error waiting for silence")
+		}
+		_, err := m.reader.Peek(m.reader.Buffered() + 1)
+		if err := m.port.SetReadDeadline(time.Time{}); err != nil {
+			return errors.Wrap(err, "error waiting for silence")
+		}
+		if err != nil && !os.IsTimeout(err) {
+			return errors.Wrap(err, "error waiting for silence")
+		}
+	}
+}
+
+// GetSilence returns for how long nothing has been sent or received
+func (m *TransportInstance) GetSilence() time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return time.Since(m.lastActivity)
+}
+
+func (m *TransportInstance) recordActivity(lastActivity time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if lastActivity.After(m.lastActivity) {
+		m.lastActivity = lastActivity
+	}
+}
+
+// activityReader records when characters are received
+type activityReader struct {
+	transportInstance *TransportInstance
+}
+
+func (m activityReader) Read(data []byte) (int, error) {
+	num, err := m.transportInstance.port.Read(data)
+	if num > 0 {
+		m.transportInstance.recordActivity(time.Now())
+	}
+	return num, err
+}
@@ -0,0 +1,88 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package serial
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseConfiguration(t *testing.T) {
+	configuration, err := ParseConfiguration(map[string][]string{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := Configuration{BaudRate: 19200, DataBits: 8, Parity: ParityNone, StopBits: 1, InterFrameDelay: 1822915 * time.Nanosecond}
+	if configuration != expected {
+		t.Errorf("Expected %+v, got %+v", expected, configuration)
+	}
+
+	configuration, err = ParseConfiguration(map[string][]string{
+		"baud-rate":                  {"115200"},
+		"data-bits":                  {"7"},
+		"parity":                     {"even"},
+		"stop-bits":                  {"2"},
+		"rs485":                      {"true"},
+		"rs485-delay-rts-after-send": {"2"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = Configuration{BaudRate: 115200, DataBits: 7, Parity: ParityEven, StopBits: 2, InterFrameDelay: 1750 * time.Microsecond, Rs485: true, Rs485DelayRtsAfterSend: 2 * time.Millisecond}
+	if configuration != expected {
+		t.Errorf("Expected %+v, got %+v", expected, configuration)
+	}
+
+	for _, options := range []map[string][]string{
+		{"baud-rate": {"fast"}},
+		{"baud-rate": {"0"}},
+		{"data-bits": {"9"}},
+		{"parity": {"mark"}},
+		{"stop-bits": {"3"}},
+		{"inter-frame-delay": {"-1"}},
+	} {
+		if _, err := ParseConfiguration(options); err == nil {
+			t.Errorf("Expected an error for %v", options)
+		}
+	}
+}
+
+func TestTransport_CreateTransportInstance(t *testing.T) {
+	transport := NewTransport()
+	for _, transportUrl := range []url.URL{
+		{Scheme: "serial", Path: "/dev/ttyUSB0"},
+		{Scheme: "serial", Host: "ttyUSB0"},
+	} {
+		transportInstance, err := transport.CreateTransportInstance(transportUrl, map[string][]string{"inter-frame-delay": {"5000"}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		serialTransportInstance := transportInstance.(*TransportInstance)
+		if serialTransportInstance.PortName != "/dev/ttyUSB0" {
+			t.Errorf("Expected port /dev/ttyUSB0, got %s", serialTransportInstance.PortName)
+		}
+		if serialTransportInstance.Configuration.InterFrameDelay != 5*time.Millisecond {
+			t.Errorf("Expected an inter-frame delay of 5ms, got %s", serialTransportInstance.Configuration.InterFrameDelay)
+		}
+	}
+	if _, err := transport.CreateTransportInstance(url.URL{Scheme: "serial"}, map[string][]string{}); err == nil {
+		t.Errorf("Expected an error without a port")
+	}
+}
@@ -20,15 +20,25 @@ package tcp
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
-	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
+	"io"
 	"net"
 	"net/url"
-	"regexp"
 	"strconv"
+	"time"
 )
 
+var optionSchema = options.OptionSchema{
+	{Name: "connect-timeout", Type: options.OptionTypeInteger, Default: "1000", Description: "Timeout for establishing the connection in milliseconds"},
+	{Name: "keep-alive", Type: options.OptionTypeInteger, Default: "15", Description: "Interval of TCP keep-alive probes in seconds (0 disables them)"},
+	{Name: "no-delay", Type: options.OptionTypeBoolean, Default: "true", Description: "Send small writes right away instead of collecting them (disables Nagle's algorithm)"},
+	{Name: "read-timeout", Type: options.OptionTypeInteger, Default: "0", Description: "Time in milliseconds without receiving data, after which the connection is considered broken (0 waits forever)"},
+}.Merge(transports.LocalAddressOptionSchema)
+
 type Transport struct {
 }
 
@@ -44,92 +54,195 @@ func (m Transport) GetTransportName() string {
 	return "TCP/IP Socket Transport"
 }
 
+func (m Transport) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
 func (m Transport) CreateTransportInstance(transportUrl url.URL, options map[string][]string) (transports.TransportInstance, error) {
-	connectionStringRegexp := regexp.MustCompile(`^((?P<ip>[0-9]{1,3}.[0-9]{1,3}.[0-9]{1,3}.[0-9]{1,3})|(?P<hostname>[a-zA-Z0-9.\-]+))(:(?P<port>[0-9]{1,5}))?`)
-	var address string
-	var port int
-	if match := utils.GetSubgroupMatches(connectionStringRegexp, transportUrl.Host); match != nil {
-		if val, ok := match["ip"]; ok && len(val) > 0 {
-			address = val
-		} else if val, ok := match["hostname"]; ok && len(val) > 0 {
-			address = val
-		} else {
-			return nil, errors.New("missing hostname or ip to connect")
-		}
-		if val, ok := match["port"]; ok && len(val) > 0 {
-			portVal, err := strconv.Atoi(val)
-			if err != nil {
-				return nil, errors.Wrap(err, "error setting port")
-			} else {
-				port = portVal
-			}
-		} else if val, ok := options["defaultTcpPort"]; ok && len(val) > 0 {
-			portVal, err := strconv.Atoi(val[0])
-			if err != nil {
-				return nil, errors.Wrap(err, "error setting default tcp port")
-			}
-			port = portVal
-		} else {
-			return nil, errors.New("error setting port. No explicit or default port provided")
-		}
+	transportInstance, err := NewTransportInstanceFromOptions(transportUrl, options, &m)
+	if err != nil {
+		return nil, err
 	}
-	var connectTimeout uint32 = 1000
-	if val, ok := options["connect-timeout"]; ok {
-		integerValue, err := strconv.Atoi(val[0])
-		if err != nil {
-			return nil, errors.Wrap(err, "error setting connect-timeout")
+
+	castFunc := func(typ interface{}) (transports.TransportInstance, error) {
+		if transportInstance, ok := typ.(transports.TransportInstance); ok {
+			return transportInstance, nil
 		}
-		connectTimeout = uint32(integerValue)
+		return nil, errors.New("couldn't cast to TransportInstance")
+	}
+	return castFunc(transportInstance)
+}
+
+// NewTransportInstanceFromOptions creates a transport instance for the transport url, configured by the options
+func NewTransportInstanceFromOptions(transportUrl url.URL, options map[string][]string, transport *Transport) (*TransportInstance, error) {
+	address, port, err := ParseAddress(transportUrl, options)
+	if err != nil {
+		return nil, err
+	}
+	connectTimeout, err := ParseConnectTimeout(options)
+	if err != nil {
+		return nil, err
 	}
 
 	// Potentially resolve the ip address, if a hostname was provided
-	tcpAddr, err := net.ResolveTCPAddr("tcp", address+":"+strconv.Itoa(port))
+	tcpAddr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(address, strconv.Itoa(port)))
 	if err != nil {
 		return nil, errors.Wrap(err, "error resolving typ address")
 	}
 
-	transportInstance := NewTcpTransportInstance(tcpAddr, connectTimeout, &m)
+	transportInstance := NewTcpTransportInstance(tcpAddr, connectTimeout, transport)
+	if values := options["keep-alive"]; len(values) > 0 {
+		keepAlive, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid keep-alive %s", values[0])
+		}
+		transportInstance.KeepAlive = time.Duration(keepAlive) * time.Second
+	}
+	if values := options["no-delay"]; len(values) > 0 {
+		noDelay, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return nil, errors.Errorf("invalid no-delay %s", values[0])
+		}
+		transportInstance.NoDelay = noDelay
+	}
+	if values := options["read-timeout"]; len(values) > 0 {
+		readTimeout, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid read-timeout %s", values[0])
+		}
+		transportInstance.ReadTimeout = time.Duration(readTimeout) * time.Millisecond
+	}
+	localAddress, err := transports.ParseLocalAddress(options, tcpAddr.IP)
+	if err != nil {
+		return nil, err
+	}
+	if localAddress != nil {
+		transportInstance.LocalBindAddress = &net.TCPAddr{IP: localAddress.IP, Zone: localAddress.Zone}
+	}
+	return transportInstance, nil
+}
 
-	castFunc := func(typ interface{}) (transports.TransportInstance, error) {
-		if transportInstance, ok := typ.(transports.TransportInstance); ok {
-			return transportInstance, nil
+// ParseAddress returns the host (name or ip) and the port of the transport url. Without a port the default port
+// provided by the driver is used.
+func ParseAddress(transportUrl url.URL, options map[string][]string) (string, int, error) {
+	return transports.ParseHostPort(transportUrl, options["defaultTcpPort"])
+}
+
+// ParseConnectTimeout returns the connect-timeout option in milliseconds
+func ParseConnectTimeout(options map[string][]string) (uint32, error) {
+	var connectTimeout uint32 = 1000
+	if val, ok := options["connect-timeout"]; ok {
+		integerValue, err := strconv.Atoi(val[0])
+		if err != nil {
+			return 0, errors.Wrap(err, "error setting connect-timeout")
 		}
-		return nil, errors.New("couldn't cast to TransportInstance")
+		connectTimeout = uint32(integerValue)
 	}
-	return castFunc(transportInstance)
+	return connectTimeout, nil
 }
 
 type TransportInstance struct {
 	RemoteAddress  *net.TCPAddr
 	LocalAddress   *net.TCPAddr
 	ConnectTimeout uint32
-	transport      *Transport
-	tcpConn        net.Conn
-	reader         *bufio.Reader
+	// Interval of keep-alive probes (0 disables them)
+	KeepAlive time.Duration
+	NoDelay   bool
+	// Time without receiving data, after which the connection is considered broken (0 waits forever)
+	ReadTimeout time.Duration
+	// If set, the socket is bound to this address before connecting
+	LocalBindAddress *net.TCPAddr
+	// If set, TLS is used on top of the connection (see the tls transport)
+	TlsConfig *tls.Config
+	transport *Transport
+	tcpConn   net.Conn
+	reader    *bufio.Reader
 }
 
 func NewTcpTransportInstance(remoteAddress *net.TCPAddr, connectTimeout uint32, transport *Transport) *TransportInstance {
 	return &TransportInstance{
 		RemoteAddress:  remoteAddress,
 		ConnectTimeout: connectTimeout,
+		KeepAlive:      15 * time.Second,
+		NoDelay:        true,
 		transport:      transport,
 	}
 }
 
 func (m *TransportInstance) Connect() error {
-	var err error
-	m.tcpConn, err = net.Dial("tcp", m.RemoteAddress.String())
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *TransportInstance) ConnectWithContext(ctx context.Context) error {
+	// The connect timeout covers the TLS handshake as well
+	if m.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(m.ConnectTimeout)*time.Millisecond)
+		defer cancel()
+	}
+	d := net.Dialer{
+		KeepAlive: m.KeepAlive,
+	}
+	if m.KeepAlive == 0 {
+		d.KeepAlive = -1
+	}
+	if m.LocalBindAddress != nil {
+		d.LocalAddr = m.LocalBindAddress
+	}
+	conn, err := d.DialContext(ctx, "tcp", m.RemoteAddress.String())
 	if err != nil {
 		return errors.Wrap(err, "error connecting to remote address")
 	}
+	if err := conn.(*net.TCPConn).SetNoDelay(m.NoDelay); err != nil {
+		_ = conn.Close()
+		return errors.Wrap(err, "error setting no-delay")
+	}
+	m.LocalAddress = conn.LocalAddr().(*net.TCPAddr)
 
-	m.LocalAddress = m.tcpConn.LocalAddr().(*net.TCPAddr)
+	if m.TlsConfig != nil {
+		conn, err = handshake(ctx, conn, m.TlsConfig)
+		if err != nil {
+			return errors.Wrap(err, "error connecting to remote address")
+		}
+	}
+	m.tcpConn = conn
 
-	m.reader = bufio.NewReader(m.tcpConn)
+	var reader io.Reader = m.tcpConn
+	if m.ReadTimeout > 0 {
+		reader = transports.DeadlineReader{Conn: m.tcpConn, Timeout: m.ReadTimeout}
+	}
+	m.reader = bufio.NewReader(reader)
 
 	return nil
 }
 
+// handshake establishes TLS on top of the connection. The connection is closed, if that fails.
+func handshake(ctx context.Context, conn net.Conn, tlsConfig *tls.Config) (net.Conn, error) {
+	tlsConn := tls.Client(conn, tlsConfig)
+	// Closing the connection aborts the handshake as soon as the context is done
+	handshakeDone := make(chan struct{})
+	aborted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			aborted <- true
+		case <-handshakeDone:
+			aborted <- false
+		}
+	}()
+	err := tlsConn.Handshake()
+	close(handshakeDone)
+	if <-aborted {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
 func (m *TransportInstance) Close() error {
 	if m.tcpConn == nil {
 		return nil
@@ -145,10 +258,27 @@ func (m *TransportInstance) GetNumReadableBytes() (uint32, error) {
 	if m.reader == nil {
 		return 0, nil
 	}
-	_, _ = m.reader.Peek(1)
+	// If nothing is buffered and the connection fails, it's broken (closed by the remote or reset)
+	if _, err := m.reader.Peek(1); err != nil && m.reader.Buffered() == 0 {
+		return 0, errors.Wrap(err, "error reading from connection")
+	}
 	return uint32(m.reader.Buffered()), nil
 }
 
+func (m *TransportInstance) WaitForReadableBytes(numBytes uint32) error {
+	if m.reader == nil {
+		return errors.New("error waiting for data. No reader available")
+	}
+	if int(numBytes) > m.reader.Size() {
+		return errors.Errorf("error waiting for data. %d bytes exceed the read buffer size of %d", numBytes, m.reader.Size())
+	}
+	// Peek blocks till the requested bytes are buffered
+	if _, err := m.reader.Peek(int(numBytes)); err != nil {
+		return errors.Wrap(err, "error waiting for data")
+	}
+	return nil
+}
+
 func (m *TransportInstance) PeekReadableBytes(numBytes uint32) ([]uint8, error) {
 	if m.reader == nil {
 		return nil, errors.New("error peeking from transport. No reader available")
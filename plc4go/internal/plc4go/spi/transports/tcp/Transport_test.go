@@ -0,0 +1,125 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package tcp
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// listen starts a server echoing everything received, reporting the remote address of each connection
+func listen(t *testing.T, network string, address string) (*net.TCPAddr, <-chan net.Addr) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Skipf("Can't listen on %s: %v", address, err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	clients := make(chan net.Addr, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			clients <- conn.RemoteAddr()
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr), clients
+}
+
+func TestTransportInstance_IPv6(t *testing.T) {
+	serverAddress, clients := listen(t, "tcp6", "[::1]:0")
+	transportInstance, err := NewTransportInstanceFromOptions(url.URL{Host: "[::1]"}, map[string][]string{
+		"defaultTcpPort": {strconv.Itoa(serverAddress.Port)},
+		"local-address":  {"::1"},
+		"keep-alive":     {"0"},
+		"no-delay":       {"false"},
+	}, NewTransport())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if transportInstance.KeepAlive != 0 || transportInstance.NoDelay || !transportInstance.LocalBindAddress.IP.Equal(net.IPv6loopback) {
+		t.Errorf("Unexpected configuration %+v", transportInstance)
+	}
+	if err := transportInstance.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer transportInstance.Close()
+	if client := (<-clients).(*net.TCPAddr); !client.IP.Equal(net.IPv6loopback) {
+		t.Errorf("Expected a connection from ::1, got %s", client)
+	}
+
+	if err := transportInstance.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := transportInstance.WaitForReadableBytes(3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, err := transportInstance.Read(3); err != nil || string(data) != "\x01\x02\x03" {
+		t.Errorf("Expected the data sent, got %v (%v)", data, err)
+	}
+}
+
+func TestTransportInstance_ReadTimeout(t *testing.T) {
+	serverAddress, _ := listen(t, "tcp4", "127.0.0.1:0")
+	transportInstance, err := NewTransportInstanceFromOptions(url.URL{Host: serverAddress.String()}, map[string][]string{
+		"read-timeout": {"50"},
+	}, NewTransport())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := transportInstance.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer transportInstance.Close()
+
+	// Nothing is sent, so nothing is received
+	start := time.Now()
+	if _, err := transportInstance.GetNumReadableBytes(); err == nil {
+		t.Error("Expected reading to time out")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("Expected reading to time out after 50ms, took %s", elapsed)
+	}
+}
+
+func TestNewTransportInstanceFromOptions_Invalid(t *testing.T) {
+	for _, options := range []map[string][]string{
+		{"keep-alive": {"-1"}},
+		{"no-delay": {"maybe"}},
+		{"read-timeout": {"soon"}},
+		{"local-address": {"localhost"}},
+		{"connect-timeout": {"never"}},
+	} {
+		if _, err := NewTransportInstanceFromOptions(url.URL{Host: "127.0.0.1:502"}, options, NewTransport()); err == nil {
+			t.Errorf("Expected an error for %v", options)
+		}
+	}
+}
@@ -19,10 +19,14 @@
 package test
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"net/url"
+	"sync"
 )
 
 type Transport struct {
@@ -41,6 +45,10 @@ func (m Transport) GetTransportName() string {
 	return "Test Transport"
 }
 
+func (m Transport) GetOptionSchema() options.OptionSchema {
+	return options.OptionSchema{}
+}
+
 func (m Transport) CreateTransportInstance(transportUrl url.URL, options map[string][]string) (transports.TransportInstance, error) {
 	log.Trace().Msg("create transport instance")
 	transportInstance := NewTransportInstance(&m)
@@ -58,63 +66,128 @@ type TransportInstance struct {
 	readBuffer  []byte
 	writeBuffer []byte
 	transport   *Transport
+	closed      bool
+	// Closed (and replaced) whenever data is added to the read buffer or the instance is closed
+	readBufferChanged chan struct{}
+	lock              sync.Mutex
+	logger            zerolog.Logger
 }
 
 func NewTransportInstance(transport *Transport) *TransportInstance {
 	return &TransportInstance{
-		readBuffer:  []byte{},
-		writeBuffer: []byte{},
-		transport:   transport,
+		readBuffer:        []byte{},
+		writeBuffer:       []byte{},
+		transport:         transport,
+		readBufferChanged: make(chan struct{}),
+		logger:            log.Logger,
 	}
 }
 
+func (m *TransportInstance) SetLogger(logger zerolog.Logger) {
+	m.logger = logger
+}
+
 func (m *TransportInstance) Connect() error {
-	log.Trace().Msg("Connect")
+	m.logger.Trace().Msg("Connect")
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *TransportInstance) ConnectWithContext(ctx context.Context) error {
+	m.logger.Trace().Msg("Connect with context")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = false
 	return nil
 }
 
 func (m *TransportInstance) Close() error {
-	log.Trace().Msg("Close")
+	m.logger.Trace().Msg("Close")
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = true
+	m.notifyReadBufferChanged()
 	return nil
 }
 
 func (m *TransportInstance) GetNumReadableBytes() (uint32, error) {
-	log.Trace().Msg("get number of readable bytes")
+	m.logger.Trace().Msg("get number of readable bytes")
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return uint32(len(m.readBuffer)), nil
 }
 
+func (m *TransportInstance) WaitForReadableBytes(numBytes uint32) error {
+	m.logger.Trace().Msgf("Wait for %d readable bytes", numBytes)
+	for {
+		m.lock.Lock()
+		if m.closed {
+			m.lock.Unlock()
+			return errors.New("transport instance closed")
+		}
+		if uint32(len(m.readBuffer)) >= numBytes {
+			m.lock.Unlock()
+			return nil
+		}
+		readBufferChanged := m.readBufferChanged
+		m.lock.Unlock()
+		<-readBufferChanged
+	}
+}
+
 func (m *TransportInstance) PeekReadableBytes(numBytes uint32) ([]uint8, error) {
-	log.Trace().Msgf("Peek %d readable bytes", numBytes)
+	m.logger.Trace().Msgf("Peek %d readable bytes", numBytes)
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.readBuffer[0:numBytes], nil
 }
 
 func (m *TransportInstance) Read(numBytes uint32) ([]uint8, error) {
-	log.Trace().Msgf("Read num bytes %d", numBytes)
+	m.logger.Trace().Msgf("Read num bytes %d", numBytes)
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	data := m.readBuffer[0:int(numBytes)]
 	m.readBuffer = m.readBuffer[int(numBytes):]
 	return data, nil
 }
 
 func (m *TransportInstance) Write(data []uint8) error {
-	log.Trace().Msgf("Write data 0x%x", data)
+	m.logger.Trace().Msgf("Write data 0x%x", data)
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.writeBuffer = append(m.writeBuffer, data...)
 	return nil
 }
 
 func (m *TransportInstance) FillReadBuffer(data []uint8) error {
-	log.Trace().Msgf("FillReadBuffer with 0x%x", data)
+	m.logger.Trace().Msgf("FillReadBuffer with 0x%x", data)
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.readBuffer = append(m.readBuffer, data...)
+	m.notifyReadBufferChanged()
 	return nil
 }
 
 func (m *TransportInstance) GetNumDrainableBytes() uint32 {
-	log.Trace().Msg("get number of drainable bytes")
+	m.logger.Trace().Msg("get number of drainable bytes")
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return uint32(len(m.writeBuffer))
 }
 
 func (m *TransportInstance) DrainWriteBuffer(numBytes uint32) ([]uint8, error) {
-	log.Trace().Msgf("Drain write buffer with number of bytes %d", numBytes)
+	m.logger.Trace().Msgf("Drain write buffer with number of bytes %d", numBytes)
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	data := m.writeBuffer[0:int(numBytes)]
 	m.writeBuffer = m.writeBuffer[int(numBytes):]
 	return data, nil
 }
+
+// notifyReadBufferChanged wakes up everyone waiting for data (has to be called while holding the lock)
+func (m *TransportInstance) notifyReadBufferChanged() {
+	close(m.readBufferChanged)
+	m.readBufferChanged = make(chan struct{})
+}
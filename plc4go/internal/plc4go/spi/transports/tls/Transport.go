@@ -0,0 +1,132 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/tcp"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/url"
+)
+
+var optionSchema = options.OptionSchema{
+	{Name: "certificate-file", Type: options.OptionTypeString, Description: "PEM file with the client certificate (and its chain)"},
+	{Name: "key-file", Type: options.OptionTypeString, Description: "PEM file with the private key of the client certificate"},
+	{Name: "ca-file", Type: options.OptionTypeString, Description: "PEM file with the CA certificates the server certificate has to be issued by (instead of the system ones)"},
+	{Name: "server-name", Type: options.OptionTypeString, Description: "Name the server certificate is verified against (defaults to the host)"},
+	{Name: "min-version", Type: options.OptionTypeString, Default: "1.2", AllowedValues: []string{"1.0", "1.1", "1.2", "1.3"}, Description: "Minimum TLS version"},
+}.Merge(tcp.NewTransport().GetOptionSchema())
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+type Transport struct {
+}
+
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+func (m Transport) GetTransportCode() string {
+	return "tls"
+}
+
+func (m Transport) GetTransportName() string {
+	return "TLS Socket Transport"
+}
+
+func (m Transport) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
+func (m Transport) CreateTransportInstance(transportUrl url.URL, options map[string][]string) (transports.TransportInstance, error) {
+	tcpTransportInstance, err := tcp.NewTransportInstanceFromOptions(transportUrl, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := ParseConfiguration(transportUrl.Hostname(), options)
+	if err != nil {
+		return nil, err
+	}
+	return NewTransportInstance(tcpTransportInstance, tlsConfig, &m), nil
+}
+
+// ParseConfiguration builds the TLS configuration for connecting to the given host from the options
+func ParseConfiguration(host string, options map[string][]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+	if values := options["server-name"]; len(values) > 0 {
+		tlsConfig.ServerName = values[0]
+	}
+	if values := options["min-version"]; len(values) > 0 {
+		version, ok := versions[values[0]]
+		if !ok {
+			return nil, errors.Errorf("invalid min-version %s", values[0])
+		}
+		tlsConfig.MinVersion = version
+	}
+	certificateFiles, keyFiles := options["certificate-file"], options["key-file"]
+	if len(certificateFiles) != len(keyFiles) {
+		return nil, errors.New("certificate-file and key-file have to be provided together")
+	}
+	if len(certificateFiles) > 0 {
+		certificate, err := tls.LoadX509KeyPair(certificateFiles[0], keyFiles[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if values := options["ca-file"]; len(values) > 0 {
+		pem, err := ioutil.ReadFile(values[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading ca-file")
+		}
+		// Only the given CAs are trusted
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in ca-file %s", values[0])
+		}
+	}
+	return tlsConfig, nil
+}
+
+// TransportInstance is a tcp transport instance using TLS on top of the connection
+type TransportInstance struct {
+	*tcp.TransportInstance
+	transport *Transport
+}
+
+func NewTransportInstance(tcpTransportInstance *tcp.TransportInstance, tlsConfig *tls.Config, transport *Transport) *TransportInstance {
+	tcpTransportInstance.TlsConfig = tlsConfig
+	return &TransportInstance{
+		TransportInstance: tcpTransportInstance,
+		transport:         transport,
+	}
+}
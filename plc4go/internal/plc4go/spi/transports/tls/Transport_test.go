@@ -0,0 +1,277 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testPki holds a CA along with a server and a client certificate issued by it
+type testPki struct {
+	caPool            *x509.CertPool
+	server            tls.Certificate
+	caFile            string
+	certificateFile   string
+	keyFile           string
+	serialNumberCount int64
+}
+
+func newTestPki(t *testing.T) *testPki {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	m := &testPki{caPool: x509.NewCertPool()}
+	caTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, caKey := m.issue(t, caTemplate, nil, nil)
+	ca, _ := x509.ParseCertificate(caDer)
+	m.caPool.AddCert(ca)
+	m.caFile = writePem(t, dir, "ca.pem", "CERTIFICATE", caDer)
+
+	serverDer, serverKey := m.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "plc"},
+		DNSNames:    []string{"plc.local"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	m.server = tls.Certificate{Certificate: [][]byte{serverDer}, PrivateKey: serverKey}
+
+	clientDer, clientKey := m.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	m.certificateFile = writePem(t, dir, "client.pem", "CERTIFICATE", clientDer)
+	clientKeyDer, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.keyFile = writePem(t, dir, "client.key", "EC PRIVATE KEY", clientKeyDer)
+	return m
+}
+
+// issue creates a certificate from the template, self-signed without issuer
+func (m *testPki) issue(t *testing.T, template *x509.Certificate, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) ([]byte, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.serialNumberCount++
+	template.SerialNumber = big.NewInt(m.serialNumberCount)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if issuer == nil {
+		issuer, issuerKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return der, key
+}
+
+func writePem(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return path
+}
+
+// listen starts a server echoing the first 4 bytes of each connection, requiring a client certificate
+func (m *testPki) listen(t *testing.T, maxVersion uint16) (int, <-chan string) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{m.server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    m.caPool,
+		MaxVersion:   maxVersion,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	clients := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			data := make([]byte, 4)
+			if _, err := io.ReadFull(conn, data); err == nil {
+				clients <- conn.(*tls.Conn).ConnectionState().PeerCertificates[0].Subject.CommonName
+				_, _ = conn.Write(data)
+			}
+			_ = conn.Close()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, clients
+}
+
+func TestTransportInstance_Connect(t *testing.T) {
+	pki := newTestPki(t)
+	port, clients := pki.listen(t, 0)
+	transportInstance, err := NewTransport().CreateTransportInstance(url.URL{Host: "127.0.0.1:" + strconv.Itoa(port)}, map[string][]string{
+		"certificate-file": {pki.certificateFile},
+		"key-file":         {pki.keyFile},
+		"ca-file":          {pki.caFile},
+		"server-name":      {"plc.local"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := transportInstance.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer transportInstance.Close()
+
+	if err := transportInstance.Write([]byte{1, 2, 3, 4}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client := <-clients; client != "client" {
+		t.Errorf("Expected the client certificate to be presented, got %s", client)
+	}
+	if err := transportInstance.(*TransportInstance).WaitForReadableBytes(4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, err := transportInstance.Read(4); err != nil || string(data) != "\x01\x02\x03\x04" {
+		t.Errorf("Expected the data sent, got %v (%v)", data, err)
+	}
+}
+
+func TestTransportInstance_ConnectFailures(t *testing.T) {
+	pki := newTestPki(t)
+	tests := []struct {
+		name       string
+		maxVersion uint16
+		options    map[string][]string
+	}{
+		{"server certificate not issued by a trusted CA", 0, map[string][]string{
+			"certificate-file": {pki.certificateFile},
+			"key-file":         {pki.keyFile},
+			"server-name":      {"plc.local"},
+		}},
+		{"server name not matching", 0, map[string][]string{
+			"certificate-file": {pki.certificateFile},
+			"key-file":         {pki.keyFile},
+			"ca-file":          {pki.caFile},
+		}},
+		{"server version too old", tls.VersionTLS12, map[string][]string{
+			"certificate-file": {pki.certificateFile},
+			"key-file":         {pki.keyFile},
+			"ca-file":          {pki.caFile},
+			"server-name":      {"plc.local"},
+			"min-version":      {"1.3"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, _ := pki.listen(t, tt.maxVersion)
+			transportInstance, err := NewTransport().CreateTransportInstance(url.URL{Host: "127.0.0.1:" + strconv.Itoa(port)}, tt.options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := transportInstance.Connect(); err == nil {
+				_ = transportInstance.Close()
+				t.Error("Expected the connection to fail")
+			}
+		})
+	}
+}
+
+func TestTransportInstance_HandshakeTimeout(t *testing.T) {
+	// The server accepts connections, but never answers the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	transportInstance, err := NewTransport().CreateTransportInstance(url.URL{Host: listener.Addr().String()}, map[string][]string{
+		"connect-timeout": {"100"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Now()
+	if err := transportInstance.Connect(); err == nil {
+		_ = transportInstance.Close()
+		t.Error("Expected the connection to fail")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("Expected the handshake to time out after 100ms, took %s", elapsed)
+	}
+}
+
+func TestParseConfiguration(t *testing.T) {
+	pki := newTestPki(t)
+	tlsConfig, err := ParseConfiguration("10.0.0.1", map[string][]string{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tlsConfig.ServerName != "10.0.0.1" || tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) != 0 {
+		t.Errorf("Unexpected defaults %+v", tlsConfig)
+	}
+
+	for _, options := range []map[string][]string{
+		{"certificate-file": {pki.certificateFile}},
+		{"certificate-file": {pki.certificateFile}, "key-file": {pki.caFile}},
+		{"ca-file": {pki.keyFile}},
+		{"ca-file": {pki.caFile + ".missing"}},
+		{"min-version": {"1.4"}},
+	} {
+		if _, err := ParseConfiguration("10.0.0.1", options); err == nil {
+			t.Errorf("Expected an error for %v", options)
+		}
+	}
+}
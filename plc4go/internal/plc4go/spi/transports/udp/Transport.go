@@ -20,15 +20,22 @@ package udp
 
 import (
 	"bufio"
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
-	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"github.com/pkg/errors"
+	"io"
 	"net"
 	"net/url"
-	"regexp"
 	"strconv"
+	"time"
 )
 
+var optionSchema = options.OptionSchema{
+	{Name: "connect-timeout", Type: options.OptionTypeInteger, Default: "1000", Description: "Timeout for establishing the connection in milliseconds"},
+	{Name: "read-timeout", Type: options.OptionTypeInteger, Default: "0", Description: "Time in milliseconds without receiving data, after which the connection is considered broken (0 waits forever)"},
+}.Merge(transports.LocalAddressOptionSchema)
+
 type Transport struct {
 	transports.Transport
 }
@@ -45,38 +52,18 @@ func (m Transport) GetTransportName() string {
 	return "UDP Datagram Transport"
 }
 
+func (m Transport) GetOptionSchema() options.OptionSchema {
+	return optionSchema
+}
+
 func (m Transport) CreateTransportInstance(transportUrl url.URL, options map[string][]string) (transports.TransportInstance, error) {
 	return m.CreateTransportInstanceForLocalAddress(transportUrl, options, nil)
 }
 
 func (m Transport) CreateTransportInstanceForLocalAddress(transportUrl url.URL, options map[string][]string, localAddress *net.UDPAddr) (transports.TransportInstance, error) {
-	connectionStringRegexp := regexp.MustCompile(`^((?P<ip>[0-9]{1,3}.[0-9]{1,3}.[0-9]{1,3}.[0-9]{1,3})|(?P<hostname>[a-zA-Z0-9.\-]+))(:(?P<port>[0-9]{1,5}))?`)
-	var remoteAddressString string
-	var remotePort int
-	if match := utils.GetSubgroupMatches(connectionStringRegexp, transportUrl.Host); match != nil {
-		if val, ok := match["ip"]; ok && len(val) > 0 {
-			remoteAddressString = val
-		} else if val, ok := match["hostname"]; ok && len(val) > 0 {
-			remoteAddressString = val
-		} else {
-			return nil, errors.New("missing hostname or ip to connect")
-		}
-
-		if val, ok := match["port"]; ok && len(val) > 0 {
-			portVal, err := strconv.Atoi(val)
-			if err != nil {
-				return nil, errors.Wrap(err, "error setting port")
-			}
-			remotePort = portVal
-		} else if val, ok := options["defaultUdpPort"]; ok && len(val) > 0 {
-			portVal, err := strconv.Atoi(val[0])
-			if err != nil {
-				return nil, errors.Wrap(err, "error setting default udp port")
-			}
-			remotePort = portVal
-		} else {
-			return nil, errors.New("error setting port. No explicit or default port provided")
-		}
+	remoteAddressString, remotePort, err := transports.ParseHostPort(transportUrl, options["defaultUdpPort"])
+	if err != nil {
+		return nil, err
 	}
 	var connectTimeout uint32 = 1000
 	if val, ok := options["connect-timeout"]; ok {
@@ -89,12 +76,29 @@ func (m Transport) CreateTransportInstanceForLocalAddress(transportUrl url.URL,
 	}
 
 	// Potentially resolve the ip address, if a hostname was provided
-	remoteAddress, err := net.ResolveUDPAddr("udp", remoteAddressString+":"+strconv.Itoa(remotePort))
+	remoteAddress, err := net.ResolveUDPAddr("udp", net.JoinHostPort(remoteAddressString, strconv.Itoa(remotePort)))
 	if err != nil {
 		return nil, errors.Wrap(err, "error resolving typ address")
 	}
+	// An explicitly provided local address takes precedence over the options
+	if localAddress == nil {
+		optionsLocalAddress, err := transports.ParseLocalAddress(options, remoteAddress.IP)
+		if err != nil {
+			return nil, err
+		}
+		if optionsLocalAddress != nil {
+			localAddress = &net.UDPAddr{IP: optionsLocalAddress.IP, Zone: optionsLocalAddress.Zone}
+		}
+	}
 
 	transportInstance := NewTransportInstance(localAddress, remoteAddress, connectTimeout, &m)
+	if values := options["read-timeout"]; len(values) > 0 {
+		readTimeout, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid read-timeout %s", values[0])
+		}
+		transportInstance.ReadTimeout = time.Duration(readTimeout) * time.Millisecond
+	}
 
 	castFunc := func(typ interface{}) (transports.TransportInstance, error) {
 		if transportInstance, ok := typ.(transports.TransportInstance); ok {
@@ -109,9 +113,11 @@ type TransportInstance struct {
 	LocalAddress   *net.UDPAddr
 	RemoteAddress  *net.UDPAddr
 	ConnectTimeout uint32
-	transport      *Transport
-	udpConn        *net.UDPConn
-	reader         *bufio.Reader
+	// Time without receiving data, after which the connection is considered broken (0 waits forever)
+	ReadTimeout time.Duration
+	transport   *Transport
+	udpConn     *net.UDPConn
+	reader      *bufio.Reader
 }
 
 func NewTransportInstance(localAddress *net.UDPAddr, remoteAddress *net.UDPAddr, connectTimeout uint32, transport *Transport) *TransportInstance {
@@ -124,10 +130,20 @@ func NewTransportInstance(localAddress *net.UDPAddr, remoteAddress *net.UDPAddr,
 }
 
 func (m *TransportInstance) Connect() error {
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *TransportInstance) ConnectWithContext(ctx context.Context) error {
+	if m.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(m.ConnectTimeout)*time.Millisecond)
+		defer cancel()
+	}
 	// If we haven't provided a local address, have the system figure it out by dialing
 	// the remote address and then using that connections local address as local address.
 	if m.LocalAddress == nil {
-		udpTest, err := net.Dial("udp", m.RemoteAddress.String())
+		var d net.Dialer
+		udpTest, err := d.DialContext(ctx, "udp", m.RemoteAddress.String())
 		if err != nil {
 			return errors.Wrap(err, "error connecting to remote address")
 		}
@@ -139,6 +155,9 @@ func (m *TransportInstance) Connect() error {
 	}
 
 	// "connect" to the remote
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "error connecting to remote address")
+	}
 	var err error
 	m.udpConn, err = net.ListenUDP("udp", m.LocalAddress)
 	if err != nil {
@@ -155,7 +174,11 @@ func (m *TransportInstance) Connect() error {
 	        }
 	    }
 	}()*/
-	m.reader = bufio.NewReader(m.udpConn)
+	var reader io.Reader = m.udpConn
+	if m.ReadTimeout > 0 {
+		reader = transports.DeadlineReader{Conn: m.udpConn, Timeout: m.ReadTimeout}
+	}
+	m.reader = bufio.NewReader(reader)
 
 	return nil
 }
@@ -175,10 +198,27 @@ func (m *TransportInstance) GetNumReadableBytes() (uint32, error) {
 	if m.reader == nil {
 		return 0, nil
 	}
-	_, _ = m.reader.Peek(1)
+	// If nothing is buffered and the connection fails, it's broken (closed by the remote or reset)
+	if _, err := m.reader.Peek(1); err != nil && m.reader.Buffered() == 0 {
+		return 0, errors.Wrap(err, "error reading from connection")
+	}
 	return uint32(m.reader.Buffered()), nil
 }
 
+func (m *TransportInstance) WaitForReadableBytes(numBytes uint32) error {
+	if m.reader == nil {
+		return errors.New("error waiting for data. No reader available")
+	}
+	if int(numBytes) > m.reader.Size() {
+		return errors.Errorf("error waiting for data. %d bytes exceed the read buffer size of %d", numBytes, m.reader.Size())
+	}
+	// Peek blocks till the requested bytes are buffered
+	if _, err := m.reader.Peek(int(numBytes)); err != nil {
+		return errors.Wrap(err, "error waiting for data")
+	}
+	return nil
+}
+
 func (m *TransportInstance) PeekReadableBytes(numBytes uint32) ([]uint8, error) {
 	if m.reader == nil {
 		return nil, errors.New("error peeking from transport. No reader available")
@@ -0,0 +1,76 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package udp
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTransportInstance_Exchange(t *testing.T) {
+	remote, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer remote.Close()
+
+	transportInstance, err := NewTransport().CreateTransportInstance(url.URL{Host: "127.0.0.1"}, map[string][]string{
+		"defaultUdpPort": {strconv.Itoa(remote.LocalAddr().(*net.UDPAddr).Port)},
+		"local-address":  {"127.0.0.1"},
+		"read-timeout":   {"50"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := transportInstance.Connect(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer transportInstance.Close()
+
+	if err := transportInstance.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := make([]byte, 16)
+	num, sender, err := remote.ReadFromUDP(data)
+	if err != nil || num != 3 || !sender.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("Expected 3 bytes from 127.0.0.1, got %d from %v (%v)", num, sender, err)
+	}
+	if _, err := remote.WriteToUDP([]byte{4, 5}, sender); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if num, err := transportInstance.GetNumReadableBytes(); err != nil || num != 2 {
+		t.Errorf("Expected 2 readable bytes, got %d (%v)", num, err)
+	}
+	if _, err := transportInstance.Read(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Nothing more is sent, so reading times out
+	start := time.Now()
+	if _, err := transportInstance.GetNumReadableBytes(); err == nil {
+		t.Error("Expected reading to time out")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("Expected reading to time out after 50ms, took %s", elapsed)
+	}
+}
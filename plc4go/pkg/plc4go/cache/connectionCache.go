@@ -0,0 +1,157 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package cache
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+// PlcConnectionCache hands out leases on shared connections. All callers asking for the same connection string
+// share one underlying PlcConnection. Closing a lease returns it to the cache instead of closing the connection.
+type PlcConnectionCache interface {
+	// Lease a connection for the given plc4x connection-string (connecting it, if needed)
+	GetConnection(connectionString string) <-chan plc4go.PlcConnectionConnectResult
+	// Variant of GetConnection, which stops waiting for a free lease as soon as the given context is done
+	GetConnectionWithContext(ctx context.Context, connectionString string) <-chan plc4go.PlcConnectionConnectResult
+	// Close all connections managed by this cache. Leases still out there become unusable.
+	Close() <-chan PlcConnectionCacheCloseResult
+}
+
+type PlcConnectionCacheCloseResult struct {
+	Cache PlcConnectionCache
+	Err   error
+}
+
+func NewPlcConnectionCacheCloseResult(cache PlcConnectionCache, err error) PlcConnectionCacheCloseResult {
+	return PlcConnectionCacheCloseResult{
+		Cache: cache,
+		Err:   err,
+	}
+}
+
+const (
+	DefaultMaxLeases           = 1
+	DefaultMaxIdleTime         = time.Minute * 5
+	DefaultHealthCheckInterval = time.Second * 10
+)
+
+type WithConnectionCacheOption func(cache *plcConnectionCache)
+
+// WithMaxLeases defines how many leases can be active on one connection at the same time.
+// Any further caller has to wait until one of the leases is returned.
+func WithMaxLeases(maxLeases int) WithConnectionCacheOption {
+	return func(cache *plcConnectionCache) {
+		cache.maxLeases = maxLeases
+	}
+}
+
+// WithMaxIdleTime defines how long a connection without any lease is kept open, before it is closed.
+// A value of 0 keeps idle connections open till the cache is closed.
+func WithMaxIdleTime(maxIdleTime time.Duration) WithConnectionCacheOption {
+	return func(cache *plcConnectionCache) {
+		cache.maxIdleTime = maxIdleTime
+	}
+}
+
+// WithHealthCheckInterval defines how long a connection may go without being checked via Ping(), before it is
+// checked again prior to handing out the next lease. A value of 0 disables health checking.
+func WithHealthCheckInterval(healthCheckInterval time.Duration) WithConnectionCacheOption {
+	return func(cache *plcConnectionCache) {
+		cache.healthCheckInterval = healthCheckInterval
+	}
+}
+
+type plcConnectionCache struct {
+	driverManager       plc4go.PlcDriverManager
+	logger              *zerolog.Logger
+	maxLeases           int
+	maxIdleTime         time.Duration
+	healthCheckInterval time.Duration
+
+	lock       sync.Mutex
+	containers map[string]*connectionContainer
+	closed     bool
+}
+
+func NewPlcConnectionCache(driverManager plc4go.PlcDriverManager, options ...WithConnectionCacheOption) PlcConnectionCache {
+	cache := &plcConnectionCache{
+		driverManager:       driverManager,
+		logger:              driverManager.GetLogger(),
+		maxLeases:           DefaultMaxLeases,
+		maxIdleTime:         DefaultMaxIdleTime,
+		healthCheckInterval: DefaultHealthCheckInterval,
+		containers:          map[string]*connectionContainer{},
+	}
+	for _, option := range options {
+		option(cache)
+	}
+	if cache.maxLeases < 1 {
+		cache.maxLeases = 1
+	}
+	cache.logger.Trace().Msg("Creating plc connection cache")
+	return cache
+}
+
+func (m *plcConnectionCache) GetConnection(connectionString string) <-chan plc4go.PlcConnectionConnectResult {
+	return m.GetConnectionWithContext(context.Background(), connectionString)
+}
+
+func (m *plcConnectionCache) GetConnectionWithContext(ctx context.Context, connectionString string) <-chan plc4go.PlcConnectionConnectResult {
+	m.logger.Debug().Str("connectionString", connectionString).Msg("Leasing connection")
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+		ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.New("connection cache is closed"))
+		return ch
+	}
+	container, ok := m.containers[connectionString]
+	if !ok {
+		container = newConnectionContainer(m, connectionString)
+		m.containers[connectionString] = container
+	}
+	m.lock.Unlock()
+	return container.lease(ctx)
+}
+
+func (m *plcConnectionCache) Close() <-chan PlcConnectionCacheCloseResult {
+	m.logger.Debug().Msg("Closing connection cache")
+	ch := make(chan PlcConnectionCacheCloseResult, 1)
+	m.lock.Lock()
+	m.closed = true
+	containers := m.containers
+	m.containers = map[string]*connectionContainer{}
+	m.lock.Unlock()
+	go func() {
+		var err error
+		for connectionString, container := range containers {
+			if closeErr := container.close(); closeErr != nil {
+				m.logger.Error().Err(closeErr).Str("connectionString", connectionString).Msg("Error closing connection")
+				err = errors.Wrapf(closeErr, "error closing connection %s", connectionString)
+			}
+		}
+		ch <- NewPlcConnectionCacheCloseResult(m, err)
+	}()
+	return ch
+}
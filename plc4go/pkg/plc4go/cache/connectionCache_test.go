@@ -0,0 +1,274 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package cache
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeConnection struct {
+	plc4go.PlcConnection
+	lock    sync.Mutex
+	closed  bool
+	pingErr error
+}
+
+func (m *fakeConnection) isClosed() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.closed
+}
+
+func (m *fakeConnection) Close() <-chan plc4go.PlcConnectionCloseResult {
+	m.lock.Lock()
+	m.closed = true
+	m.lock.Unlock()
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
+	ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+	return ch
+}
+
+func (m *fakeConnection) BlockingClose() {
+	<-m.Close()
+}
+
+func (m *fakeConnection) PingWithContext(_ context.Context) <-chan plc4go.PlcConnectionPingResult {
+	ch := make(chan plc4go.PlcConnectionPingResult, 1)
+	ch <- plc4go.NewPlcConnectionPingResult(m.pingErr)
+	return ch
+}
+
+type fakeDriverManager struct {
+	plc4go.PlcDriverManager
+	lock        sync.Mutex
+	connections []*fakeConnection
+}
+
+func (m *fakeDriverManager) GetLogger() *zerolog.Logger {
+	logger := zerolog.Nop()
+	return &logger
+}
+
+func (m *fakeDriverManager) GetConnectionWithContext(_ context.Context, _ string) <-chan plc4go.PlcConnectionConnectResult {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	connection := &fakeConnection{}
+	m.connections = append(m.connections, connection)
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+	ch <- plc4go.NewPlcConnectionConnectResult(connection, nil)
+	return ch
+}
+
+func (m *fakeDriverManager) numConnections() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.connections)
+}
+
+func TestPlcConnectionCache_SharesConnection(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager, WithMaxLeases(2))
+
+	first := <-cache.GetConnection("test://first")
+	second := <-cache.GetConnection("test://first")
+	if first.Err != nil || second.Err != nil {
+		t.Fatalf("Unexpected errors: %v %v", first.Err, second.Err)
+	}
+	if driverManager.numConnections() != 1 {
+		t.Errorf("Expected exactly one connection, got %d", driverManager.numConnections())
+	}
+
+	// A third lease has to wait until one of the others is returned
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if third := <-cache.GetConnectionWithContext(ctx, "test://first"); third.Err == nil {
+		t.Errorf("Expected waiting for a third lease to time out")
+	}
+	first.Connection.BlockingClose()
+	third := <-cache.GetConnection("test://first")
+	if third.Err != nil {
+		t.Fatalf("Unexpected error: %v", third.Err)
+	}
+	if driverManager.connections[0].isClosed() {
+		t.Errorf("Returning a lease must not close the shared connection")
+	}
+
+	<-cache.Close()
+	if !driverManager.connections[0].isClosed() {
+		t.Errorf("Closing the cache must close the shared connection")
+	}
+}
+
+func TestPlcConnectionCache_ClosesIdleConnections(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager, WithMaxIdleTime(time.Millisecond*10))
+
+	lease := <-cache.GetConnection("test://idle")
+	if lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	lease.Connection.BlockingClose()
+	time.Sleep(time.Millisecond * 100)
+	if !driverManager.connections[0].isClosed() {
+		t.Errorf("Expected the idle connection to be closed")
+	}
+	if lease := <-cache.GetConnection("test://idle"); lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	if driverManager.numConnections() != 2 {
+		t.Errorf("Expected a new connection, got %d", driverManager.numConnections())
+	}
+}
+
+func TestPlcConnectionCache_ReconnectsUnhealthyConnections(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager, WithHealthCheckInterval(time.Nanosecond))
+
+	lease := <-cache.GetConnection("test://unhealthy")
+	if lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	driverManager.connections[0].pingErr = errors.New("gone")
+	lease.Connection.BlockingClose()
+	time.Sleep(time.Millisecond)
+
+	if lease := <-cache.GetConnection("test://unhealthy"); lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	if driverManager.numConnections() != 2 {
+		t.Errorf("Expected a new connection, got %d", driverManager.numConnections())
+	}
+	if !driverManager.connections[0].isClosed() {
+		t.Errorf("Expected the unhealthy connection to be closed")
+	}
+}
+
+func TestPlcConnectionCache_KeepsConnectionsNotSupportingPing(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager, WithHealthCheckInterval(time.Nanosecond))
+
+	lease := <-cache.GetConnection("test://no-ping")
+	if lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	driverManager.connections[0].pingErr = plc4go.ErrPingNotSupported
+	lease.Connection.BlockingClose()
+	time.Sleep(time.Millisecond)
+
+	if lease := <-cache.GetConnection("test://no-ping"); lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	if driverManager.numConnections() != 1 {
+		t.Errorf("Expected the connection to be reused, got %d connections", driverManager.numConnections())
+	}
+	if driverManager.connections[0].isClosed() {
+		t.Errorf("A connection not supporting ping must not be treated as unhealthy")
+	}
+}
+
+func TestPlcConnectionCache_ReturnedLease(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager)
+
+	lease := <-cache.GetConnection("test://returned")
+	if lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	lease.Connection.BlockingClose()
+
+	// The shared connection may be leased by someone else by now, so it must not be used anymore
+	if _, err := lease.Connection.ReadRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a read request to fail")
+	}
+	if _, err := lease.Connection.WriteRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a write request to fail")
+	}
+	if _, err := lease.Connection.SubscriptionRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a subscription request to fail")
+	}
+	if _, err := lease.Connection.UnsubscriptionRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building an unsubscription request to fail")
+	}
+	if _, err := lease.Connection.BrowseRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a browse request to fail")
+	}
+	if lease.Connection.GetMetadata().CanRead() {
+		t.Errorf("Expected the metadata not to allow reading")
+	}
+}
+
+func TestPlcConnectionCache_ClosesIdleConnectionsAfterFailedLease(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager, WithMaxIdleTime(time.Millisecond*10)).(*plcConnectionCache)
+
+	lease := <-cache.GetConnection("test://idle")
+	if lease.Err != nil {
+		t.Fatalf("Unexpected error: %v", lease.Err)
+	}
+	lease.Connection.BlockingClose()
+
+	// Someone trying to lease the connection while it becomes idle, who doesn't get it in the end
+	container := cache.containers["test://idle"]
+	container.connectionLock <- struct{}{}
+	time.Sleep(time.Millisecond * 50)
+	<-container.connectionLock
+
+	time.Sleep(time.Millisecond * 100)
+	if !driverManager.connections[0].isClosed() {
+		t.Errorf("Expected the idle connection to be closed")
+	}
+}
+
+func TestPlcConnectionCache_KeepsUnhealthyConnectionsWhileLeased(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	cache := NewPlcConnectionCache(driverManager, WithMaxLeases(2), WithHealthCheckInterval(time.Nanosecond))
+
+	first := <-cache.GetConnection("test://unhealthy")
+	if first.Err != nil {
+		t.Fatalf("Unexpected error: %v", first.Err)
+	}
+	driverManager.connections[0].pingErr = errors.New("gone")
+	time.Sleep(time.Millisecond)
+
+	second := <-cache.GetConnection("test://unhealthy")
+	if second.Err != nil {
+		t.Fatalf("Unexpected error: %v", second.Err)
+	}
+	if driverManager.numConnections() != 2 {
+		t.Errorf("Expected a new connection, got %d", driverManager.numConnections())
+	}
+	if driverManager.connections[0].isClosed() {
+		t.Errorf("The unhealthy connection must not be closed while it is still leased")
+	}
+
+	first.Connection.BlockingClose()
+	if !driverManager.connections[0].isClosed() {
+		t.Errorf("Expected the unhealthy connection to be closed as soon as its last lease is returned")
+	}
+	second.Connection.BlockingClose()
+	if driverManager.connections[1].isClosed() {
+		t.Errorf("Returning a lease must not close the healthy connection")
+	}
+}
@@ -0,0 +1,238 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package cache
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// sharedConnection is a connection handed out by a container, along with the number of leases using it
+type sharedConnection struct {
+	connection plc4go.PlcConnection
+	leases     int
+	// Set as soon as the connection failed a health check. It is closed when the last lease using it is returned.
+	invalid bool
+}
+
+// connectionContainer manages the one shared connection for a single connection string
+type connectionContainer struct {
+	cache            *plcConnectionCache
+	connectionString string
+	// Semaphore limiting the number of leases active at the same time
+	leases chan struct{}
+	// Serializes connecting and health-checking (a channel, so waiting for it can be aborted)
+	connectionLock chan struct{}
+
+	stateLock       sync.Mutex
+	connection      *sharedConnection
+	lastHealthCheck time.Time
+	idleTimer       *time.Timer
+	closed          bool
+}
+
+func newConnectionContainer(cache *plcConnectionCache, connectionString string) *connectionContainer {
+	return &connectionContainer{
+		cache:            cache,
+		connectionString: connectionString,
+		leases:           make(chan struct{}, cache.maxLeases),
+		connectionLock:   make(chan struct{}, 1),
+	}
+}
+
+func (m *connectionContainer) lease(ctx context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+	go func() {
+		// Wait for a free lease
+		select {
+		case m.leases <- struct{}{}:
+		case <-ctx.Done():
+			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(ctx.Err(), "error waiting for a free lease"))
+			return
+		}
+		shared, err := m.getConnection(ctx)
+		if err != nil {
+			<-m.leases
+			ch <- plc4go.NewPlcConnectionConnectResult(nil, err)
+			return
+		}
+		m.cache.logger.Trace().Str("connectionString", m.connectionString).Msg("Handing out lease")
+		ch <- plc4go.NewPlcConnectionConnectResult(newPlcConnectionLease(m, shared), nil)
+	}()
+	return ch
+}
+
+func (m *connectionContainer) getConnection(ctx context.Context) (*sharedConnection, error) {
+	select {
+	case m.connectionLock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "error waiting for connection")
+	}
+	defer func() { <-m.connectionLock }()
+
+	m.stateLock.Lock()
+	if m.closed {
+		m.stateLock.Unlock()
+		return nil, errors.New("connection cache is closed")
+	}
+	shared := m.connection
+	needsHealthCheck := m.cache.healthCheckInterval > 0 && time.Since(m.lastHealthCheck) > m.cache.healthCheckInterval
+	m.stateLock.Unlock()
+
+	// Make sure the connection we hand out is still usable
+	if shared != nil && needsHealthCheck {
+		if err := m.ping(ctx, shared.connection); err != nil {
+			m.cache.logger.Warn().Err(err).Str("connectionString", m.connectionString).Msg("Health check failed, reconnecting")
+			// Other leases might still be using the connection, so it's only closed as soon as they are returned
+			m.stateLock.Lock()
+			m.connection = nil
+			shared.invalid = true
+			unused := shared.leases == 0
+			m.stateLock.Unlock()
+			if unused {
+				shared.connection.Close()
+			}
+			shared = nil
+		} else {
+			m.stateLock.Lock()
+			m.lastHealthCheck = time.Now()
+			m.stateLock.Unlock()
+		}
+	}
+
+	if shared == nil {
+		m.cache.logger.Debug().Str("connectionString", m.connectionString).Msg("Creating new connection")
+		connectionResultChan := m.cache.driverManager.GetConnectionWithContext(ctx, m.connectionString)
+		select {
+		case connectionResult := <-connectionResultChan:
+			if connectionResult.Err != nil {
+				return nil, errors.Wrap(connectionResult.Err, "error connecting")
+			}
+			shared = &sharedConnection{connection: connectionResult.Connection}
+		case <-ctx.Done():
+			// Don't leak the connection, if it comes in later on
+			go func() {
+				if connectionResult := <-connectionResultChan; connectionResult.Connection != nil {
+					connectionResult.Connection.Close()
+				}
+			}()
+			return nil, errors.Wrap(ctx.Err(), "error connecting")
+		}
+	}
+
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+	if m.closed {
+		// The cache was closed while we were connecting
+		shared.connection.Close()
+		return nil, errors.New("connection cache is closed")
+	}
+	if m.connection != shared {
+		m.connection = shared
+		m.lastHealthCheck = time.Now()
+	}
+	shared.leases++
+	if m.idleTimer != nil {
+		m.idleTimer.Stop()
+		m.idleTimer = nil
+	}
+	return shared, nil
+}
+
+// ping checks the connection health. Drivers not implementing ping are treated as healthy.
+func (m *connectionContainer) ping(ctx context.Context, connection plc4go.PlcConnection) error {
+	select {
+	case pingResult := <-connection.PingWithContext(ctx):
+		if errors.Is(pingResult.Err, plc4go.ErrPingNotSupported) {
+			m.cache.logger.Debug().Str("connectionString", m.connectionString).Msg("Ping not supported by connection")
+			return nil
+		}
+		return pingResult.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *connectionContainer) release(shared *sharedConnection) {
+	m.stateLock.Lock()
+	shared.leases--
+	if shared.leases == 0 && shared == m.connection && m.cache.maxIdleTime > 0 && !m.closed {
+		m.idleTimer = time.AfterFunc(m.cache.maxIdleTime, m.closeIdle)
+	}
+	closeInvalid := shared.leases == 0 && shared.invalid
+	m.stateLock.Unlock()
+	<-m.leases
+	m.cache.logger.Trace().Str("connectionString", m.connectionString).Msg("Lease returned")
+	if closeInvalid {
+		m.cache.logger.Debug().Str("connectionString", m.connectionString).Msg("Closing connection, which failed its health check")
+		shared.connection.Close()
+	}
+}
+
+func (m *connectionContainer) closeIdle() {
+	select {
+	case m.connectionLock <- struct{}{}:
+	default:
+		// Someone is about to lease the connection. Check again later, as that might still fail.
+		m.stateLock.Lock()
+		if m.connection != nil && m.connection.leases == 0 && !m.closed {
+			m.idleTimer = time.AfterFunc(m.cache.maxIdleTime, m.closeIdle)
+		}
+		m.stateLock.Unlock()
+		return
+	}
+	defer func() { <-m.connectionLock }()
+	m.stateLock.Lock()
+	if m.connection == nil || m.connection.leases > 0 || m.closed {
+		m.stateLock.Unlock()
+		return
+	}
+	shared := m.connection
+	m.connection = nil
+	m.idleTimer = nil
+	m.stateLock.Unlock()
+	m.cache.logger.Debug().Str("connectionString", m.connectionString).Msg("Closing idle connection")
+	shared.connection.BlockingClose()
+}
+
+func (m *connectionContainer) close() error {
+	m.connectionLock <- struct{}{}
+	defer func() { <-m.connectionLock }()
+	m.stateLock.Lock()
+	m.closed = true
+	if m.idleTimer != nil {
+		m.idleTimer.Stop()
+		m.idleTimer = nil
+	}
+	shared := m.connection
+	m.connection = nil
+	m.stateLock.Unlock()
+	if shared == nil {
+		return nil
+	}
+	select {
+	case closeResult := <-shared.connection.Close():
+		return closeResult.Err
+	case <-time.After(time.Second * 5):
+		return errors.New("timeout closing connection")
+	}
+}
@@ -0,0 +1,168 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package cache
+
+import (
+	"context"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+// plcConnectionLease is the PlcConnection handed out by the cache. It delegates to the shared connection and
+// returns itself to the cache on Close, instead of closing the shared connection.
+type plcConnectionLease struct {
+	container   *connectionContainer
+	shared      *sharedConnection
+	connection  plc4go.PlcConnection
+	releaseOnce sync.Once
+	released    bool
+	lock        sync.Mutex
+}
+
+// errLeaseReturned is reported when a lease is used after it has been returned, as the shared connection may be used
+// by someone else by then
+var errLeaseReturned = errors.New("lease has already been returned")
+
+func newPlcConnectionLease(container *connectionContainer, shared *sharedConnection) *plcConnectionLease {
+	return &plcConnectionLease{
+		container:  container,
+		shared:     shared,
+		connection: shared.connection,
+	}
+}
+
+func (m *plcConnectionLease) isReleased() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.released
+}
+
+func (m *plcConnectionLease) Connect() <-chan plc4go.PlcConnectionConnectResult {
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *plcConnectionLease) ConnectWithContext(_ context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	// The shared connection is already connected when the lease is handed out
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+	if m.isReleased() {
+		ch <- plc4go.NewPlcConnectionConnectResult(nil, errLeaseReturned)
+	} else {
+		ch <- plc4go.NewPlcConnectionConnectResult(m, nil)
+	}
+	return ch
+}
+
+func (m *plcConnectionLease) BlockingClose() {
+	<-m.Close()
+}
+
+func (m *plcConnectionLease) Close() <-chan plc4go.PlcConnectionCloseResult {
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *plcConnectionLease) CloseWithContext(_ context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	m.releaseOnce.Do(func() {
+		m.lock.Lock()
+		m.released = true
+		m.lock.Unlock()
+		m.container.release(m.shared)
+	})
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
+	ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+	return ch
+}
+
+func (m *plcConnectionLease) IsConnected() bool {
+	if m.isReleased() {
+		return false
+	}
+	return m.connection.IsConnected()
+}
+
+func (m *plcConnectionLease) Ping() <-chan plc4go.PlcConnectionPingResult {
+	return m.PingWithContext(context.Background())
+}
+
+func (m *plcConnectionLease) PingWithContext(ctx context.Context) <-chan plc4go.PlcConnectionPingResult {
+	if m.isReleased() {
+		ch := make(chan plc4go.PlcConnectionPingResult, 1)
+		ch <- plc4go.NewPlcConnectionPingResult(errLeaseReturned)
+		return ch
+	}
+	return m.connection.PingWithContext(ctx)
+}
+
+func (m *plcConnectionLease) GetMetadata() model.PlcConnectionMetadata {
+	if m.isReleased() {
+		return internalModel.UnavailableConnectionMetadata{}
+	}
+	return m.connection.GetMetadata()
+}
+
+func (m *plcConnectionLease) GetRequestQueueMetrics() model.PlcRequestQueueMetrics {
+	if provider, ok := m.connection.(plc4go.PlcRequestQueueMetricsProvider); ok {
+		return provider.GetRequestQueueMetrics()
+	}
+	return model.PlcRequestQueueMetrics{}
+}
+
+func (m *plcConnectionLease) GetConnectionMetrics() model.PlcConnectionMetrics {
+	if provider, ok := m.connection.(plc4go.PlcConnectionMetricsProvider); ok {
+		return provider.GetConnectionMetrics()
+	}
+	return model.PlcConnectionMetrics{}
+}
+
+func (m *plcConnectionLease) ReadRequestBuilder() model.PlcReadRequestBuilder {
+	if m.isReleased() {
+		return internalModel.NewFailingPlcReadRequestBuilder(errLeaseReturned)
+	}
+	return m.connection.ReadRequestBuilder()
+}
+
+func (m *plcConnectionLease) WriteRequestBuilder() model.PlcWriteRequestBuilder {
+	if m.isReleased() {
+		return internalModel.NewFailingPlcWriteRequestBuilder(errLeaseReturned)
+	}
+	return m.connection.WriteRequestBuilder()
+}
+
+func (m *plcConnectionLease) SubscriptionRequestBuilder() model.PlcSubscriptionRequestBuilder {
+	if m.isReleased() {
+		return internalModel.NewFailingPlcSubscriptionRequestBuilder(errLeaseReturned)
+	}
+	return m.connection.SubscriptionRequestBuilder()
+}
+
+func (m *plcConnectionLease) UnsubscriptionRequestBuilder() model.PlcUnsubscriptionRequestBuilder {
+	if m.isReleased() {
+		return internalModel.NewFailingPlcUnsubscriptionRequestBuilder(errLeaseReturned)
+	}
+	return m.connection.UnsubscriptionRequestBuilder()
+}
+
+func (m *plcConnectionLease) BrowseRequestBuilder() model.PlcBrowseRequestBuilder {
+	if m.isReleased() {
+		return internalModel.NewFailingPlcBrowseRequestBuilder(errLeaseReturned)
+	}
+	return m.connection.BrowseRequestBuilder()
+}
@@ -18,7 +18,14 @@
 //
 package plc4go
 
-import "github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+)
+
+// ErrPingNotSupported is reported by connections of drivers not able to check the connection health
+var ErrPingNotSupported = errors.New("ping not supported by this connection")
 
 type PlcConnectionConnectResult struct {
 	Connection PlcConnection
@@ -57,15 +64,22 @@ func NewPlcConnectionPingResult(err error) PlcConnectionPingResult {
 type PlcConnection interface {
 	// Initiate the connection to the PLC
 	Connect() <-chan PlcConnectionConnectResult
+	// Variant of Connect, which aborts the connection attempt as soon as the given context is done
+	ConnectWithContext(ctx context.Context) <-chan PlcConnectionConnectResult
 	// Blocking variant of Close (for usage in "defer" statements)
 	BlockingClose()
 	// Close the connection to the PLC (gracefully)
 	Close() <-chan PlcConnectionCloseResult
+	// Variant of Close, which stops waiting for the PLC to acknowledge as soon as the given context is done
+	CloseWithContext(ctx context.Context) <-chan PlcConnectionCloseResult
 	// Checks if the connection is currently still connected
 	IsConnected() bool
 
 	// Executes a no-op operation to check if the current connection is still able to communicate
+	// (reports ErrPingNotSupported, if the driver doesn't support this)
 	Ping() <-chan PlcConnectionPingResult
+	// Variant of Ping, which aborts as soon as the given context is done
+	PingWithContext(ctx context.Context) <-chan PlcConnectionPingResult
 
 	// Get some metadata regarding the current connection
 	GetMetadata() model.PlcConnectionMetadata
@@ -81,3 +95,13 @@ type PlcConnection interface {
 
 	BrowseRequestBuilder() model.PlcBrowseRequestBuilder
 }
+
+// PlcRequestQueueMetricsProvider is implemented by connections queueing their requests, so the queue can be monitored
+type PlcRequestQueueMetricsProvider interface {
+	GetRequestQueueMetrics() model.PlcRequestQueueMetrics
+}
+
+// PlcConnectionMetricsProvider is implemented by connections counting their requests and traffic
+type PlcConnectionMetricsProvider interface {
+	GetConnectionMetrics() model.PlcConnectionMetrics
+}
@@ -0,0 +1,150 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package plc4go
+
+import (
+	"github.com/pkg/errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ConnectionStringProvider is implemented by everything, which can be turned into a connection string
+// (ConnectionString itself as well as the driver specific option structs in the drivers package).
+type ConnectionStringProvider interface {
+	GetConnectionString() (ConnectionString, error)
+}
+
+// ConnectionString is the parsed form of a plc4x connection-string:
+//
+//	{driver}:{transport}://{host}:{port}?{options} or {driver}://{host}:{port}?{options}
+//
+// The second form uses the default transport of the driver. Devices, which are addressed by a path (like serial ports),
+// use the path as host: {driver}:{transport}:///dev/ttyUSB0?{options}
+type ConnectionString struct {
+	Driver string
+	// Empty, if the default transport of the driver should be used
+	Transport string
+	Host      string
+	// 0, if the default port of the driver should be used
+	Port    uint16
+	Options url.Values
+}
+
+func NewConnectionString(driver string, host string) ConnectionString {
+	return ConnectionString{
+		Driver:  driver,
+		Host:    host,
+		Options: url.Values{},
+	}
+}
+
+func ParseConnectionString(connectionString string) (ConnectionString, error) {
+	connectionUrl, err := url.Parse(connectionString)
+	if err != nil {
+		return ConnectionString{}, errors.Wrap(err, "error parsing connection string")
+	}
+	if connectionUrl.Scheme == "" {
+		return ConnectionString{}, errors.Errorf("connection string %s doesn't specify a driver", connectionString)
+	}
+	parsed := ConnectionString{
+		Driver:  connectionUrl.Scheme,
+		Options: connectionUrl.Query(),
+	}
+	hostAndPort := connectionUrl.Host
+	if hostAndPort == "" {
+		hostAndPort = connectionUrl.Path
+	}
+	// If a transport is provided alongside the driver, the URL content is decoded as "opaque" data
+	// Then we have to re-parse that to get the transport code as well as the host & port information.
+	if len(connectionUrl.Opaque) > 0 {
+		transportUrl, err := url.Parse(connectionUrl.Opaque)
+		if err != nil {
+			return ConnectionString{}, errors.Wrap(err, "error parsing transport part of connection string")
+		}
+		parsed.Transport = transportUrl.Scheme
+		hostAndPort = transportUrl.Host
+		if hostAndPort == "" {
+			hostAndPort = transportUrl.Path
+		}
+	}
+	if host, port, err := net.SplitHostPort(hostAndPort); err == nil {
+		portValue, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return ConnectionString{}, errors.Errorf("invalid port %s", port)
+		}
+		parsed.Host = host
+		parsed.Port = uint16(portValue)
+	} else {
+		parsed.Host = strings.TrimSuffix(strings.TrimPrefix(hostAndPort, "["), "]")
+	}
+	return parsed, nil
+}
+
+// GetHostAndPort returns the host and (if set) port in the form used by the transports
+func (m ConnectionString) GetHostAndPort() string {
+	if m.Port != 0 {
+		return net.JoinHostPort(m.Host, strconv.Itoa(int(m.Port)))
+	}
+	if strings.Contains(m.Host, ":") {
+		// IPv6 addresses have to be put in brackets
+		return "[" + m.Host + "]"
+	}
+	return m.Host
+}
+
+// WithOption returns a copy of the connection string with the given option set
+func (m ConnectionString) WithOption(name string, value string) ConnectionString {
+	options := url.Values{}
+	for optionName, values := range m.Options {
+		options[optionName] = append([]string(nil), values...)
+	}
+	options.Set(name, value)
+	m.Options = options
+	return m
+}
+
+func (m ConnectionString) GetConnectionString() (ConnectionString, error) {
+	if m.Driver == "" {
+		return ConnectionString{}, errors.New("no driver specified")
+	}
+	if m.Host == "" {
+		return ConnectionString{}, errors.New("no host specified")
+	}
+	return m, nil
+}
+
+func (m ConnectionString) String() string {
+	var builder strings.Builder
+	builder.WriteString(m.Driver)
+	builder.WriteString(":")
+	if m.Transport != "" {
+		builder.WriteString(m.Transport)
+		builder.WriteString(":")
+	}
+	builder.WriteString("//")
+	// The zone of IPv6 addresses has to be escaped in URLs
+	builder.WriteString(strings.Replace(m.GetHostAndPort(), "%", "%25", 1))
+	if len(m.Options) > 0 {
+		builder.WriteString("?")
+		builder.WriteString(m.Options.Encode())
+	}
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package plc4go
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	tests := []struct {
+		connectionString string
+		expected         ConnectionString
+	}{
+		{"s7://10.0.0.1?remote-slot=1", ConnectionString{Driver: "s7", Host: "10.0.0.1", Options: map[string][]string{"remote-slot": {"1"}}}},
+		{"modbus:tcp://plc.local:5020", ConnectionString{Driver: "modbus", Transport: "tcp", Host: "plc.local", Port: 5020, Options: map[string][]string{}}},
+		{"ads:test://hurz?sourceAmsPort=65534&targetAmsPort=851", ConnectionString{Driver: "ads", Transport: "test", Host: "hurz", Options: map[string][]string{"sourceAmsPort": {"65534"}, "targetAmsPort": {"851"}}}},
+		{"modbus://[fe80::1]:502", ConnectionString{Driver: "modbus", Host: "fe80::1", Port: 502, Options: map[string][]string{}}},
+		{"modbus:tcp://[fe80::1%25eth0]:502", ConnectionString{Driver: "modbus", Transport: "tcp", Host: "fe80::1%eth0", Port: 502, Options: map[string][]string{}}},
+		{"modbus:serial:///dev/ttyUSB0?baud-rate=9600", ConnectionString{Driver: "modbus", Transport: "serial", Host: "/dev/ttyUSB0", Options: map[string][]string{"baud-rate": {"9600"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.connectionString, func(t *testing.T) {
+			parsed, err := ParseConnectionString(tt.connectionString)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(parsed, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, parsed)
+			}
+			if parsed.String() != tt.connectionString {
+				t.Errorf("Expected %s to round-trip, got %s", tt.connectionString, parsed.String())
+			}
+		})
+	}
+}
+
+func TestParseConnectionString_Invalid(t *testing.T) {
+	for _, connectionString := range []string{"", "10.0.0.1", "s7://10.0.0.1:99999", "s7://%zz"} {
+		if _, err := ParseConnectionString(connectionString); err == nil {
+			t.Errorf("Expected an error for '%s'", connectionString)
+		}
+	}
+}
+
+func TestConnectionString_WithOption(t *testing.T) {
+	connectionString := NewConnectionString("s7", "10.0.0.1")
+	modified := connectionString.WithOption("remote-slot", "1")
+	if len(connectionString.Options) != 0 {
+		t.Errorf("Expected the original connection string to be unchanged")
+	}
+	if modified.String() != "s7://10.0.0.1?remote-slot=1" {
+		t.Errorf("Unexpected connection string %s", modified)
+	}
+}
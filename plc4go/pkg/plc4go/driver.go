@@ -19,8 +19,10 @@
 package plc4go
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
 	"net/url"
 )
 
@@ -37,8 +39,13 @@ type PlcDriver interface {
 	// Have the driver parse the query string and provide feedback if it's not a valid one
 	CheckQuery(query string) error
 
+	// Get the options supported in the query string of the connection string (without the transport options)
+	GetOptionSchema() options.OptionSchema
+
 	// Establishes a connection to a given PLC using the information in the connectionString
 	GetConnection(transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan PlcConnectionConnectResult
+	// Variant of GetConnection, which aborts the connection attempt as soon as the given context is done
+	GetConnectionWithContext(ctx context.Context, transportUrl url.URL, transports map[string]transports.Transport, options map[string][]string) <-chan PlcConnectionConnectResult
 
 	SupportsDiscovery() bool
 
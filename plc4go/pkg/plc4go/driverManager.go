@@ -19,11 +19,17 @@
 package plc4go
 
 import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 // This is the main entry point for PLC4Go applications
@@ -44,113 +50,241 @@ type PlcDriverManager interface {
 
 	// Get a connection to a remote PLC for a given plc4x connection-string
 	GetConnection(connectionString string) <-chan PlcConnectionConnectResult
+	// Variant of GetConnection, which aborts the connection attempt as soon as the given context is done
+	GetConnectionWithContext(ctx context.Context, connectionString string) <-chan PlcConnectionConnectResult
+	// Get a connection to a remote PLC for a typed connection string (see ConnectionString and the drivers package)
+	GetConnectionFor(connectionString ConnectionStringProvider) <-chan PlcConnectionConnectResult
+	// Variant of GetConnectionFor, which aborts the connection attempt as soon as the given context is done
+	GetConnectionForWithContext(ctx context.Context, connectionString ConnectionStringProvider) <-chan PlcConnectionConnectResult
+
+	// Add a middleware to all connections created from now on (see PlcMiddleware)
+	AddMiddleware(middleware PlcMiddleware)
+
+	// Get a snapshot of the metrics of all connections created by this driver manager
+	GetMetrics() model.PlcDriverManagerMetrics
 
 	// Execute all available discovery methods on all available drivers using all transports
 	Discover(func(event model.PlcDiscoveryEvent)) error
+
+	// Close all connections created by this driver manager. Afterwards no new connections can be created.
+	Close() <-chan PlcDriverManagerCloseResult
+
+	// Get the logger of the driver manager (the global logger, if none was provided using WithLogger)
+	GetLogger() *zerolog.Logger
+}
+
+type PlcDriverManagerCloseResult struct {
+	DriverManager PlcDriverManager
+	Err           error
 }
 
-type PlcDriverManger struct {
+func NewPlcDriverManagerCloseResult(driverManager PlcDriverManager, err error) PlcDriverManagerCloseResult {
+	return PlcDriverManagerCloseResult{
+		DriverManager: driverManager,
+		Err:           err,
+	}
+}
+
+type plcDriverManager struct {
 	drivers    map[string]PlcDriver
 	transports map[string]transports.Transport
+	// Connections handed out, which haven't been found closed yet (a slice, as not all connections are comparable)
+	connections      []trackedConnection
+	lastConnectionId uint64
+	// Metrics of the connections found closed, by driver
+	closedConnectionMetrics map[string]model.PlcConnectionMetrics
+	middlewares             []PlcMiddleware
+	closed                  bool
+	lock                    sync.RWMutex
+	// Logger of the driver manager and the connections it creates (nil, if the global logger is used)
+	logger *zerolog.Logger
+}
+
+// trackedConnection is a connection handed out by the driver manager
+type trackedConnection struct {
+	connection   PlcConnection
+	driver       string
+	connectionId uint64
+	address      string
 }
 
-func NewPlcDriverManager() PlcDriverManager {
-	log.Trace().Msg("Creating plc driver manager")
-	return PlcDriverManger{
-		drivers:    map[string]PlcDriver{},
-		transports: map[string]transports.Transport{},
+type WithPlcDriverManagerOption func(driverManager *plcDriverManager)
+
+// WithLogger makes the driver manager log with the given logger instead of the global one. The connections created by
+// the driver manager use it too (see ContextWithLogger for using a different one per connection), with details like
+// the driver and the transport url attached.
+func WithLogger(logger zerolog.Logger) WithPlcDriverManagerOption {
+	return func(driverManager *plcDriverManager) {
+		driverManager.logger = &logger
+	}
+}
+
+func NewPlcDriverManager(options ...WithPlcDriverManagerOption) PlcDriverManager {
+	driverManager := &plcDriverManager{
+		drivers:                 map[string]PlcDriver{},
+		transports:              map[string]transports.Transport{},
+		closedConnectionMetrics: map[string]model.PlcConnectionMetrics{},
+	}
+	for _, option := range options {
+		option(driverManager)
 	}
+	driverManager.GetLogger().Trace().Msg("Creating plc driver manager")
+	return driverManager
 }
 
-func (m PlcDriverManger) RegisterDriver(driver PlcDriver) {
+// ContextWithLogger returns a copy of the context, which makes GetConnectionWithContext and GetConnectionForWithContext
+// use the logger for the new connection instead of the one of the driver manager
+func ContextWithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	return spi.ContextWithLogger(ctx, logger)
+}
+
+func (m *plcDriverManager) GetLogger() *zerolog.Logger {
+	if m.logger == nil {
+		return &log.Logger
+	}
+	return m.logger
+}
+
+func (m *plcDriverManager) RegisterDriver(driver PlcDriver) {
 	if driver == nil {
 		panic("driver must not be nil")
 	}
-	log.Debug().Str("protocolName", driver.GetProtocolName()).Msg("Registering driver")
+	m.GetLogger().Debug().Str("protocolName", driver.GetProtocolName()).Msg("Registering driver")
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	// If this driver is already registered, just skip resetting it
 	for driverName := range m.drivers {
 		if driverName == driver.GetProtocolCode() {
-			log.Warn().Str("protocolName", driver.GetProtocolName()).Msg("Already registered")
+			m.GetLogger().Warn().Str("protocolName", driver.GetProtocolName()).Msg("Already registered")
 			return
 		}
 	}
 	m.drivers[driver.GetProtocolCode()] = driver
-	log.Info().Str("protocolName", driver.GetProtocolName()).Msgf("Driver for %s registered", driver.GetProtocolName())
+	m.GetLogger().Info().Str("protocolName", driver.GetProtocolName()).Msgf("Driver for %s registered", driver.GetProtocolName())
 }
 
-func (m PlcDriverManger) ListDriverNames() []string {
-	log.Trace().Msg("Listing driver names")
+func (m *plcDriverManager) ListDriverNames() []string {
+	m.GetLogger().Trace().Msg("Listing driver names")
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	var driverNames []string
 	for driverName := range m.drivers {
 		driverNames = append(driverNames, driverName)
 	}
-	log.Trace().Msgf("Found %d driver(s)", len(driverNames))
+	m.GetLogger().Trace().Msgf("Found %d driver(s)", len(driverNames))
 	return driverNames
 }
 
-func (m PlcDriverManger) GetDriver(driverName string) (PlcDriver, error) {
+func (m *plcDriverManager) GetDriver(driverName string) (PlcDriver, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	if val, ok := m.drivers[driverName]; ok {
 		return val, nil
 	}
 	return nil, errors.Errorf("couldn't find driver %s", driverName)
 }
 
-func (m PlcDriverManger) RegisterTransport(transport transports.Transport) {
+func (m *plcDriverManager) RegisterTransport(transport transports.Transport) {
 	if transport == nil {
 		panic("transport must not be nil")
 	}
-	log.Debug().Str("transportName", transport.GetTransportName()).Msg("Registering transport")
+	m.GetLogger().Debug().Str("transportName", transport.GetTransportName()).Msg("Registering transport")
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	// If this transport is already registered, just skip resetting it
 	for transportName := range m.transports {
 		if transportName == transport.GetTransportCode() {
-			log.Warn().Str("transportName", transport.GetTransportName()).Msg("Transport already registered")
+			m.GetLogger().Warn().Str("transportName", transport.GetTransportName()).Msg("Transport already registered")
 			return
 		}
 	}
 	m.transports[transport.GetTransportCode()] = transport
-	log.Info().Str("transportName", transport.GetTransportName()).Msgf("Transport for %s registered", transport.GetTransportName())
+	m.GetLogger().Info().Str("transportName", transport.GetTransportName()).Msgf("Transport for %s registered", transport.GetTransportName())
 }
 
-func (m PlcDriverManger) ListTransportNames() []string {
-	log.Trace().Msg("Listing transport names")
+func (m *plcDriverManager) ListTransportNames() []string {
+	m.GetLogger().Trace().Msg("Listing transport names")
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	var transportNames []string
 	for transportName := range m.transports {
 		transportNames = append(transportNames, transportName)
 	}
-	log.Trace().Msgf("Found %d transports", len(transportNames))
+	m.GetLogger().Trace().Msgf("Found %d transports", len(transportNames))
 	return transportNames
 }
 
-func (m PlcDriverManger) GetTransport(transportName string, _ string, _ map[string][]string) (transports.Transport, error) {
+func (m *plcDriverManager) GetTransport(transportName string, _ string, _ map[string][]string) (transports.Transport, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	if val, ok := m.transports[transportName]; ok {
-		log.Debug().Str("transportName", transportName).Msg("Returning transport")
+		m.GetLogger().Debug().Str("transportName", transportName).Msg("Returning transport")
 		return val, nil
 	}
 	return nil, errors.Errorf("couldn't find transport %s", transportName)
 }
 
-func (m PlcDriverManger) GetConnection(connectionString string) <-chan PlcConnectionConnectResult {
-	log.Debug().Str("connectionString", connectionString).Msgf("Getting connection for %s", connectionString)
+func (m *plcDriverManager) AddMiddleware(middleware PlcMiddleware) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.middlewares = append(m.middlewares, middleware)
+}
+
+func (m *plcDriverManager) GetConnection(connectionString string) <-chan PlcConnectionConnectResult {
+	return m.GetConnectionWithContext(context.Background(), connectionString)
+}
+
+func (m *plcDriverManager) GetConnectionWithContext(ctx context.Context, connectionString string) <-chan PlcConnectionConnectResult {
+	m.GetLogger().Debug().Str("connectionString", connectionString).Msgf("Getting connection for %s", connectionString)
 	// Parse the connection string.
-	connectionUrl, err := url.Parse(connectionString)
+	parsedConnectionString, err := ParseConnectionString(connectionString)
 	if err != nil {
-		log.Error().Err(err).Msg("Error parsing connection")
-		ch := make(chan PlcConnectionConnectResult)
-		go func() {
-			ch <- NewPlcConnectionConnectResult(nil, errors.Wrap(err, "error parsing connection string"))
-		}()
+		m.GetLogger().Error().Err(err).Msg("Error parsing connection")
+		ch := make(chan PlcConnectionConnectResult, 1)
+		ch <- NewPlcConnectionConnectResult(nil, err)
+		return ch
+	}
+	return m.getConnection(ctx, parsedConnectionString)
+}
+
+func (m *plcDriverManager) GetConnectionFor(connectionString ConnectionStringProvider) <-chan PlcConnectionConnectResult {
+	return m.GetConnectionForWithContext(context.Background(), connectionString)
+}
+
+func (m *plcDriverManager) GetConnectionForWithContext(ctx context.Context, connectionString ConnectionStringProvider) <-chan PlcConnectionConnectResult {
+	parsedConnectionString, err := connectionString.GetConnectionString()
+	if err != nil {
+		m.GetLogger().Error().Err(err).Msg("Error getting connection string")
+		ch := make(chan PlcConnectionConnectResult, 1)
+		ch <- NewPlcConnectionConnectResult(nil, errors.Wrap(err, "error getting connection string"))
+		return ch
+	}
+	m.GetLogger().Debug().Stringer("connectionString", parsedConnectionString).Msgf("Getting connection for %s", parsedConnectionString)
+	return m.getConnection(ctx, parsedConnectionString)
+}
+
+func (m *plcDriverManager) getConnection(ctx context.Context, connectionString ConnectionString) <-chan PlcConnectionConnectResult {
+	m.lock.RLock()
+	closed := m.closed
+	m.lock.RUnlock()
+	if closed {
+		ch := make(chan PlcConnectionConnectResult, 1)
+		ch <- NewPlcConnectionConnectResult(nil, errors.New("driver manager is closed"))
 		return ch
 	}
-	log.Debug().Stringer("connectionUrl", connectionUrl).Msg("parsed connection URL")
 
 	// The options will be used to configure both the transports as well as the connections/drivers
-	configOptions := connectionUrl.Query()
+	// (drivers add their own internal options, so they get a copy)
+	configOptions := url.Values{}
+	for name, values := range connectionString.Options {
+		configOptions[name] = append([]string(nil), values...)
+	}
 
 	// Find the driver specified in the url.
-	driverName := connectionUrl.Scheme
+	driverName := connectionString.Driver
 	driver, err := m.GetDriver(driverName)
 	if err != nil {
-		log.Err(err).Str("driverName", driverName).Msgf("Couldn't get driver for %s", driverName)
+		m.GetLogger().Err(err).Str("driverName", driverName).Msgf("Couldn't get driver for %s", driverName)
 		ch := make(chan PlcConnectionConnectResult)
 		go func() {
 			ch <- NewPlcConnectionConnectResult(nil, errors.Wrap(err, "error getting driver for connection string"))
@@ -158,36 +292,20 @@ func (m PlcDriverManger) GetConnection(connectionString string) <-chan PlcConnec
 		return ch
 	}
 
-	// If a transport is provided alongside the driver, the URL content is decoded as "opaque" data
-	// Then we have to re-parse that to get the transport code as well as the host & port information.
-	var transportName string
-	var transportConnectionString string
-	if len(connectionUrl.Opaque) > 0 {
-		log.Trace().Msg("we handling a opaque connectionUrl")
-		connectionUrl, err := url.Parse(connectionUrl.Opaque)
-		if err != nil {
-			log.Err(err).Str("connectionUrl.Opaque", connectionUrl.Opaque).Msg("Couldn't get transport due to parsing error")
-			ch := make(chan PlcConnectionConnectResult)
-			go func() {
-				ch <- NewPlcConnectionConnectResult(nil, errors.Wrap(err, "error parsing connection string"))
-			}()
-			return ch
-		}
-		transportName = connectionUrl.Scheme
-		transportConnectionString = connectionUrl.Host
-	} else {
-		log.Trace().Msg("we handling a non-opaque connectionUrl")
+	transportName := connectionString.Transport
+	transportConnectionString := connectionString.GetHostAndPort()
+	if transportName == "" {
+		m.GetLogger().Trace().Msg("no transport in connection string")
 		// If no transport was provided the driver has to provide a default transport.
 		transportName = driver.GetDefaultTransport()
-		transportConnectionString = connectionUrl.Host
 	}
-	log.Debug().
+	m.GetLogger().Debug().
 		Str("transportName", transportName).
 		Str("transportConnectionString", transportConnectionString).
 		Msgf("got a transport %s", transportName)
 	// If no transport has been specified explicitly or per default, we have to abort.
 	if transportName == "" {
-		log.Error().Msg("got a empty transport")
+		m.GetLogger().Error().Msg("got a empty transport")
 		ch := make(chan PlcConnectionConnectResult)
 		go func() {
 			ch <- NewPlcConnectionConnectResult(nil, errors.New("no transport specified and no default defined by driver"))
@@ -195,20 +313,213 @@ func (m PlcDriverManger) GetConnection(connectionString string) <-chan PlcConnec
 		return ch
 	}
 
-	// Assemble a correct transport url
+	// Drivers may keep the transports map, so they get a copy
+	m.lock.RLock()
+	transportsCopy := make(map[string]transports.Transport, len(m.transports))
+	for transportCode, transport := range m.transports {
+		transportsCopy[transportCode] = transport
+	}
+	middlewares := append([]PlcMiddleware(nil), m.middlewares...)
+	m.lock.RUnlock()
+
+	// Make sure all options are understood by either the driver or the transport
+	optionSchema := driver.GetOptionSchema().Merge(spi.LoggingOptionSchema)
+	if transport, ok := transportsCopy[transportName]; ok {
+		optionSchema = optionSchema.Merge(transport.GetOptionSchema())
+	}
+	if err := optionSchema.Validate(configOptions); err != nil {
+		m.GetLogger().Error().Err(err).Msg("Invalid connection string options")
+		ch := make(chan PlcConnectionConnectResult, 1)
+		ch <- NewPlcConnectionConnectResult(nil, errors.Wrap(err, "error validating connection string"))
+		return ch
+	}
+
+	// Assemble a correct transport url (devices like serial ports are addressed by their path)
 	transportUrl := url.URL{
 		Scheme: transportName,
 		Host:   transportConnectionString,
 	}
-	log.Debug().Stringer("transportUrl", &transportUrl).Msg("Assembled transport url")
+	if strings.HasPrefix(transportConnectionString, "/") {
+		transportUrl = url.URL{
+			Scheme: transportName,
+			Path:   transportConnectionString,
+		}
+	}
+	m.GetLogger().Debug().Stringer("transportUrl", &transportUrl).Msg("Assembled transport url")
+
+	// Everything belonging to the connection logs with the connection details attached
+	connectionLogger, err := spi.ApplyLoggingOptions(spi.LoggerFromContextOr(ctx, *m.GetLogger()), configOptions)
+	if err != nil {
+		m.GetLogger().Error().Err(err).Msg("Invalid connection string options")
+		ch := make(chan PlcConnectionConnectResult, 1)
+		ch <- NewPlcConnectionConnectResult(nil, errors.Wrap(err, "error validating connection string"))
+		return ch
+	}
+	connectionLogger = connectionLogger.With().
+		Str("driver", driverName).
+		Str("transportUrl", transportUrl.String()).
+		Logger()
 
 	// Create a new connection
-	return driver.GetConnection(transportUrl, m.transports, configOptions)
+	connectionResults := driver.GetConnectionWithContext(spi.ContextWithLogger(ctx, connectionLogger), transportUrl, transportsCopy, configOptions)
+
+	// Keep track of the connection, so it can be closed when the driver manager is closed
+	ch := make(chan PlcConnectionConnectResult, 1)
+	go func() {
+		connectionResult := <-connectionResults
+		if connectionResult.Err == nil && connectionResult.Connection != nil && !m.trackConnection(connectionResult.Connection, driverName, transportUrl.String()) {
+			// The driver manager was closed while connecting
+			connectionResult.Connection.Close()
+			connectionResult = NewPlcConnectionConnectResult(nil, errors.New("driver manager is closed"))
+		}
+		if connectionResult.Err == nil && connectionResult.Connection != nil {
+			connectionResult.Connection = NewPlcConnectionWithMiddleware(connectionResult.Connection, middlewares...)
+		}
+		ch <- connectionResult
+	}()
+	return ch
+}
+
+// trackConnection remembers the connection (and forgets about connections, which have been closed in the meantime).
+// It returns false, if the driver manager has been closed already.
+func (m *plcDriverManager) trackConnection(connection PlcConnection, driver string, address string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return false
+	}
+	m.pruneConnections()
+	m.lastConnectionId++
+	m.connections = append(m.connections, trackedConnection{
+		connection:   connection,
+		driver:       driver,
+		connectionId: m.lastConnectionId,
+		address:      address,
+	})
+	return true
+}
+
+// pruneConnections forgets about the connections which have been closed, keeping their metrics in the ones of
+// their driver. Has to be called with the lock held.
+func (m *plcDriverManager) pruneConnections() {
+	var connections []trackedConnection
+	for _, connection := range m.connections {
+		if isClosed(connection.connection) {
+			m.addClosedConnectionMetrics(connection)
+		} else {
+			connections = append(connections, connection)
+		}
+	}
+	m.connections = connections
+}
+
+// addClosedConnectionMetrics has to be called with the lock held
+func (m *plcDriverManager) addClosedConnectionMetrics(connection trackedConnection) {
+	if provider, ok := connection.connection.(PlcConnectionMetricsProvider); ok {
+		m.closedConnectionMetrics[connection.driver] = m.closedConnectionMetrics[connection.driver].Merge(provider.GetConnectionMetrics())
+	}
+}
+
+func (m *plcDriverManager) GetMetrics() model.PlcDriverManagerMetrics {
+	m.lock.Lock()
+	m.pruneConnections()
+	connections := append([]trackedConnection(nil), m.connections...)
+	drivers := map[string]model.PlcConnectionMetrics{}
+	for driver, metrics := range m.closedConnectionMetrics {
+		drivers[driver] = metrics
+	}
+	m.lock.Unlock()
+
+	metrics := model.PlcDriverManagerMetrics{
+		Drivers: drivers,
+	}
+	for _, connection := range connections {
+		provider, ok := connection.connection.(PlcConnectionMetricsProvider)
+		if !ok {
+			continue
+		}
+		connectionMetrics := provider.GetConnectionMetrics()
+		metrics.Connections = append(metrics.Connections, model.PlcDriverManagerConnectionMetrics{
+			Driver:       connection.driver,
+			ConnectionId: connection.connectionId,
+			Address:      connection.address,
+			Metrics:      connectionMetrics,
+		})
+		drivers[connection.driver] = drivers[connection.driver].Merge(connectionMetrics)
+	}
+	return metrics
+}
+
+// isClosed returns true, if the connection is known to be closed. As IsConnected() might have to talk to the
+// PLC, only the state of the message codec is checked (for connections exposing theirs).
+func isClosed(connection PlcConnection) bool {
+	if exposer, ok := connection.(spi.MessageCodecExposer); ok {
+		if messageCodec := exposer.GetMessageCodec(); messageCodec != nil {
+			return !messageCodec.IsRunning()
+		}
+	}
+	return false
+}
+
+func (m *plcDriverManager) Close() <-chan PlcDriverManagerCloseResult {
+	m.GetLogger().Debug().Msg("Closing driver manager")
+	ch := make(chan PlcDriverManagerCloseResult, 1)
+	m.lock.Lock()
+	m.closed = true
+	connections := m.connections
+	m.connections = nil
+	m.lock.Unlock()
+	go func() {
+		var closeErrors []error
+		var closeErrorsLock sync.Mutex
+		var wg sync.WaitGroup
+		for _, connection := range connections {
+			if isClosed(connection.connection) {
+				continue
+			}
+			wg.Add(1)
+			go func(connection PlcConnection) {
+				defer wg.Done()
+				var err error
+				select {
+				case closeResult := <-connection.Close():
+					err = closeResult.Err
+				case <-time.After(time.Second * 5):
+					err = errors.New("timeout closing connection")
+				}
+				if err != nil {
+					m.GetLogger().Error().Err(err).Msg("Error closing connection")
+					closeErrorsLock.Lock()
+					closeErrors = append(closeErrors, err)
+					closeErrorsLock.Unlock()
+				}
+			}(connection.connection)
+		}
+		wg.Wait()
+		// Keep the metrics of the connections, now that they are closed
+		m.lock.Lock()
+		for _, connection := range connections {
+			m.addClosedConnectionMetrics(connection)
+		}
+		m.lock.Unlock()
+		var err error
+		if len(closeErrors) > 0 {
+			err = errors.Wrapf(closeErrors[0], "error closing %d connection(s)", len(closeErrors))
+		}
+		ch <- NewPlcDriverManagerCloseResult(m, err)
+	}()
+	return ch
 }
 
 // TODO: Currently all network devices are used as well as all transports and all protocols. It would be cool if we had some sort of DiscoveryRequestBuilder instead of only this single method.
-func (m PlcDriverManger) Discover(callback func(event model.PlcDiscoveryEvent)) error {
+func (m *plcDriverManager) Discover(callback func(event model.PlcDiscoveryEvent)) error {
+	m.lock.RLock()
+	var drivers []PlcDriver
 	for _, driver := range m.drivers {
+		drivers = append(drivers, driver)
+	}
+	m.lock.RUnlock()
+	for _, driver := range drivers {
 		if driver.SupportsDiscovery() {
 			err := driver.Discover(callback)
 			if err != nil {
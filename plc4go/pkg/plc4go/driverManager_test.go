@@ -0,0 +1,241 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package plc4go
+
+import (
+	"context"
+	"fmt"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"github.com/rs/zerolog"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+type fakeConnection struct {
+	PlcConnection
+	lock   sync.Mutex
+	closed bool
+}
+
+func (m *fakeConnection) isClosed() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.closed
+}
+
+func (m *fakeConnection) Close() <-chan PlcConnectionCloseResult {
+	m.lock.Lock()
+	m.closed = true
+	m.lock.Unlock()
+	ch := make(chan PlcConnectionCloseResult, 1)
+	ch <- NewPlcConnectionCloseResult(m, nil)
+	return ch
+}
+
+type fakeDriver struct {
+	PlcDriver
+	protocolCode string
+}
+
+func (m fakeDriver) GetProtocolCode() string {
+	return m.protocolCode
+}
+
+func (m fakeDriver) GetProtocolName() string {
+	return m.protocolCode
+}
+
+func (m fakeDriver) GetDefaultTransport() string {
+	return "tcp"
+}
+
+func (m fakeDriver) GetOptionSchema() options.OptionSchema {
+	return options.OptionSchema{
+		{Name: "timeout", Type: options.OptionTypeInteger},
+	}
+}
+
+func (m fakeDriver) GetConnectionWithContext(_ context.Context, _ url.URL, _ map[string]transports.Transport, _ map[string][]string) <-chan PlcConnectionConnectResult {
+	ch := make(chan PlcConnectionConnectResult, 1)
+	ch <- NewPlcConnectionConnectResult(&fakeConnection{}, nil)
+	return ch
+}
+
+func TestPlcDriverManager_ConcurrentUse(t *testing.T) {
+	driverManager := NewPlcDriverManager()
+	driverManager.RegisterDriver(fakeDriver{protocolCode: "test"})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			driverManager.RegisterDriver(fakeDriver{protocolCode: fmt.Sprintf("test%d", i)})
+		}(i)
+		go func() {
+			defer wg.Done()
+			if connectionResult := <-driverManager.GetConnection("test://localhost"); connectionResult.Err != nil {
+				t.Errorf("Unexpected error: %v", connectionResult.Err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(driverManager.ListDriverNames()) != 11 {
+		t.Errorf("Expected 11 drivers, got %v", driverManager.ListDriverNames())
+	}
+}
+
+func TestPlcDriverManager_CloseClosesConnections(t *testing.T) {
+	driverManager := NewPlcDriverManager()
+	driverManager.RegisterDriver(fakeDriver{protocolCode: "test"})
+	var connections []*fakeConnection
+	for i := 0; i < 3; i++ {
+		connectionResult := <-driverManager.GetConnection("test://localhost")
+		if connectionResult.Err != nil {
+			t.Fatalf("Unexpected error: %v", connectionResult.Err)
+		}
+		connections = append(connections, connectionResult.Connection.(*fakeConnection))
+	}
+
+	if closeResult := <-driverManager.Close(); closeResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", closeResult.Err)
+	}
+	for i, connection := range connections {
+		if !connection.isClosed() {
+			t.Errorf("Expected connection %d to be closed", i)
+		}
+	}
+	if connectionResult := <-driverManager.GetConnection("test://localhost"); connectionResult.Err == nil {
+		t.Errorf("Expected an error getting a connection from a closed driver manager")
+	}
+}
+
+func TestPlcDriverManager_ValidatesOptions(t *testing.T) {
+	driverManager := NewPlcDriverManager()
+	driverManager.RegisterDriver(fakeDriver{protocolCode: "test"})
+	if connectionResult := <-driverManager.GetConnection("test://localhost?timeout=500"); connectionResult.Err != nil {
+		t.Errorf("Unexpected error: %v", connectionResult.Err)
+	}
+	for _, connectionString := range []string{
+		"test://localhost?timeout=soon",
+		"test://localhost?timeut=500",
+		"test://localhost?timeout=500&timeout=600",
+	} {
+		if connectionResult := <-driverManager.GetConnection(connectionString); connectionResult.Err == nil {
+			t.Errorf("Expected an error for %s", connectionString)
+		}
+	}
+}
+
+// meteredConnection counts a single read request
+type meteredConnection struct {
+	fakeConnection
+}
+
+func (m *meteredConnection) GetConnectionMetrics() model.PlcConnectionMetrics {
+	return model.PlcConnectionMetrics{
+		Requests:  map[string]model.PlcRequestMetrics{"read": {Requests: 1}},
+		BytesSent: 10,
+	}
+}
+
+type meteredDriver struct {
+	fakeDriver
+}
+
+func (m meteredDriver) GetConnectionWithContext(_ context.Context, _ url.URL, _ map[string]transports.Transport, _ map[string][]string) <-chan PlcConnectionConnectResult {
+	ch := make(chan PlcConnectionConnectResult, 1)
+	ch <- NewPlcConnectionConnectResult(&meteredConnection{}, nil)
+	return ch
+}
+
+func TestPlcDriverManager_GetMetrics(t *testing.T) {
+	driverManager := NewPlcDriverManager()
+	driverManager.RegisterDriver(meteredDriver{fakeDriver{protocolCode: "test"}})
+	for i := 0; i < 2; i++ {
+		if connectionResult := <-driverManager.GetConnection("test://localhost"); connectionResult.Err != nil {
+			t.Fatalf("Unexpected error: %v", connectionResult.Err)
+		}
+	}
+
+	metrics := driverManager.GetMetrics()
+	if len(metrics.Connections) != 2 {
+		t.Fatalf("Expected 2 connections, got %d", len(metrics.Connections))
+	}
+	if connection := metrics.Connections[1]; connection.ConnectionId != 2 || connection.Driver != "test" || connection.Address != "tcp://localhost" {
+		t.Errorf("Unexpected connection %+v", connection)
+	}
+	if driverMetrics := metrics.Drivers["test"]; driverMetrics.Requests["read"].Requests != 2 || driverMetrics.BytesSent != 20 {
+		t.Errorf("Expected the metrics of both connections, got %+v", driverMetrics)
+	}
+
+	// The metrics of the closed connections are kept for their driver
+	if closeResult := <-driverManager.Close(); closeResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", closeResult.Err)
+	}
+	metrics = driverManager.GetMetrics()
+	if len(metrics.Connections) != 0 || metrics.Drivers["test"].BytesSent != 20 {
+		t.Errorf("Expected only the metrics of the driver, got %+v", metrics)
+	}
+}
+
+// loggingDriver remembers the level of the logger it got for its last connection
+type loggingDriver struct {
+	fakeDriver
+	levels chan zerolog.Level
+}
+
+func (m loggingDriver) GetConnectionWithContext(ctx context.Context, _ url.URL, _ map[string]transports.Transport, _ map[string][]string) <-chan PlcConnectionConnectResult {
+	m.levels <- spi.LoggerFromContext(ctx).GetLevel()
+	ch := make(chan PlcConnectionConnectResult, 1)
+	ch <- NewPlcConnectionConnectResult(&fakeConnection{}, nil)
+	return ch
+}
+
+func TestPlcDriverManager_PassesLogger(t *testing.T) {
+	levels := make(chan zerolog.Level, 1)
+	driverManager := NewPlcDriverManager(WithLogger(zerolog.Nop().Level(zerolog.InfoLevel)))
+	driverManager.RegisterDriver(loggingDriver{fakeDriver{protocolCode: "test"}, levels})
+
+	for _, testCase := range []struct {
+		ctx              context.Context
+		connectionString string
+		expectedLevel    zerolog.Level
+	}{
+		{context.Background(), "test://localhost", zerolog.InfoLevel},
+		{context.Background(), "test://localhost?log-level=debug", zerolog.DebugLevel},
+		{context.Background(), "test://localhost?log-level=disabled", zerolog.Disabled},
+		{ContextWithLogger(context.Background(), zerolog.Nop().Level(zerolog.WarnLevel)), "test://localhost", zerolog.WarnLevel},
+		{ContextWithLogger(context.Background(), zerolog.Nop().Level(zerolog.WarnLevel)), "test://localhost?log-level=trace", zerolog.TraceLevel},
+	} {
+		if connectionResult := <-driverManager.GetConnectionWithContext(testCase.ctx, testCase.connectionString); connectionResult.Err != nil {
+			t.Fatalf("Unexpected error for %s: %v", testCase.connectionString, connectionResult.Err)
+		}
+		if level := <-levels; level != testCase.expectedLevel {
+			t.Errorf("Expected level %s for %s, got %s", testCase.expectedLevel, testCase.connectionString, level)
+		}
+	}
+
+	if connectionResult := <-driverManager.GetConnection("test://localhost?log-level=verbose"); connectionResult.Err == nil {
+		t.Errorf("Expected an error for an unknown log-level")
+	}
+}
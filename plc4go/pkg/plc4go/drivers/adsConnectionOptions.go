@@ -0,0 +1,72 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package drivers
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+)
+
+var amsNetIdRegexp = regexp.MustCompile(`^([0-9]{1,3}\.){5}[0-9]{1,3}$`)
+
+// AdsConnectionOptions describes a connection to a Beckhoff TwinCat ADS runtime. All AMS addresses are required.
+type AdsConnectionOptions struct {
+	Host string
+	// 0 uses the default port 48898
+	Port           uint16
+	SourceAmsNetId string
+	SourceAmsPort  uint16
+	TargetAmsNetId string
+	TargetAmsPort  uint16
+}
+
+func NewAdsConnectionOptions(host string, sourceAmsNetId string, sourceAmsPort uint16, targetAmsNetId string, targetAmsPort uint16) AdsConnectionOptions {
+	return AdsConnectionOptions{
+		Host:           host,
+		SourceAmsNetId: sourceAmsNetId,
+		SourceAmsPort:  sourceAmsPort,
+		TargetAmsNetId: targetAmsNetId,
+		TargetAmsPort:  targetAmsPort,
+	}
+}
+
+func (m AdsConnectionOptions) GetConnectionString() (plc4go.ConnectionString, error) {
+	if !amsNetIdRegexp.MatchString(m.SourceAmsNetId) {
+		return plc4go.ConnectionString{}, errors.Errorf("invalid source AMS Net ID '%s'", m.SourceAmsNetId)
+	}
+	if !amsNetIdRegexp.MatchString(m.TargetAmsNetId) {
+		return plc4go.ConnectionString{}, errors.Errorf("invalid target AMS Net ID '%s'", m.TargetAmsNetId)
+	}
+	if m.SourceAmsPort == 0 || m.TargetAmsPort == 0 {
+		return plc4go.ConnectionString{}, errors.New("source and target AMS port are required")
+	}
+	connectionString := plc4go.NewConnectionString("ads", m.Host)
+	connectionString.Port = m.Port
+	connectionString.Options.Set("sourceAmsNetId", m.SourceAmsNetId)
+	connectionString.Options.Set("sourceAmsPort", strconv.Itoa(int(m.SourceAmsPort)))
+	connectionString.Options.Set("targetAmsNetId", m.TargetAmsNetId)
+	connectionString.Options.Set("targetAmsPort", strconv.Itoa(int(m.TargetAmsPort)))
+	return connectionString.GetConnectionString()
+}
+
+func (m AdsConnectionOptions) String() string {
+	return connectionStringOrError(m)
+}
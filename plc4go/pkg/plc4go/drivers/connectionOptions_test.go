@@ -0,0 +1,110 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package drivers
+
+import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/ads"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/s7"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/tls"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/options"
+	"testing"
+)
+
+func TestConnectionOptions_GetConnectionString(t *testing.T) {
+	s7Options := NewS7ConnectionOptions("10.0.0.1")
+	s7Options.RemoteSlot = 1
+	s7Options.ControllerType = S7ControllerTypeS7_1500
+	modbusOptions := NewModbusConnectionOptions("10.0.0.2")
+	modbusOptions.Transport = "tcp"
+	modbusOptions.Port = 5020
+	modbusOptions.UnitIdentifier = 3
+	modbusOptions.OptimizeReads = true
+	modbusRtuOptions := NewModbusConnectionOptions("/dev/ttyUSB0")
+	modbusRtuOptions.Framing = ModbusFramingRtu
+	modbusRtuOptions.Transport = "serial"
+	modbusRtuOptions.UnitIdentifier = 17
+	modbusAsciiOptions := NewModbusConnectionOptions("10.0.0.6")
+	modbusAsciiOptions.Framing = ModbusFramingAscii
+	modbusAsciiOptions.Transport = "tcp"
+	modbusAsciiOptions.Port = 4001
+	modbusTlsOptions := NewModbusConnectionOptions("10.0.0.7")
+	modbusTlsOptions.Transport = "tls"
+	modbusTlsOptions.Tls.CaFile = "/etc/plc4x/ca.pem"
+	modbusTlsOptions.Tls.MinVersion = "1.3"
+	knxOptions := NewKnxConnectionOptions("10.0.0.3")
+	knxOptions.GroupAddressNumLevels = 2
+	tests := []struct {
+		name     string
+		options  plc4go.ConnectionStringProvider
+		driver   plc4go.PlcDriver
+		expected string
+	}{
+		{"s7 defaults", NewS7ConnectionOptions("10.0.0.1"), s7.NewDriver(), "s7://10.0.0.1"},
+		{"s7", s7Options, s7.NewDriver(), "s7://10.0.0.1?controller-type=S7_1500&remote-slot=1"},
+		{"ads", NewAdsConnectionOptions("10.0.0.4", "10.0.0.5.1.1", 65534, "10.0.0.4.1.1", 851), ads.NewDriver(),
+			"ads://10.0.0.4?sourceAmsNetId=10.0.0.5.1.1&sourceAmsPort=65534&targetAmsNetId=10.0.0.4.1.1&targetAmsPort=851"},
+		{"modbus", modbusOptions, modbus.NewDriver(), "modbus:tcp://10.0.0.2:5020?optimize-reads=true&unit-identifier=3"},
+		{"modbus rtu", modbusRtuOptions, modbus.NewRtuDriver(), "modbus-rtu:serial:///dev/ttyUSB0?unit-identifier=17"},
+		{"modbus ascii", modbusAsciiOptions, modbus.NewAsciiDriver(), "modbus-ascii:tcp://10.0.0.6:4001"},
+		{"modbus tls", modbusTlsOptions, modbus.NewDriver(), "modbus:tls://10.0.0.7?ca-file=%2Fetc%2Fplc4x%2Fca.pem&min-version=1.3"},
+		{"knx", knxOptions, knxnetip.NewDriver(), "knxnet-ip://10.0.0.3?group-address-num-levels=2"},
+	}
+	// Transport options are accepted along with the ones of the driver
+	transportOptionSchemas := map[string]options.OptionSchema{
+		"tls": tls.NewTransport().GetOptionSchema(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connectionString, err := tt.options.GetConnectionString()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if connectionString.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, connectionString)
+			}
+			optionSchema := tt.driver.GetOptionSchema().Merge(transportOptionSchemas[connectionString.Transport])
+			if err := optionSchema.Validate(connectionString.Options); err != nil {
+				t.Errorf("Rendered options not accepted by the driver: %v", err)
+			}
+		})
+	}
+}
+
+func TestConnectionOptions_Invalid(t *testing.T) {
+	s7Options := NewS7ConnectionOptions("10.0.0.1")
+	s7Options.ControllerType = "S7_200"
+	knxOptions := NewKnxConnectionOptions("10.0.0.3")
+	knxOptions.GroupAddressNumLevels = 4
+	modbusOptions := NewModbusConnectionOptions("10.0.0.2")
+	modbusOptions.Framing = "bin"
+	for _, options := range []plc4go.ConnectionStringProvider{
+		s7Options,
+		knxOptions,
+		NewAdsConnectionOptions("10.0.0.4", "10.0.0.5", 65534, "10.0.0.4.1.1", 851),
+		NewModbusConnectionOptions(""),
+		modbusOptions,
+	} {
+		if _, err := options.GetConnectionString(); err == nil {
+			t.Errorf("Expected an error for %#v", options)
+		}
+	}
+}
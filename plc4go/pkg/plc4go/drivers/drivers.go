@@ -22,10 +22,37 @@ import (
 	"github.com/apache/plc4x/plc4go/internal/plc4go/ads"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/knxnetip"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/modbus"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/s7"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/transports"
+	"sync"
 )
 
+var (
+	defaultDriverManager     plc4go.PlcDriverManager
+	defaultDriverManagerOnce sync.Once
+)
+
+// DefaultDriverManager returns a shared driver manager with all drivers and transports registered.
+// It is created on first use.
+func DefaultDriverManager() plc4go.PlcDriverManager {
+	defaultDriverManagerOnce.Do(func() {
+		defaultDriverManager = plc4go.NewPlcDriverManager()
+		RegisterAllDrivers(defaultDriverManager)
+	})
+	return defaultDriverManager
+}
+
+// RegisterAllDrivers registers all drivers along with the transports they use
+func RegisterAllDrivers(driverManager plc4go.PlcDriverManager) {
+	RegisterAdsDriver(driverManager)
+	RegisterKnxDriver(driverManager)
+	RegisterModbusDriver(driverManager)
+	RegisterModbusRtuDriver(driverManager)
+	RegisterModbusAsciiDriver(driverManager)
+	RegisterS7Driver(driverManager)
+}
+
 func RegisterAdsDriver(driverManager plc4go.PlcDriverManager) {
 	driverManager.RegisterDriver(ads.NewDriver())
 	transports.RegisterTcpTransport(driverManager)
@@ -36,7 +63,30 @@ func RegisterKnxDriver(driverManager plc4go.PlcDriverManager) {
 	transports.RegisterUdpTransport(driverManager)
 }
 
+// RegisterModbusDriver registers the Modbus driver along with the tcp transport and the tls transport (for
+// Modbus/TCP Security)
 func RegisterModbusDriver(driverManager plc4go.PlcDriverManager) {
 	driverManager.RegisterDriver(modbus.NewDriver())
 	transports.RegisterTcpTransport(driverManager)
+	transports.RegisterTlsTransport(driverManager)
+}
+
+// RegisterModbusRtuDriver registers the Modbus RTU driver along with the serial transport and the tcp transport (for
+// RTU-over-TCP converters)
+func RegisterModbusRtuDriver(driverManager plc4go.PlcDriverManager) {
+	driverManager.RegisterDriver(modbus.NewRtuDriver())
+	transports.RegisterSerialTransport(driverManager)
+	transports.RegisterTcpTransport(driverManager)
+}
+
+// RegisterModbusAsciiDriver registers the Modbus ASCII driver along with the serial transport and the tcp transport
+func RegisterModbusAsciiDriver(driverManager plc4go.PlcDriverManager) {
+	driverManager.RegisterDriver(modbus.NewAsciiDriver())
+	transports.RegisterSerialTransport(driverManager)
+	transports.RegisterTcpTransport(driverManager)
+}
+
+func RegisterS7Driver(driverManager plc4go.PlcDriverManager) {
+	driverManager.RegisterDriver(s7.NewDriver())
+	transports.RegisterTcpTransport(driverManager)
 }
@@ -0,0 +1,60 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package drivers
+
+import (
+	"encoding/hex"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+)
+
+// KnxConnectionOptions describes a connection to a KNXnet/IP gateway
+type KnxConnectionOptions struct {
+	Host string
+	// 0 uses the default port 3671
+	Port uint16
+	// Key for decoding secure telegrams (optional)
+	BuildingKey []byte
+	// Number of levels of the group addresses (1, 2 or 3)
+	GroupAddressNumLevels uint8
+}
+
+func NewKnxConnectionOptions(host string) KnxConnectionOptions {
+	return KnxConnectionOptions{
+		Host:                  host,
+		GroupAddressNumLevels: 3,
+	}
+}
+
+func (m KnxConnectionOptions) GetConnectionString() (plc4go.ConnectionString, error) {
+	if m.GroupAddressNumLevels < 1 || m.GroupAddressNumLevels > 3 {
+		return plc4go.ConnectionString{}, errors.Errorf("group address levels must be 1, 2 or 3, got %d", m.GroupAddressNumLevels)
+	}
+	connectionString := plc4go.NewConnectionString("knxnet-ip", m.Host)
+	connectionString.Port = m.Port
+	if len(m.BuildingKey) > 0 {
+		connectionString.Options.Set("buildingKey", hex.EncodeToString(m.BuildingKey))
+	}
+	setIntOption(connectionString, "group-address-num-levels", int64(m.GroupAddressNumLevels), 3)
+	return connectionString.GetConnectionString()
+}
+
+func (m KnxConnectionOptions) String() string {
+	return connectionStringOrError(m)
+}
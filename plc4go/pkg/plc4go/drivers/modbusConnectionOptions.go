@@ -0,0 +1,83 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package drivers
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+)
+
+// ModbusFraming selects how requests are framed on the wire, and with that the driver used
+type ModbusFraming string
+
+const (
+	ModbusFramingTcp   ModbusFraming = ""
+	ModbusFramingRtu   ModbusFraming = "rtu"
+	ModbusFramingAscii ModbusFraming = "ascii"
+)
+
+// ModbusConnectionOptions describes a connection to a Modbus device
+type ModbusConnectionOptions struct {
+	Framing ModbusFraming
+	// Empty uses the default transport (tcp, serial with RTU or ASCII framing). tls uses Modbus/TCP Security.
+	Transport string
+	// Host name, or the serial port
+	Host string
+	// 0 uses the default port 502 (802 with tls)
+	Port uint16
+	// Unit identifier, or the slave address with RTU or ASCII framing
+	UnitIdentifier uint8
+	// Read consecutive registers with a single request instead of one request per field
+	OptimizeReads bool
+	// Only used with the tls transport
+	Tls TlsOptions
+}
+
+func NewModbusConnectionOptions(host string) ModbusConnectionOptions {
+	return ModbusConnectionOptions{
+		Host:           host,
+		UnitIdentifier: 1,
+	}
+}
+
+func (m ModbusConnectionOptions) GetConnectionString() (plc4go.ConnectionString, error) {
+	driverCode := "modbus"
+	switch m.Framing {
+	case ModbusFramingTcp:
+	case ModbusFramingRtu, ModbusFramingAscii:
+		driverCode += "-" + string(m.Framing)
+	default:
+		return plc4go.ConnectionString{}, errors.Errorf("unknown framing %s", m.Framing)
+	}
+	connectionString := plc4go.NewConnectionString(driverCode, m.Host)
+	connectionString.Transport = m.Transport
+	connectionString.Port = m.Port
+	setIntOption(connectionString, "unit-identifier", int64(m.UnitIdentifier), 1)
+	if m.OptimizeReads {
+		connectionString.Options.Set("optimize-reads", "true")
+	}
+	if m.Transport == "tls" {
+		m.Tls.setOptions(connectionString)
+	}
+	return connectionString.GetConnectionString()
+}
+
+func (m ModbusConnectionOptions) String() string {
+	return connectionStringOrError(m)
+}
@@ -0,0 +1,108 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package drivers
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+type S7ControllerType string
+
+const (
+	S7ControllerTypeAutoDetect S7ControllerType = ""
+	S7ControllerTypeAny        S7ControllerType = "ANY"
+	S7ControllerTypeS7_300     S7ControllerType = "S7_300"
+	S7ControllerTypeS7_400     S7ControllerType = "S7_400"
+	S7ControllerTypeS7_1200    S7ControllerType = "S7_1200"
+	S7ControllerTypeS7_1500    S7ControllerType = "S7_1500"
+	S7ControllerTypeLogo       S7ControllerType = "LOGO"
+)
+
+// S7ConnectionOptions describes a connection to a Siemens S7 PLC. Only options differing from the driver defaults
+// end up in the connection string.
+type S7ConnectionOptions struct {
+	Host string
+	// 0 uses the default port 102
+	Port           uint16
+	LocalRack      int32
+	LocalSlot      int32
+	RemoteRack     int32
+	RemoteSlot     int32
+	PduSize        uint16
+	MaxAmqCaller   uint16
+	MaxAmqCallee   uint16
+	ControllerType S7ControllerType
+}
+
+// NewS7ConnectionOptions creates options for the given host, initialized with the driver defaults
+func NewS7ConnectionOptions(host string) S7ConnectionOptions {
+	return S7ConnectionOptions{
+		Host:         host,
+		LocalRack:    1,
+		LocalSlot:    1,
+		RemoteRack:   0,
+		RemoteSlot:   0,
+		PduSize:      1024,
+		MaxAmqCaller: 8,
+		MaxAmqCallee: 8,
+	}
+}
+
+func (m S7ConnectionOptions) GetConnectionString() (plc4go.ConnectionString, error) {
+	defaults := NewS7ConnectionOptions(m.Host)
+	connectionString := plc4go.NewConnectionString("s7", m.Host)
+	connectionString.Port = m.Port
+	setIntOption(connectionString, "local-rack", int64(m.LocalRack), int64(defaults.LocalRack))
+	setIntOption(connectionString, "local-slot", int64(m.LocalSlot), int64(defaults.LocalSlot))
+	setIntOption(connectionString, "remote-rack", int64(m.RemoteRack), int64(defaults.RemoteRack))
+	setIntOption(connectionString, "remote-slot", int64(m.RemoteSlot), int64(defaults.RemoteSlot))
+	setIntOption(connectionString, "pdu-size", int64(m.PduSize), int64(defaults.PduSize))
+	setIntOption(connectionString, "max-amq-caller", int64(m.MaxAmqCaller), int64(defaults.MaxAmqCaller))
+	setIntOption(connectionString, "max-amq-callee", int64(m.MaxAmqCallee), int64(defaults.MaxAmqCallee))
+	switch m.ControllerType {
+	case S7ControllerTypeAutoDetect:
+	case S7ControllerTypeAny, S7ControllerTypeS7_300, S7ControllerTypeS7_400, S7ControllerTypeS7_1200, S7ControllerTypeS7_1500, S7ControllerTypeLogo:
+		connectionString.Options.Set("controller-type", string(m.ControllerType))
+	default:
+		return plc4go.ConnectionString{}, errors.Errorf("unknown controller type %s", m.ControllerType)
+	}
+	return connectionString.GetConnectionString()
+}
+
+func (m S7ConnectionOptions) String() string {
+	return connectionStringOrError(m)
+}
+
+// setIntOption sets the option, if the value differs from the default
+func setIntOption(connectionString plc4go.ConnectionString, name string, value int64, defaultValue int64) {
+	if value != defaultValue {
+		connectionString.Options.Set(name, strconv.FormatInt(value, 10))
+	}
+}
+
+// connectionStringOrError renders the connection string or the reason, why it can't be rendered
+func connectionStringOrError(provider plc4go.ConnectionStringProvider) string {
+	connectionString, err := provider.GetConnectionString()
+	if err != nil {
+		return "invalid connection string: " + err.Error()
+	}
+	return connectionString.String()
+}
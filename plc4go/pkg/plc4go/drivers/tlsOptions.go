@@ -0,0 +1,53 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package drivers
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+)
+
+// TlsOptions describes the settings of connections using the tls transport. Empty fields use the transport defaults.
+type TlsOptions struct {
+	// PEM files with the client certificate and its private key (optional)
+	CertificateFile string
+	KeyFile         string
+	// PEM file with the CA certificates the server certificate has to be issued by (the system ones otherwise)
+	CaFile string
+	// Name the server certificate is verified against (the host otherwise)
+	ServerName string
+	// Minimum TLS version (1.0, 1.1, 1.2 or 1.3)
+	MinVersion string
+}
+
+// setOptions adds the settings to the options of the connection string
+func (m TlsOptions) setOptions(connectionString plc4go.ConnectionString) {
+	setStringOption(connectionString, "certificate-file", m.CertificateFile)
+	setStringOption(connectionString, "key-file", m.KeyFile)
+	setStringOption(connectionString, "ca-file", m.CaFile)
+	setStringOption(connectionString, "server-name", m.ServerName)
+	setStringOption(connectionString, "min-version", m.MinVersion)
+}
+
+// setStringOption sets the option, if the value isn't empty
+func setStringOption(connectionString plc4go.ConnectionString, name string, value string) {
+	if value != "" {
+		connectionString.Options.Set(name, value)
+	}
+}
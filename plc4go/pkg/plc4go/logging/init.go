@@ -16,6 +16,9 @@
 // specific language governing permissions and limitations
 // under the License.
 //
+
+// Package logging configures the global logger. It is only used by driver managers created without a logger of their
+// own (see plc4go.WithLogger) and by code running outside of a connection.
 package logging
 
 import (
@@ -23,13 +26,8 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-var oldLogger zerolog.Logger
-
-// init is used for _ imports for easy log config
-func init() {
-	oldLogger = log.Logger
-	log.Logger = log.Logger.Level(zerolog.ErrorLevel)
-}
+// The global logger as it has been before changing its level (importing the package doesn't change it)
+var oldLogger = log.Logger
 
 // ErrorLevel configures zerolog to WarnLevel
 func ErrorLevel() {
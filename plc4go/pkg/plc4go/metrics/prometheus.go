@@ -0,0 +1,254 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package metrics
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsProvider provides the metrics to expose (e.g. a plc4go.PlcDriverManager)
+type MetricsProvider interface {
+	GetMetrics() model.PlcDriverManagerMetrics
+}
+
+// loggerProvider is implemented by providers having a logger of their own (like a plc4go.PlcDriverManager)
+type loggerProvider interface {
+	GetLogger() *zerolog.Logger
+}
+
+type prometheusHandler struct {
+	provider MetricsProvider
+	logger   *zerolog.Logger
+}
+
+// NewPrometheusHandler returns a handler serving the metrics of the given provider in the Prometheus text format, so
+// they can be scraped by Prometheus (or anything else understanding the format). Errors are logged with the logger
+// of the provider, if it has one (the global logger otherwise).
+func NewPrometheusHandler(provider MetricsProvider) http.Handler {
+	logger := &log.Logger
+	if loggerProvider, ok := provider.(loggerProvider); ok {
+		logger = loggerProvider.GetLogger()
+	}
+	return &prometheusHandler{
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+func (m *prometheusHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.Header().Set("Allow", "GET, HEAD")
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writer.Header().Set("Content-Type", PrometheusContentType)
+	if request.Method == http.MethodHead {
+		return
+	}
+	if err := WritePrometheusText(writer, m.provider.GetMetrics()); err != nil {
+		m.logger.Debug().Err(err).Msg("Error writing metrics")
+	}
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+// series are the metrics of one connection or driver, identified by their labels
+type series struct {
+	labels  []label
+	metrics model.PlcConnectionMetrics
+}
+
+type family struct {
+	name       string
+	help       string
+	metricType string
+	write      func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics)
+}
+
+// The families exposed for both connections and drivers (prefixed with "plc4go_connection_" or "plc4go_driver_")
+var families = []family{
+	{"requests_total", "Number of requests executed.", "counter", requestCounter(func(metrics model.PlcRequestMetrics) uint64 {
+		return metrics.Requests
+	})},
+	{"request_errors_total", "Number of requests which failed (including the ones which timed out).", "counter", requestCounter(func(metrics model.PlcRequestMetrics) uint64 {
+		return metrics.Errors
+	})},
+	{"request_timeouts_total", "Number of requests which timed out.", "counter", requestCounter(func(metrics model.PlcRequestMetrics) uint64 {
+		return metrics.Timeouts
+	})},
+	{"request_duration_seconds", "Time it took to execute the requests.", "histogram", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		for _, operation := range getOperations(metrics) {
+			writeHistogram(text, name, withLabel(labels, "operation", operation), metrics.Requests[operation].Latency)
+		}
+	}},
+	{"exchanges_total", "Number of request/response exchanges with the PLC.", "counter", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeSample(text, name, labels, strconv.FormatUint(metrics.Exchanges.Requests, 10))
+	}},
+	{"exchange_errors_total", "Number of request/response exchanges which failed (including the ones which timed out).", "counter", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeSample(text, name, labels, strconv.FormatUint(metrics.Exchanges.Errors, 10))
+	}},
+	{"exchange_timeouts_total", "Number of request/response exchanges which timed out.", "counter", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeSample(text, name, labels, strconv.FormatUint(metrics.Exchanges.Timeouts, 10))
+	}},
+	{"exchange_duration_seconds", "Time it took the PLC to respond.", "histogram", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeHistogram(text, name, labels, metrics.Exchanges.Latency)
+	}},
+	{"sent_bytes_total", "Number of bytes sent to the PLC.", "counter", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeSample(text, name, labels, strconv.FormatUint(metrics.BytesSent, 10))
+	}},
+	{"received_bytes_total", "Number of bytes received from the PLC.", "counter", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeSample(text, name, labels, strconv.FormatUint(metrics.BytesReceived, 10))
+	}},
+	{"dropped_messages_total", "Number of unsolicited messages dropped, as nobody consumed them in time.", "counter", func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		writeSample(text, name, labels, strconv.FormatUint(metrics.DroppedMessages, 10))
+	}},
+}
+
+// WritePrometheusText writes the given metrics in the Prometheus text format. Every open connection is exposed with
+// the labels "driver", "connection" (its id) and "address", the metrics of all connections of a driver (including the
+// closed ones) with the label "driver".
+func WritePrometheusText(writer io.Writer, metrics model.PlcDriverManagerMetrics) error {
+	connections := append([]model.PlcDriverManagerConnectionMetrics(nil), metrics.Connections...)
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].ConnectionId < connections[j].ConnectionId
+	})
+	var connectionSeries []series
+	openConnections := map[string]int{}
+	for _, connection := range connections {
+		connectionSeries = append(connectionSeries, series{
+			labels: []label{
+				{"driver", connection.Driver},
+				{"connection", strconv.FormatUint(connection.ConnectionId, 10)},
+				{"address", connection.Address},
+			},
+			metrics: connection.Metrics,
+		})
+		openConnections[connection.Driver]++
+	}
+	var driverNames []string
+	for driverName := range metrics.Drivers {
+		driverNames = append(driverNames, driverName)
+	}
+	sort.Strings(driverNames)
+	var driverSeries []series
+	for _, driverName := range driverNames {
+		driverSeries = append(driverSeries, series{
+			labels:  []label{{"driver", driverName}},
+			metrics: metrics.Drivers[driverName],
+		})
+	}
+
+	var text strings.Builder
+	writeFamilies(&text, "plc4go_connection_", connectionSeries)
+	writeFamilies(&text, "plc4go_driver_", driverSeries)
+	writeHeader(&text, "plc4go_driver_open_connections", "Number of open connections of the driver.", "gauge")
+	for _, driverName := range driverNames {
+		writeSample(&text, "plc4go_driver_open_connections", []label{{"driver", driverName}}, strconv.Itoa(openConnections[driverName]))
+	}
+	_, err := io.WriteString(writer, text.String())
+	return err
+}
+
+// writeFamilies writes the samples family by family, as all samples of a family have to be grouped together
+func writeFamilies(text *strings.Builder, prefix string, allSeries []series) {
+	for _, family := range families {
+		name := prefix + family.name
+		writeHeader(text, name, family.help, family.metricType)
+		for _, series := range allSeries {
+			family.write(text, name, series.labels, series.metrics)
+		}
+	}
+}
+
+func requestCounter(value func(metrics model.PlcRequestMetrics) uint64) func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+	return func(text *strings.Builder, name string, labels []label, metrics model.PlcConnectionMetrics) {
+		for _, operation := range getOperations(metrics) {
+			writeSample(text, name, withLabel(labels, "operation", operation), strconv.FormatUint(value(metrics.Requests[operation]), 10))
+		}
+	}
+}
+
+func getOperations(metrics model.PlcConnectionMetrics) []string {
+	var operations []string
+	for operation := range metrics.Requests {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func writeHistogram(text *strings.Builder, name string, labels []label, histogram model.PlcLatencyHistogram) {
+	for i, bucket := range histogram.Buckets {
+		if i >= len(histogram.Counts) {
+			break
+		}
+		writeSample(text, name+"_bucket", withLabel(labels, "le", formatFloat(bucket.Seconds())), strconv.FormatUint(histogram.Counts[i], 10))
+	}
+	writeSample(text, name+"_bucket", withLabel(labels, "le", "+Inf"), strconv.FormatUint(histogram.Count, 10))
+	writeSample(text, name+"_sum", labels, formatFloat(histogram.Sum.Seconds()))
+	writeSample(text, name+"_count", labels, strconv.FormatUint(histogram.Count, 10))
+}
+
+func writeHeader(text *strings.Builder, name string, help string, metricType string) {
+	text.WriteString("# HELP " + name + " " + help + "\n")
+	text.WriteString("# TYPE " + name + " " + metricType + "\n")
+}
+
+func writeSample(text *strings.Builder, name string, labels []label, value string) {
+	text.WriteString(name)
+	if len(labels) > 0 {
+		text.WriteString("{")
+		for i, label := range labels {
+			if i > 0 {
+				text.WriteString(",")
+			}
+			text.WriteString(label.name + "=\"" + escapeLabelValue(label.value) + "\"")
+		}
+		text.WriteString("}")
+	}
+	text.WriteString(" " + value + "\n")
+}
+
+// withLabel returns a copy of the labels with the given one added
+func withLabel(labels []label, name string, value string) []label {
+	return append(append([]label(nil), labels...), label{name, value})
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
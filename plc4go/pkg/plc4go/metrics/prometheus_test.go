@@ -0,0 +1,122 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package metrics
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedMetricsProvider struct {
+	metrics model.PlcDriverManagerMetrics
+}
+
+func (m fixedMetricsProvider) GetMetrics() model.PlcDriverManagerMetrics {
+	return m.metrics
+}
+
+func newTestMetrics() model.PlcDriverManagerMetrics {
+	connectionMetrics := model.PlcConnectionMetrics{
+		Requests: map[string]model.PlcRequestMetrics{
+			"read": {
+				Requests: 3,
+				Errors:   1,
+				Timeouts: 1,
+				Latency: model.PlcLatencyHistogram{
+					Buckets: []time.Duration{time.Millisecond * 10, time.Millisecond * 100},
+					Counts:  []uint64{1, 2},
+					Count:   3,
+					Sum:     time.Millisecond * 1500,
+				},
+			},
+		},
+		BytesSent:       12,
+		BytesReceived:   34,
+		DroppedMessages: 5,
+	}
+	return model.PlcDriverManagerMetrics{
+		Connections: []model.PlcDriverManagerConnectionMetrics{
+			{Driver: "modbus", ConnectionId: 1, Address: "tcp://\"plc\":502", Metrics: connectionMetrics},
+		},
+		Drivers: map[string]model.PlcConnectionMetrics{
+			"modbus": connectionMetrics,
+			"s7":     {},
+		},
+	}
+}
+
+func TestWritePrometheusText(t *testing.T) {
+	var text strings.Builder
+	if err := WritePrometheusText(&text, newTestMetrics()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	connectionLabels := `driver="modbus",connection="1",address="tcp://\"plc\":502"`
+	for _, expected := range []string{
+		"# HELP plc4go_connection_requests_total Number of requests executed.",
+		"# TYPE plc4go_connection_requests_total counter",
+		`plc4go_connection_requests_total{` + connectionLabels + `,operation="read"} 3`,
+		`plc4go_connection_request_timeouts_total{` + connectionLabels + `,operation="read"} 1`,
+		"# TYPE plc4go_connection_request_duration_seconds histogram",
+		`plc4go_connection_request_duration_seconds_bucket{` + connectionLabels + `,operation="read",le="0.01"} 1`,
+		`plc4go_connection_request_duration_seconds_bucket{` + connectionLabels + `,operation="read",le="0.1"} 2`,
+		`plc4go_connection_request_duration_seconds_bucket{` + connectionLabels + `,operation="read",le="+Inf"} 3`,
+		`plc4go_connection_request_duration_seconds_sum{` + connectionLabels + `,operation="read"} 1.5`,
+		`plc4go_connection_request_duration_seconds_count{` + connectionLabels + `,operation="read"} 3`,
+		`plc4go_connection_sent_bytes_total{` + connectionLabels + `} 12`,
+		`plc4go_driver_received_bytes_total{driver="modbus"} 34`,
+		`plc4go_driver_received_bytes_total{driver="s7"} 0`,
+		`plc4go_connection_dropped_messages_total{` + connectionLabels + `} 5`,
+		`plc4go_driver_open_connections{driver="modbus"} 1`,
+		`plc4go_driver_open_connections{driver="s7"} 0`,
+	} {
+		if !strings.Contains(text.String(), expected+"\n") {
+			t.Errorf("Expected line %s in\n%s", expected, text.String())
+		}
+	}
+	// Every family is announced exactly once
+	if count := strings.Count(text.String(), "# TYPE plc4go_driver_requests_total "); count != 1 {
+		t.Errorf("Expected the family to be announced once, got %d", count)
+	}
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	handler := NewPrometheusHandler(fixedMetricsProvider{metrics: newTestMetrics()})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != PrometheusContentType {
+		t.Errorf("Unexpected content type %s", contentType)
+	}
+	if !strings.Contains(recorder.Body.String(), `plc4go_driver_requests_total{driver="modbus",operation="read"} 3`) {
+		t.Errorf("Unexpected body %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", recorder.Code)
+	}
+}
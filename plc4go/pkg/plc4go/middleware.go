@@ -0,0 +1,350 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package plc4go
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+)
+
+// Handlers execute a request and wait for its result. Middlewares wrap them, in order to do something before the
+// request is sent and after the result has been received (or to not send the request at all).
+type PlcReadHandler func(ctx context.Context, readRequest model.PlcReadRequest) model.PlcReadRequestResult
+type PlcWriteHandler func(ctx context.Context, writeRequest model.PlcWriteRequest) model.PlcWriteRequestResult
+type PlcSubscriptionHandler func(ctx context.Context, subscriptionRequest model.PlcSubscriptionRequest) model.PlcSubscriptionRequestResult
+
+// The interceptor is nil, if the browse request is executed without one
+type PlcBrowseHandler func(ctx context.Context, browseRequest model.PlcBrowseRequest, interceptor func(result model.PlcBrowseEvent) bool) model.PlcBrowseRequestResult
+
+// PlcMiddleware adds cross-cutting concerns like logging, auditing, authorization, caching, metrics or tracing to the
+// requests of a connection. Every function is optional and wraps the next handler of the chain.
+type PlcMiddleware struct {
+	Read      func(next PlcReadHandler) PlcReadHandler
+	Write     func(next PlcWriteHandler) PlcWriteHandler
+	Subscribe func(next PlcSubscriptionHandler) PlcSubscriptionHandler
+	Browse    func(next PlcBrowseHandler) PlcBrowseHandler
+}
+
+// NewPlcConnectionWithMiddleware wraps the given connection, so all requests built with it pass the middlewares.
+// The first middleware is the outermost one, so it sees the requests first and their results last.
+func NewPlcConnectionWithMiddleware(connection PlcConnection, middlewares ...PlcMiddleware) PlcConnection {
+	if len(middlewares) == 0 {
+		return connection
+	}
+	return &middlewareConnection{
+		connection:  connection,
+		middlewares: append([]PlcMiddleware(nil), middlewares...),
+	}
+}
+
+type middlewareConnection struct {
+	connection  PlcConnection
+	middlewares []PlcMiddleware
+}
+
+func (m *middlewareConnection) readHandler() PlcReadHandler {
+	handler := func(ctx context.Context, readRequest model.PlcReadRequest) model.PlcReadRequestResult {
+		return <-readRequest.ExecuteWithContext(ctx)
+	}
+	for i := len(m.middlewares) - 1; i >= 0; i-- {
+		if m.middlewares[i].Read != nil {
+			handler = m.middlewares[i].Read(handler)
+		}
+	}
+	return handler
+}
+
+func (m *middlewareConnection) writeHandler() PlcWriteHandler {
+	handler := func(ctx context.Context, writeRequest model.PlcWriteRequest) model.PlcWriteRequestResult {
+		return <-writeRequest.ExecuteWithContext(ctx)
+	}
+	for i := len(m.middlewares) - 1; i >= 0; i-- {
+		if m.middlewares[i].Write != nil {
+			handler = m.middlewares[i].Write(handler)
+		}
+	}
+	return handler
+}
+
+func (m *middlewareConnection) subscriptionHandler() PlcSubscriptionHandler {
+	handler := func(ctx context.Context, subscriptionRequest model.PlcSubscriptionRequest) model.PlcSubscriptionRequestResult {
+		return <-subscriptionRequest.ExecuteWithContext(ctx)
+	}
+	for i := len(m.middlewares) - 1; i >= 0; i-- {
+		if m.middlewares[i].Subscribe != nil {
+			handler = m.middlewares[i].Subscribe(handler)
+		}
+	}
+	return handler
+}
+
+func (m *middlewareConnection) browseHandler() PlcBrowseHandler {
+	handler := func(ctx context.Context, browseRequest model.PlcBrowseRequest, interceptor func(result model.PlcBrowseEvent) bool) model.PlcBrowseRequestResult {
+		if interceptor == nil {
+			return <-browseRequest.ExecuteWithContext(ctx)
+		}
+		return <-browseRequest.ExecuteWithInterceptorWithContext(ctx, interceptor)
+	}
+	for i := len(m.middlewares) - 1; i >= 0; i-- {
+		if m.middlewares[i].Browse != nil {
+			handler = m.middlewares[i].Browse(handler)
+		}
+	}
+	return handler
+}
+
+func (m *middlewareConnection) Connect() <-chan PlcConnectionConnectResult {
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *middlewareConnection) ConnectWithContext(ctx context.Context) <-chan PlcConnectionConnectResult {
+	ch := make(chan PlcConnectionConnectResult, 1)
+	go func() {
+		connectionResult := <-m.connection.ConnectWithContext(ctx)
+		if connectionResult.Err != nil {
+			ch <- connectionResult
+			return
+		}
+		ch <- NewPlcConnectionConnectResult(m, nil)
+	}()
+	return ch
+}
+
+func (m *middlewareConnection) BlockingClose() {
+	m.connection.BlockingClose()
+}
+
+func (m *middlewareConnection) Close() <-chan PlcConnectionCloseResult {
+	return m.connection.Close()
+}
+
+func (m *middlewareConnection) CloseWithContext(ctx context.Context) <-chan PlcConnectionCloseResult {
+	return m.connection.CloseWithContext(ctx)
+}
+
+func (m *middlewareConnection) IsConnected() bool {
+	return m.connection.IsConnected()
+}
+
+func (m *middlewareConnection) Ping() <-chan PlcConnectionPingResult {
+	return m.connection.Ping()
+}
+
+func (m *middlewareConnection) PingWithContext(ctx context.Context) <-chan PlcConnectionPingResult {
+	return m.connection.PingWithContext(ctx)
+}
+
+func (m *middlewareConnection) GetMetadata() model.PlcConnectionMetadata {
+	return m.connection.GetMetadata()
+}
+
+func (m *middlewareConnection) GetRequestQueueMetrics() model.PlcRequestQueueMetrics {
+	if provider, ok := m.connection.(PlcRequestQueueMetricsProvider); ok {
+		return provider.GetRequestQueueMetrics()
+	}
+	return model.PlcRequestQueueMetrics{}
+}
+
+func (m *middlewareConnection) GetConnectionMetrics() model.PlcConnectionMetrics {
+	if provider, ok := m.connection.(PlcConnectionMetricsProvider); ok {
+		return provider.GetConnectionMetrics()
+	}
+	return model.PlcConnectionMetrics{}
+}
+
+// GetMessageCodec exposes the message codec of the wrapped connection (nil, if it doesn't expose one)
+func (m *middlewareConnection) GetMessageCodec() spi.MessageCodec {
+	if exposer, ok := m.connection.(spi.MessageCodecExposer); ok {
+		return exposer.GetMessageCodec()
+	}
+	return nil
+}
+
+func (m *middlewareConnection) ReadRequestBuilder() model.PlcReadRequestBuilder {
+	return &middlewareReadRequestBuilder{
+		PlcReadRequestBuilder: m.connection.ReadRequestBuilder(),
+		connection:            m,
+	}
+}
+
+func (m *middlewareConnection) WriteRequestBuilder() model.PlcWriteRequestBuilder {
+	return &middlewareWriteRequestBuilder{
+		PlcWriteRequestBuilder: m.connection.WriteRequestBuilder(),
+		connection:             m,
+	}
+}
+
+func (m *middlewareConnection) SubscriptionRequestBuilder() model.PlcSubscriptionRequestBuilder {
+	return &middlewareSubscriptionRequestBuilder{
+		PlcSubscriptionRequestBuilder: m.connection.SubscriptionRequestBuilder(),
+		connection:                    m,
+	}
+}
+
+func (m *middlewareConnection) UnsubscriptionRequestBuilder() model.PlcUnsubscriptionRequestBuilder {
+	return m.connection.UnsubscriptionRequestBuilder()
+}
+
+func (m *middlewareConnection) BrowseRequestBuilder() model.PlcBrowseRequestBuilder {
+	return &middlewareBrowseRequestBuilder{
+		PlcBrowseRequestBuilder: m.connection.BrowseRequestBuilder(),
+		connection:              m,
+	}
+}
+
+type middlewareReadRequestBuilder struct {
+	model.PlcReadRequestBuilder
+	connection *middlewareConnection
+}
+
+func (m *middlewareReadRequestBuilder) Build() (model.PlcReadRequest, error) {
+	readRequest, err := m.PlcReadRequestBuilder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &middlewareReadRequest{
+		PlcReadRequest: readRequest,
+		handler:        m.connection.readHandler(),
+	}, nil
+}
+
+type middlewareReadRequest struct {
+	model.PlcReadRequest
+	handler PlcReadHandler
+}
+
+func (m *middlewareReadRequest) Execute() <-chan model.PlcReadRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m *middlewareReadRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcReadRequestResult {
+	result := make(chan model.PlcReadRequestResult, 1)
+	go func() {
+		result <- m.handler(ctx, m.PlcReadRequest)
+	}()
+	return result
+}
+
+type middlewareWriteRequestBuilder struct {
+	model.PlcWriteRequestBuilder
+	connection *middlewareConnection
+}
+
+func (m *middlewareWriteRequestBuilder) Build() (model.PlcWriteRequest, error) {
+	writeRequest, err := m.PlcWriteRequestBuilder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &middlewareWriteRequest{
+		PlcWriteRequest: writeRequest,
+		handler:         m.connection.writeHandler(),
+	}, nil
+}
+
+type middlewareWriteRequest struct {
+	model.PlcWriteRequest
+	handler PlcWriteHandler
+}
+
+func (m *middlewareWriteRequest) Execute() <-chan model.PlcWriteRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m *middlewareWriteRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcWriteRequestResult {
+	result := make(chan model.PlcWriteRequestResult, 1)
+	go func() {
+		result <- m.handler(ctx, m.PlcWriteRequest)
+	}()
+	return result
+}
+
+type middlewareSubscriptionRequestBuilder struct {
+	model.PlcSubscriptionRequestBuilder
+	connection *middlewareConnection
+}
+
+func (m *middlewareSubscriptionRequestBuilder) Build() (model.PlcSubscriptionRequest, error) {
+	subscriptionRequest, err := m.PlcSubscriptionRequestBuilder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &middlewareSubscriptionRequest{
+		PlcSubscriptionRequest: subscriptionRequest,
+		handler:                m.connection.subscriptionHandler(),
+	}, nil
+}
+
+type middlewareSubscriptionRequest struct {
+	model.PlcSubscriptionRequest
+	handler PlcSubscriptionHandler
+}
+
+func (m *middlewareSubscriptionRequest) Execute() <-chan model.PlcSubscriptionRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m *middlewareSubscriptionRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcSubscriptionRequestResult {
+	result := make(chan model.PlcSubscriptionRequestResult, 1)
+	go func() {
+		result <- m.handler(ctx, m.PlcSubscriptionRequest)
+	}()
+	return result
+}
+
+type middlewareBrowseRequestBuilder struct {
+	model.PlcBrowseRequestBuilder
+	connection *middlewareConnection
+}
+
+func (m *middlewareBrowseRequestBuilder) Build() (model.PlcBrowseRequest, error) {
+	browseRequest, err := m.PlcBrowseRequestBuilder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &middlewareBrowseRequest{
+		PlcBrowseRequest: browseRequest,
+		handler:          m.connection.browseHandler(),
+	}, nil
+}
+
+type middlewareBrowseRequest struct {
+	model.PlcBrowseRequest
+	handler PlcBrowseHandler
+}
+
+func (m *middlewareBrowseRequest) Execute() <-chan model.PlcBrowseRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m *middlewareBrowseRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcBrowseRequestResult {
+	return m.ExecuteWithInterceptorWithContext(ctx, nil)
+}
+
+func (m *middlewareBrowseRequest) ExecuteWithInterceptor(interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult {
+	return m.ExecuteWithInterceptorWithContext(context.Background(), interceptor)
+}
+
+func (m *middlewareBrowseRequest) ExecuteWithInterceptorWithContext(ctx context.Context, interceptor func(result model.PlcBrowseEvent) bool) <-chan model.PlcBrowseRequestResult {
+	result := make(chan model.PlcBrowseRequestResult, 1)
+	go func() {
+		result <- m.handler(ctx, m.PlcBrowseRequest, interceptor)
+	}()
+	return result
+}
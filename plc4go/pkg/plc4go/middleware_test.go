@@ -0,0 +1,151 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package plc4go
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type fakeReadRequest struct {
+	model.PlcReadRequest
+	calls *recordedCalls
+}
+
+func (m fakeReadRequest) ExecuteWithContext(_ context.Context) <-chan model.PlcReadRequestResult {
+	m.calls.add("execute")
+	ch := make(chan model.PlcReadRequestResult, 1)
+	ch <- model.PlcReadRequestResult{Request: m}
+	return ch
+}
+
+type fakeReadRequestBuilder struct {
+	model.PlcReadRequestBuilder
+	calls *recordedCalls
+}
+
+func (m fakeReadRequestBuilder) Build() (model.PlcReadRequest, error) {
+	return fakeReadRequest{calls: m.calls}, nil
+}
+
+type fakeReadConnection struct {
+	fakeConnection
+	calls *recordedCalls
+}
+
+func (m *fakeReadConnection) ReadRequestBuilder() model.PlcReadRequestBuilder {
+	return fakeReadRequestBuilder{calls: m.calls}
+}
+
+type recordedCalls struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (m *recordedCalls) add(call string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+func (m *recordedCalls) get() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+func recordingMiddleware(name string, calls *recordedCalls) PlcMiddleware {
+	return PlcMiddleware{
+		Read: func(next PlcReadHandler) PlcReadHandler {
+			return func(ctx context.Context, readRequest model.PlcReadRequest) model.PlcReadRequestResult {
+				calls.add(name + " before")
+				result := next(ctx, readRequest)
+				calls.add(name + " after")
+				return result
+			}
+		},
+	}
+}
+
+func TestPlcConnectionWithMiddleware_Order(t *testing.T) {
+	calls := &recordedCalls{}
+	connection := NewPlcConnectionWithMiddleware(&fakeReadConnection{calls: calls},
+		recordingMiddleware("first", calls),
+		// Middlewares not handling reads are skipped
+		PlcMiddleware{},
+		recordingMiddleware("second", calls))
+
+	readRequest, err := connection.ReadRequestBuilder().Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if readResult := <-readRequest.Execute(); readResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", readResult.Err)
+	}
+	expectedCalls := []string{"first before", "second before", "execute", "second after", "first after"}
+	if !reflect.DeepEqual(calls.get(), expectedCalls) {
+		t.Errorf("Expected calls %v, got %v", expectedCalls, calls.get())
+	}
+}
+
+func TestPlcConnectionWithMiddleware_ShortCircuit(t *testing.T) {
+	calls := &recordedCalls{}
+	denied := errors.New("access denied")
+	connection := NewPlcConnectionWithMiddleware(&fakeReadConnection{calls: calls}, PlcMiddleware{
+		Read: func(next PlcReadHandler) PlcReadHandler {
+			return func(ctx context.Context, readRequest model.PlcReadRequest) model.PlcReadRequestResult {
+				return model.PlcReadRequestResult{Request: readRequest, Err: denied}
+			}
+		},
+	})
+
+	readRequest, err := connection.ReadRequestBuilder().Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if readResult := <-readRequest.Execute(); readResult.Err != denied {
+		t.Errorf("Expected the error of the middleware, got %v", readResult.Err)
+	}
+	if len(calls.get()) != 0 {
+		t.Errorf("Expected the request not to be executed, got %v", calls.get())
+	}
+}
+
+func TestPlcDriverManager_AddMiddleware(t *testing.T) {
+	driverManager := NewPlcDriverManager()
+	driverManager.RegisterDriver(fakeDriver{protocolCode: "test"})
+	if connectionResult := <-driverManager.GetConnection("test://localhost"); connectionResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", connectionResult.Err)
+	} else if _, ok := connectionResult.Connection.(*fakeConnection); !ok {
+		t.Errorf("Expected connections not to be wrapped without middlewares, got %T", connectionResult.Connection)
+	}
+
+	driverManager.AddMiddleware(PlcMiddleware{})
+	connectionResult := <-driverManager.GetConnection("test://localhost")
+	if connectionResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", connectionResult.Err)
+	}
+	if _, ok := connectionResult.Connection.(*middlewareConnection); !ok {
+		t.Errorf("Expected the connection to be wrapped, got %T", connectionResult.Connection)
+	}
+}
@@ -18,6 +18,8 @@
 //
 package model
 
+import "context"
+
 type PlcBrowseRequestBuilder interface {
 	AddItem(name string, query string)
 	Build() (PlcBrowseRequest, error)
@@ -35,8 +37,12 @@ type PlcBrowseQueryResult struct {
 type PlcBrowseRequest interface {
 	// Will not return until a potential scan is finished and will return all results in one block
 	Execute() <-chan PlcBrowseRequestResult
+	// Variant of Execute, which aborts the scan as soon as the given context is done
+	ExecuteWithContext(ctx context.Context) <-chan PlcBrowseRequestResult
 	// Will call the given callback for every found resource
 	ExecuteWithInterceptor(interceptor func(result PlcBrowseEvent) bool) <-chan PlcBrowseRequestResult
+	// Variant of ExecuteWithInterceptor, which aborts the scan as soon as the given context is done
+	ExecuteWithInterceptorWithContext(ctx context.Context, interceptor func(result PlcBrowseEvent) bool) <-chan PlcBrowseRequestResult
 	GetQueryNames() []string
 	GetQueryString(name string) string
 	PlcRequest
@@ -0,0 +1,138 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import "time"
+
+// Snapshot of the traffic of a connection since it was created
+type PlcConnectionMetrics struct {
+	// Requests executed on the connection, by operation ("read", "write", "subscribe", "unsubscribe" and "browse")
+	Requests map[string]PlcRequestMetrics
+	// Request/response exchanges of the connection with the PLC (a single request might need several of them)
+	Exchanges     PlcRequestMetrics
+	BytesSent     uint64
+	BytesReceived uint64
+	// Unsolicited messages, which have been dropped, as nobody consumed them in time
+	DroppedMessages uint64
+}
+
+// Merge adds up the metrics of both connections (e.g. to get the metrics of all connections of a driver)
+func (m PlcConnectionMetrics) Merge(other PlcConnectionMetrics) PlcConnectionMetrics {
+	requests := map[string]PlcRequestMetrics{}
+	for operation, requestMetrics := range m.Requests {
+		requests[operation] = requestMetrics
+	}
+	for operation, requestMetrics := range other.Requests {
+		requests[operation] = requests[operation].Merge(requestMetrics)
+	}
+	return PlcConnectionMetrics{
+		Requests:        requests,
+		Exchanges:       m.Exchanges.Merge(other.Exchanges),
+		BytesSent:       m.BytesSent + other.BytesSent,
+		BytesReceived:   m.BytesReceived + other.BytesReceived,
+		DroppedMessages: m.DroppedMessages + other.DroppedMessages,
+	}
+}
+
+type PlcRequestMetrics struct {
+	Requests uint64
+	// Requests which failed, including the ones which timed out
+	Errors   uint64
+	Timeouts uint64
+	Latency  PlcLatencyHistogram
+}
+
+func (m PlcRequestMetrics) Merge(other PlcRequestMetrics) PlcRequestMetrics {
+	return PlcRequestMetrics{
+		Requests: m.Requests + other.Requests,
+		Errors:   m.Errors + other.Errors,
+		Timeouts: m.Timeouts + other.Timeouts,
+		Latency:  m.Latency.Merge(other.Latency),
+	}
+}
+
+// Distribution of the latencies of finished requests
+type PlcLatencyHistogram struct {
+	// Upper bounds of the buckets in ascending order
+	Buckets []time.Duration
+	// Number of latencies less than or equal to the upper bound of the bucket with the same index
+	Counts []uint64
+	// Number and sum of all latencies (including the ones above the highest upper bound)
+	Count uint64
+	Sum   time.Duration
+}
+
+// Merge adds up both histograms. Histograms with different buckets can't be merged, so only the receiver is
+// returned in that case.
+func (m PlcLatencyHistogram) Merge(other PlcLatencyHistogram) PlcLatencyHistogram {
+	if len(m.Buckets) == 0 && m.Count == 0 {
+		return other.copy()
+	}
+	if len(other.Buckets) == 0 && other.Count == 0 {
+		return m.copy()
+	}
+	if len(m.Buckets) != len(other.Buckets) {
+		return m.copy()
+	}
+	for i := range m.Buckets {
+		if m.Buckets[i] != other.Buckets[i] {
+			return m.copy()
+		}
+	}
+	merged := m.copy()
+	for i := range merged.Counts {
+		merged.Counts[i] += other.Counts[i]
+	}
+	merged.Count += other.Count
+	merged.Sum += other.Sum
+	return merged
+}
+
+func (m PlcLatencyHistogram) copy() PlcLatencyHistogram {
+	return PlcLatencyHistogram{
+		Buckets: append([]time.Duration(nil), m.Buckets...),
+		Counts:  append([]uint64(nil), m.Counts...),
+		Count:   m.Count,
+		Sum:     m.Sum,
+	}
+}
+
+func (m PlcLatencyHistogram) GetAverage() time.Duration {
+	if m.Count == 0 {
+		return 0
+	}
+	return m.Sum / time.Duration(m.Count)
+}
+
+// Snapshot of the metrics of all connections created by a driver manager
+type PlcDriverManagerMetrics struct {
+	// Metrics of the connections which are still open
+	Connections []PlcDriverManagerConnectionMetrics
+	// Metrics of all connections of a driver by its name, including the ones which have been closed in the meantime
+	Drivers map[string]PlcConnectionMetrics
+}
+
+type PlcDriverManagerConnectionMetrics struct {
+	Driver string
+	// Assigned by the driver manager in the order the connections were created
+	ConnectionId uint64
+	// Transport url the connection was created for
+	Address string
+	Metrics PlcConnectionMetrics
+}
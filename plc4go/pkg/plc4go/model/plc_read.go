@@ -18,11 +18,19 @@
 //
 package model
 
-import "github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"time"
+)
 
 type PlcReadRequestBuilder interface {
 	AddQuery(name string, query string)
 	AddField(name string, field PlcField)
+	// Overrides the request-timeout of the connection for this request
+	SetTimeout(timeout time.Duration)
+	// Overrides the number of retries of the connection for this request (reads are only retried after timeouts)
+	SetRetries(retries int)
 	Build() (PlcReadRequest, error)
 }
 
@@ -34,8 +42,14 @@ type PlcReadRequestResult struct {
 
 type PlcReadRequest interface {
 	Execute() <-chan PlcReadRequestResult
+	// Variant of Execute, which aborts the request as soon as the given context is done
+	ExecuteWithContext(ctx context.Context) <-chan PlcReadRequestResult
 	GetFieldNames() []string
 	GetField(name string) PlcField
+	// Timeout overriding the connection default (0, if the connection default is used)
+	GetTimeout() time.Duration
+	// Number of retries overriding the connection default (negative, if the connection default is used)
+	GetRetries() int
 	PlcRequest
 }
 
@@ -0,0 +1,49 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package model
+
+import "time"
+
+// Snapshot of the queue the requests of a connection wait in, till the connection is free to send them.
+type PlcRequestQueueMetrics struct {
+	// Number of requests waiting, by priority ("low", "normal" and "high")
+	QueueDepth map[string]int
+	// Number of requests sent, but not yet finished
+	ActiveRequests int
+	// Number of requests taken from the queue since the connection was created
+	StartedRequests uint64
+	// Time the started requests spent waiting in the queue
+	TotalWaitTime time.Duration
+	MaxWaitTime   time.Duration
+}
+
+func (m PlcRequestQueueMetrics) GetTotalQueueDepth() int {
+	total := 0
+	for _, depth := range m.QueueDepth {
+		total += depth
+	}
+	return total
+}
+
+func (m PlcRequestQueueMetrics) GetAverageWaitTime() time.Duration {
+	if m.StartedRequests == 0 {
+		return 0
+	}
+	return m.TotalWaitTime / time.Duration(m.StartedRequests)
+}
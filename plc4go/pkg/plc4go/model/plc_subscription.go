@@ -19,6 +19,7 @@
 package model
 
 import (
+	"context"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
 	"time"
 )
@@ -42,6 +43,8 @@ type PlcSubscriptionRequestBuilder interface {
 	AddEventQuery(name string, query string)
 	AddEventField(name string, field PlcField)
 	AddItemHandler(handler PlcSubscriptionEventHandler)
+	// Overrides the request-timeout of the connection for subscribing (subscriptions are never retried)
+	SetTimeout(timeout time.Duration)
 	Build() (PlcSubscriptionRequest, error)
 }
 
@@ -53,14 +56,26 @@ type PlcSubscriptionRequestResult struct {
 
 type PlcSubscriptionRequest interface {
 	Execute() <-chan PlcSubscriptionRequestResult
+	// Variant of Execute, which aborts the request as soon as the given context is done
+	ExecuteWithContext(ctx context.Context) <-chan PlcSubscriptionRequestResult
 	GetFieldNames() []string
 	GetField(name string) PlcField
 	GetEventHandler() PlcSubscriptionEventHandler
+	// Timeout overriding the connection default (0, if the connection default is used)
+	GetTimeout() time.Duration
 	PlcRequest
 }
 
+// Handle identifying the subscription of a single field, which can be used to unsubscribe again
+type PlcSubscriptionHandle interface {
+	GetFieldName() string
+}
+
 type PlcSubscriptionResponse interface {
 	GetRequest() PlcSubscriptionRequest
 	GetFieldNames() []string
 	GetResponseCode(name string) PlcResponseCode
+	// Get the handle for the subscription of the field with the given name (nil if it wasn't subscribed successfully)
+	GetSubscriptionHandle(name string) PlcSubscriptionHandle
+	GetSubscriptionHandles() []PlcSubscriptionHandle
 }
@@ -18,8 +18,14 @@
 //
 package model
 
+import "context"
+
 type PlcUnsubscriptionRequestBuilder interface {
-	// TODO: Implement
+	// Remove the subscriptions identified by the given handles
+	AddHandles(handles ...PlcSubscriptionHandle)
+	// Remove all subscriptions for fields with the given names
+	AddFieldNames(fieldNames ...string)
+	Build() (PlcUnsubscriptionRequest, error)
 }
 
 type PlcUnsubscriptionRequestResult struct {
@@ -30,8 +36,17 @@ type PlcUnsubscriptionRequestResult struct {
 
 type PlcUnsubscriptionRequest interface {
 	Execute() <-chan PlcUnsubscriptionRequestResult
+	// Variant of Execute, which aborts the request as soon as the given context is done
+	ExecuteWithContext(ctx context.Context) <-chan PlcUnsubscriptionRequestResult
+	GetSubscriptionHandles() []PlcSubscriptionHandle
+	GetFieldNames() []string
 	PlcRequest
 }
 
-type PlcUnsubscriptionResponse struct {
+type PlcUnsubscriptionResponse interface {
+	GetRequest() PlcUnsubscriptionRequest
+	// Names of all fields the unsubscription was processed for
+	GetFieldNames() []string
+	GetResponseCode(name string) PlcResponseCode
+	PlcResponse
 }
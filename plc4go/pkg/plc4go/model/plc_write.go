@@ -18,11 +18,17 @@
 //
 package model
 
-import "github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"time"
+)
 
 type PlcWriteRequestBuilder interface {
 	AddQuery(name string, query string, value interface{})
 	AddField(name string, field PlcField, value interface{})
+	// Overrides the request-timeout of the connection for this request (writes are never retried)
+	SetTimeout(timeout time.Duration)
 	Build() (PlcWriteRequest, error)
 }
 
@@ -34,9 +40,13 @@ type PlcWriteRequestResult struct {
 
 type PlcWriteRequest interface {
 	Execute() <-chan PlcWriteRequestResult
+	// Variant of Execute, which aborts the request as soon as the given context is done
+	ExecuteWithContext(ctx context.Context) <-chan PlcWriteRequestResult
 	GetFieldNames() []string
 	GetField(name string) PlcField
 	GetValue(name string) values.PlcValue
+	// Timeout overriding the connection default (0, if the connection default is used)
+	GetTimeout() time.Duration
 	PlcRequest
 }
 
@@ -0,0 +1,98 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package opm
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Name of the struct tag containing the address (query) of a field
+const TagName = "plc4x"
+
+// entityField describes one tagged field of a struct mapped by the PlcMapper
+type entityField struct {
+	// Name of the struct field, which is used as field name in the requests
+	name  string
+	query string
+	index int
+}
+
+type entityMetadata struct {
+	fields []entityField
+}
+
+// entityMetadataCache keeps the parsed metadata per struct type, so the tags only have to be parsed once
+type entityMetadataCache struct {
+	lock     sync.RWMutex
+	metadata map[reflect.Type]*entityMetadata
+}
+
+func newEntityMetadataCache() *entityMetadataCache {
+	return &entityMetadataCache{
+		metadata: map[reflect.Type]*entityMetadata{},
+	}
+}
+
+func (m *entityMetadataCache) get(entityType reflect.Type) (*entityMetadata, error) {
+	m.lock.RLock()
+	metadata, ok := m.metadata[entityType]
+	m.lock.RUnlock()
+	if ok {
+		return metadata, nil
+	}
+	metadata, err := parseEntityMetadata(entityType)
+	if err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	m.metadata[entityType] = metadata
+	m.lock.Unlock()
+	return metadata, nil
+}
+
+func parseEntityMetadata(entityType reflect.Type) (*entityMetadata, error) {
+	metadata := &entityMetadata{}
+	for i := 0; i < entityType.NumField(); i++ {
+		structField := entityType.Field(i)
+		tag, ok := structField.Tag.Lookup(TagName)
+		if !ok || tag == "-" {
+			continue
+		}
+		query := strings.TrimSpace(tag)
+		if query == "" {
+			return nil, errors.Errorf("field %s.%s has an empty %s tag", entityType.Name(), structField.Name, TagName)
+		}
+		// Unexported fields can neither be read nor set via reflection
+		if structField.PkgPath != "" {
+			return nil, errors.Errorf("field %s.%s is tagged, but not exported", entityType.Name(), structField.Name)
+		}
+		metadata.fields = append(metadata.fields, entityField{
+			name:  structField.Name,
+			query: query,
+			index: i,
+		})
+	}
+	if len(metadata.fields) == 0 {
+		return nil, errors.Errorf("type %s has no fields tagged with %s", entityType.Name(), TagName)
+	}
+	return metadata, nil
+}
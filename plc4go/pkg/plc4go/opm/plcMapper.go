@@ -0,0 +1,151 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package opm
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/utils"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"reflect"
+)
+
+// PlcMapper reads and writes structs, whose fields are tagged with the address they are mapped to:
+//
+//	type MachineState struct {
+//	    Running bool    `plc4x:"%DB1.DBX0.0:BOOL"`
+//	    Speed   int16   `plc4x:"%DB1.DBW2:INT"`
+//	    Temp    float32 `plc4x:"%DB1.DBD4:REAL"`
+//	}
+//
+// All tagged fields of a struct are read or written with one single request. When reading, the values are
+// converted to the kind of the Go field (with range checks for integers). When writing, the Go value is passed to
+// the drivers value handler, so the kind of the field has to match the data type of the address (int16 for INT, ...).
+type PlcMapper struct {
+	metadataCache *entityMetadataCache
+}
+
+func NewPlcMapper() *PlcMapper {
+	return &PlcMapper{
+		metadataCache: newEntityMetadataCache(),
+	}
+}
+
+// Read all tagged fields of the struct the given pointer points to
+func (m *PlcMapper) Read(connection plc4go.PlcConnection, entity interface{}) error {
+	return m.ReadWithContext(context.Background(), connection, entity)
+}
+
+// Variant of Read, which aborts the request as soon as the given context is done
+func (m *PlcMapper) ReadWithContext(ctx context.Context, connection plc4go.PlcConnection, entity interface{}) error {
+	entityValue, metadata, err := m.getEntity(entity)
+	if err != nil {
+		return err
+	}
+
+	builder := connection.ReadRequestBuilder()
+	for _, field := range metadata.fields {
+		builder.AddQuery(field.name, field.query)
+	}
+	readRequest, err := builder.Build()
+	if err != nil {
+		return errors.Wrapf(err, "error building read request for %s", entityValue.Type())
+	}
+	var readResult model.PlcReadRequestResult
+	select {
+	case readResult = <-readRequest.ExecuteWithContext(ctx):
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "error reading %s", entityValue.Type())
+	}
+	if readResult.Err != nil {
+		return errors.Wrapf(readResult.Err, "error reading %s", entityValue.Type())
+	}
+
+	var fieldErrors []error
+	for _, field := range metadata.fields {
+		if responseCode := readResult.Response.GetResponseCode(field.name); responseCode != model.PlcResponseCode_OK {
+			fieldErrors = append(fieldErrors, errors.Errorf("field %s (%s): got response code %s", field.name, field.query, responseCode.GetName()))
+			continue
+		}
+		if err := setValue(entityValue.Field(field.index), readResult.Response.GetValue(field.name)); err != nil {
+			fieldErrors = append(fieldErrors, errors.Wrapf(err, "field %s (%s)", field.name, field.query))
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return utils.MultiError{MainError: errors.Errorf("error mapping %s", entityValue.Type()), Errors: fieldErrors}
+	}
+	return nil
+}
+
+// Write all tagged fields of the struct the given pointer points to
+func (m *PlcMapper) Write(connection plc4go.PlcConnection, entity interface{}) error {
+	return m.WriteWithContext(context.Background(), connection, entity)
+}
+
+// Variant of Write, which aborts the request as soon as the given context is done
+func (m *PlcMapper) WriteWithContext(ctx context.Context, connection plc4go.PlcConnection, entity interface{}) error {
+	entityValue, metadata, err := m.getEntity(entity)
+	if err != nil {
+		return err
+	}
+
+	builder := connection.WriteRequestBuilder()
+	for _, field := range metadata.fields {
+		builder.AddQuery(field.name, field.query, entityValue.Field(field.index).Interface())
+	}
+	writeRequest, err := builder.Build()
+	if err != nil {
+		return errors.Wrapf(err, "error building write request for %s", entityValue.Type())
+	}
+	var writeResult model.PlcWriteRequestResult
+	select {
+	case writeResult = <-writeRequest.ExecuteWithContext(ctx):
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "error writing %s", entityValue.Type())
+	}
+	if writeResult.Err != nil {
+		return errors.Wrapf(writeResult.Err, "error writing %s", entityValue.Type())
+	}
+
+	var fieldErrors []error
+	for _, field := range metadata.fields {
+		if responseCode := writeResult.Response.GetResponseCode(field.name); responseCode != model.PlcResponseCode_OK {
+			fieldErrors = append(fieldErrors, errors.Errorf("field %s (%s): got response code %s", field.name, field.query, responseCode.GetName()))
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return utils.MultiError{MainError: errors.Errorf("error writing %s", entityValue.Type()), Errors: fieldErrors}
+	}
+	return nil
+}
+
+// getEntity checks the entity is a pointer to a struct and returns the struct along with its metadata
+func (m *PlcMapper) getEntity(entity interface{}) (reflect.Value, *entityMetadata, error) {
+	pointer := reflect.ValueOf(entity)
+	if pointer.Kind() != reflect.Ptr || pointer.IsNil() || pointer.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, errors.Errorf("expected a non-nil pointer to a struct, got %T", entity)
+	}
+	entityValue := pointer.Elem()
+	metadata, err := m.metadataCache.get(entityValue.Type())
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return entityValue, metadata, nil
+}
@@ -0,0 +1,179 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package opm
+
+import (
+	"context"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	internalValues "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"strings"
+	"testing"
+)
+
+// fakeField uses the part of the query after the colon as type name, "%DB1.DBW0:INT" is of type "INT"
+type fakeField struct {
+	query string
+}
+
+func (m fakeField) GetAddressString() string {
+	return m.query
+}
+
+func (m fakeField) GetTypeName() string {
+	return m.query[strings.LastIndex(m.query, ":")+1:]
+}
+
+func (m fakeField) GetQuantity() uint16 {
+	return 1
+}
+
+type fakeFieldHandler struct {
+}
+
+func (m fakeFieldHandler) ParseQuery(query string) (model.PlcField, error) {
+	return fakeField{query: query}, nil
+}
+
+// fakeConnection serves reads from and stores writes in a map of values per address
+type fakeConnection struct {
+	plc4go.PlcConnection
+	values map[string]values.PlcValue
+}
+
+func (m *fakeConnection) ReadRequestBuilder() model.PlcReadRequestBuilder {
+	return internalModel.NewDefaultPlcReadRequestBuilder(fakeFieldHandler{}, m)
+}
+
+func (m *fakeConnection) WriteRequestBuilder() model.PlcWriteRequestBuilder {
+	return internalModel.NewDefaultPlcWriteRequestBuilder(fakeFieldHandler{}, internalValues.NewIEC61131ValueHandler(), m)
+}
+
+func (m *fakeConnection) Read(_ context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	responseCodes := map[string]model.PlcResponseCode{}
+	plcValues := map[string]values.PlcValue{}
+	for _, fieldName := range readRequest.GetFieldNames() {
+		if value, ok := m.values[readRequest.GetField(fieldName).GetAddressString()]; ok {
+			responseCodes[fieldName] = model.PlcResponseCode_OK
+			plcValues[fieldName] = value
+		} else {
+			responseCodes[fieldName] = model.PlcResponseCode_NOT_FOUND
+		}
+	}
+	ch := make(chan model.PlcReadRequestResult, 1)
+	ch <- model.PlcReadRequestResult{
+		Request:  readRequest,
+		Response: internalModel.NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues),
+	}
+	return ch
+}
+
+func (m *fakeConnection) Write(_ context.Context, writeRequest model.PlcWriteRequest) <-chan model.PlcWriteRequestResult {
+	responseCodes := map[string]model.PlcResponseCode{}
+	for _, fieldName := range writeRequest.GetFieldNames() {
+		m.values[writeRequest.GetField(fieldName).GetAddressString()] = writeRequest.GetValue(fieldName)
+		responseCodes[fieldName] = model.PlcResponseCode_OK
+	}
+	ch := make(chan model.PlcWriteRequestResult, 1)
+	ch <- model.PlcWriteRequestResult{
+		Request:  writeRequest,
+		Response: internalModel.NewDefaultPlcWriteResponse(writeRequest, responseCodes),
+	}
+	return ch
+}
+
+type machineState struct {
+	Running bool    `plc4x:"%DB1.DBX0.0:BOOL"`
+	Speed   int16   `plc4x:"%DB1.DBW2:INT"`
+	Counter int64   `plc4x:"%DB1.DBD4:DINT"`
+	Temp    float32 `plc4x:"%DB1.DBD8:REAL"`
+	Flags   []bool  `plc4x:"%DB1.DBB12:BYTE"`
+	Comment string
+}
+
+func TestPlcMapper_Read(t *testing.T) {
+	connection := &fakeConnection{values: map[string]values.PlcValue{
+		"%DB1.DBX0.0:BOOL": internalValues.NewPlcBOOL(true),
+		"%DB1.DBW2:INT":    internalValues.NewPlcINT(-42),
+		"%DB1.DBD4:DINT":   internalValues.NewPlcDINT(123456),
+		"%DB1.DBD8:REAL":   internalValues.NewPlcREAL(1.5),
+		"%DB1.DBB12:BYTE":  internalValues.NewPlcBYTE(0x81),
+	}}
+	state := machineState{Comment: "untouched"}
+	if err := NewPlcMapper().Read(connection, &state); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !state.Running || state.Speed != -42 || state.Counter != 123456 || state.Temp != 1.5 || state.Comment != "untouched" {
+		t.Errorf("Unexpected state %+v", state)
+	}
+	if len(state.Flags) != 8 || !state.Flags[0] || !state.Flags[7] {
+		t.Errorf("Unexpected flags %v", state.Flags)
+	}
+}
+
+func TestPlcMapper_ReadReportsMismatches(t *testing.T) {
+	type mismatch struct {
+		// A negative value doesn't fit into an unsigned field
+		Speed   uint16 `plc4x:"%DB1.DBW2:INT"`
+		Missing int16  `plc4x:"%DB1.DBW4:INT"`
+	}
+	connection := &fakeConnection{values: map[string]values.PlcValue{
+		"%DB1.DBW2:INT": internalValues.NewPlcINT(-42),
+	}}
+	err := NewPlcMapper().Read(connection, &mismatch{})
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if !strings.Contains(err.Error(), "field Speed") || !strings.Contains(err.Error(), "field Missing") {
+		t.Errorf("Expected both fields to be reported, got %v", err)
+	}
+}
+
+func TestPlcMapper_Write(t *testing.T) {
+	type setpoints struct {
+		Speed  int16   `plc4x:"%DB2.DBW0:INT"`
+		Target float32 `plc4x:"%DB2.DBD2:REAL"`
+	}
+	connection := &fakeConnection{values: map[string]values.PlcValue{}}
+	if err := NewPlcMapper().Write(connection, &setpoints{Speed: 1200, Target: 2.5}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if speed := connection.values["%DB2.DBW0:INT"]; speed == nil || speed.GetInt16() != 1200 {
+		t.Errorf("Unexpected speed %v", speed)
+	}
+	if target := connection.values["%DB2.DBD2:REAL"]; target == nil || target.GetFloat32() != 2.5 {
+		t.Errorf("Unexpected target %v", target)
+	}
+}
+
+func TestPlcMapper_RejectsInvalidEntities(t *testing.T) {
+	mapper := NewPlcMapper()
+	connection := &fakeConnection{values: map[string]values.PlcValue{}}
+	if err := mapper.Read(connection, machineState{}); err == nil {
+		t.Errorf("Expected an error for a non-pointer entity")
+	}
+	type untagged struct {
+		Speed int16
+	}
+	if err := mapper.Read(connection, &untagged{}); err == nil {
+		t.Errorf("Expected an error for an entity without tagged fields")
+	}
+}
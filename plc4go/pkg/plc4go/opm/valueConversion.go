@@ -0,0 +1,176 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package opm
+
+import (
+	internalValues "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"reflect"
+	"time"
+)
+
+var (
+	plcValueType = reflect.TypeOf((*values.PlcValue)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// setValue converts the given PlcValue to the type of the target and assigns it. Integer values are range-checked,
+// so a value which doesn't fit into the target type results in an error instead of being truncated.
+func setValue(target reflect.Value, plcValue values.PlcValue) error {
+	if plcValue == nil || plcValue.IsNull() {
+		return errors.New("no value returned")
+	}
+	targetType := target.Type()
+	// Fields of type PlcValue (or interface{}) get the raw value
+	if targetType == plcValueType || (targetType.Kind() == reflect.Interface && targetType.NumMethod() == 0) {
+		target.Set(reflect.ValueOf(plcValue))
+		return nil
+	}
+	if targetType == timeType {
+		switch {
+		case plcValue.IsDateTime():
+			target.Set(reflect.ValueOf(plcValue.GetDateTime()))
+		case plcValue.IsDate():
+			target.Set(reflect.ValueOf(plcValue.GetDate()))
+		case plcValue.IsTime():
+			target.Set(reflect.ValueOf(plcValue.GetTime()))
+		default:
+			return newConversionError(plcValue, targetType)
+		}
+		return nil
+	}
+
+	switch targetType.Kind() {
+	case reflect.Bool:
+		if !plcValue.IsBool() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetBool(plcValue.GetBool())
+	case reflect.Int8:
+		if !plcValue.IsInt8() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetInt(int64(plcValue.GetInt8()))
+	case reflect.Int16:
+		if !plcValue.IsInt16() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetInt(int64(plcValue.GetInt16()))
+	case reflect.Int32:
+		if !plcValue.IsInt32() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetInt(int64(plcValue.GetInt32()))
+	case reflect.Int, reflect.Int64:
+		if !plcValue.IsInt64() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetInt(plcValue.GetInt64())
+	case reflect.Uint8:
+		if !plcValue.IsUint8() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetUint(uint64(plcValue.GetUint8()))
+	case reflect.Uint16:
+		if !plcValue.IsUint16() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetUint(uint64(plcValue.GetUint16()))
+	case reflect.Uint32:
+		if !plcValue.IsUint32() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetUint(uint64(plcValue.GetUint32()))
+	case reflect.Uint, reflect.Uint64:
+		if !plcValue.IsUint64() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetUint(plcValue.GetUint64())
+	case reflect.Float32:
+		if !plcValue.IsFloat32() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetFloat(float64(plcValue.GetFloat32()))
+	case reflect.Float64:
+		if !plcValue.IsFloat64() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetFloat(plcValue.GetFloat64())
+	case reflect.String:
+		if !plcValue.IsString() {
+			return newConversionError(plcValue, targetType)
+		}
+		target.SetString(plcValue.GetString())
+	case reflect.Slice:
+		elements, err := getElements(plcValue, targetType)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(targetType, len(elements), len(elements))
+		if err := setElements(slice, elements); err != nil {
+			return err
+		}
+		target.Set(slice)
+	case reflect.Array:
+		elements, err := getElements(plcValue, targetType)
+		if err != nil {
+			return err
+		}
+		if len(elements) != targetType.Len() {
+			return errors.Errorf("cannot convert %d values to %s", len(elements), targetType)
+		}
+		if err := setElements(target, elements); err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("unsupported field type %s", targetType)
+	}
+	return nil
+}
+
+// getElements returns the individual values of a list (or bit-string) value
+func getElements(plcValue values.PlcValue, targetType reflect.Type) ([]values.PlcValue, error) {
+	// Simple values claim to be lists as well, so only real lists are taken apart
+	if plcValue.IsList() && !plcValue.IsSimple() {
+		return plcValue.GetList(), nil
+	}
+	// Bit-strings (BYTE, WORD, ...) can be mapped to bool slices
+	if targetType.Elem().Kind() == reflect.Bool && plcValue.IsBool() && plcValue.GetBoolLength() > 1 {
+		var elements []values.PlcValue
+		for _, bit := range plcValue.GetBoolArray() {
+			elements = append(elements, internalValues.NewPlcBOOL(bit))
+		}
+		return elements, nil
+	}
+	return nil, newConversionError(plcValue, targetType)
+}
+
+func setElements(target reflect.Value, elements []values.PlcValue) error {
+	for i, element := range elements {
+		if err := setValue(target.Index(i), element); err != nil {
+			return errors.Wrapf(err, "error converting element %d", i)
+		}
+	}
+	return nil
+}
+
+func newConversionError(plcValue values.PlcValue, targetType reflect.Type) error {
+	return errors.Errorf("cannot convert value of type %s to %s", reflect.TypeOf(plcValue).Name(), targetType)
+}
@@ -0,0 +1,220 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package options
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type OptionType uint8
+
+const (
+	OptionTypeString OptionType = iota
+	OptionTypeInteger
+	OptionTypeFloat
+	OptionTypeBoolean
+)
+
+func (m OptionType) String() string {
+	switch m {
+	case OptionTypeString:
+		return "string"
+	case OptionTypeInteger:
+		return "integer"
+	case OptionTypeFloat:
+		return "float"
+	case OptionTypeBoolean:
+		return "boolean"
+	default:
+		return "unknown"
+	}
+}
+
+// OptionDescriptor describes a single option, which can be passed in the query part of a connection string
+type OptionDescriptor struct {
+	Name string
+	Type OptionType
+	// Value used if the option isn't provided (empty, if there is none)
+	Default  string
+	Required bool
+	// If not empty, only these values are accepted
+	AllowedValues []string
+	Description   string
+}
+
+func (m OptionDescriptor) String() string {
+	var details []string
+	details = append(details, m.Type.String())
+	if m.Required {
+		details = append(details, "required")
+	}
+	if m.Default != "" {
+		details = append(details, "default: "+m.Default)
+	}
+	if len(m.AllowedValues) > 0 {
+		details = append(details, "one of: "+strings.Join(m.AllowedValues, ", "))
+	}
+	return fmt.Sprintf("%s (%s): %s", m.Name, strings.Join(details, ", "), m.Description)
+}
+
+func (m OptionDescriptor) validate(values []string) error {
+	if len(values) != 1 {
+		return errors.Errorf("option %s must be given exactly once, got %d values", m.Name, len(values))
+	}
+	value := values[0]
+	switch m.Type {
+	case OptionTypeInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errors.Errorf("option %s must be an integer, got '%s'", m.Name, value)
+		}
+	case OptionTypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.Errorf("option %s must be a number, got '%s'", m.Name, value)
+		}
+	case OptionTypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.Errorf("option %s must be a boolean, got '%s'", m.Name, value)
+		}
+	}
+	if len(m.AllowedValues) > 0 {
+		for _, allowedValue := range m.AllowedValues {
+			if value == allowedValue {
+				return nil
+			}
+		}
+		return errors.Errorf("option %s must be one of %s, got '%s'", m.Name, strings.Join(m.AllowedValues, ", "), value)
+	}
+	return nil
+}
+
+// OptionSchema lists all options supported by a driver or transport
+type OptionSchema []OptionDescriptor
+
+// Get the descriptor of the option with the given name
+func (m OptionSchema) Get(name string) (OptionDescriptor, bool) {
+	for _, descriptor := range m {
+		if descriptor.Name == name {
+			return descriptor, true
+		}
+	}
+	return OptionDescriptor{}, false
+}
+
+// GetNames returns the sorted names of all options
+func (m OptionSchema) GetNames() []string {
+	var names []string
+	for _, descriptor := range m {
+		names = append(names, descriptor.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Merge returns a schema containing the options of both schemas
+func (m OptionSchema) Merge(other OptionSchema) OptionSchema {
+	merged := make(OptionSchema, 0, len(m)+len(other))
+	merged = append(merged, m...)
+	for _, descriptor := range other {
+		if _, ok := merged.Get(descriptor.Name); !ok {
+			merged = append(merged, descriptor)
+		}
+	}
+	return merged
+}
+
+// Validate checks the given options only contain known options with valid values and all required options are
+// present. All problems found are reported in one error.
+func (m OptionSchema) Validate(options map[string][]string) error {
+	var problems []string
+	var names []string
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		descriptor, ok := m.Get(name)
+		if !ok {
+			problem := fmt.Sprintf("unknown option %s", name)
+			if suggestion := m.suggest(name); suggestion != "" {
+				problem += fmt.Sprintf(" (did you mean %s?)", suggestion)
+			}
+			problems = append(problems, problem)
+			continue
+		}
+		if err := descriptor.validate(options[name]); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, descriptor := range m {
+		if _, ok := options[descriptor.Name]; descriptor.Required && !ok {
+			problems = append(problems, fmt.Sprintf("required option %s is missing", descriptor.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("invalid options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// suggest returns the name of the option closest to the given (probably misspelled) name, if there is a close one
+func (m OptionSchema) suggest(name string) string {
+	bestName := ""
+	bestDistance := 3
+	for _, descriptor := range m {
+		if distance := levenshteinDistance(strings.ToLower(name), strings.ToLower(descriptor.Name)); distance < bestDistance {
+			bestName = descriptor.Name
+			bestDistance = distance
+		}
+	}
+	return bestName
+}
+
+func levenshteinDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+var testSchema = OptionSchema{
+	{Name: "host-id", Type: OptionTypeString, Required: true},
+	{Name: "timeout", Type: OptionTypeInteger, Default: "1000"},
+	{Name: "ratio", Type: OptionTypeFloat},
+	{Name: "secure", Type: OptionTypeBoolean, Default: "false"},
+	{Name: "mode", Type: OptionTypeString, AllowedValues: []string{"fast", "slow"}},
+}
+
+func TestOptionSchema_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       map[string][]string
+		expectedError string
+	}{
+		{"valid", map[string][]string{"host-id": {"a"}, "timeout": {"10"}, "ratio": {"0.5"}, "secure": {"true"}, "mode": {"fast"}}, ""},
+		{"missing required", map[string][]string{"timeout": {"10"}}, "required option host-id is missing"},
+		{"unknown with suggestion", map[string][]string{"host-id": {"a"}, "timeuot": {"10"}}, "unknown option timeuot (did you mean timeout?)"},
+		{"unknown", map[string][]string{"host-id": {"a"}, "something": {"10"}}, "unknown option something"},
+		{"not an integer", map[string][]string{"host-id": {"a"}, "timeout": {"soon"}}, "option timeout must be an integer, got 'soon'"},
+		{"not a number", map[string][]string{"host-id": {"a"}, "ratio": {"half"}}, "option ratio must be a number, got 'half'"},
+		{"not a boolean", map[string][]string{"host-id": {"a"}, "secure": {"yes please"}}, "option secure must be a boolean, got 'yes please'"},
+		{"not allowed", map[string][]string{"host-id": {"a"}, "mode": {"medium"}}, "option mode must be one of fast, slow, got 'medium'"},
+		{"given twice", map[string][]string{"host-id": {"a", "b"}}, "option host-id must be given exactly once, got 2 values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testSchema.Validate(tt.options)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestOptionSchema_Merge(t *testing.T) {
+	merged := testSchema.Merge(OptionSchema{
+		{Name: "timeout", Type: OptionTypeString},
+		{Name: "connect-timeout", Type: OptionTypeInteger},
+	})
+	if len(merged) != len(testSchema)+1 {
+		t.Fatalf("Expected %d options, got %v", len(testSchema)+1, merged.GetNames())
+	}
+	if descriptor, _ := merged.Get("timeout"); descriptor.Type != OptionTypeInteger {
+		t.Errorf("Expected the first schema to take precedence")
+	}
+	if _, ok := merged.Get("connect-timeout"); !ok {
+		t.Errorf("Expected connect-timeout to be merged in")
+	}
+}
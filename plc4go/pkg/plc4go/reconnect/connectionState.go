@@ -0,0 +1,54 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package reconnect
+
+import "fmt"
+
+type ConnectionState uint8
+
+const (
+	ConnectionStateClosed ConnectionState = iota
+	ConnectionStateConnecting
+	ConnectionStateConnected
+	ConnectionStateReconnecting
+)
+
+func (m ConnectionState) String() string {
+	switch m {
+	case ConnectionStateClosed:
+		return "CLOSED"
+	case ConnectionStateConnecting:
+		return "CONNECTING"
+	case ConnectionStateConnected:
+		return "CONNECTED"
+	case ConnectionStateReconnecting:
+		return "RECONNECTING"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", uint8(m))
+}
+
+type ConnectionStateEvent struct {
+	Connection *ReconnectingPlcConnection
+	OldState   ConnectionState
+	NewState   ConnectionState
+	// The error causing this transition (if any)
+	Err error
+}
+
+type ConnectionStateListener func(event ConnectionStateEvent)
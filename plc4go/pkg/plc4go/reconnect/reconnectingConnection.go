@@ -0,0 +1,487 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package reconnect
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = time.Minute
+	DefaultPingInterval   = time.Second * 10
+)
+
+type WithReconnectOption func(connection *ReconnectingPlcConnection)
+
+// WithInitialBackoff defines how long to wait after the first failed reconnect attempt.
+// With every further failed attempt this time is doubled.
+func WithInitialBackoff(initialBackoff time.Duration) WithReconnectOption {
+	return func(connection *ReconnectingPlcConnection) {
+		connection.initialBackoff = initialBackoff
+	}
+}
+
+// WithMaxBackoff defines the maximum time to wait between two reconnect attempts.
+func WithMaxBackoff(maxBackoff time.Duration) WithReconnectOption {
+	return func(connection *ReconnectingPlcConnection) {
+		connection.maxBackoff = maxBackoff
+	}
+}
+
+// WithPingInterval defines how often the connection is checked using Ping(). A value of 0 disables pinging.
+func WithPingInterval(pingInterval time.Duration) WithReconnectOption {
+	return func(connection *ReconnectingPlcConnection) {
+		connection.pingInterval = pingInterval
+	}
+}
+
+// ReconnectingPlcConnection is a PlcConnection which transparently re-establishes the connection (including all
+// active subscriptions) as soon as the transport fails, too many requests time out or a ping fails.
+// Requests built before a reconnect are bound to the old connection, so they have to be built again.
+type ReconnectingPlcConnection struct {
+	driverManager    plc4go.PlcDriverManager
+	logger           *zerolog.Logger
+	connectionString string
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+	pingInterval     time.Duration
+
+	lock          sync.RWMutex
+	state         ConnectionState
+	connection    plc4go.PlcConnection
+	generation    uint64
+	subscriptions []*subscriptionRecord
+	listeners     []ConnectionStateListener
+	done          chan struct{}
+}
+
+func NewReconnectingPlcConnection(driverManager plc4go.PlcDriverManager, connectionString string, options ...WithReconnectOption) *ReconnectingPlcConnection {
+	connection := &ReconnectingPlcConnection{
+		driverManager:    driverManager,
+		logger:           driverManager.GetLogger(),
+		connectionString: connectionString,
+		initialBackoff:   DefaultInitialBackoff,
+		maxBackoff:       DefaultMaxBackoff,
+		pingInterval:     DefaultPingInterval,
+		state:            ConnectionStateClosed,
+	}
+	for _, option := range options {
+		option(connection)
+	}
+	return connection
+}
+
+func (m *ReconnectingPlcConnection) AddConnectionStateListener(listener ConnectionStateListener) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+func (m *ReconnectingPlcConnection) GetState() ConnectionState {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.state
+}
+
+// setState has to be called while holding the lock. The returned function publishes the transition and has to be
+// called after releasing the lock.
+func (m *ReconnectingPlcConnection) setState(newState ConnectionState, err error) func() {
+	event := ConnectionStateEvent{
+		Connection: m,
+		OldState:   m.state,
+		NewState:   newState,
+		Err:        err,
+	}
+	m.state = newState
+	listeners := make([]ConnectionStateListener, len(m.listeners))
+	copy(listeners, m.listeners)
+	return func() {
+		m.logger.Debug().Str("connectionString", m.connectionString).
+			Stringer("oldState", event.OldState).
+			Stringer("newState", event.NewState).
+			Msg("Connection state changed")
+		for _, listener := range listeners {
+			listener(event)
+		}
+	}
+}
+
+func (m *ReconnectingPlcConnection) Connect() <-chan plc4go.PlcConnectionConnectResult {
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *ReconnectingPlcConnection) ConnectWithContext(ctx context.Context) <-chan plc4go.PlcConnectionConnectResult {
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+	m.lock.Lock()
+	if m.state != ConnectionStateClosed {
+		m.lock.Unlock()
+		ch <- plc4go.NewPlcConnectionConnectResult(m, nil)
+		return ch
+	}
+	m.done = make(chan struct{})
+	publish := m.setState(ConnectionStateConnecting, nil)
+	m.lock.Unlock()
+	publish()
+
+	go func() {
+		var connectionResult plc4go.PlcConnectionConnectResult
+		connectionResultChan := m.driverManager.GetConnectionWithContext(ctx, m.connectionString)
+		select {
+		case connectionResult = <-connectionResultChan:
+		case <-ctx.Done():
+			// Don't leak the connection, if it comes in later on
+			go func() {
+				if connectionResult := <-connectionResultChan; connectionResult.Connection != nil {
+					connectionResult.Connection.Close()
+				}
+			}()
+			connectionResult = plc4go.NewPlcConnectionConnectResult(nil, ctx.Err())
+		}
+		m.lock.Lock()
+		if connectionResult.Err != nil {
+			publish := m.setState(ConnectionStateClosed, connectionResult.Err)
+			m.lock.Unlock()
+			publish()
+			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.Wrap(connectionResult.Err, "error connecting"))
+			return
+		}
+		if m.state != ConnectionStateConnecting {
+			// Closed while connecting
+			m.lock.Unlock()
+			connectionResult.Connection.Close()
+			ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.New("connection closed while connecting"))
+			return
+		}
+		m.install(connectionResult.Connection)
+		publish := m.setState(ConnectionStateConnected, nil)
+		m.lock.Unlock()
+		publish()
+		ch <- plc4go.NewPlcConnectionConnectResult(m, nil)
+	}()
+	return ch
+}
+
+// install has to be called while holding the lock
+func (m *ReconnectingPlcConnection) install(connection plc4go.PlcConnection) {
+	m.connection = connection
+	m.generation++
+	generation := m.generation
+	// Get notified as soon as the codec considers the transport broken
+	if exposer, ok := connection.(spi.MessageCodecExposer); ok {
+		if notifier, ok := exposer.GetMessageCodec().(spi.TransportFailureNotifier); ok {
+			notifier.AddTransportFailureListener(func(err error) {
+				m.handleFailure(generation, err)
+			})
+		}
+	}
+	if m.pingInterval > 0 {
+		go m.pingLoop(generation, connection, m.done)
+	}
+}
+
+func (m *ReconnectingPlcConnection) pingLoop(generation uint64, connection plc4go.PlcConnection, done chan struct{}) {
+	ticker := time.NewTicker(m.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		m.lock.RLock()
+		current := m.generation == generation && m.state == ConnectionStateConnected
+		m.lock.RUnlock()
+		if !current {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), m.pingInterval)
+		pingResult := <-connection.PingWithContext(ctx)
+		cancel()
+		if errors.Is(pingResult.Err, plc4go.ErrPingNotSupported) {
+			m.logger.Debug().Str("connectionString", m.connectionString).Msg("Ping not supported by connection, disabling pinging")
+			return
+		}
+		if pingResult.Err != nil {
+			m.handleFailure(generation, errors.Wrap(pingResult.Err, "ping failed"))
+			return
+		}
+	}
+}
+
+func (m *ReconnectingPlcConnection) handleFailure(generation uint64, err error) {
+	m.lock.Lock()
+	if m.generation != generation || m.state != ConnectionStateConnected {
+		// Either already reconnecting, closed or this is an outdated notification
+		m.lock.Unlock()
+		return
+	}
+	m.logger.Warn().Err(err).Str("connectionString", m.connectionString).Msg("Connection failed, reconnecting")
+	brokenConnection := m.connection
+	done := m.done
+	publish := m.setState(ConnectionStateReconnecting, err)
+	m.lock.Unlock()
+	publish()
+	go m.reconnect(brokenConnection, done)
+}
+
+func (m *ReconnectingPlcConnection) reconnect(brokenConnection plc4go.PlcConnection, done chan struct{}) {
+	brokenConnection.BlockingClose()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := m.initialBackoff
+	for {
+		connectionResult := <-m.driverManager.GetConnectionWithContext(ctx, m.connectionString)
+		if connectionResult.Err == nil {
+			m.lock.Lock()
+			if m.state != ConnectionStateReconnecting {
+				m.lock.Unlock()
+				connectionResult.Connection.Close()
+				return
+			}
+			m.install(connectionResult.Connection)
+			subscriptions := make([]*subscriptionRecord, len(m.subscriptions))
+			copy(subscriptions, m.subscriptions)
+			publish := m.setState(ConnectionStateConnected, nil)
+			m.lock.Unlock()
+			m.restoreSubscriptions(connectionResult.Connection, subscriptions)
+			publish()
+			return
+		}
+		m.logger.Debug().Err(connectionResult.Err).Dur("backoff", backoff).Msg("Reconnect failed")
+		select {
+		case <-done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > m.maxBackoff {
+			backoff = m.maxBackoff
+		}
+	}
+}
+
+func (m *ReconnectingPlcConnection) restoreSubscriptions(connection plc4go.PlcConnection, subscriptions []*subscriptionRecord) {
+	for _, subscription := range subscriptions {
+		subscriptionRequest, err := subscription.build(connection)
+		if err != nil {
+			m.logger.Error().Err(err).Msg("Error re-building subscription request")
+			continue
+		}
+		subscriptionResult := <-subscriptionRequest.Execute()
+		if subscriptionResult.Err != nil {
+			m.logger.Error().Err(subscriptionResult.Err).Msg("Error re-establishing subscription")
+			continue
+		}
+		subscription.updateHandles(subscriptionResult.Response)
+	}
+}
+
+// addSubscription registers a subscription for being re-established after reconnecting (only once, even if the same
+// request is executed multiple times)
+func (m *ReconnectingPlcConnection) addSubscription(subscription *subscriptionRecord) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, existingSubscription := range m.subscriptions {
+		if existingSubscription == subscription {
+			return
+		}
+	}
+	m.subscriptions = append(m.subscriptions, subscription)
+}
+
+// removeSubscriptions forgets about unsubscribed fields and drops subscriptions without any remaining fields
+func (m *ReconnectingPlcConnection) removeSubscriptions(handles []*subscriptionHandle, fieldNames []string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, handle := range handles {
+		handle.record.removeField(handle.fieldName)
+	}
+	for _, fieldName := range fieldNames {
+		for _, subscription := range m.subscriptions {
+			if subscription.hasField(fieldName) {
+				subscription.removeField(fieldName)
+			}
+		}
+	}
+	var subscriptions []*subscriptionRecord
+	for _, subscription := range m.subscriptions {
+		if !subscription.isEmpty() {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	m.subscriptions = subscriptions
+}
+
+// errNeverConnected is reported when the connection is used before it has been connected for the first time
+var errNeverConnected = errors.New("connection has never been connected")
+
+func (m *ReconnectingPlcConnection) current() (plc4go.PlcConnection, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if m.connection == nil {
+		return nil, errNeverConnected
+	}
+	return m.connection, nil
+}
+
+func (m *ReconnectingPlcConnection) BlockingClose() {
+	closeResults := m.Close()
+	select {
+	case <-closeResults:
+		return
+	case <-time.After(time.Second * 5):
+		return
+	}
+}
+
+func (m *ReconnectingPlcConnection) Close() <-chan plc4go.PlcConnectionCloseResult {
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *ReconnectingPlcConnection) CloseWithContext(ctx context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
+	m.lock.Lock()
+	if m.state == ConnectionStateClosed {
+		m.lock.Unlock()
+		ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+		return ch
+	}
+	wasConnected := m.state == ConnectionStateConnected
+	close(m.done)
+	m.subscriptions = nil
+	connection := m.connection
+	publish := m.setState(ConnectionStateClosed, nil)
+	m.lock.Unlock()
+	publish()
+	if !wasConnected || connection == nil {
+		// When reconnecting the broken connection is closed by the reconnect loop
+		ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+		return ch
+	}
+	go func() {
+		select {
+		case closeResult := <-connection.CloseWithContext(ctx):
+			ch <- plc4go.NewPlcConnectionCloseResult(m, closeResult.Err)
+		case <-ctx.Done():
+			ch <- plc4go.NewPlcConnectionCloseResult(m, ctx.Err())
+		}
+	}()
+	return ch
+}
+
+func (m *ReconnectingPlcConnection) IsConnected() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.state == ConnectionStateConnected && m.connection.IsConnected()
+}
+
+func (m *ReconnectingPlcConnection) Ping() <-chan plc4go.PlcConnectionPingResult {
+	return m.PingWithContext(context.Background())
+}
+
+func (m *ReconnectingPlcConnection) PingWithContext(ctx context.Context) <-chan plc4go.PlcConnectionPingResult {
+	if m.GetState() != ConnectionStateConnected {
+		ch := make(chan plc4go.PlcConnectionPingResult, 1)
+		ch <- plc4go.NewPlcConnectionPingResult(errors.Errorf("connection is %s", m.GetState()))
+		return ch
+	}
+	connection, err := m.current()
+	if err != nil {
+		ch := make(chan plc4go.PlcConnectionPingResult, 1)
+		ch <- plc4go.NewPlcConnectionPingResult(err)
+		return ch
+	}
+	return connection.PingWithContext(ctx)
+}
+
+func (m *ReconnectingPlcConnection) GetMetadata() model.PlcConnectionMetadata {
+	connection, err := m.current()
+	if err != nil {
+		return internalModel.UnavailableConnectionMetadata{}
+	}
+	return connection.GetMetadata()
+}
+
+// GetRequestQueueMetrics returns the metrics of the current underlying connection, so they start over after a reconnect
+func (m *ReconnectingPlcConnection) GetRequestQueueMetrics() model.PlcRequestQueueMetrics {
+	connection, _ := m.current()
+	if provider, ok := connection.(plc4go.PlcRequestQueueMetricsProvider); ok {
+		return provider.GetRequestQueueMetrics()
+	}
+	return model.PlcRequestQueueMetrics{}
+}
+
+// GetConnectionMetrics returns the metrics of the current underlying connection, so they start over after a reconnect
+func (m *ReconnectingPlcConnection) GetConnectionMetrics() model.PlcConnectionMetrics {
+	connection, _ := m.current()
+	if provider, ok := connection.(plc4go.PlcConnectionMetricsProvider); ok {
+		return provider.GetConnectionMetrics()
+	}
+	return model.PlcConnectionMetrics{}
+}
+
+func (m *ReconnectingPlcConnection) ReadRequestBuilder() model.PlcReadRequestBuilder {
+	connection, err := m.current()
+	if err != nil {
+		return internalModel.NewFailingPlcReadRequestBuilder(err)
+	}
+	return connection.ReadRequestBuilder()
+}
+
+func (m *ReconnectingPlcConnection) WriteRequestBuilder() model.PlcWriteRequestBuilder {
+	connection, err := m.current()
+	if err != nil {
+		return internalModel.NewFailingPlcWriteRequestBuilder(err)
+	}
+	return connection.WriteRequestBuilder()
+}
+
+func (m *ReconnectingPlcConnection) SubscriptionRequestBuilder() model.PlcSubscriptionRequestBuilder {
+	return newSubscriptionRequestBuilder(m)
+}
+
+func (m *ReconnectingPlcConnection) UnsubscriptionRequestBuilder() model.PlcUnsubscriptionRequestBuilder {
+	return newUnsubscriptionRequestBuilder(m)
+}
+
+func (m *ReconnectingPlcConnection) BrowseRequestBuilder() model.PlcBrowseRequestBuilder {
+	connection, err := m.current()
+	if err != nil {
+		return internalModel.NewFailingPlcBrowseRequestBuilder(err)
+	}
+	return connection.BrowseRequestBuilder()
+}
@@ -0,0 +1,337 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package reconnect
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCodec struct {
+	spi.MessageCodec
+	listener spi.TransportFailureListener
+}
+
+func (m *fakeCodec) AddTransportFailureListener(listener spi.TransportFailureListener) {
+	m.listener = listener
+}
+
+// fakeSubscriber accepts every subscription and remembers what has been subscribed and unsubscribed
+type fakeSubscriber struct {
+	lock             sync.Mutex
+	subscribedFields []string
+	unsubscribed     []model.PlcSubscriptionHandle
+}
+
+func (m *fakeSubscriber) Subscribe(_ context.Context, subscriptionRequest model.PlcSubscriptionRequest) <-chan model.PlcSubscriptionRequestResult {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	responseCodes := map[string]model.PlcResponseCode{}
+	handles := map[string]model.PlcSubscriptionHandle{}
+	for _, fieldName := range subscriptionRequest.GetFieldNames() {
+		m.subscribedFields = append(m.subscribedFields, fieldName)
+		responseCodes[fieldName] = model.PlcResponseCode_OK
+		handles[fieldName] = internalModel.NewDefaultPlcSubscriptionHandle(m, 1, fieldName)
+	}
+	ch := make(chan model.PlcSubscriptionRequestResult, 1)
+	ch <- model.PlcSubscriptionRequestResult{
+		Request:  subscriptionRequest,
+		Response: internalModel.NewDefaultPlcSubscriptionResponse(subscriptionRequest, responseCodes, handles),
+	}
+	return ch
+}
+
+func (m *fakeSubscriber) Unsubscribe(_ context.Context, unsubscriptionRequest model.PlcUnsubscriptionRequest) <-chan model.PlcUnsubscriptionRequestResult {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.unsubscribed = append(m.unsubscribed, unsubscriptionRequest.GetSubscriptionHandles()...)
+	ch := make(chan model.PlcUnsubscriptionRequestResult, 1)
+	ch <- model.PlcUnsubscriptionRequestResult{
+		Request:  unsubscriptionRequest,
+		Response: internalModel.NewDefaultPlcUnsubscriptionResponse(unsubscriptionRequest, nil, nil),
+	}
+	return ch
+}
+
+type fakeConnection struct {
+	plc4go.PlcConnection
+	codec      *fakeCodec
+	subscriber *fakeSubscriber
+	closed     chan struct{}
+}
+
+func (m *fakeConnection) SubscriptionRequestBuilder() model.PlcSubscriptionRequestBuilder {
+	return internalModel.NewDefaultPlcSubscriptionRequestBuilder(nil, nil, m.subscriber)
+}
+
+func (m *fakeConnection) UnsubscriptionRequestBuilder() model.PlcUnsubscriptionRequestBuilder {
+	return internalModel.NewDefaultPlcUnsubscriptionRequestBuilder(m.subscriber)
+}
+
+func (m *fakeConnection) GetMessageCodec() spi.MessageCodec {
+	return m.codec
+}
+
+func (m *fakeConnection) Close() <-chan plc4go.PlcConnectionCloseResult {
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *fakeConnection) CloseWithContext(_ context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	close(m.closed)
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
+	ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+	return ch
+}
+
+func (m *fakeConnection) BlockingClose() {
+	<-m.Close()
+}
+
+type fakeDriverManager struct {
+	plc4go.PlcDriverManager
+	lock        sync.Mutex
+	failures    int
+	connections []*fakeConnection
+	// If set, connections are only handed out as soon as it is closed
+	delay chan struct{}
+}
+
+func (m *fakeDriverManager) GetLogger() *zerolog.Logger {
+	logger := zerolog.Nop()
+	return &logger
+}
+
+func (m *fakeDriverManager) GetConnectionWithContext(_ context.Context, _ string) <-chan plc4go.PlcConnectionConnectResult {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+	if m.failures > 0 {
+		m.failures--
+		ch <- plc4go.NewPlcConnectionConnectResult(nil, errors.New("unreachable"))
+		return ch
+	}
+	connection := &fakeConnection{codec: &fakeCodec{}, subscriber: &fakeSubscriber{}, closed: make(chan struct{})}
+	m.connections = append(m.connections, connection)
+	if m.delay != nil {
+		delay := m.delay
+		go func() {
+			<-delay
+			ch <- plc4go.NewPlcConnectionConnectResult(connection, nil)
+		}()
+		return ch
+	}
+	ch <- plc4go.NewPlcConnectionConnectResult(connection, nil)
+	return ch
+}
+
+func TestReconnectingPlcConnection_ReconnectsOnTransportFailure(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	connection := NewReconnectingPlcConnection(driverManager, "test://reconnect",
+		WithPingInterval(0), WithInitialBackoff(time.Millisecond))
+	states := make(chan ConnectionState, 10)
+	connection.AddConnectionStateListener(func(event ConnectionStateEvent) {
+		states <- event.NewState
+	})
+
+	if connectResult := <-connection.Connect(); connectResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", connectResult.Err)
+	}
+	expectStates(t, states, ConnectionStateConnecting, ConnectionStateConnected)
+
+	// The next two connection attempts fail, the third succeeds
+	driverManager.lock.Lock()
+	driverManager.failures = 2
+	firstConnection := driverManager.connections[0]
+	driverManager.lock.Unlock()
+	firstConnection.codec.listener(errors.New("connection reset"))
+	expectStates(t, states, ConnectionStateReconnecting, ConnectionStateConnected)
+
+	select {
+	case <-firstConnection.closed:
+	default:
+		t.Errorf("Expected the broken connection to be closed")
+	}
+	driverManager.lock.Lock()
+	if len(driverManager.connections) != 2 {
+		t.Errorf("Expected a second connection, got %d", len(driverManager.connections))
+	}
+	driverManager.lock.Unlock()
+
+	// Outdated notifications of the broken connection must be ignored
+	firstConnection.codec.listener(errors.New("connection reset"))
+	connection.BlockingClose()
+	expectStates(t, states, ConnectionStateClosed)
+}
+
+func TestReconnectingPlcConnection_UnsubscribesAcrossReconnects(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	connection := NewReconnectingPlcConnection(driverManager, "test://reconnect",
+		WithPingInterval(0), WithInitialBackoff(time.Millisecond))
+	states := make(chan ConnectionState, 10)
+	connection.AddConnectionStateListener(func(event ConnectionStateEvent) {
+		states <- event.NewState
+	})
+	if connectResult := <-connection.Connect(); connectResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", connectResult.Err)
+	}
+	expectStates(t, states, ConnectionStateConnecting, ConnectionStateConnected)
+
+	builder := connection.SubscriptionRequestBuilder()
+	builder.AddChangeOfStateField("first", nil)
+	builder.AddChangeOfStateField("second", nil)
+	subscriptionRequest, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	subscriptionResult := <-subscriptionRequest.Execute()
+	if subscriptionResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", subscriptionResult.Err)
+	}
+	handle := subscriptionResult.Response.GetSubscriptionHandle("first")
+
+	// After reconnecting, the handle has to resolve to the subscription on the new connection
+	driverManager.connections[0].codec.listener(errors.New("connection reset"))
+	expectStates(t, states, ConnectionStateReconnecting, ConnectionStateConnected)
+	unsubscriptionBuilder := connection.UnsubscriptionRequestBuilder()
+	unsubscriptionBuilder.AddHandles(handle)
+	unsubscriptionRequest, err := unsubscriptionBuilder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if unsubscriptionResult := <-unsubscriptionRequest.Execute(); unsubscriptionResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", unsubscriptionResult.Err)
+	}
+	secondSubscriber := driverManager.connections[1].subscriber
+	if len(secondSubscriber.unsubscribed) != 1 || secondSubscriber.unsubscribed[0].(*internalModel.DefaultPlcSubscriptionHandle).GetSubscriber() != secondSubscriber {
+		t.Errorf("Expected the handle of the second connection to be unsubscribed, got %v", secondSubscriber.unsubscribed)
+	}
+
+	// The unsubscribed field must not be re-established
+	driverManager.connections[1].codec.listener(errors.New("connection reset"))
+	expectStates(t, states, ConnectionStateReconnecting, ConnectionStateConnected)
+	if subscribedFields := driverManager.connections[2].subscriber.subscribedFields; len(subscribedFields) != 1 || subscribedFields[0] != "second" {
+		t.Errorf("Expected only the second field to be re-subscribed, got %v", subscribedFields)
+	}
+	connection.BlockingClose()
+}
+
+func TestReconnectingPlcConnection_RestoresRepeatedSubscriptionsOnce(t *testing.T) {
+	driverManager := &fakeDriverManager{}
+	connection := NewReconnectingPlcConnection(driverManager, "test://repeated",
+		WithPingInterval(0), WithInitialBackoff(time.Millisecond))
+	states := make(chan ConnectionState, 10)
+	connection.AddConnectionStateListener(func(event ConnectionStateEvent) {
+		states <- event.NewState
+	})
+	if connectResult := <-connection.Connect(); connectResult.Err != nil {
+		t.Fatalf("Unexpected error: %v", connectResult.Err)
+	}
+	expectStates(t, states, ConnectionStateConnecting, ConnectionStateConnected)
+
+	builder := connection.SubscriptionRequestBuilder()
+	builder.AddChangeOfStateField("field", nil)
+	subscriptionRequest, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if subscriptionResult := <-subscriptionRequest.Execute(); subscriptionResult.Err != nil {
+			t.Fatalf("Unexpected error: %v", subscriptionResult.Err)
+		}
+	}
+
+	driverManager.connections[0].codec.listener(errors.New("connection reset"))
+	expectStates(t, states, ConnectionStateReconnecting, ConnectionStateConnected)
+	if subscribedFields := driverManager.connections[1].subscriber.subscribedFields; len(subscribedFields) != 1 {
+		t.Errorf("Expected the subscription to be re-established once, got %v", subscribedFields)
+	}
+	connection.BlockingClose()
+}
+
+func expectStates(t *testing.T, states chan ConnectionState, expectedStates ...ConnectionState) {
+	for _, expectedState := range expectedStates {
+		select {
+		case state := <-states:
+			if state != expectedState {
+				t.Fatalf("Expected state %s, got %s", expectedState, state)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for state %s", expectedState)
+		}
+	}
+}
+
+func TestReconnectingPlcConnection_NeverConnected(t *testing.T) {
+	connection := NewReconnectingPlcConnection(&fakeDriverManager{}, "test://never", WithPingInterval(0))
+
+	if _, err := connection.ReadRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a read request to fail")
+	}
+	if _, err := connection.WriteRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a write request to fail")
+	}
+	if _, err := connection.SubscriptionRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a subscription request to fail")
+	}
+	if _, err := connection.UnsubscriptionRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building an unsubscription request to fail")
+	}
+	if _, err := connection.BrowseRequestBuilder().Build(); err == nil {
+		t.Errorf("Expected building a browse request to fail")
+	}
+	if connection.GetMetadata().CanRead() {
+		t.Errorf("Expected the metadata not to allow reading")
+	}
+	if pingResult := <-connection.Ping(); pingResult.Err == nil {
+		t.Errorf("Expected pinging to fail")
+	}
+	connection.GetConnectionMetrics()
+	connection.GetRequestQueueMetrics()
+}
+
+func TestReconnectingPlcConnection_ClosesLateConnections(t *testing.T) {
+	driverManager := &fakeDriverManager{delay: make(chan struct{})}
+	connection := NewReconnectingPlcConnection(driverManager, "test://late", WithPingInterval(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	connectResults := connection.ConnectWithContext(ctx)
+	cancel()
+	if connectResult := <-connectResults; connectResult.Err == nil {
+		t.Fatalf("Expected connecting to be aborted")
+	}
+
+	// The connection coming in after giving up must not be leaked
+	close(driverManager.delay)
+	driverManager.lock.Lock()
+	lateConnection := driverManager.connections[0]
+	driverManager.lock.Unlock()
+	select {
+	case <-lateConnection.closed:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the late connection to be closed")
+	}
+}
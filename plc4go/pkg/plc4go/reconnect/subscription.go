@@ -0,0 +1,342 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package reconnect
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"sync"
+	"time"
+)
+
+// subscriptionStep is one call on the subscription request builder. Steps not adding a field have an empty name.
+type subscriptionStep struct {
+	fieldName string
+	apply     func(builder model.PlcSubscriptionRequestBuilder)
+}
+
+// subscriptionRecord remembers how a subscription request was assembled, so it can be re-assembled and re-executed
+// on a new connection after reconnecting.
+type subscriptionRecord struct {
+	steps []subscriptionStep
+
+	lock sync.Mutex
+	// Fields which have been unsubscribed and must not be re-established
+	removedFields map[string]bool
+	// Handles of the subscription on the current underlying connection
+	handles map[string]model.PlcSubscriptionHandle
+}
+
+func newSubscriptionRecord() *subscriptionRecord {
+	return &subscriptionRecord{
+		removedFields: map[string]bool{},
+		handles:       map[string]model.PlcSubscriptionHandle{},
+	}
+}
+
+func (m *subscriptionRecord) build(connection plc4go.PlcConnection) (model.PlcSubscriptionRequest, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	builder := connection.SubscriptionRequestBuilder()
+	for _, step := range m.steps {
+		if step.fieldName != "" && m.removedFields[step.fieldName] {
+			continue
+		}
+		step.apply(builder)
+	}
+	return builder.Build()
+}
+
+// updateHandles remembers the handles of the subscription response received from the underlying connection
+func (m *subscriptionRecord) updateHandles(subscriptionResponse model.PlcSubscriptionResponse) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.handles = map[string]model.PlcSubscriptionHandle{}
+	for _, fieldName := range subscriptionResponse.GetFieldNames() {
+		if handle := subscriptionResponse.GetSubscriptionHandle(fieldName); handle != nil {
+			m.handles[fieldName] = handle
+		}
+	}
+}
+
+func (m *subscriptionRecord) getHandle(fieldName string) model.PlcSubscriptionHandle {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.removedFields[fieldName] {
+		return nil
+	}
+	return m.handles[fieldName]
+}
+
+func (m *subscriptionRecord) hasField(fieldName string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.removedFields[fieldName] {
+		return false
+	}
+	for _, step := range m.steps {
+		if step.fieldName == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *subscriptionRecord) removeField(fieldName string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.removedFields[fieldName] = true
+	delete(m.handles, fieldName)
+}
+
+// isEmpty returns true as soon as all fields of the subscription have been unsubscribed
+func (m *subscriptionRecord) isEmpty() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, step := range m.steps {
+		if step.fieldName != "" && !m.removedFields[step.fieldName] {
+			return false
+		}
+	}
+	return true
+}
+
+// subscriptionHandle is the handle handed out by the ReconnectingPlcConnection. It stays valid across reconnects,
+// as it is resolved to the handle of the current underlying connection when unsubscribing.
+type subscriptionHandle struct {
+	record    *subscriptionRecord
+	fieldName string
+}
+
+func (m *subscriptionHandle) GetFieldName() string {
+	return m.fieldName
+}
+
+type subscriptionRequestBuilder struct {
+	connection *ReconnectingPlcConnection
+	record     *subscriptionRecord
+}
+
+func newSubscriptionRequestBuilder(connection *ReconnectingPlcConnection) *subscriptionRequestBuilder {
+	return &subscriptionRequestBuilder{
+		connection: connection,
+		record:     newSubscriptionRecord(),
+	}
+}
+
+func (m *subscriptionRequestBuilder) addStep(fieldName string, apply func(builder model.PlcSubscriptionRequestBuilder)) {
+	m.record.steps = append(m.record.steps, subscriptionStep{fieldName: fieldName, apply: apply})
+}
+
+func (m *subscriptionRequestBuilder) AddCyclicQuery(name string, query string, interval time.Duration) {
+	m.addStep(name, func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddCyclicQuery(name, query, interval)
+	})
+}
+
+func (m *subscriptionRequestBuilder) AddCyclicField(name string, field model.PlcField, interval time.Duration) {
+	m.addStep(name, func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddCyclicField(name, field, interval)
+	})
+}
+
+func (m *subscriptionRequestBuilder) AddChangeOfStateQuery(name string, query string) {
+	m.addStep(name, func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddChangeOfStateQuery(name, query)
+	})
+}
+
+func (m *subscriptionRequestBuilder) AddChangeOfStateField(name string, field model.PlcField) {
+	m.addStep(name, func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddChangeOfStateField(name, field)
+	})
+}
+
+func (m *subscriptionRequestBuilder) AddEventQuery(name string, query string) {
+	m.addStep(name, func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddEventQuery(name, query)
+	})
+}
+
+func (m *subscriptionRequestBuilder) AddEventField(name string, field model.PlcField) {
+	m.addStep(name, func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddEventField(name, field)
+	})
+}
+
+func (m *subscriptionRequestBuilder) AddItemHandler(handler model.PlcSubscriptionEventHandler) {
+	m.addStep("", func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.AddItemHandler(handler)
+	})
+}
+
+func (m *subscriptionRequestBuilder) SetTimeout(timeout time.Duration) {
+	m.addStep("", func(builder model.PlcSubscriptionRequestBuilder) {
+		builder.SetTimeout(timeout)
+	})
+}
+
+func (m *subscriptionRequestBuilder) Build() (model.PlcSubscriptionRequest, error) {
+	connection, err := m.connection.current()
+	if err != nil {
+		return nil, err
+	}
+	subscriptionRequest, err := m.record.build(connection)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedSubscriptionRequest{
+		PlcSubscriptionRequest: subscriptionRequest,
+		connection:             m.connection,
+		record:                 m.record,
+	}, nil
+}
+
+// trackedSubscriptionRequest registers the subscription with the connection as soon as it has been executed
+// successfully, so it is re-established after a reconnect.
+type trackedSubscriptionRequest struct {
+	model.PlcSubscriptionRequest
+	connection *ReconnectingPlcConnection
+	record     *subscriptionRecord
+}
+
+func (m *trackedSubscriptionRequest) Execute() <-chan model.PlcSubscriptionRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m *trackedSubscriptionRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcSubscriptionRequestResult {
+	result := make(chan model.PlcSubscriptionRequestResult, 1)
+	go func() {
+		subscriptionResult := <-m.PlcSubscriptionRequest.ExecuteWithContext(ctx)
+		if subscriptionResult.Err == nil {
+			m.record.updateHandles(subscriptionResult.Response)
+			m.connection.addSubscription(m.record)
+			subscriptionResult.Response = &trackedSubscriptionResponse{
+				PlcSubscriptionResponse: subscriptionResult.Response,
+				record:                  m.record,
+			}
+		}
+		result <- subscriptionResult
+	}()
+	return result
+}
+
+// trackedSubscriptionResponse hands out handles, which survive reconnects
+type trackedSubscriptionResponse struct {
+	model.PlcSubscriptionResponse
+	record *subscriptionRecord
+}
+
+func (m *trackedSubscriptionResponse) GetSubscriptionHandle(name string) model.PlcSubscriptionHandle {
+	if m.PlcSubscriptionResponse.GetSubscriptionHandle(name) == nil {
+		return nil
+	}
+	return &subscriptionHandle{
+		record:    m.record,
+		fieldName: name,
+	}
+}
+
+func (m *trackedSubscriptionResponse) GetSubscriptionHandles() []model.PlcSubscriptionHandle {
+	var handles []model.PlcSubscriptionHandle
+	for _, name := range m.GetFieldNames() {
+		if handle := m.GetSubscriptionHandle(name); handle != nil {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+type unsubscriptionRequestBuilder struct {
+	connection *ReconnectingPlcConnection
+	handles    []model.PlcSubscriptionHandle
+	fieldNames []string
+}
+
+func newUnsubscriptionRequestBuilder(connection *ReconnectingPlcConnection) *unsubscriptionRequestBuilder {
+	return &unsubscriptionRequestBuilder{
+		connection: connection,
+	}
+}
+
+func (m *unsubscriptionRequestBuilder) AddHandles(handles ...model.PlcSubscriptionHandle) {
+	m.handles = append(m.handles, handles...)
+}
+
+func (m *unsubscriptionRequestBuilder) AddFieldNames(fieldNames ...string) {
+	m.fieldNames = append(m.fieldNames, fieldNames...)
+}
+
+func (m *unsubscriptionRequestBuilder) Build() (model.PlcUnsubscriptionRequest, error) {
+	connection, err := m.connection.current()
+	if err != nil {
+		return nil, err
+	}
+	builder := connection.UnsubscriptionRequestBuilder()
+	var removedHandles []*subscriptionHandle
+	for _, handle := range m.handles {
+		trackedHandle, ok := handle.(*subscriptionHandle)
+		if !ok {
+			// Not one of ours, so it has to belong to the underlying connection
+			builder.AddHandles(handle)
+			continue
+		}
+		// Translate the handle to the one of the current underlying connection
+		if underlyingHandle := trackedHandle.record.getHandle(trackedHandle.fieldName); underlyingHandle != nil {
+			builder.AddHandles(underlyingHandle)
+		}
+		removedHandles = append(removedHandles, trackedHandle)
+	}
+	builder.AddFieldNames(m.fieldNames...)
+	unsubscriptionRequest, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &trackedUnsubscriptionRequest{
+		PlcUnsubscriptionRequest: unsubscriptionRequest,
+		connection:               m.connection,
+		removedHandles:           removedHandles,
+		removedFieldNames:        m.fieldNames,
+	}, nil
+}
+
+// trackedUnsubscriptionRequest makes sure unsubscribed fields are not re-established after a reconnect
+type trackedUnsubscriptionRequest struct {
+	model.PlcUnsubscriptionRequest
+	connection        *ReconnectingPlcConnection
+	removedHandles    []*subscriptionHandle
+	removedFieldNames []string
+}
+
+func (m *trackedUnsubscriptionRequest) Execute() <-chan model.PlcUnsubscriptionRequestResult {
+	return m.ExecuteWithContext(context.Background())
+}
+
+func (m *trackedUnsubscriptionRequest) ExecuteWithContext(ctx context.Context) <-chan model.PlcUnsubscriptionRequestResult {
+	result := make(chan model.PlcUnsubscriptionRequestResult, 1)
+	go func() {
+		unsubscriptionResult := <-m.PlcUnsubscriptionRequest.ExecuteWithContext(ctx)
+		if unsubscriptionResult.Err == nil {
+			m.connection.removeSubscriptions(m.removedHandles, m.removedFieldNames)
+		}
+		result <- unsubscriptionResult
+	}()
+	return result
+}
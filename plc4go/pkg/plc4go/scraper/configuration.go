@@ -0,0 +1,132 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package scraper
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"time"
+)
+
+// Configuration of a scraper, usually loaded from a YAML (or JSON) file:
+//
+//	sources:
+//	  machine1: s7://10.10.64.20
+//	  machine2: s7://10.10.64.21
+//	jobs:
+//	  - name: temperatures
+//	    scrapeRate: 1000
+//	    sources:
+//	      - machine1
+//	      - machine2
+//	    fields:
+//	      temp1: '%DB1.DBD0:REAL'
+//	      temp2: '%DB1.DBD4:REAL'
+type Configuration struct {
+	// Connection strings by source alias
+	Sources map[string]string  `yaml:"sources" json:"sources"`
+	Jobs    []JobConfiguration `yaml:"jobs" json:"jobs"`
+}
+
+type JobConfiguration struct {
+	Name string `yaml:"name" json:"name"`
+	// Scrape rate in milliseconds (not used by triggered jobs)
+	ScrapeRate int `yaml:"scrapeRate" json:"scrapeRate"`
+	// If set, the fields are only scraped when the trigger fires
+	Trigger *TriggerConfiguration `yaml:"trigger" json:"trigger"`
+	// Aliases of the sources this job collects from
+	Sources []string `yaml:"sources" json:"sources"`
+	// Field queries by alias
+	Fields map[string]string `yaml:"fields" json:"fields"`
+}
+
+func (m JobConfiguration) GetScrapeRate() time.Duration {
+	return time.Duration(m.ScrapeRate) * time.Millisecond
+}
+
+// TriggerConfiguration describes a cheap field, which is watched to decide when to scrape the fields of a job
+type TriggerConfiguration struct {
+	// Query of the trigger field
+	Field string `yaml:"field" json:"field"`
+	// One of rising, falling, changed, ==, !=, <, <=, >, >=
+	Condition string `yaml:"condition" json:"condition"`
+	// Value the trigger field is compared to (only used by the comparing conditions)
+	Value string `yaml:"value" json:"value"`
+	// Poll rate in milliseconds, used if the connection doesn't support subscriptions
+	PollRate int `yaml:"pollRate" json:"pollRate"`
+}
+
+func (m TriggerConfiguration) GetPollRate() time.Duration {
+	return time.Duration(m.PollRate) * time.Millisecond
+}
+
+// LoadConfiguration reads the configuration from a YAML or JSON file
+func LoadConfiguration(path string) (Configuration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Configuration{}, errors.Wrapf(err, "error reading scraper configuration %s", path)
+	}
+	return ParseConfiguration(data)
+}
+
+// ParseConfiguration parses a YAML configuration. As JSON is a subset of YAML, JSON configurations work as well.
+func ParseConfiguration(data []byte) (Configuration, error) {
+	var configuration Configuration
+	if err := yaml.UnmarshalStrict(data, &configuration); err != nil {
+		return Configuration{}, errors.Wrap(err, "error parsing scraper configuration")
+	}
+	if err := configuration.Validate(); err != nil {
+		return Configuration{}, err
+	}
+	return configuration, nil
+}
+
+// Validate checks all jobs have a name, a scrape rate (or a valid trigger), fields and only reference known sources
+func (m Configuration) Validate() error {
+	jobNames := map[string]bool{}
+	for i, job := range m.Jobs {
+		if job.Name == "" {
+			return errors.Errorf("job %d has no name", i)
+		}
+		if jobNames[job.Name] {
+			return errors.Errorf("job %s is defined more than once", job.Name)
+		}
+		jobNames[job.Name] = true
+		if job.Trigger != nil {
+			if _, err := newTrigger(*job.Trigger); err != nil {
+				return errors.Wrapf(err, "job %s has an invalid trigger", job.Name)
+			}
+		} else if job.ScrapeRate <= 0 {
+			return errors.Errorf("job %s needs a positive scrape rate", job.Name)
+		}
+		if len(job.Sources) == 0 {
+			return errors.Errorf("job %s has no sources", job.Name)
+		}
+		for _, source := range job.Sources {
+			if _, ok := m.Sources[source]; !ok {
+				return errors.Errorf("job %s references unknown source %s", job.Name, source)
+			}
+		}
+		if len(job.Fields) == 0 {
+			return errors.Errorf("job %s has no fields", job.Name)
+		}
+	}
+	return nil
+}
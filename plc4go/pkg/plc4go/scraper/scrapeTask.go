@@ -0,0 +1,163 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package scraper
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// scrapeTask collects the fields of one job from one source
+type scrapeTask struct {
+	scraper          *Scraper
+	job              JobConfiguration
+	source           string
+	connectionString string
+	// Field aliases in a stable order
+	aliases []string
+	// Set while a scrape is in progress, so slow sources don't pile up scrapes
+	scraping int32
+	wg       sync.WaitGroup
+
+	// Only set for triggered jobs
+	trigger     *trigger
+	triggerLock sync.Mutex
+}
+
+func newScrapeTask(scraper *Scraper, job JobConfiguration, source string, connectionString string) *scrapeTask {
+	var aliases []string
+	for alias := range job.Fields {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	task := &scrapeTask{
+		scraper:          scraper,
+		job:              job,
+		source:           source,
+		connectionString: connectionString,
+		aliases:          aliases,
+	}
+	if job.Trigger != nil {
+		// The configuration has been validated already
+		task.trigger, _ = newTrigger(*job.Trigger)
+	}
+	return task
+}
+
+// schedule starts scrapes at the jobs scrape rate (or whenever the trigger fires) till done is closed
+func (m *scrapeTask) schedule(done <-chan struct{}) {
+	defer m.wg.Wait()
+	if m.trigger != nil {
+		// Prefer getting notified about changes of the trigger field over polling it
+		if !m.subscribeTrigger(done) {
+			m.pollTrigger(done)
+		}
+		return
+	}
+	ticker := time.NewTicker(m.job.GetScrapeRate())
+	defer ticker.Stop()
+	for {
+		m.startScrape(done)
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// startScrape starts a scrape in the background, unless the previous one is still running
+func (m *scrapeTask) startScrape(done <-chan struct{}) {
+	// Subscription events might still come in while stopping
+	select {
+	case <-done:
+		return
+	default:
+	}
+	if !atomic.CompareAndSwapInt32(&m.scraping, 0, 1) {
+		m.scraper.logger.Warn().Str("job", m.job.Name).Str("source", m.source).Msg("Previous scrape still running, skipping")
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer atomic.StoreInt32(&m.scraping, 0)
+		// Wait for a free slot
+		select {
+		case m.scraper.semaphore <- struct{}{}:
+		case <-done:
+			return
+		}
+		defer func() { <-m.scraper.semaphore }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.scraper.requestTimeout)
+		defer cancel()
+		timestamp := time.Now()
+		plcValues, err := m.scrape(ctx)
+		if err != nil {
+			m.scraper.logger.Error().Err(err).Str("job", m.job.Name).Str("source", m.source).Msg("Error scraping")
+			return
+		}
+		m.scraper.resultHandler(m.job.Name, m.source, plcValues, timestamp)
+	}()
+}
+
+func (m *scrapeTask) scrape(ctx context.Context) (map[string]values.PlcValue, error) {
+	connectionResult := <-m.scraper.connectionCache.GetConnectionWithContext(ctx, m.connectionString)
+	if connectionResult.Err != nil {
+		return nil, errors.Wrap(connectionResult.Err, "error getting connection")
+	}
+	connection := connectionResult.Connection
+	defer connection.Close()
+
+	builder := connection.ReadRequestBuilder()
+	for _, alias := range m.aliases {
+		builder.AddQuery(alias, m.job.Fields[alias])
+	}
+	readRequest, err := builder.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building read request")
+	}
+	var readResult model.PlcReadRequestResult
+	select {
+	case readResult = <-readRequest.ExecuteWithContext(ctx):
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "error reading")
+	}
+	if readResult.Err != nil {
+		return nil, errors.Wrap(readResult.Err, "error reading")
+	}
+
+	plcValues := map[string]values.PlcValue{}
+	for _, alias := range m.aliases {
+		if responseCode := readResult.Response.GetResponseCode(alias); responseCode != model.PlcResponseCode_OK {
+			m.scraper.logger.Warn().Str("job", m.job.Name).Str("source", m.source).Str("field", alias).
+				Msgf("Got response code %s", responseCode.GetName())
+			continue
+		}
+		plcValues[alias] = readResult.Response.GetValue(alias)
+	}
+	return plcValues, nil
+}
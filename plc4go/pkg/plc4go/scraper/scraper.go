@@ -0,0 +1,152 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package scraper
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/cache"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+// ResultHandler is called with the values of every successful scrape, keyed by field alias.
+// Fields which couldn't be read are missing in the map.
+type ResultHandler func(job string, source string, values map[string]values.PlcValue, timestamp time.Time)
+
+const (
+	DefaultMaxConcurrency = 10
+	DefaultRequestTimeout = time.Second * 5
+)
+
+type WithScraperOption func(scraper *Scraper)
+
+// WithMaxConcurrency limits how many scrapes are executed at the same time (across all jobs and sources)
+func WithMaxConcurrency(maxConcurrency int) WithScraperOption {
+	return func(scraper *Scraper) {
+		scraper.maxConcurrency = maxConcurrency
+	}
+}
+
+// WithRequestTimeout limits how long a single scrape (getting the connection and reading) may take
+func WithRequestTimeout(requestTimeout time.Duration) WithScraperOption {
+	return func(scraper *Scraper) {
+		scraper.requestTimeout = requestTimeout
+	}
+}
+
+// WithConnectionCache makes the scraper use the given connection cache instead of creating its own one.
+// A cache passed in is not closed when the scraper is stopped.
+func WithConnectionCache(connectionCache cache.PlcConnectionCache) WithScraperOption {
+	return func(scraper *Scraper) {
+		scraper.connectionCache = connectionCache
+	}
+}
+
+// Scraper periodically reads the fields of all jobs from all of their sources and passes the values to the
+// result handler. Connections are shared between jobs using the same source.
+type Scraper struct {
+	configuration   Configuration
+	driverManager   plc4go.PlcDriverManager
+	logger          *zerolog.Logger
+	resultHandler   ResultHandler
+	maxConcurrency  int
+	requestTimeout  time.Duration
+	connectionCache cache.PlcConnectionCache
+	ownsCache       bool
+	// Semaphore limiting the number of concurrent scrapes
+	semaphore chan struct{}
+
+	lock    sync.Mutex
+	running bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewScraper(configuration Configuration, driverManager plc4go.PlcDriverManager, resultHandler ResultHandler, options ...WithScraperOption) (*Scraper, error) {
+	if err := configuration.Validate(); err != nil {
+		return nil, err
+	}
+	if resultHandler == nil {
+		return nil, errors.New("a result handler is required")
+	}
+	scraper := &Scraper{
+		configuration:  configuration,
+		driverManager:  driverManager,
+		logger:         driverManager.GetLogger(),
+		resultHandler:  resultHandler,
+		maxConcurrency: DefaultMaxConcurrency,
+		requestTimeout: DefaultRequestTimeout,
+	}
+	for _, option := range options {
+		option(scraper)
+	}
+	if scraper.maxConcurrency < 1 {
+		scraper.maxConcurrency = 1
+	}
+	scraper.semaphore = make(chan struct{}, scraper.maxConcurrency)
+	return scraper, nil
+}
+
+// Start scheduling all jobs
+func (m *Scraper) Start() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.running {
+		return errors.New("scraper is already running")
+	}
+	if m.connectionCache == nil {
+		m.connectionCache = cache.NewPlcConnectionCache(m.driverManager)
+		m.ownsCache = true
+	}
+	m.done = make(chan struct{})
+	for _, job := range m.configuration.Jobs {
+		for _, source := range job.Sources {
+			task := newScrapeTask(m, job, source, m.configuration.Sources[source])
+			m.logger.Debug().Str("job", job.Name).Str("source", source).Msg("Scheduling scrape task")
+			m.wg.Add(1)
+			go func() {
+				defer m.wg.Done()
+				task.schedule(m.done)
+			}()
+		}
+	}
+	m.running = true
+	return nil
+}
+
+// Stop all jobs and wait for running scrapes to finish
+func (m *Scraper) Stop() {
+	m.lock.Lock()
+	if !m.running {
+		m.lock.Unlock()
+		return
+	}
+	m.running = false
+	close(m.done)
+	m.lock.Unlock()
+	m.wg.Wait()
+	if m.ownsCache {
+		<-m.connectionCache.Close()
+		m.connectionCache = nil
+		m.ownsCache = false
+	}
+}
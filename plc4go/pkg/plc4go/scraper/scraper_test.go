@@ -0,0 +1,318 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package scraper
+
+import (
+	"context"
+	internalModel "github.com/apache/plc4x/plc4go/internal/plc4go/spi/model"
+	internalValues "github.com/apache/plc4x/plc4go/internal/plc4go/spi/values"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/rs/zerolog"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testConfiguration = `
+sources:
+  machine1: test://machine1
+  machine2: test://machine2
+jobs:
+  - name: job1
+    scrapeRate: 10
+    sources:
+      - machine1
+      - machine2
+    fields:
+      speed: 'speed'
+      temp: 'temp'
+`
+
+type fakeField struct {
+	query string
+}
+
+func (m fakeField) GetAddressString() string {
+	return m.query
+}
+
+func (m fakeField) GetTypeName() string {
+	return ""
+}
+
+func (m fakeField) GetQuantity() uint16 {
+	return 1
+}
+
+type fakeFieldHandler struct {
+}
+
+func (m fakeFieldHandler) ParseQuery(query string) (model.PlcField, error) {
+	return fakeField{query: query}, nil
+}
+
+type fakeMetadata struct {
+	model.PlcConnectionMetadata
+}
+
+func (m fakeMetadata) CanSubscribe() bool {
+	return false
+}
+
+// fakeConnection returns the number of reads done so far for every field, except for the field "trigger"
+type fakeConnection struct {
+	plc4go.PlcConnection
+	lock    sync.Mutex
+	reads   int
+	trigger bool
+}
+
+func (m *fakeConnection) setTrigger(trigger bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.trigger = trigger
+}
+
+func (m *fakeConnection) Close() <-chan plc4go.PlcConnectionCloseResult {
+	return m.CloseWithContext(context.Background())
+}
+
+func (m *fakeConnection) CloseWithContext(_ context.Context) <-chan plc4go.PlcConnectionCloseResult {
+	ch := make(chan plc4go.PlcConnectionCloseResult, 1)
+	ch <- plc4go.NewPlcConnectionCloseResult(m, nil)
+	return ch
+}
+
+func (m *fakeConnection) GetMetadata() model.PlcConnectionMetadata {
+	return fakeMetadata{}
+}
+
+func (m *fakeConnection) ReadRequestBuilder() model.PlcReadRequestBuilder {
+	return internalModel.NewDefaultPlcReadRequestBuilder(fakeFieldHandler{}, m)
+}
+
+func (m *fakeConnection) Read(_ context.Context, readRequest model.PlcReadRequest) <-chan model.PlcReadRequestResult {
+	m.lock.Lock()
+	m.reads++
+	reads := m.reads
+	trigger := m.trigger
+	m.lock.Unlock()
+	responseCodes := map[string]model.PlcResponseCode{}
+	plcValues := map[string]values.PlcValue{}
+	for _, fieldName := range readRequest.GetFieldNames() {
+		responseCodes[fieldName] = model.PlcResponseCode_OK
+		if readRequest.GetField(fieldName).GetAddressString() == "trigger" {
+			plcValues[fieldName] = internalValues.NewPlcBOOL(trigger)
+		} else {
+			plcValues[fieldName] = internalValues.NewPlcDINT(int32(reads))
+		}
+	}
+	ch := make(chan model.PlcReadRequestResult, 1)
+	ch <- model.PlcReadRequestResult{
+		Request:  readRequest,
+		Response: internalModel.NewDefaultPlcReadResponse(readRequest, responseCodes, plcValues),
+	}
+	return ch
+}
+
+type fakeDriverManager struct {
+	plc4go.PlcDriverManager
+	lock        sync.Mutex
+	connections map[string]*fakeConnection
+}
+
+func (m *fakeDriverManager) GetLogger() *zerolog.Logger {
+	logger := zerolog.Nop()
+	return &logger
+}
+
+func (m *fakeDriverManager) GetConnectionWithContext(_ context.Context, connectionString string) <-chan plc4go.PlcConnectionConnectResult {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	connection, ok := m.connections[connectionString]
+	if !ok {
+		connection = &fakeConnection{}
+		m.connections[connectionString] = connection
+	}
+	ch := make(chan plc4go.PlcConnectionConnectResult, 1)
+	ch <- plc4go.NewPlcConnectionConnectResult(connection, nil)
+	return ch
+}
+
+func TestParseConfiguration(t *testing.T) {
+	configuration, err := ParseConfiguration([]byte(testConfiguration))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(configuration.Jobs) != 1 || configuration.Jobs[0].GetScrapeRate() != time.Millisecond*10 ||
+		len(configuration.Jobs[0].Fields) != 2 || configuration.Sources["machine2"] != "test://machine2" {
+		t.Errorf("Unexpected configuration %+v", configuration)
+	}
+
+	jsonConfiguration := `{"sources": {"machine1": "test://machine1"}, "jobs": [{"name": "job1", "scrapeRate": 100, "sources": ["machine1"], "fields": {"speed": "speed"}}]}`
+	if _, err := ParseConfiguration([]byte(jsonConfiguration)); err != nil {
+		t.Errorf("Unexpected error parsing json: %v", err)
+	}
+
+	unknownSource := `{"sources": {}, "jobs": [{"name": "job1", "scrapeRate": 100, "sources": ["machine1"], "fields": {"speed": "speed"}}]}`
+	if _, err := ParseConfiguration([]byte(unknownSource)); err == nil {
+		t.Errorf("Expected an error for an unknown source")
+	}
+}
+
+func TestScraper_ScrapesAllSources(t *testing.T) {
+	configuration, err := ParseConfiguration([]byte(testConfiguration))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	results := make(chan string, 100)
+	resultHandler := func(job string, source string, values map[string]values.PlcValue, timestamp time.Time) {
+		if job != "job1" || len(values) != 2 || values["speed"] == nil || timestamp.IsZero() {
+			t.Errorf("Unexpected result %s %s %v", job, source, values)
+		}
+		select {
+		case results <- source:
+		default:
+		}
+	}
+	driverManager := &fakeDriverManager{connections: map[string]*fakeConnection{}}
+	scraper, err := NewScraper(configuration, driverManager, resultHandler, WithMaxConcurrency(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := scraper.Start(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scraped := map[string]int{}
+	timeout := time.After(time.Second)
+	for scraped["machine1"] < 3 || scraped["machine2"] < 3 {
+		select {
+		case source := <-results:
+			scraped[source]++
+		case <-timeout:
+			t.Fatalf("Timeout waiting for results, got %v", scraped)
+		}
+	}
+	scraper.Stop()
+	if err := scraper.Start(); err != nil {
+		t.Errorf("Expected the scraper to be restartable: %v", err)
+	}
+	scraper.Stop()
+}
+
+func TestScraper_ScrapesWhenTriggered(t *testing.T) {
+	configuration, err := ParseConfiguration([]byte(`
+sources:
+  machine1: test://machine1
+jobs:
+  - name: batch
+    trigger:
+      field: trigger
+      condition: rising
+      pollRate: 5
+    sources:
+      - machine1
+    fields:
+      batchId: batchId
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	results := make(chan map[string]values.PlcValue, 10)
+	resultHandler := func(job string, source string, values map[string]values.PlcValue, timestamp time.Time) {
+		results <- values
+	}
+	driverManager := &fakeDriverManager{connections: map[string]*fakeConnection{}}
+	scraper, err := NewScraper(configuration, driverManager, resultHandler)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := scraper.Start(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer scraper.Stop()
+
+	select {
+	case <-results:
+		t.Fatalf("Scraped without the trigger firing")
+	case <-time.After(time.Millisecond * 50):
+	}
+	driverManager.GetConnectionWithContext(context.Background(), "test://machine1")
+	driverManager.lock.Lock()
+	connection := driverManager.connections["test://machine1"]
+	driverManager.lock.Unlock()
+	connection.setTrigger(true)
+	select {
+	case values := <-results:
+		if values["batchId"] == nil {
+			t.Errorf("Unexpected values %v", values)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timeout waiting for the triggered scrape")
+	}
+	// The trigger stays true, which is no rising edge
+	select {
+	case <-results:
+		t.Errorf("Scraped again without a new rising edge")
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestTrigger_Conditions(t *testing.T) {
+	fires := func(configuration TriggerConfiguration, plcValues ...values.PlcValue) []bool {
+		configuration.Field = "trigger"
+		configuration.PollRate = 1
+		trigger, err := newTrigger(configuration)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result []bool
+		for _, plcValue := range plcValues {
+			fire, err := trigger.update(plcValue)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			result = append(result, fire)
+		}
+		return result
+	}
+	expect := func(name string, actual []bool, expected ...bool) {
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("%s: expected %v, got %v", name, expected, actual)
+				return
+			}
+		}
+	}
+	low, high := internalValues.NewPlcBOOL(false), internalValues.NewPlcBOOL(true)
+	expect("rising", fires(TriggerConfiguration{Condition: TriggerRising}, low, high, high, low, high), false, true, false, false, true)
+	expect("falling", fires(TriggerConfiguration{Condition: TriggerFalling}, high, low, low, high), false, true, false, false)
+	expect("equal", fires(TriggerConfiguration{Condition: TriggerEqual, Value: "true"}, high, high, low, high), true, false, false, true)
+	expect("greater", fires(TriggerConfiguration{Condition: TriggerGreater, Value: "10"},
+		internalValues.NewPlcINT(5), internalValues.NewPlcINT(11), internalValues.NewPlcINT(12), internalValues.NewPlcINT(3), internalValues.NewPlcINT(20)),
+		false, true, false, false, true)
+	expect("changed", fires(TriggerConfiguration{Condition: TriggerChanged},
+		internalValues.NewPlcINT(1), internalValues.NewPlcINT(1), internalValues.NewPlcINT(2)), false, false, true)
+
+	if _, err := newTrigger(TriggerConfiguration{Field: "trigger", PollRate: 1, Condition: TriggerLess, Value: "abc"}); err == nil {
+		t.Errorf("Expected an error for a non-numeric comparison value")
+	}
+}
@@ -0,0 +1,165 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package scraper
+
+import (
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+const (
+	TriggerRising       = "rising"
+	TriggerFalling      = "falling"
+	TriggerChanged      = "changed"
+	TriggerEqual        = "=="
+	TriggerNotEqual     = "!="
+	TriggerLess         = "<"
+	TriggerLessEqual    = "<="
+	TriggerGreater      = ">"
+	TriggerGreaterEqual = ">="
+)
+
+// trigger evaluates the trigger condition for every new value of the trigger field. Comparing conditions fire
+// when the comparison becomes true, not for every value it stays true for.
+type trigger struct {
+	field     string
+	pollRate  time.Duration
+	condition string
+	// Parsed representations of the configured value
+	value       string
+	boolValue   *bool
+	numberValue *float64
+
+	previous      values.PlcValue
+	previousMatch bool
+}
+
+func newTrigger(configuration TriggerConfiguration) (*trigger, error) {
+	if configuration.Field == "" {
+		return nil, errors.New("no trigger field")
+	}
+	if configuration.PollRate <= 0 {
+		return nil, errors.New("trigger needs a positive poll rate")
+	}
+	newTrigger := &trigger{
+		field:     configuration.Field,
+		pollRate:  configuration.GetPollRate(),
+		condition: configuration.Condition,
+		value:     configuration.Value,
+	}
+	if boolValue, err := strconv.ParseBool(configuration.Value); err == nil {
+		newTrigger.boolValue = &boolValue
+	}
+	if numberValue, err := strconv.ParseFloat(configuration.Value, 64); err == nil {
+		newTrigger.numberValue = &numberValue
+	}
+	switch configuration.Condition {
+	case TriggerRising, TriggerFalling, TriggerChanged, TriggerEqual, TriggerNotEqual:
+	case TriggerLess, TriggerLessEqual, TriggerGreater, TriggerGreaterEqual:
+		if newTrigger.numberValue == nil {
+			return nil, errors.Errorf("condition %s needs a numeric value, got '%s'", configuration.Condition, configuration.Value)
+		}
+	default:
+		return nil, errors.Errorf("unknown trigger condition '%s'", configuration.Condition)
+	}
+	return newTrigger, nil
+}
+
+// update feeds the next value of the trigger field and returns true, if the trigger fires
+func (m *trigger) update(value values.PlcValue) (bool, error) {
+	if value == nil {
+		return false, errors.New("no trigger value")
+	}
+	previous := m.previous
+	m.previous = value
+	switch m.condition {
+	case TriggerRising, TriggerFalling:
+		if !value.IsBool() {
+			return false, errors.Errorf("condition %s needs a boolean trigger field", m.condition)
+		}
+		if previous == nil {
+			return false, nil
+		}
+		if m.condition == TriggerRising {
+			return !previous.GetBool() && value.GetBool(), nil
+		}
+		return previous.GetBool() && !value.GetBool(), nil
+	case TriggerChanged:
+		if previous == nil {
+			return false, nil
+		}
+		return !valuesEqual(previous, value), nil
+	}
+
+	match, err := m.compare(value)
+	if err != nil {
+		return false, err
+	}
+	fire := match && !m.previousMatch
+	m.previousMatch = match
+	return fire, nil
+}
+
+func (m *trigger) compare(value values.PlcValue) (bool, error) {
+	switch m.condition {
+	case TriggerEqual, TriggerNotEqual:
+		var equal bool
+		switch {
+		case m.numberValue != nil && value.IsFloat64():
+			equal = value.GetFloat64() == *m.numberValue
+		case m.boolValue != nil && value.IsBool() && value.GetBoolLength() == 1:
+			equal = value.GetBool() == *m.boolValue
+		case value.IsString():
+			equal = value.GetString() == m.value
+		default:
+			return false, errors.New("trigger field can't be compared to the configured value")
+		}
+		if m.condition == TriggerEqual {
+			return equal, nil
+		}
+		return !equal, nil
+	default:
+		if !value.IsFloat64() {
+			return false, errors.Errorf("condition %s needs a numeric trigger field", m.condition)
+		}
+		number := value.GetFloat64()
+		switch m.condition {
+		case TriggerLess:
+			return number < *m.numberValue, nil
+		case TriggerLessEqual:
+			return number <= *m.numberValue, nil
+		case TriggerGreater:
+			return number > *m.numberValue, nil
+		default:
+			return number >= *m.numberValue, nil
+		}
+	}
+}
+
+func valuesEqual(first values.PlcValue, second values.PlcValue) bool {
+	if first.IsString() && second.IsString() {
+		return first.GetString() == second.GetString()
+	}
+	if first.IsBool() && second.IsBool() {
+		return first.GetBool() == second.GetBool()
+	}
+	return first.IsFloat64() && second.IsFloat64() && first.GetFloat64() == second.GetFloat64()
+}
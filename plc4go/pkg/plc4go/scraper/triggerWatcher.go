@@ -0,0 +1,140 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+package scraper
+
+import (
+	"context"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/model"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/reconnect"
+	"github.com/apache/plc4x/plc4go/pkg/plc4go/values"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const triggerFieldName = "trigger"
+
+// subscribeTrigger subscribes to changes of the trigger field and blocks till done is closed. It returns false
+// without blocking, if the source doesn't support subscriptions.
+func (m *scrapeTask) subscribeTrigger(done <-chan struct{}) bool {
+	// The subscription needs a connection of its own, as leases on the shared one are only held while scraping
+	connection := reconnect.NewReconnectingPlcConnection(m.scraper.driverManager, m.connectionString)
+	ctx, cancel := context.WithTimeout(context.Background(), m.scraper.requestTimeout)
+	defer cancel()
+	connectionResult := <-connection.ConnectWithContext(ctx)
+	if connectionResult.Err != nil {
+		m.scraper.logger.Warn().Err(connectionResult.Err).Str("job", m.job.Name).Str("source", m.source).
+			Msg("Error connecting for trigger subscription, polling instead")
+		return false
+	}
+	defer connection.BlockingClose()
+	if !connection.GetMetadata().CanSubscribe() {
+		return false
+	}
+
+	builder := connection.SubscriptionRequestBuilder()
+	builder.AddChangeOfStateQuery(triggerFieldName, m.trigger.field)
+	builder.AddItemHandler(func(event model.PlcSubscriptionEvent) {
+		if responseCode := event.GetResponseCode(triggerFieldName); responseCode != model.PlcResponseCode_OK {
+			m.scraper.logger.Warn().Str("job", m.job.Name).Str("source", m.source).
+				Msgf("Got response code %s for trigger", responseCode.GetName())
+			return
+		}
+		m.updateTrigger(event.GetValue(triggerFieldName), done)
+	})
+	subscriptionRequest, err := builder.Build()
+	if err != nil {
+		m.scraper.logger.Warn().Err(err).Str("job", m.job.Name).Str("source", m.source).
+			Msg("Error building trigger subscription, polling instead")
+		return false
+	}
+	subscriptionResult := <-subscriptionRequest.ExecuteWithContext(ctx)
+	if subscriptionResult.Err != nil || subscriptionResult.Response.GetResponseCode(triggerFieldName) != model.PlcResponseCode_OK {
+		m.scraper.logger.Warn().Err(subscriptionResult.Err).Str("job", m.job.Name).Str("source", m.source).
+			Msg("Error subscribing to trigger, polling instead")
+		return false
+	}
+	m.scraper.logger.Debug().Str("job", m.job.Name).Str("source", m.source).Msg("Subscribed to trigger")
+	<-done
+	return true
+}
+
+// pollTrigger reads the trigger field at the poll rate till done is closed
+func (m *scrapeTask) pollTrigger(done <-chan struct{}) {
+	ticker := time.NewTicker(m.trigger.pollRate)
+	defer ticker.Stop()
+	for {
+		value, err := m.readTrigger()
+		if err != nil {
+			m.scraper.logger.Warn().Err(err).Str("job", m.job.Name).Str("source", m.source).Msg("Error reading trigger")
+		} else {
+			m.updateTrigger(value, done)
+		}
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (m *scrapeTask) readTrigger() (values.PlcValue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.scraper.requestTimeout)
+	defer cancel()
+	connectionResult := <-m.scraper.connectionCache.GetConnectionWithContext(ctx, m.connectionString)
+	if connectionResult.Err != nil {
+		return nil, errors.Wrap(connectionResult.Err, "error getting connection")
+	}
+	connection := connectionResult.Connection
+	defer connection.Close()
+
+	builder := connection.ReadRequestBuilder()
+	builder.AddQuery(triggerFieldName, m.trigger.field)
+	readRequest, err := builder.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "error building read request")
+	}
+	var readResult model.PlcReadRequestResult
+	select {
+	case readResult = <-readRequest.ExecuteWithContext(ctx):
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "error reading")
+	}
+	if readResult.Err != nil {
+		return nil, errors.Wrap(readResult.Err, "error reading")
+	}
+	if responseCode := readResult.Response.GetResponseCode(triggerFieldName); responseCode != model.PlcResponseCode_OK {
+		return nil, errors.Errorf("got response code %s", responseCode.GetName())
+	}
+	return readResult.Response.GetValue(triggerFieldName), nil
+}
+
+// updateTrigger evaluates the trigger condition for the new value and starts a scrape, if it fires
+func (m *scrapeTask) updateTrigger(value values.PlcValue, done <-chan struct{}) {
+	m.triggerLock.Lock()
+	fire, err := m.trigger.update(value)
+	m.triggerLock.Unlock()
+	if err != nil {
+		m.scraper.logger.Warn().Err(err).Str("job", m.job.Name).Str("source", m.source).Msg("Error evaluating trigger")
+		return
+	}
+	if fire {
+		m.scraper.logger.Debug().Str("job", m.job.Name).Str("source", m.source).Msg("Trigger fired")
+		m.startScrape(done)
+	}
+}
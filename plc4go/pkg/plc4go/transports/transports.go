@@ -19,7 +19,9 @@
 package transports
 
 import (
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/serial"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/tcp"
+	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/tls"
 	"github.com/apache/plc4x/plc4go/internal/plc4go/spi/transports/udp"
 	"github.com/apache/plc4x/plc4go/pkg/plc4go"
 )
@@ -28,6 +30,14 @@ func RegisterTcpTransport(driverManager plc4go.PlcDriverManager) {
 	driverManager.RegisterTransport(tcp.NewTransport())
 }
 
+func RegisterTlsTransport(driverManager plc4go.PlcDriverManager) {
+	driverManager.RegisterTransport(tls.NewTransport())
+}
+
 func RegisterUdpTransport(driverManager plc4go.PlcDriverManager) {
 	driverManager.RegisterTransport(udp.NewTransport())
 }
+
+func RegisterSerialTransport(driverManager plc4go.PlcDriverManager) {
+	driverManager.RegisterTransport(serial.NewTransport())
+}